@@ -0,0 +1,310 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+const (
+	defaultTimeout       = 30 * time.Second
+	defaultRetries       = 3
+	defaultRetryInterval = time.Second
+	// tokenRefreshMargin is how long before it expires a cached token is replaced
+	tokenRefreshMargin = time.Minute
+)
+
+var log = logger.NewLogger("coa.runtime")
+
+// Options configures a Client
+type Options struct {
+	// BaseUrl is the base URL of the Symphony API, such as http://symphony-service:8080/v1alpha2/
+	BaseUrl string
+	// User and Password sign in to get a token. Calls aren't signed in when User and TokenPath are both empty, such as
+	// with a client certificate in TLSConfig.
+	User     string
+	Password string
+	// TokenPath is a file with a bearer token, such as a Kubernetes service account token, sent in place of signing
+	// in. It's read on every call, so that rotated tokens are picked up.
+	TokenPath string
+	// TLSConfig verifies the API with a CA, and carries a client certificate for mTLS
+	TLSConfig *tls.Config
+	// Timeout of each attempt of a call. The default is 30 seconds.
+	Timeout time.Duration
+	// Retries is how many times a call is retried when the API can't be reached or is unavailable. The default is
+	// 3, and a negative value disables retries.
+	Retries int
+	// RetryInterval is the wait before the first retry, doubled for each following retry. The default is 1 second.
+	RetryInterval time.Duration
+	// HTTPClient replaces the HTTP client made from TLSConfig
+	HTTPClient *http.Client
+}
+
+// Client calls a Symphony API. It caches the token it signs in with until shortly before the token expires, and
+// signs in again when the API rejects it. Errors are v1alpha2.COAError, with the state of the HTTP response code,
+// TransientError when the API couldn't be reached and SerializationError when a response couldn't be read.
+// A Client is safe for concurrent use.
+type Client struct {
+	options Options
+	http    *http.Client
+
+	tokenLock sync.Mutex
+	token     string
+	expiry    time.Time
+}
+
+type authRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type authResponse struct {
+	AccessToken string   `json:"accessToken"`
+	TokenType   string   `json:"tokenType"`
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles"`
+}
+
+// NewClient makes a Client of the Symphony API at the base URL of the options
+func NewClient(options Options) (*Client, error) {
+	if options.BaseUrl == "" {
+		return nil, v1alpha2.NewCOAError(nil, "Symphony API base URL is not set", v1alpha2.BadConfig)
+	}
+	if _, err := url.Parse(options.BaseUrl); err != nil {
+		return nil, v1alpha2.NewCOAError(err, "Symphony API base URL is invalid", v1alpha2.BadConfig)
+	}
+	if !strings.HasSuffix(options.BaseUrl, "/") {
+		options.BaseUrl += "/"
+	}
+	if options.Timeout == 0 {
+		options.Timeout = defaultTimeout
+	}
+	if options.Retries == 0 {
+		options.Retries = defaultRetries
+	} else if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.RetryInterval == 0 {
+		options.RetryInterval = defaultRetryInterval
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+		if options.TLSConfig != nil {
+			httpClient.Transport = &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: options.TLSConfig,
+			}
+		}
+	}
+	return &Client{options: options, http: httpClient}, nil
+}
+
+// BaseUrl is the base URL of the Symphony API the client calls, with a trailing "/"
+func (c *Client) BaseUrl() string {
+	return c.options.BaseUrl
+}
+
+// Token returns the bearer token that calls are sent with. A cached token is returned until shortly before it
+// expires. The token is empty when calls aren't signed in.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	if c.options.TokenPath != "" {
+		data, err := os.ReadFile(c.options.TokenPath)
+		if err != nil {
+			return "", v1alpha2.NewCOAError(err, "failed to read service account token", v1alpha2.InternalError)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if c.options.User == "" {
+		return "", nil
+	}
+
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Before(c.expiry.Add(-tokenRefreshMargin))) {
+		return c.token, nil
+	}
+	var response authResponse
+	_, err := c.send(ctx, http.MethodPost, "users/auth", authRequest{Username: c.options.User, Password: c.options.Password}, &response, "")
+	if err != nil {
+		return "", err
+	}
+	c.token = response.AccessToken
+	c.expiry = tokenExpiry(response.AccessToken)
+	return c.token, nil
+}
+
+// InvalidateToken drops a cached token that the API rejected, so that the next call signs in again. It returns
+// false when the client doesn't sign in, so that signing in again can't help.
+func (c *Client) InvalidateToken(token string) bool {
+	if c.options.TokenPath != "" || c.options.User == "" {
+		return false
+	}
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+	return true
+}
+
+// tokenExpiry reads the expiry of a JWT. It's zero when the token isn't a JWT or doesn't expire.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if json.Unmarshal(data, &claims) != nil || claims.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+// call sends a signed-in request to a route. The payload is sent as JSON, unless it's a []byte that is sent as is,
+// and the response is read into result when it's not nil. It returns the metadata that the API attached to the
+// response.
+func (c *Client) call(ctx context.Context, method string, route string, query url.Values, payload interface{}, result interface{}) (map[string]string, error) {
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := c.send(ctx, method, route, payload, result, token)
+	if coaErr, ok := err.(v1alpha2.COAError); ok && token != "" && (coaErr.State == v1alpha2.Unauthorized || coaErr.State == v1alpha2.Forbidden) {
+		// the token may have expired or been revoked before its expiry, which the JWT middleware answers with 403
+		if !c.InvalidateToken(token) {
+			return nil, err
+		}
+		if token, err = c.Token(ctx); err != nil {
+			return nil, err
+		}
+		metadata, err = c.send(ctx, method, route, payload, result, token)
+	}
+	return metadata, err
+}
+
+// send sends a request, and retries it when the API can't be reached or is unavailable
+func (c *Client) send(ctx context.Context, method string, route string, payload interface{}, result interface{}, token string) (map[string]string, error) {
+	var data []byte
+	switch p := payload.(type) {
+	case nil:
+	case []byte:
+		data = p
+	default:
+		var err error
+		data, err = json.Marshal(p)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to serialize request", v1alpha2.SerializationError)
+		}
+	}
+
+	ctx, span := observability.StartSpan("Symphony-API-Client", ctx, &map[string]string{
+		"method":      "send",
+		"http.method": method,
+		"http.url":    c.options.BaseUrl + route,
+	})
+	var err error
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	interval := c.options.RetryInterval
+	for attempt := 0; ; attempt++ {
+		var body []byte
+		var metadata map[string]string
+		var retriable bool
+		body, metadata, retriable, err = c.attempt(ctx, method, route, data, token)
+		if err == nil {
+			if result != nil && len(body) > 0 {
+				if uErr := json.Unmarshal(body, result); uErr != nil {
+					err = v1alpha2.NewCOAError(uErr, "failed to read response of "+method+" "+route, v1alpha2.SerializationError)
+					return nil, err
+				}
+			}
+			return metadata, nil
+		}
+		if !retriable || attempt >= c.options.Retries {
+			return nil, err
+		}
+		log.Infof("Symphony API call %s %s failed, retrying in %s: %v", method, c.options.BaseUrl+route, interval, err)
+		select {
+		case <-ctx.Done():
+			err = v1alpha2.NewCOAError(ctx.Err(), "Symphony API call was cancelled", v1alpha2.Cancelled)
+			return nil, err
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// attempt sends a request once. The bool is true when the request can be retried.
+func (c *Client) attempt(ctx context.Context, method string, route string, data []byte, token string) ([]byte, map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.options.BaseUrl+route, reader)
+	if err != nil {
+		return nil, nil, false, v1alpha2.NewCOAError(err, "failed to make request", v1alpha2.BadRequest)
+	}
+	observ_utils.PropagateSpanContextToHttpRequestHeader(req)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, true, v1alpha2.NewCOAError(err, "failed to call Symphony API", v1alpha2.TransientError)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, true, v1alpha2.NewCOAError(err, "failed to read response of Symphony API", v1alpha2.TransientError)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout:
+		return nil, nil, true, v1alpha2.NewCOAError(v1alpha2.FromHTTPResponseCode(resp.StatusCode, body), "Symphony API is unavailable", v1alpha2.TransientError)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, nil, false, v1alpha2.NewCOAError(nil, http.StatusText(http.StatusUnauthorized), v1alpha2.Unauthorized)
+	case resp.StatusCode >= 300:
+		return nil, nil, false, v1alpha2.FromHTTPResponseCode(resp.StatusCode, body)
+	}
+
+	var metadata map[string]string
+	if meta := resp.Header.Get(v1alpha2.COAMetaHeader); meta != "" {
+		json.Unmarshal([]byte(meta), &metadata)
+	}
+	return body, metadata, false, nil
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/client/fake"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, server *fake.Server) *Client {
+	client, err := NewClient(Options{
+		BaseUrl:       server.BaseUrl(),
+		User:          "admin",
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func requireState(t *testing.T, err error, state v1alpha2.State) {
+	require.Error(t, err)
+	coaErr, ok := err.(v1alpha2.COAError)
+	require.True(t, ok, "error is not a COAError: %v", err)
+	require.Equal(t, state, coaErr.State)
+}
+
+func TestNewClientWithoutBaseUrl(t *testing.T) {
+	_, err := NewClient(Options{})
+	requireState(t, err, v1alpha2.BadConfig)
+}
+
+func TestNewClientAddsTrailingSlash(t *testing.T) {
+	client, err := NewClient(Options{BaseUrl: "http://localhost:8082/v1alpha2"})
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8082/v1alpha2/", client.BaseUrl())
+}
+
+func TestObjects(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	for _, name := range []string{"solution1", "solution2", "solution3"} {
+		err := client.Solutions().Upsert(ctx, name, "default", model.SolutionState{
+			Spec: &model.SolutionSpec{DisplayName: name},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, client.Solutions().Upsert(ctx, "solution4", "other", model.SolutionState{}))
+
+	solution, err := client.Solutions().Get(ctx, "solution2", "default")
+	require.NoError(t, err)
+	require.Equal(t, "solution2", solution.ObjectMeta.Name)
+	require.Equal(t, "solution2", solution.Spec.DisplayName)
+
+	page, continueToken, err := client.Solutions().List(ctx, "default", model.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.NotEmpty(t, continueToken)
+	page, continueToken, err = client.Solutions().List(ctx, "default", model.ListOptions{Limit: 2, Continue: continueToken})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Empty(t, continueToken)
+
+	all, err := client.Solutions().ListAll(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+
+	require.NoError(t, client.Solutions().Delete(ctx, "solution2", "default"))
+	_, err = client.Solutions().Get(ctx, "solution2", "default")
+	requireState(t, err, v1alpha2.NotFound)
+	require.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestInstancesDeleteDirectly(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	require.NoError(t, client.Instances().Upsert(ctx, "instance1", "default", []byte(`{"spec":{"solution":"solution1"}}`)))
+	require.NoError(t, client.Instances().Delete(ctx, "instance1", "default"))
+	requests := server.Requests()
+	require.Equal(t, http.MethodDelete, requests[len(requests)-1].Method)
+	require.Equal(t, "true", requests[len(requests)-1].Query.Get("direct"))
+}
+
+func TestQueueJob(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	_, err := client.GetSummary(ctx, "instance1", "default")
+	requireState(t, err, v1alpha2.NotFound)
+
+	require.NoError(t, client.QueueJob(ctx, "instance1", "default", true, false))
+	require.Equal(t, []fake.Job{{Id: "instance1", Namespace: "default", IsDelete: true}}, server.Jobs())
+
+	server.SetSummary("instance1", "default", model.SummaryResult{Summary: model.SummarySpec{TargetCount: 1, SuccessCount: 1, IsRemoval: true}})
+	summary, err := client.GetSummary(ctx, "instance1", "default")
+	require.NoError(t, err)
+	require.True(t, summary.Summary.IsRemoval)
+}
+
+func TestSites(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	require.NoError(t, client.UpsertSite(ctx, "site1", model.SiteSpec{Name: "site1"}))
+	site, err := client.GetSite(ctx, "site1")
+	require.NoError(t, err)
+	require.Equal(t, "site1", site.Id)
+	require.Equal(t, "site1", site.Spec.Name)
+	sites, err := client.ListSites(ctx)
+	require.NoError(t, err)
+	require.Len(t, sites, 1)
+}
+
+func TestTokenIsCached(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err := client.Solutions().ListAll(ctx, "default")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, server.SignIns())
+}
+
+func TestTokenIsRefreshedBeforeExpiry(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	// tokens that expire within the refresh margin are replaced on every call
+	server.TokenTTL = tokenRefreshMargin / 2
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Solutions().ListAll(ctx, "default")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, server.SignIns())
+}
+
+func TestSignInAgainWhenTokenIsRejected(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	_, err := client.Solutions().ListAll(ctx, "default")
+	require.NoError(t, err)
+	server.RevokeTokens()
+	_, err = client.Solutions().ListAll(ctx, "default")
+	require.NoError(t, err)
+	require.Equal(t, 2, server.SignIns())
+}
+
+func TestWithoutSignIn(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client, err := NewClient(Options{BaseUrl: server.BaseUrl(), Retries: -1})
+	require.NoError(t, err)
+
+	_, err = client.Solutions().ListAll(context.Background(), "default")
+	requireState(t, err, v1alpha2.Unauthorized)
+	require.Equal(t, 0, server.SignIns())
+
+	server.AllowAnonymous = true
+	_, err = client.Solutions().ListAll(context.Background(), "default")
+	require.NoError(t, err)
+}
+
+func TestRetriesUnavailableAPI(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	server.Fail("solutions", http.StatusServiceUnavailable, 2)
+	_, err := client.Solutions().ListAll(ctx, "default")
+	require.NoError(t, err)
+
+	server.Fail("solutions", http.StatusServiceUnavailable, defaultRetries+1)
+	_, err = client.Solutions().ListAll(ctx, "default")
+	requireState(t, err, v1alpha2.TransientError)
+}
+
+func TestDoesNotRetryBadRequest(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+
+	server.Fail("solutions/solution1", http.StatusBadRequest, 1)
+	err := client.Solutions().Upsert(context.Background(), "solution1", "default", model.SolutionState{})
+	requireState(t, err, v1alpha2.BadRequest)
+	require.Len(t, server.Requests(), 1)
+}
+
+func TestRetryIsCancelled(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client, err := NewClient(Options{BaseUrl: server.BaseUrl(), User: "admin", RetryInterval: time.Hour})
+	require.NoError(t, err)
+
+	server.Fail("solutions", http.StatusServiceUnavailable, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Solutions().ListAll(ctx, "default")
+	requireState(t, err, v1alpha2.Cancelled)
+}
+
+func TestSerializationError(t *testing.T) {
+	server := fake.NewServer()
+	defer server.Close()
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	require.NoError(t, server.SetObject("solutions", "solution1", "default", map[string]interface{}{"spec": "not a spec"}))
+	_, err := client.Solutions().Get(ctx, "solution1", "default")
+	requireState(t, err, v1alpha2.SerializationError)
+}
+
+func TestTLS(t *testing.T) {
+	server := fake.NewTLSServer()
+	defer server.Close()
+
+	// the server isn't trusted without its CA
+	client, err := NewClient(Options{BaseUrl: server.BaseUrl(), User: "admin", Retries: -1})
+	require.NoError(t, err)
+	_, err = client.Solutions().ListAll(context.Background(), "default")
+	requireState(t, err, v1alpha2.TransientError)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.HTTPServer().Certificate())
+	client, err = NewClient(Options{BaseUrl: server.BaseUrl(), User: "admin", TLSConfig: &tls.Config{RootCAs: pool}})
+	require.NoError(t, err)
+	_, err = client.Solutions().ListAll(context.Background(), "default")
+	require.NoError(t, err)
+}
+
+func TestTokenExpiry(t *testing.T) {
+	require.True(t, tokenExpiry("not a token").IsZero())
+	require.True(t, tokenExpiry("a.b.c").IsZero())
+	// {"exp":1700000000}
+	require.Equal(t, int64(1700000000), tokenExpiry("e30.eyJleHAiOjE3MDAwMDAwMDB9.").Unix())
+}
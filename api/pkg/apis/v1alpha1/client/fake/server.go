@@ -0,0 +1,367 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+// Package fake is an in-memory Symphony API for tests of code that calls the Symphony API
+package fake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// objectRoutes are the routes that the server keeps objects of
+var objectRoutes = []string{
+	"solutions",
+	"instances",
+	"targets/registry",
+	"devices",
+	"catalogs/registry",
+	"campaigns",
+	"activations/registry",
+	"skills",
+	"models",
+	"federation/registry",
+}
+
+// Request is a request that the server received
+type Request struct {
+	Method string
+	// Route is the path of the request after the base URL, such as solutions/solution1
+	Route string
+	Query url.Values
+	Body  []byte
+}
+
+// Job is a job queued with the solution/queue route
+type Job struct {
+	Id        string
+	Namespace string
+	IsDelete  bool
+	IsTarget  bool
+}
+
+type failure struct {
+	status int
+	times  int
+}
+
+// Server is an in-memory Symphony API. It signs in any user, keeps the objects that are upserted in memory, pages
+// lists, records queued jobs, and records every request so that tests can check the calls of routes that it
+// doesn't implement. Routes it doesn't implement respond with 200 and an empty body.
+type Server struct {
+	server *httptest.Server
+
+	// TokenTTL is how long the tokens issued by the server are valid. The default is an hour.
+	TokenTTL time.Duration
+	// AllowAnonymous accepts calls without a token
+	AllowAnonymous bool
+
+	lock      sync.Mutex
+	objects   map[string]map[string]json.RawMessage
+	summaries map[string]model.SummaryResult
+	jobs      []Job
+	requests  []Request
+	tokens    map[string]bool
+	signIns   int
+	failures  map[string]*failure
+}
+
+// NewServer starts a server. Close it when the test is done.
+func NewServer() *Server {
+	s := &Server{
+		TokenTTL:  time.Hour,
+		objects:   make(map[string]map[string]json.RawMessage),
+		summaries: make(map[string]model.SummaryResult),
+		tokens:    make(map[string]bool),
+		failures:  make(map[string]*failure),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewTLSServer starts a server with TLS. The certificate of the server is returned by Certificate.
+func NewTLSServer() *Server {
+	s := NewServer()
+	s.server.Close()
+	s.server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BaseUrl is the base URL of the server, with a trailing "/"
+func (s *Server) BaseUrl() string {
+	return s.server.URL + "/v1alpha2/"
+}
+
+// HTTPServer is the underlying test server, such as to get its certificate or client
+func (s *Server) HTTPServer() *httptest.Server {
+	return s.server
+}
+
+// Close stops the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Fail makes the next calls of a route respond with a status code, such as 503, the given number of times
+func (s *Server) Fail(route string, status int, times int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[route] = &failure{status: status, times: times}
+}
+
+// RevokeTokens makes the server reject the tokens it issued, such as to test that clients sign in again
+func (s *Server) RevokeTokens() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokens = make(map[string]bool)
+}
+
+// SignIns is how many times clients signed in
+func (s *Server) SignIns() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.signIns
+}
+
+// Jobs are the jobs queued with the solution/queue route
+func (s *Server) Jobs() []Job {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Job{}, s.jobs...)
+}
+
+// Requests are the requests the server received, other than signing in
+func (s *Server) Requests() []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// SetSummary sets the deployment summary of an instance or a target that the solution/queue route returns
+func (s *Server) SetSummary(id string, namespace string, summary model.SummaryResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.summaries[namespace+"/"+id] = summary
+}
+
+// SetObject puts an object, such as a model.SolutionState, in a route, such as solutions
+func (s *Server) SetObject(route string, name string, namespace string, object interface{}) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.upsert(route, name, namespace, data)
+}
+
+// Object gets the JSON of an object in a route. The bool is false when the object doesn't exist.
+func (s *Server) Object(route string, name string, namespace string) (json.RawMessage, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.objects[route][namespace+"/"+name]
+	return data, ok
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	route := strings.TrimPrefix(r.URL.Path, "/v1alpha2/")
+	body, _ := io.ReadAll(r.Body)
+	if route == "users/auth" {
+		s.signIns++
+		token := s.issueToken()
+		data, _ := json.Marshal(map[string]interface{}{"accessToken": token, "tokenType": "Bearer"})
+		w.Write(data)
+		return
+	}
+	if !s.AllowAnonymous && !s.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.requests = append(s.requests, Request{Method: r.Method, Route: route, Query: r.URL.Query(), Body: body})
+	if f, ok := s.failures[route]; ok && f.times > 0 {
+		f.times--
+		w.WriteHeader(f.status)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+	if route == "solution/queue" {
+		s.serveQueue(w, r, namespace)
+		return
+	}
+	for _, objectRoute := range objectRoutes {
+		if route == objectRoute || strings.HasPrefix(route, objectRoute+"/") {
+			name, _ := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(route, objectRoute), "/"))
+			s.serveObjects(w, r, objectRoute, name, namespace, body)
+			return
+		}
+	}
+}
+
+func (s *Server) serveQueue(w http.ResponseWriter, r *http.Request, namespace string) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	id := r.URL.Query().Get("instance")
+	switch r.Method {
+	case http.MethodGet:
+		summary, ok := s.summaries[namespace+"/"+id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "summary of '"+id+"' is not found")
+			return
+		}
+		writeJSON(w, summary)
+	case http.MethodPost:
+		s.jobs = append(s.jobs, Job{
+			Id:        id,
+			Namespace: namespace,
+			IsDelete:  r.URL.Query().Get("delete") == "true",
+			IsTarget:  r.URL.Query().Get("target") == "true",
+		})
+	}
+}
+
+func (s *Server) serveObjects(w http.ResponseWriter, r *http.Request, route string, name string, namespace string, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		if name == "" {
+			s.serveList(w, r, route, namespace)
+			return
+		}
+		if namespace == "" {
+			namespace = "default"
+		}
+		data, ok := s.objects[route][namespace+"/"+name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "'"+name+"' is not found")
+			return
+		}
+		w.Write(data)
+	case http.MethodPost:
+		if namespace == "" {
+			namespace = "default"
+		}
+		if err := s.upsert(route, name, namespace, body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+		}
+	case http.MethodDelete:
+		if namespace == "" {
+			namespace = "default"
+		}
+		key := namespace + "/" + name
+		if _, ok := s.objects[route][key]; !ok {
+			writeError(w, http.StatusNotFound, "'"+name+"' is not found")
+			return
+		}
+		delete(s.objects[route], key)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, r *http.Request, route string, namespace string) {
+	options, err := model.ListOptionsFromParameters(map[string]string{
+		model.ListLimit:    r.URL.Query().Get(model.ListLimit),
+		model.ListContinue: r.URL.Query().Get(model.ListContinue),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	keys := make([]string, 0)
+	for key := range s.objects[route] {
+		if namespace == "" || strings.HasPrefix(key, namespace+"/") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	start := 0
+	if options.Continue != "" {
+		start, err = strconv.Atoi(options.Continue)
+		if err != nil || start < 0 || start > len(keys) {
+			writeError(w, http.StatusBadRequest, "invalid continue token")
+			return
+		}
+	}
+	end := len(keys)
+	if options.Limit > 0 && start+options.Limit < end {
+		end = start + options.Limit
+		meta, _ := json.Marshal(map[string]string{model.ListContinue: strconv.Itoa(end)})
+		w.Header().Set(v1alpha2.COAMetaHeader, string(meta))
+	}
+	page := make([]json.RawMessage, 0, end-start)
+	for _, key := range keys[start:end] {
+		page = append(page, s.objects[route][key])
+	}
+	writeJSON(w, page)
+}
+
+// upsert keeps an object with its name and namespace in its metadata, or a site as its state
+func (s *Server) upsert(route string, name string, namespace string, body []byte) error {
+	var data []byte
+	if route == "federation/registry" {
+		var spec model.SiteSpec
+		if err := json.Unmarshal(body, &spec); err != nil {
+			return err
+		}
+		data, _ = json.Marshal(model.SiteState{Id: name, Spec: &spec})
+		namespace = "default"
+	} else {
+		var object map[string]interface{}
+		if err := json.Unmarshal(body, &object); err != nil {
+			return err
+		}
+		metadata, _ := object["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["name"] = name
+		metadata["namespace"] = namespace
+		object["metadata"] = metadata
+		data, _ = json.Marshal(object)
+	}
+	if s.objects[route] == nil {
+		s.objects[route] = make(map[string]json.RawMessage)
+	}
+	s.objects[route][namespace+"/"+name] = data
+	return nil
+}
+
+// issueToken issues an unsigned JWT that expires after the TTL of the server
+func (s *Server) issueToken() string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d,"jti":"%d"}`, time.Now().Add(s.TokenTTL).Unix(), s.signIns)))
+	token := header + "." + claims + "."
+	s.tokens[token] = true
+	return token
+}
+
+func writeJSON(w http.ResponseWriter, object interface{}) {
+	data, _ := json.Marshal(object)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	w.Write([]byte(message))
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+)
+
+// listPageSize is the page size used by ListAll
+const listPageSize = 500
+
+// Objects calls the registry route of an object type, such as solutions or targets/registry. The objects are sent
+// and returned as their state, with metadata and spec.
+type Objects[T any] struct {
+	client *Client
+	route  string
+	// directDelete deletes objects that a Delete would otherwise queue a removal job for, such as instances
+	directDelete bool
+}
+
+func newObjects[T any](client *Client, route string, directDelete bool) Objects[T] {
+	return Objects[T]{client: client, route: route, directDelete: directDelete}
+}
+
+// Solutions calls the solutions route
+func (c *Client) Solutions() Objects[model.SolutionState] {
+	return newObjects[model.SolutionState](c, "solutions", false)
+}
+
+// Instances calls the instances route. Delete removes the instance without removing its components from the targets;
+// use QueueJob to remove them.
+func (c *Client) Instances() Objects[model.InstanceState] {
+	return newObjects[model.InstanceState](c, "instances", true)
+}
+
+// Targets calls the targets/registry route. Delete removes the target without removing its components; use QueueJob
+// to remove them.
+func (c *Client) Targets() Objects[model.TargetState] {
+	return newObjects[model.TargetState](c, "targets/registry", true)
+}
+
+// Devices calls the devices route
+func (c *Client) Devices() Objects[model.DeviceState] {
+	return newObjects[model.DeviceState](c, "devices", false)
+}
+
+// Catalogs calls the catalogs/registry route
+func (c *Client) Catalogs() Objects[model.CatalogState] {
+	return newObjects[model.CatalogState](c, "catalogs/registry", false)
+}
+
+// Campaigns calls the campaigns route
+func (c *Client) Campaigns() Objects[model.CampaignState] {
+	return newObjects[model.CampaignState](c, "campaigns", false)
+}
+
+// Activations calls the activations/registry route
+func (c *Client) Activations() Objects[model.ActivationState] {
+	return newObjects[model.ActivationState](c, "activations/registry", false)
+}
+
+// Skills calls the skills route
+func (c *Client) Skills() Objects[model.SkillState] {
+	return newObjects[model.SkillState](c, "skills", false)
+}
+
+// Models calls the models route
+func (c *Client) Models() Objects[model.ModelState] {
+	return newObjects[model.ModelState](c, "models", false)
+}
+
+// Get gets an object
+func (o Objects[T]) Get(ctx context.Context, name string, namespace string) (T, error) {
+	var ret T
+	_, err := o.client.call(ctx, http.MethodGet, o.route+"/"+url.PathEscape(name), namespaceQuery(namespace), nil, &ret)
+	return ret, err
+}
+
+// List gets a page of the objects of a namespace, or of all namespaces when the namespace is empty, and the
+// continuation token of the next page. The token is empty on the last page.
+func (o Objects[T]) List(ctx context.Context, namespace string, options model.ListOptions) ([]T, string, error) {
+	query := namespaceQuery(namespace)
+	options.Encode(query)
+	ret := make([]T, 0)
+	metadata, err := o.client.call(ctx, http.MethodGet, o.route, query, nil, &ret)
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, metadata[model.ListContinue], nil
+}
+
+// ListAll gets every object of a namespace, or of all namespaces when the namespace is empty, one page at a time
+func (o Objects[T]) ListAll(ctx context.Context, namespace string) ([]T, error) {
+	ret := make([]T, 0)
+	options := model.ListOptions{Limit: listPageSize}
+	for {
+		page, continueToken, err := o.List(ctx, namespace, options)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, page...)
+		if continueToken == "" {
+			return ret, nil
+		}
+		options.Continue = continueToken
+	}
+}
+
+// Upsert creates or updates an object. The object is a state, such as model.SolutionState, or its JSON.
+func (o Objects[T]) Upsert(ctx context.Context, name string, namespace string, object interface{}) error {
+	_, err := o.client.call(ctx, http.MethodPost, o.route+"/"+url.PathEscape(name), namespaceQuery(namespace), object, nil)
+	return err
+}
+
+// Delete deletes an object
+func (o Objects[T]) Delete(ctx context.Context, name string, namespace string) error {
+	query := namespaceQuery(namespace)
+	if o.directDelete {
+		query.Set("direct", "true")
+	}
+	_, err := o.client.call(ctx, http.MethodDelete, o.route+"/"+url.PathEscape(name), query, nil, nil)
+	return err
+}
+
+func namespaceQuery(namespace string) url.Values {
+	query := url.Values{}
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	return query
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// User is a user of the Symphony API
+type User struct {
+	Id       string   `json:"id"`
+	Roles    []string `json:"roles,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+	// TokenTTL is how long the tokens issued to the user are valid, such as "8h". Empty uses the default.
+	TokenTTL     string     `json:"tokenTTL,omitempty"`
+	FailedLogins int        `json:"failedLogins,omitempty"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty"`
+}
+
+type changePasswordRequest struct {
+	UserName    string `json:"username"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type tokenTTLRequest struct {
+	TokenTTL string `json:"tokenTTL"`
+}
+
+// ListSites gets the sites known to the Symphony API, including itself
+func (c *Client) ListSites(ctx context.Context) ([]model.SiteState, error) {
+	ret := make([]model.SiteState, 0)
+	_, err := c.call(ctx, http.MethodGet, "federation/registry", nil, nil, &ret)
+	return ret, err
+}
+
+// GetSite gets a site
+func (c *Client) GetSite(ctx context.Context, name string) (model.SiteState, error) {
+	var ret model.SiteState
+	_, err := c.call(ctx, http.MethodGet, "federation/registry/"+url.PathEscape(name), nil, nil, &ret)
+	return ret, err
+}
+
+// UpsertSite registers a site, or updates its registration
+func (c *Client) UpsertSite(ctx context.Context, name string, spec model.SiteSpec) error {
+	_, err := c.call(ctx, http.MethodPost, "federation/registry/"+url.PathEscape(name), nil, spec, nil)
+	return err
+}
+
+// ReportSiteStatus reports the status of a site to its parent
+func (c *Client) ReportSiteStatus(ctx context.Context, name string, state model.SiteState) error {
+	_, err := c.call(ctx, http.MethodPost, "federation/status/"+url.PathEscape(name), nil, state, nil)
+	return err
+}
+
+// GetSyncBatch gets up to count catalogs and jobs that a site hasn't synchronized yet
+func (c *Client) GetSyncBatch(ctx context.Context, site string, namespace string, count int) (model.SyncPackage, error) {
+	var ret model.SyncPackage
+	query := namespaceQuery(namespace)
+	if count > 0 {
+		query.Set("count", strconv.Itoa(count))
+	}
+	_, err := c.call(ctx, http.MethodGet, "federation/sync/"+url.PathEscape(site), query, nil, &ret)
+	return ret, err
+}
+
+// SyncActivationStatus reports the status of an activation that a site ran to its parent
+func (c *Client) SyncActivationStatus(ctx context.Context, status model.ActivationStatus) error {
+	_, err := c.call(ctx, http.MethodPost, "federation/sync", nil, status, nil)
+	return err
+}
+
+// CatalogHook notifies the Symphony API of a catalog that changed in Kubernetes
+func (c *Client) CatalogHook(ctx context.Context, payload []byte) error {
+	query := url.Values{}
+	query.Set("objectType", "catalog")
+	_, err := c.call(ctx, http.MethodPost, "federation/k8shook", query, payload, nil)
+	return err
+}
+
+// PauseActivation pauses an activation before its next stage
+func (c *Client) PauseActivation(ctx context.Context, name string, namespace string) (model.ActivationState, error) {
+	return c.controlActivation(ctx, "pause", name, namespace)
+}
+
+// ResumeActivation resumes a paused activation
+func (c *Client) ResumeActivation(ctx context.Context, name string, namespace string) (model.ActivationState, error) {
+	return c.controlActivation(ctx, "resume", name, namespace)
+}
+
+// CancelActivation cancels an activation
+func (c *Client) CancelActivation(ctx context.Context, name string, namespace string) (model.ActivationState, error) {
+	return c.controlActivation(ctx, "cancel", name, namespace)
+}
+
+func (c *Client) controlActivation(ctx context.Context, control string, name string, namespace string) (model.ActivationState, error) {
+	var ret model.ActivationState
+	_, err := c.call(ctx, http.MethodPost, "activations/"+control+"/"+url.PathEscape(name), namespaceQuery(namespace), nil, &ret)
+	return ret, err
+}
+
+// ReportActivationStatus reports the status of an activation
+func (c *Client) ReportActivationStatus(ctx context.Context, name string, namespace string, status model.ActivationStatus) error {
+	_, err := c.call(ctx, http.MethodPost, "activations/status/"+url.PathEscape(name), namespaceQuery(namespace), status, nil)
+	return err
+}
+
+// PublishActivationEvent starts the stage of an activation
+func (c *Client) PublishActivationEvent(ctx context.Context, event v1alpha2.ActivationData) error {
+	_, err := c.call(ctx, http.MethodPost, "jobs", nil, event, nil)
+	return err
+}
+
+// ListSchedules gets up to count scheduled stages of activations
+func (c *Client) ListSchedules(ctx context.Context, count int) ([]model.ScheduledStage, error) {
+	ret := make([]model.ScheduledStage, 0)
+	query := url.Values{}
+	if count > 0 {
+		query.Set("count", strconv.Itoa(count))
+	}
+	_, err := c.call(ctx, http.MethodGet, "jobs/schedules", query, nil, &ret)
+	return ret, err
+}
+
+// QueueJob queues a job that deploys an instance, or a target when isTarget is set, or removes its components when
+// isDelete is set
+func (c *Client) QueueJob(ctx context.Context, id string, namespace string, isDelete bool, isTarget bool) error {
+	query := namespaceQuery(namespace)
+	query.Set("instance", id)
+	if isDelete {
+		query.Set("delete", "true")
+	}
+	if isTarget {
+		query.Set("target", "true")
+	}
+	_, err := c.call(ctx, http.MethodPost, "solution/queue", query, nil, nil)
+	return err
+}
+
+// GetSummary gets the summary of the last deployment of an instance or a target
+func (c *Client) GetSummary(ctx context.Context, id string, namespace string) (model.SummaryResult, error) {
+	var ret model.SummaryResult
+	query := namespaceQuery(namespace)
+	query.Set("instance", id)
+	_, err := c.call(ctx, http.MethodGet, "solution/queue", query, nil, &ret)
+	return ret, err
+}
+
+// Reconcile deploys a deployment, or removes it when isDelete is set, and waits for the result
+func (c *Client) Reconcile(ctx context.Context, deployment model.DeploymentSpec, namespace string, isDelete bool) (model.SummarySpec, error) {
+	var ret model.SummarySpec
+	_, err := c.call(ctx, http.MethodPost, "solution/reconcile", deploymentQuery(namespace, isDelete), deployment, &ret)
+	return ret, err
+}
+
+// PlanDeployment previews what reconciling a deployment would change on its targets, without changing them
+func (c *Client) PlanDeployment(ctx context.Context, deployment model.DeploymentSpec, namespace string, isDelete bool) (model.PlanPreviewSpec, error) {
+	var ret model.PlanPreviewSpec
+	_, err := c.call(ctx, http.MethodPost, "solution/plan", deploymentQuery(namespace, isDelete), deployment, &ret)
+	return ret, err
+}
+
+func deploymentQuery(namespace string, isDelete bool) url.Values {
+	query := namespaceQuery(namespace)
+	if isDelete {
+		query.Set("delete", "true")
+	}
+	return query
+}
+
+// ReportTargetStatus sets status properties of a target
+func (c *Client) ReportTargetStatus(ctx context.Context, name string, namespace string, properties map[string]string) (model.TargetState, error) {
+	var ret model.TargetState
+	payload := map[string]interface{}{
+		"status": map[string]interface{}{
+			"properties": properties,
+		},
+	}
+	_, err := c.call(ctx, http.MethodPut, "targets/status/"+url.PathEscape(name), namespaceQuery(namespace), payload, &ret)
+	return ret, err
+}
+
+// SendHeartbeat reports that a target is alive
+func (c *Client) SendHeartbeat(ctx context.Context, name string, namespace string) error {
+	_, err := c.call(ctx, http.MethodPost, "targets/ping/"+url.PathEscape(name), namespaceQuery(namespace), nil, nil)
+	return err
+}
+
+// SendTrails sends trails of changes made to catalogs
+func (c *Client) SendTrails(ctx context.Context, trails []v1alpha2.Trail) error {
+	_, err := c.call(ctx, http.MethodPost, "trails", nil, trails, nil)
+	return err
+}
+
+// GetCatalogGraph gets the catalogs of a namespace as a graph, with the config-chains or asset-trees template
+func (c *Client) GetCatalogGraph(ctx context.Context, template string, namespace string) (json.RawMessage, error) {
+	var ret json.RawMessage
+	query := namespaceQuery(namespace)
+	query.Set("template", template)
+	_, err := c.call(ctx, http.MethodGet, "catalogs/graph", query, nil, &ret)
+	return ret, err
+}
+
+// CheckCatalog validates a catalog against its schema without saving it. The error is a BadRequest with the
+// validation errors when the catalog isn't valid.
+func (c *Client) CheckCatalog(ctx context.Context, catalog model.CatalogState) error {
+	_, err := c.call(ctx, http.MethodPost, "catalogs/check", nil, catalog, nil)
+	return err
+}
+
+// ListUsers gets the users of the Symphony API
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	ret := make([]User, 0)
+	_, err := c.call(ctx, http.MethodGet, "users/registry", nil, nil, &ret)
+	return ret, err
+}
+
+// GetUser gets a user
+func (c *Client) GetUser(ctx context.Context, name string) (User, error) {
+	var ret User
+	_, err := c.call(ctx, http.MethodGet, "users/registry/"+url.PathEscape(name), nil, nil, &ret)
+	return ret, err
+}
+
+// ChangePassword changes the password of a user
+func (c *Client) ChangePassword(ctx context.Context, name string, oldPassword string, newPassword string) error {
+	_, err := c.call(ctx, http.MethodPost, "users/password", nil, changePasswordRequest{
+		UserName:    name,
+		OldPassword: oldPassword,
+		NewPassword: newPassword,
+	}, nil)
+	return err
+}
+
+// DisableUser disables a user, so that the user can't sign in
+func (c *Client) DisableUser(ctx context.Context, name string) error {
+	_, err := c.call(ctx, http.MethodPost, "users/disable/"+url.PathEscape(name), nil, nil, nil)
+	return err
+}
+
+// EnableUser enables a disabled user
+func (c *Client) EnableUser(ctx context.Context, name string) error {
+	_, err := c.call(ctx, http.MethodPost, "users/enable/"+url.PathEscape(name), nil, nil, nil)
+	return err
+}
+
+// SetTokenTTL sets how long the tokens issued to a user are valid, such as "8h". Empty resets it to the default.
+func (c *Client) SetTokenTTL(ctx context.Context, name string, ttl string) error {
+	_, err := c.call(ctx, http.MethodPost, "users/token-ttl/"+url.PathEscape(name), nil, tokenTTLRequest{TokenTTL: ttl}, nil)
+	return err
+}
+
+// GetConfig gets a configuration object, or a field of it when the field is set, with the overrides applied
+func (c *Client) GetConfig(ctx context.Context, name string, field string, overrides []string) (interface{}, error) {
+	var ret interface{}
+	query := url.Values{}
+	if field != "" {
+		query.Set("field", field)
+	}
+	if len(overrides) > 0 {
+		query.Set("overrides", strings.Join(overrides, ","))
+	}
+	_, err := c.call(ctx, http.MethodGet, "settings/config/"+url.PathEscape(name), query, nil, &ret)
+	return ret, err
+}
+
+// ListProviderTypes gets the provider types that the Symphony API can create
+func (c *Client) ListProviderTypes(ctx context.Context) ([]string, error) {
+	ret := make([]string, 0)
+	_, err := c.call(ctx, http.MethodGet, "settings/providers", nil, nil, &ret)
+	return ret, err
+}
+
+// SendVisualizationPacket sends a visualization packet
+func (c *Client) SendVisualizationPacket(ctx context.Context, packet model.Packet) error {
+	_, err := c.call(ctx, http.MethodPost, "visualization", nil, packet, nil)
+	return err
+}
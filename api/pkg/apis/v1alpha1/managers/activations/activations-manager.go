@@ -176,13 +176,22 @@ func (m *ActivationsManager) DeleteState(ctx context.Context, name string, names
 }
 
 func (t *ActivationsManager) ListState(ctx context.Context, namespace string) ([]model.ActivationState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the activations that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *ActivationsManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.ActivationState, string, error) {
 	ctx, span := observability.StartSpan("Activations Manager", ctx, &map[string]string{
-		"method": "ListSpec",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.WorkflowGroup,
@@ -191,20 +200,24 @@ func (t *ActivationsManager) ListState(ctx context.Context, namespace string) ([
 			"kind":      "Activation",
 		},
 	}
-	solutions, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	solutions, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.ActivationState, 0)
 	for _, t := range solutions {
 		var rt model.ActivationState
 		rt, err = getActivationState(t.ID, t.Body, t.ETag)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
 func (t *ActivationsManager) ReportStatus(ctx context.Context, name string, current model.ActivationStatus) error {
 	ctx, span := observability.StartSpan("Activations Manager", ctx, &map[string]string{
@@ -235,6 +248,17 @@ func (t *ActivationsManager) ReportStatus(ctx context.Context, name string, curr
 		return err
 	}
 
+	// an operator's pause or cancel holds until the activation is resumed or re-run, so reports from stages that were
+	// still running are dropped
+	if previous := activationState.Status; previous != nil && current.Control == "" &&
+		(current.ActivationGeneration == "" || previous.ActivationGeneration == current.ActivationGeneration) {
+		if previous.Control == model.ActivationPause || previous.Control == model.ActivationCancel {
+			log.Debugf(" M (Activations): dropped status of %s, which is %sd", name, previous.Control)
+			return nil
+		}
+		current.Control = previous.Control
+	}
+
 	current.UpdateTime = time.Now().Format(time.RFC3339) // TODO: is this correct? Shouldn't it be reported?
 	activationState.Status = &current
 
@@ -259,3 +283,115 @@ func (t *ActivationsManager) ReportStatus(ctx context.Context, name string, curr
 	}
 	return nil
 }
+
+// ControlActivation applies an operator's pause, resume or cancel to a running activation. The stages see the request
+// through the "activation-control" event and at their checkpoints: a paused activation stops before its next stage
+// or site, and a cancelled one stops for good.
+func (t *ActivationsManager) ControlActivation(ctx context.Context, name string, namespace string, control string) (model.ActivationState, error) {
+	ctx, span := observability.StartSpan("Activations Manager", ctx, &map[string]string{
+		"method": "ControlActivation",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	lock.Lock()
+	defer lock.Unlock()
+	entry, err := t.StateProvider.Get(ctx, states.GetRequest{
+		ID: name,
+		Metadata: map[string]interface{}{
+			"version":   "v1",
+			"group":     model.WorkflowGroup,
+			"resource":  "activations",
+			"namespace": namespace,
+			"kind":      "Activation",
+		},
+	})
+	if err != nil {
+		return model.ActivationState{}, err
+	}
+	var activationState model.ActivationState
+	bytes, _ := json.Marshal(entry.Body)
+	err = json.Unmarshal(bytes, &activationState)
+	if err != nil {
+		return model.ActivationState{}, err
+	}
+	if activationState.Spec == nil {
+		activationState.Spec = &model.ActivationSpec{}
+	}
+	status := model.ActivationStatus{}
+	if activationState.Status != nil {
+		status = *activationState.Status
+	}
+
+	running := status.IsActive || status.Status == v1alpha2.Paused || status.Status == v1alpha2.Untouched
+	switch control {
+	case model.ActivationPause:
+		if status.Control == model.ActivationPause {
+			return activationState, nil
+		}
+		if !running || status.Control == model.ActivationCancel {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("activation %s is not running", name), v1alpha2.Conflict)
+			return model.ActivationState{}, err
+		}
+	case model.ActivationResume:
+		if status.Control != model.ActivationPause {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("activation %s is not paused", name), v1alpha2.Conflict)
+			return model.ActivationState{}, err
+		}
+		status.IsActive = true
+		status.Status = v1alpha2.Running
+	case model.ActivationCancel:
+		if status.Control == model.ActivationCancel {
+			return activationState, nil
+		}
+		if !running && status.Control != model.ActivationPause {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("activation %s is not running", name), v1alpha2.Conflict)
+			return model.ActivationState{}, err
+		}
+		status.IsActive = false
+		status.Status = v1alpha2.Cancelled
+		status.ErrorMessage = fmt.Sprintf("activation %s is cancelled", name)
+	default:
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid activation control '%s'", control), v1alpha2.BadRequest)
+		return model.ActivationState{}, err
+	}
+	if activationState.ObjectMeta.Namespace == "" {
+		activationState.ObjectMeta.Namespace = namespace
+	}
+	status.Control = control
+	status.UpdateTime = time.Now().Format(time.RFC3339)
+	activationState.Status = &status
+
+	entry.Body = activationState
+	_, err = t.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: entry,
+		Metadata: map[string]interface{}{
+			"version":   "v1",
+			"group":     model.WorkflowGroup,
+			"resource":  "activations",
+			"namespace": activationState.ObjectMeta.Namespace,
+			"kind":      "Activation",
+		},
+		Options: states.UpsertOption{
+			UpdateStateOnly: true,
+		},
+	})
+	if err != nil {
+		return model.ActivationState{}, err
+	}
+	log.Infof(" M (Activations): activation %s is requested to %s", name, control)
+
+	t.Context.Publish("activation-control", v1alpha2.Event{
+		Metadata: map[string]string{
+			"control": control,
+		},
+		Body: v1alpha2.ActivationData{
+			Campaign:             activationState.Spec.Campaign,
+			Activation:           name,
+			ActivationGeneration: status.ActivationGeneration,
+			Namespace:            activationState.ObjectMeta.Namespace,
+			Stage:                status.Stage,
+		},
+	})
+	return activationState, nil
+}
@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,3 +55,109 @@ func TestCleanupOldActivationSpec(t *testing.T) {
 	_, err = manager.GetState(context.Background(), "test", "default")
 	assert.NotNil(t, err)
 }
+
+func TestControlActivation(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := ActivationsManager{
+		StateProvider: stateProvider,
+	}
+	manager.Context = &contexts.ManagerContext{}
+	err := manager.UpsertState(context.Background(), "test", model.ActivationState{Spec: &model.ActivationSpec{Campaign: "campaign"}})
+	assert.Nil(t, err)
+	err = manager.ReportStatus(context.Background(), "test", model.ActivationStatus{
+		Stage:                "deploy",
+		ActivationGeneration: "1",
+		Status:               v1alpha2.Running,
+		IsActive:             true,
+	})
+	assert.Nil(t, err)
+
+	_, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationResume)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.Conflict, err.(v1alpha2.COAError).State)
+
+	activation, err := manager.ControlActivation(context.Background(), "test", "default", model.ActivationPause)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ActivationPause, activation.Status.Control)
+	assert.Equal(t, "deploy", activation.Status.Stage)
+
+	// a stage that was still running doesn't override the pause
+	err = manager.ReportStatus(context.Background(), "test", model.ActivationStatus{
+		Stage:                "deploy",
+		ActivationGeneration: "1",
+		Status:               v1alpha2.Done,
+	})
+	assert.Nil(t, err)
+	activation, err = manager.GetState(context.Background(), "test", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Running, activation.Status.Status)
+	assert.Equal(t, model.ActivationPause, activation.Status.Control)
+
+	err = manager.ReportStatus(context.Background(), "test", model.ActivationStatus{
+		Stage:                "deploy",
+		ActivationGeneration: "1",
+		Status:               v1alpha2.Paused,
+		Control:              model.ActivationPause,
+		StopReason:           model.StopReasonInterrupted,
+	})
+	assert.Nil(t, err)
+
+	activation, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationResume)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Running, activation.Status.Status)
+	assert.True(t, activation.Status.IsActive)
+
+	// reports go through again once the activation is resumed
+	err = manager.ReportStatus(context.Background(), "test", model.ActivationStatus{
+		Stage:                "verify",
+		ActivationGeneration: "1",
+		Status:               v1alpha2.Running,
+		IsActive:             true,
+	})
+	assert.Nil(t, err)
+	activation, err = manager.GetState(context.Background(), "test", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "verify", activation.Status.Stage)
+	assert.Equal(t, model.ActivationResume, activation.Status.Control)
+
+	activation, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationCancel)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Cancelled, activation.Status.Status)
+	assert.False(t, activation.Status.IsActive)
+
+	_, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationCancel)
+	assert.Nil(t, err)
+	_, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationPause)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.Conflict, err.(v1alpha2.COAError).State)
+	_, err = manager.ControlActivation(context.Background(), "test", "default", "restart")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+
+	// the cancelled activation keeps its history
+	activation, err = manager.GetState(context.Background(), "test", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "verify", activation.Status.Stage)
+}
+
+func TestControlActivationNotRunning(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := ActivationsManager{
+		StateProvider: stateProvider,
+	}
+	manager.Context = &contexts.ManagerContext{}
+	err := manager.UpsertState(context.Background(), "test", model.ActivationState{Spec: &model.ActivationSpec{}})
+	assert.Nil(t, err)
+	err = manager.ReportStatus(context.Background(), "test", model.ActivationStatus{Status: v1alpha2.Done})
+	assert.Nil(t, err)
+	_, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationPause)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.Conflict, err.(v1alpha2.COAError).State)
+	_, err = manager.ControlActivation(context.Background(), "test", "default", model.ActivationCancel)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.Conflict, err.(v1alpha2.COAError).State)
+	_, err = manager.ControlActivation(context.Background(), "missing", "default", model.ActivationPause)
+	assert.NotNil(t, err)
+}
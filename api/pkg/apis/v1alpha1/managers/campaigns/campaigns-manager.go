@@ -111,6 +111,13 @@ func (m *CampaignsManager) UpsertState(ctx context.Context, name string, state m
 	}
 	state.ObjectMeta.FixNames(name)
 
+	if state.Spec != nil {
+		err = state.Spec.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	upsertRequest := states.UpsertRequest{
 		Value: states.StateEntry{
 			ID: name,
@@ -155,13 +162,22 @@ func (m *CampaignsManager) DeleteState(ctx context.Context, name string, namespa
 }
 
 func (t *CampaignsManager) ListState(ctx context.Context, namespace string) ([]model.CampaignState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the campaigns that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *CampaignsManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.CampaignState, string, error) {
 	ctx, span := observability.StartSpan("Campaigns Manager", ctx, &map[string]string{
-		"method": "ListState",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.WorkflowGroup,
@@ -170,18 +186,22 @@ func (t *CampaignsManager) ListState(ctx context.Context, namespace string) ([]m
 			"kind":      "Campaign",
 		},
 	}
-	solutions, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	solutions, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.CampaignState, 0)
 	for _, t := range solutions {
 		var rt model.CampaignState
 		rt, err = getCampaignState(t.ID, t.Body)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
@@ -228,13 +228,22 @@ func (m *CatalogsManager) DeleteState(ctx context.Context, name string, namespac
 }
 
 func (t *CatalogsManager) ListState(ctx context.Context, namespace string) ([]model.CatalogState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the catalogs that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *CatalogsManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.CatalogState, string, error) {
 	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
-		"method": "ListState",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.FederationGroup,
@@ -243,20 +252,24 @@ func (t *CatalogsManager) ListState(ctx context.Context, namespace string) ([]mo
 			"kind":      "Catalog",
 		},
 	}
-	catalogs, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	catalogs, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.CatalogState, 0)
 	for _, t := range catalogs {
 		var rt model.CatalogState
 		rt, err = getCatalogState(t.ID, t.Body, t.ETag)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
 func (g *CatalogsManager) setProviderDataIfNecessary(ctx context.Context, namespace string) error {
 	if !g.GraphProvider.IsPure() {
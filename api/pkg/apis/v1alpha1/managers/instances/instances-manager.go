@@ -105,13 +105,22 @@ func (t *InstancesManager) UpsertState(ctx context.Context, name string, state m
 }
 
 func (t *InstancesManager) ListState(ctx context.Context, namespace string) ([]model.InstanceState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the instances that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *InstancesManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.InstanceState, string, error) {
 	ctx, span := observability.StartSpan("Instances Manager", ctx, &map[string]string{
-		"method": "ListSpec",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.SolutionGroup,
@@ -120,20 +129,24 @@ func (t *InstancesManager) ListState(ctx context.Context, namespace string) ([]m
 			"kind":      "Instance",
 		},
 	}
-	instances, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	instances, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.InstanceState, 0)
 	for _, t := range instances {
 		var rt model.InstanceState
 		rt, err = getInstanceState(t.ID, t.Body, t.ETag)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
 
 func getInstanceState(id string, body interface{}, etag string) (model.InstanceState, error) {
@@ -10,6 +10,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -29,6 +32,25 @@ var log = logger.NewLogger("coa.runtime")
 type JobsManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
+	// WatchProvider holds the instances and targets. When it's set, object changes are picked up from its change
+	// feed instead of re-listing the objects on every poll.
+	WatchProvider states.IWatchableStateProvider
+	watchLock     sync.Mutex
+	watching      map[string]bool
+	watched       map[string]map[string]string // object type -> object name -> ETag
+}
+
+var watchedObjects = map[string]map[string]interface{}{
+	"instance": {
+		"group":    model.SolutionGroup,
+		"version":  "v1",
+		"resource": "instances",
+	},
+	"target": {
+		"group":    model.FabricGroup,
+		"version":  "v1",
+		"resource": "targets",
+	},
 }
 
 type LastSuccessTime struct {
@@ -47,6 +69,16 @@ func (s *JobsManager) Init(context *contexts.VendorContext, config managers.Mana
 	} else {
 		return err
 	}
+
+	s.watching = make(map[string]bool)
+	s.watched = make(map[string]map[string]string)
+	if name, ok := config.Properties["providers.watch"]; ok {
+		if p, ok := providers[name].(states.IWatchableStateProvider); ok {
+			s.WatchProvider = p
+		} else {
+			log.Infof(" M (Job): provider '%s' doesn't support watching, instances and targets will be polled", name)
+		}
+	}
 	return nil
 }
 
@@ -61,91 +93,157 @@ func (s *JobsManager) pollObjects() []error {
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
+	interval := utils.ReadInt32(s.Manager.Config.Properties, "interval", 0)
+	if interval == 0 {
+		return nil
+	}
+	s.startWatches()
+	for _, objectType := range []string{"instance", "target"} {
+		var names []string
+		names, err = s.listObjects(context, objectType)
+		if err != nil {
+			log.Errorf(" M (Job): failed to list %s objects: %+v", objectType, err)
+			return []error{err}
+		}
+		prefix := "i_"
+		if objectType == "target" {
+			prefix = "t_"
+		}
+		for _, name := range names {
+			var entry states.StateEntry
+			entry, err = s.StateProvider.Get(context, states.GetRequest{
+				ID: prefix + name,
+			})
+			needsPub := true
+			if err == nil {
+				var stamp LastSuccessTime
+				jData, _ := json.Marshal(entry.Body)
+				err = json.Unmarshal(jData, &stamp)
+				if err == nil {
+					if time.Since(stamp.Time) > time.Duration(interval)*time.Second { //TODO: compare object hash as well?
+						needsPub = true
+					} else {
+						needsPub = false
+					}
+				}
+			}
+			if needsPub {
+				s.publishJob(objectType, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// listObjects returns the names of the instances or targets. While the objects are watched, the names come from the
+// watch; otherwise they're listed through the Symphony API.
+func (s *JobsManager) listObjects(context context.Context, objectType string) ([]string, error) {
+	s.watchLock.Lock()
+	if s.watching[objectType] {
+		ret := make([]string, 0, len(s.watched[objectType]))
+		for name := range s.watched[objectType] {
+			ret = append(ret, name)
+		}
+		s.watchLock.Unlock()
+		return ret, nil
+	}
+	s.watchLock.Unlock()
+
 	baseUrl, err := utils.GetString(s.Manager.Config.Properties, "baseUrl")
 	if err != nil {
-		return []error{err}
+		return nil, err
 	}
 	user, err := utils.GetString(s.Manager.Config.Properties, "user")
 	if err != nil {
-		return []error{err}
+		return nil, err
 	}
 	password, err := utils.GetString(s.Manager.Config.Properties, "password")
 	if err != nil {
-		return []error{err}
+		return nil, err
 	}
-	interval := utils.ReadInt32(s.Manager.Config.Properties, "interval", 0)
-	if interval == 0 {
-		return nil
+	ret := make([]string, 0)
+	if objectType == "instance" {
+		instances, err := utils.GetInstancesForAllNamespaces(context, baseUrl, user, password)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			ret = append(ret, instance.ObjectMeta.Name)
+		}
+	} else {
+		targets, err := utils.GetTargetsForAllNamespaces(context, baseUrl, user, password)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			ret = append(ret, target.ObjectMeta.Name)
+		}
 	}
-	instances, err := utils.GetInstancesForAllNamespaces(context, baseUrl, user, password)
-	if err != nil {
-		fmt.Println(err.Error())
-		return []error{err}
+	return ret, nil
+}
+
+// startWatches watches instances and targets on the watch provider, if there is one, for the object types that
+// aren't watched yet. A watch that ends is started again on the next poll; until then the objects are polled.
+func (s *JobsManager) startWatches() {
+	if s.WatchProvider == nil {
+		return
 	}
-	for _, instance := range instances {
-		var entry states.StateEntry
-		entry, err = s.StateProvider.Get(context, states.GetRequest{
-			ID: "i_" + instance.ObjectMeta.Name,
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	for objectType, metadata := range watchedObjects {
+		if s.watching[objectType] {
+			continue
+		}
+		events, err := s.WatchProvider.Watch(context.Background(), states.WatchRequest{
+			Metadata: metadata,
 		})
-		needsPub := true
-		if err == nil {
-			if stamp, ok := entry.Body.(LastSuccessTime); ok {
-				if time.Since(stamp.Time) > time.Duration(interval)*time.Second { //TODO: compare object hash as well?
-					needsPub = true
-				} else {
-					needsPub = false
-				}
-			}
+		if err != nil {
+			log.Errorf(" M (Job): failed to watch %ss, falling back to polling: %+v", objectType, err)
+			continue
 		}
-		if needsPub {
-			s.Context.Publish("job", v1alpha2.Event{
-				Metadata: map[string]string{
-					"objectType": "instance",
-				},
-				Body: v1alpha2.JobData{
-					Id:     instance.ObjectMeta.Name,
-					Action: v1alpha2.JobUpdate,
-				},
-			})
+		s.watching[objectType] = true
+		if _, ok := s.watched[objectType]; !ok {
+			s.watched[objectType] = make(map[string]string)
 		}
+		go s.handleWatchEvents(objectType, events)
 	}
-	targets, err := utils.GetTargetsForAllNamespaces(context, baseUrl, user, password)
-	if err != nil {
-		fmt.Println(err.Error())
-		return []error{err}
-	}
-	for _, target := range targets {
-		var entry states.StateEntry
-		entry, err = s.StateProvider.Get(context, states.GetRequest{
-			ID: "t_" + target.ObjectMeta.Name,
-		})
-		needsPub := true
-		if err == nil {
-			var stamp LastSuccessTime
-			jData, _ := json.Marshal(entry.Body)
-			err = json.Unmarshal(jData, &stamp)
-			if err == nil {
-				if time.Since(stamp.Time) > time.Duration(interval)*time.Second { //TODO: compare object hash as well?
-					needsPub = true
-				} else {
-					needsPub = false
-				}
-			}
+}
+
+// handleWatchEvents publishes a job for every object whose ETag changed. ETags are kept across watches, so the
+// created events replayed when a watch is re-established don't trigger jobs for unchanged objects.
+func (s *JobsManager) handleWatchEvents(objectType string, events <-chan states.WatchEvent) {
+	for event := range events {
+		s.watchLock.Lock()
+		known := s.watched[objectType]
+		etag, seen := known[event.Entry.ID]
+		if event.Type == states.WatchEventDeleted {
+			delete(known, event.Entry.ID)
+		} else {
+			known[event.Entry.ID] = event.Entry.ETag
 		}
-		if needsPub {
-			s.Context.Publish("job", v1alpha2.Event{
-				Metadata: map[string]string{
-					"objectType": "target",
-				},
-				Body: v1alpha2.JobData{
-					Id:     target.ObjectMeta.Name,
-					Action: v1alpha2.JobUpdate,
-				},
-			})
+		s.watchLock.Unlock()
+
+		if event.Type != states.WatchEventDeleted && (!seen || etag != event.Entry.ETag) {
+			s.publishJob(objectType, event.Entry.ID)
 		}
 	}
+	log.Infof(" M (Job): watch on %ss ended", objectType)
+	s.watchLock.Lock()
+	s.watching[objectType] = false
+	s.watchLock.Unlock()
+}
 
-	return nil
+func (s *JobsManager) publishJob(objectType string, name string) {
+	s.Context.Publish("job", v1alpha2.Event{
+		Metadata: map[string]string{
+			"objectType": objectType,
+		},
+		Body: v1alpha2.JobData{
+			Id:     name,
+			Action: v1alpha2.JobUpdate,
+		},
+	})
 }
 func (s *JobsManager) Poll() []error {
 	// TODO: do these in parallel?
@@ -184,7 +282,12 @@ func (s *JobsManager) pollSchedules() []error {
 		if err != nil {
 			return []error{err}
 		}
-		if activationData.Schedule != nil {
+		if activationData.Schedule != nil && activationData.Schedule.IsRecurring() {
+			err = s.pollRecurringSchedule(context, entry.ID, activationData)
+			if err != nil {
+				return []error{err}
+			}
+		} else if activationData.Schedule != nil {
 			var fire bool
 			fire, err = activationData.Schedule.ShouldFireNow()
 			if err != nil {
@@ -207,6 +310,107 @@ func (s *JobsManager) pollSchedules() []error {
 	return nil
 }
 
+// pollRecurringSchedule triggers a recurring schedule for each of its due fire times. The schedule is kept, with the
+// latest fire time recorded, until its activation is deleted.
+func (s *JobsManager) pollRecurringSchedule(ctx context.Context, id string, activationData v1alpha2.ActivationData) error {
+	fireTimes, lastFireTime, err := activationData.Schedule.DueFireTimes(time.Now())
+	if err != nil {
+		return err
+	}
+	if lastFireTime.IsZero() {
+		return nil
+	}
+
+	baseUrl, _ := utils.GetString(s.Manager.Config.Properties, "baseUrl")
+	if baseUrl != "" {
+		user, _ := utils.GetString(s.Manager.Config.Properties, "user")
+		password, _ := utils.GetString(s.Manager.Config.Properties, "password")
+		_, err = utils.GetActivation(ctx, baseUrl, activationData.Activation, user, password)
+		if err != nil {
+			if !v1alpha2.IsNotFound(err) {
+				return err
+			}
+			log.Infof(" M (Job): activation %s is gone, removing schedule %s", activationData.Activation, id)
+			return s.StateProvider.Delete(ctx, states.DeleteRequest{
+				ID: id,
+			})
+		}
+	}
+
+	schedule := *activationData.Schedule
+	schedule.LastFireTime = lastFireTime.UTC().Format(time.RFC3339)
+	activationData.Schedule = &schedule
+	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   id,
+			Body: activationData,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if len(fireTimes) == 0 {
+		log.Infof(" M (Job): schedule %s missed its fire time %s, skipping it", id, schedule.LastFireTime)
+	}
+	for _, fireTime := range fireTimes {
+		log.Debugf(" M (Job): firing schedule %s for %s", id, fireTime.UTC().Format(time.RFC3339))
+		trigger := activationData
+		trigger.Schedule = nil
+		s.Context.Publish("trigger", v1alpha2.Event{
+			Body: trigger,
+		})
+	}
+	return nil
+}
+
+// ListSchedules lists the stages waiting on a schedule, with up to count upcoming fire times each
+func (s *JobsManager) ListSchedules(ctx context.Context, count int) ([]model.ScheduledStage, error) {
+	ctx, span := observability.StartSpan("Job Manager", ctx, &map[string]string{
+		"method": "ListSchedules",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	list, _, err := s.StateProvider.List(ctx, states.ListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ret := make([]model.ScheduledStage, 0)
+	for _, entry := range list {
+		if !strings.HasPrefix(entry.ID, "sch_") {
+			continue
+		}
+		var activationData v1alpha2.ActivationData
+		entryData, _ := json.Marshal(entry.Body)
+		err = json.Unmarshal(entryData, &activationData)
+		if err != nil {
+			return nil, err
+		}
+		if activationData.Schedule == nil {
+			continue
+		}
+		var fireTimes []time.Time
+		fireTimes, err = activationData.Schedule.UpcomingFireTimes(now, count)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, model.ScheduledStage{
+			Id:                entry.ID,
+			Campaign:          activationData.Campaign,
+			Activation:        activationData.Activation,
+			Namespace:         activationData.Namespace,
+			Stage:             activationData.Stage,
+			Schedule:          activationData.Schedule,
+			UpcomingFireTimes: fireTimes,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Id < ret[j].Id
+	})
+	return ret, nil
+}
+
 func (s *JobsManager) Reconcil() []error {
 	return nil
 }
@@ -286,7 +490,21 @@ func (s *JobsManager) HandleScheduleEvent(ctx context.Context, event v1alpha2.Ev
 	if err != nil {
 		return v1alpha2.NewCOAError(nil, "event body is not a activation data", v1alpha2.BadRequest)
 	}
+	if activationData.Schedule != nil {
+		err = activationData.Schedule.Validate()
+		if err != nil {
+			return err
+		}
+	}
 	key := fmt.Sprintf("sch_%s-%s", activationData.Campaign, activationData.Activation)
+	if activationData.Schedule != nil && activationData.Schedule.IsRecurring() {
+		// each stage of an activation can have its own recurring schedule
+		key = fmt.Sprintf("sch_%s-%s-%s", activationData.Campaign, activationData.Activation, activationData.Stage)
+		err = s.initLastFireTime(ctx, key, &activationData)
+		if err != nil {
+			return err
+		}
+	}
 	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
 		Value: states.StateEntry{
 			ID:   key,
@@ -295,6 +513,33 @@ func (s *JobsManager) HandleScheduleEvent(ctx context.Context, event v1alpha2.Ev
 	})
 	return err
 }
+
+// initLastFireTime sets where a recurring schedule starts counting fire times. A schedule that is handled again, for
+// example because its stage ran again, keeps its place; a new schedule without a date and time starts now.
+func (s *JobsManager) initLastFireTime(ctx context.Context, key string, activationData *v1alpha2.ActivationData) error {
+	schedule := *activationData.Schedule
+	activationData.Schedule = &schedule
+	if schedule.LastFireTime != "" {
+		return nil
+	}
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID: key,
+	})
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		var existing v1alpha2.ActivationData
+		entryData, _ := json.Marshal(entry.Body)
+		if json.Unmarshal(entryData, &existing) == nil && existing.Schedule != nil {
+			schedule.LastFireTime = existing.Schedule.LastFireTime
+		}
+	}
+	if schedule.LastFireTime == "" && schedule.Date == "" && schedule.Time == "" {
+		schedule.LastFireTime = time.Now().UTC().Format(time.RFC3339)
+	}
+	return nil
+}
 func (s *JobsManager) HandleJobEvent(ctx context.Context, event v1alpha2.Event) error {
 	ctx, span := observability.StartSpan("Job Manager", ctx, &map[string]string{
 		"method": "HandleJobEvent",
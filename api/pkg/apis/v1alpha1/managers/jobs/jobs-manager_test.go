@@ -17,8 +17,10 @@ import (
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/stretchr/testify/assert"
@@ -186,6 +188,219 @@ func TestPoll(t *testing.T) {
 	assert.Nil(t, errlist)
 }
 
+func TestPollWithWatch(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	objectProvider := &memorystate.MemoryStateProvider{}
+	objectProvider.Init(memorystate.MemoryStateProviderConfig{})
+	_, err := objectProvider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "instance1",
+			Body: map[string]interface{}{},
+		},
+	})
+	assert.Nil(t, err)
+
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(&pubSubProvider)
+	jobs := make(chan string, 10)
+	vendorContext.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		var job v1alpha2.JobData
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &job)
+		if event.Metadata["objectType"] == "instance" {
+			jobs <- job.Id
+		}
+		return nil
+	})
+
+	// no baseUrl: objects can only come from the watch
+	jobManager := JobsManager{}
+	err = jobManager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "state",
+			"providers.watch": "objects",
+			"interval":        "#15",
+			"poll.enabled":    "true",
+		},
+	}, map[string]providers.IProvider{
+		"state":   stateProvider,
+		"objects": objectProvider,
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, jobManager.WatchProvider)
+	errlist := jobManager.Poll()
+	assert.Nil(t, errlist)
+
+	assert.True(t, waitForJob(jobs, "instance1"))
+
+	_, err = objectProvider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "instance2",
+			Body: map[string]interface{}{},
+		},
+	})
+	assert.Nil(t, err)
+	assert.True(t, waitForJob(jobs, "instance2"))
+}
+
+// waitForJob skips jobs for other objects, since both the watch and the poll may publish
+func waitForJob(jobs chan string, expected string) bool {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case id := <-jobs:
+			if id == expected {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func initScheduleTest(t *testing.T, properties map[string]string) (*JobsManager, *memorystate.MemoryStateProvider, chan v1alpha2.ActivationData) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(&pubSubProvider)
+	triggers := make(chan v1alpha2.ActivationData, 10)
+	vendorContext.Subscribe("trigger", func(topic string, event v1alpha2.Event) error {
+		var activationData v1alpha2.ActivationData
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &activationData)
+		triggers <- activationData
+		return nil
+	})
+	properties["providers.state"] = "state"
+	properties["interval"] = "#15"
+	properties["schedule.enabled"] = "true"
+	jobManager := &JobsManager{}
+	err := jobManager.Init(vendorContext, managers.ManagerConfig{
+		Properties: properties,
+	}, map[string]providers.IProvider{
+		"state": stateProvider,
+	})
+	assert.Nil(t, err)
+	return jobManager, stateProvider, triggers
+}
+
+func TestPollRecurringSchedule(t *testing.T) {
+	jobManager, stateProvider, triggers := initScheduleTest(t, map[string]string{})
+	lastFireTime := time.Now().UTC().Add(-2*time.Hour - 30*time.Second).Format(time.RFC3339)
+	err := jobManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{Campaign: "campaign1", Activation: "activation1", Stage: "stage1", Schedule: &v1alpha2.ScheduleSpec{
+			Interval:        "1h",
+			MissedRunPolicy: v1alpha2.MissedRunCatchUpAll,
+			LastFireTime:    lastFireTime,
+		}},
+	})
+	assert.Nil(t, err)
+
+	errlist := jobManager.Poll()
+	assert.Nil(t, errlist)
+	for i := 0; i < 2; i++ {
+		select {
+		case trigger := <-triggers:
+			assert.Equal(t, "stage1", trigger.Stage)
+			assert.Nil(t, trigger.Schedule)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "schedule wasn't triggered")
+		}
+	}
+
+	// the schedule is kept for its next fire time
+	entry, err := stateProvider.Get(context.Background(), states.GetRequest{ID: "sch_campaign1-activation1-stage1"})
+	assert.Nil(t, err)
+	var activationData v1alpha2.ActivationData
+	jData, _ := json.Marshal(entry.Body)
+	json.Unmarshal(jData, &activationData)
+	assert.NotEqual(t, lastFireTime, activationData.Schedule.LastFireTime)
+
+	errlist = jobManager.Poll()
+	assert.Nil(t, errlist)
+	select {
+	case <-triggers:
+		assert.Fail(t, "schedule was triggered again")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPollRecurringScheduleActivationGone(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/activations/registry/activation1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(AuthResponse{AccessToken: "test-token", TokenType: "Bearer"})
+	}))
+	defer ts.Close()
+	jobManager, stateProvider, triggers := initScheduleTest(t, map[string]string{
+		"baseUrl":  ts.URL + "/",
+		"user":     "admin",
+		"password": "",
+	})
+	err := jobManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{Campaign: "campaign1", Activation: "activation1", Stage: "stage1", Schedule: &v1alpha2.ScheduleSpec{
+			Interval:     "1h",
+			LastFireTime: time.Now().UTC().Add(-time.Hour).Format(time.RFC3339),
+		}},
+	})
+	assert.Nil(t, err)
+
+	errlist := jobManager.Poll()
+	assert.Nil(t, errlist)
+	_, err = stateProvider.Get(context.Background(), states.GetRequest{ID: "sch_campaign1-activation1-stage1"})
+	assert.True(t, v1alpha2.IsNotFound(err))
+	select {
+	case <-triggers:
+		assert.Fail(t, "schedule of a deleted activation was triggered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHandleScheduleEventInvalidSchedule(t *testing.T) {
+	jobManager, _, _ := initScheduleTest(t, map[string]string{})
+	err := jobManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{Campaign: "campaign1", Activation: "activation1", Schedule: &v1alpha2.ScheduleSpec{Cron: "every sunday"}},
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+}
+
+func TestListSchedules(t *testing.T) {
+	jobManager, _, _ := initScheduleTest(t, map[string]string{})
+	err := jobManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{Campaign: "campaign1", Activation: "activation1", Stage: "stage1", Schedule: &v1alpha2.ScheduleSpec{
+			Cron: "0 2 * * SUN",
+			Zone: "America/Los_Angeles",
+		}},
+	})
+	assert.Nil(t, err)
+	err = jobManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{Campaign: "campaign2", Activation: "activation2", Stage: "stage1", Schedule: &v1alpha2.ScheduleSpec{
+			Date: "2999-01-01",
+			Time: "12:00:00PM",
+		}},
+	})
+	assert.Nil(t, err)
+
+	schedules, err := jobManager.ListSchedules(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(schedules))
+	assert.Equal(t, "sch_campaign1-activation1-stage1", schedules[0].Id)
+	assert.Equal(t, "campaign1", schedules[0].Campaign)
+	assert.Equal(t, 3, len(schedules[0].UpcomingFireTimes))
+	assert.Equal(t, time.Sunday, schedules[0].UpcomingFireTimes[0].Weekday())
+	assert.Equal(t, "sch_campaign2-activation2", schedules[1].Id)
+	assert.Equal(t, 1, len(schedules[1].UpcomingFireTimes))
+}
+
 func TestDelayOrSkipJobPoll(t *testing.T) {
 	stateProvider := &memorystate.MemoryStateProvider{}
 	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -26,6 +27,10 @@ import (
 type SitesManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
+	watchLock     sync.Mutex
+	statusLock    sync.Mutex // serializes the updates of site statuses, which read and write back the whole status
+	watching      bool
+	self          *model.SiteState // this site as last seen by the watch
 }
 
 func (s *SitesManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -106,13 +111,42 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	current.Metadata = map[string]interface{}{
-		"version":  "v1",
-		"group":    model.FederationGroup,
-		"resource": "sites",
-	}
+	// if current.Status is not nil, update the status using new IsOnline, InstanceStatuses and TargetStatuses
+	// otherwise, only update LastReported as time.Now()
+	err = t.updateStatus(ctx, current.Id, current.Spec, func(status *model.SiteStatus) {
+		if current.Status != nil {
+			status.IsOnline = current.Status.IsOnline
+			status.InstanceStatuses = current.Status.InstanceStatuses
+			status.TargetStatuses = current.Status.TargetStatuses
+		}
+		status.LastReported = time.Now().UTC().Format(time.RFC3339)
+	})
+	return err
+}
+
+// SetEnrollment records the token that a site is enrolled with, or that its token is revoked. A site that isn't
+// registered yet is registered with its name.
+func (t *SitesManager) SetEnrollment(ctx context.Context, name string, enrollment model.SiteEnrollment) error {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "SetEnrollment",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	err = t.updateStatus(ctx, name, nil, func(status *model.SiteStatus) {
+		status.Enrollment = &enrollment
+	})
+	return err
+}
+
+// updateStatus updates the status of a site, and registers the site with the given spec when it isn't registered yet.
+// The spec of a registered site is kept.
+func (t *SitesManager) updateStatus(ctx context.Context, name string, spec *model.SiteSpec, update func(status *model.SiteStatus)) error {
+	t.statusLock.Lock()
+	defer t.statusLock.Unlock()
+
 	getRequest := states.GetRequest{
-		ID: current.Id,
+		ID: name,
 		Metadata: map[string]interface{}{
 			"version":  "v1",
 			"group":    model.FederationGroup,
@@ -125,7 +159,10 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 		if !v1alpha2.IsNotFound(err) {
 			return err
 		}
-		err = t.UpsertSpec(ctx, current.Id, *current.Spec)
+		if spec == nil {
+			spec = &model.SiteSpec{Name: name}
+		}
+		err = t.UpsertSpec(ctx, name, *spec)
 		if err != nil {
 			return err
 		}
@@ -140,7 +177,6 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 	var dict map[string]interface{}
 	json.Unmarshal(jTransfer, &dict)
 
-	delete(dict, "spec")
 	status := dict["status"]
 
 	j, _ := json.Marshal(status)
@@ -149,21 +185,20 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 	if err != nil {
 		return err
 	}
-	// if current.Status is not nil, update the status using new IsOnline, InstanceStatuses and TargetStatuses
-	// otherwise, only update LastReported as time.Now()
-	if current.Status != nil {
-		rStatus.IsOnline = current.Status.IsOnline
-		rStatus.InstanceStatuses = current.Status.InstanceStatuses
-		rStatus.TargetStatuses = current.Status.TargetStatuses
-	}
-	rStatus.LastReported = time.Now().UTC().Format(time.RFC3339)
+	update(&rStatus)
 	dict["status"] = rStatus
 
 	entry.Body = dict
 
 	updateRequest := states.UpsertRequest{
-		Value:    entry,
-		Metadata: current.Metadata,
+		Value: entry,
+		Metadata: map[string]interface{}{
+			"namespace": "",
+			"group":     model.FederationGroup,
+			"version":   "v1",
+			"resource":  "sites",
+			"kind":      "Site",
+		},
 	}
 
 	_, err = t.StateProvider.Upsert(ctx, updateRequest)
@@ -180,17 +215,35 @@ func (m *SitesManager) UpsertSpec(ctx context.Context, name string, spec model.S
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
+	body := map[string]interface{}{
+		"apiVersion": model.FederationGroup + "/v1",
+		"kind":       "Site",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": spec,
+	}
+	// the status, which has the enrollment of the site, is kept when the site is registered again
+	entry, getErr := m.StateProvider.Get(ctx, states.GetRequest{
+		ID: name,
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	})
+	if getErr == nil {
+		if dict, ok := entry.Body.(map[string]interface{}); ok && dict["status"] != nil {
+			body["status"] = dict["status"]
+		}
+	} else if !v1alpha2.IsNotFound(getErr) {
+		err = getErr
+		return err
+	}
 	upsertRequest := states.UpsertRequest{
 		Value: states.StateEntry{
-			ID: name,
-			Body: map[string]interface{}{
-				"apiVersion": model.FederationGroup + "/v1",
-				"kind":       "Site",
-				"metadata": map[string]interface{}{
-					"name": name,
-				},
-				"spec": spec,
-			},
+			ID:   name,
+			Body: body,
 		},
 		Metadata: map[string]interface{}{
 			"template":  fmt.Sprintf(`{"apiVersion":"%s/v1", "kind": "Site", "metadata": {"name": "${{$site()}}"}}`, model.FederationGroup),
@@ -268,12 +321,30 @@ func (s *SitesManager) Poll() []error {
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	thisSite, err := s.GetSpec(ctx, s.VendorContext.SiteInfo.SiteId)
-	if err != nil {
-		//TOOD: only ignore not found, and log the error
-		return nil
+	s.startWatch()
+	s.watchLock.Lock()
+	self := s.self
+	s.watchLock.Unlock()
+
+	var thisSite model.SiteState
+	if self != nil {
+		thisSite = *self
+	} else {
+		thisSite, err = s.GetSpec(ctx, s.VendorContext.SiteInfo.SiteId)
+		if err != nil {
+			//TOOD: only ignore not found, and log the error
+			return nil
+		}
 	}
-	thisSite.Spec.IsSelf = false
+	s.reportToParent(ctx, thisSite)
+	return nil
+}
+
+func (s *SitesManager) reportToParent(ctx context.Context, thisSite model.SiteState) {
+	// copy the spec, thisSite may be shared with the watch
+	spec := *thisSite.Spec
+	spec.IsSelf = false
+	thisSite.Spec = &spec
 	jData, _ := json.Marshal(thisSite)
 	utils.UpdateSite(
 		ctx,
@@ -283,7 +354,60 @@ func (s *SitesManager) Poll() []error {
 		s.VendorContext.SiteInfo.ParentSite.Password,
 		jData,
 	)
-	return nil
+}
+
+// startWatch watches the sites when the state provider supports it, so that changes to this site reach the parent
+// right away and polls don't need to read the site back. A watch that ends is started again on the next poll.
+func (s *SitesManager) startWatch() {
+	watcher, ok := s.StateProvider.(states.IWatchableStateProvider)
+	if !ok {
+		return
+	}
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	if s.watching {
+		return
+	}
+	events, err := watcher.Watch(context.Background(), states.WatchRequest{
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	})
+	if err != nil {
+		s.Context.Logger.Errorf(" M (Sites): failed to watch sites, falling back to polling: %+v", err)
+		return
+	}
+	s.watching = true
+	go s.handleWatchEvents(events)
+}
+
+func (s *SitesManager) handleWatchEvents(events <-chan states.WatchEvent) {
+	for event := range events {
+		if event.Entry.ID != s.VendorContext.SiteInfo.SiteId {
+			continue
+		}
+		if event.Type == states.WatchEventDeleted {
+			s.watchLock.Lock()
+			s.self = nil
+			s.watchLock.Unlock()
+			continue
+		}
+		thisSite, err := getSiteState(event.Entry.ID, event.Entry.Body)
+		if err != nil {
+			s.Context.Logger.Errorf(" M (Sites): failed to read site %s from watch: %+v", event.Entry.ID, err)
+			continue
+		}
+		s.watchLock.Lock()
+		s.self = &thisSite
+		s.watchLock.Unlock()
+		s.reportToParent(context.Background(), thisSite)
+	}
+	s.watchLock.Lock()
+	s.watching = false
+	s.self = nil
+	s.watchLock.Unlock()
 }
 func (s *SitesManager) Reconcil() []error {
 	return nil
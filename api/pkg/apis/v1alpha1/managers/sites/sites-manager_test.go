@@ -8,10 +8,20 @@ package sites
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,3 +68,123 @@ func TestUpdateTargetStatus(t *testing.T) {
 	assert.Equal(t, true, spec.Status.IsOnline)
 	assert.NotEqual(t, "", spec.Status.LastReported)
 }
+
+func TestReportStateKeepsSpec(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SitesManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.UpsertSpec(context.Background(), "test", model.SiteSpec{Name: "test", Properties: map[string]string{"region": "west"}})
+	assert.Nil(t, err)
+	err = manager.ReportState(context.Background(), model.SiteState{
+		Id:     "test",
+		Spec:   &model.SiteSpec{Name: "test", Properties: map[string]string{"region": "east"}},
+		Status: &model.SiteStatus{IsOnline: true},
+	})
+	assert.Nil(t, err)
+	site, err := manager.GetSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "west", site.Spec.Properties["region"])
+	assert.True(t, site.Status.IsOnline)
+}
+
+func TestSetEnrollment(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SitesManager{
+		StateProvider: stateProvider,
+	}
+	// enrolling a site that isn't registered registers it
+	err := manager.SetEnrollment(context.Background(), "test", model.SiteEnrollment{TokenHash: "hash"})
+	assert.Nil(t, err)
+	site, err := manager.GetSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "test", site.Spec.Name)
+	assert.Equal(t, "hash", site.Status.Enrollment.TokenHash)
+
+	// the enrollment is kept when the site reports its status or is registered again
+	err = manager.ReportState(context.Background(), model.SiteState{
+		Id:     "test",
+		Spec:   &model.SiteSpec{Name: "test"},
+		Status: &model.SiteStatus{IsOnline: true},
+	})
+	assert.Nil(t, err)
+	err = manager.UpsertSpec(context.Background(), "test", model.SiteSpec{Name: "test", PublicKey: "key"})
+	assert.Nil(t, err)
+	site, err = manager.GetSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "key", site.Spec.PublicKey)
+	assert.True(t, site.Status.IsOnline)
+	assert.Equal(t, "hash", site.Status.Enrollment.TokenHash)
+
+	err = manager.SetEnrollment(context.Background(), "test", model.SiteEnrollment{TokenHash: "hash", Revoked: true})
+	assert.Nil(t, err)
+	site, err = manager.GetSpec(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.True(t, site.Status.Enrollment.Revoked)
+}
+
+func TestPollWithWatch(t *testing.T) {
+	reports := make(chan model.SiteState, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/auth":
+			json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+		case "/federation/status/self":
+			var site model.SiteState
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &site)
+			reports <- site
+		}
+	}))
+	defer ts.Close()
+
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SitesManager{}
+	err := manager.Init(&contexts.VendorContext{
+		Logger: logger.NewLogger("coa.runtime"),
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "self",
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl: ts.URL + "/",
+			},
+		},
+	}, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "state",
+		},
+	}, map[string]providers.IProvider{
+		"state": stateProvider,
+	})
+	assert.Nil(t, err)
+	err = manager.UpsertSpec(context.Background(), "self", model.SiteSpec{Name: "self", IsSelf: true})
+	assert.Nil(t, err)
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	site := waitForReport(t, reports)
+	assert.Equal(t, "self", site.Id)
+	assert.False(t, site.Spec.IsSelf)
+
+	// a change to this site is reported without waiting for the next poll
+	err = manager.UpsertSpec(context.Background(), "self", model.SiteSpec{Name: "self", IsSelf: true, PublicKey: "key"})
+	assert.Nil(t, err)
+	for {
+		site = waitForReport(t, reports)
+		if site.Spec.PublicKey == "key" {
+			break
+		}
+	}
+}
+
+func waitForReport(t *testing.T, reports chan model.SiteState) model.SiteState {
+	select {
+	case site := <-reports:
+		return site
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "site wasn't reported to the parent")
+	}
+	return model.SiteState{}
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// keyedLock serializes work on the same key while letting different keys run in parallel.
+// Entries are reference counted and dropped once the last holder releases them.
+type keyedLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLockEntry
+}
+
+type keyedLockEntry struct {
+	sync.Mutex
+	refs int
+}
+
+func newKeyedLock() *keyedLock {
+	return &keyedLock{
+		locks: make(map[string]*keyedLockEntry),
+	}
+}
+
+func (k *keyedLock) Lock(key string) {
+	k.mu.Lock()
+	entry, ok := k.locks[key]
+	if !ok {
+		entry = &keyedLockEntry{}
+		k.locks[key] = entry
+	}
+	entry.refs++
+	k.mu.Unlock()
+
+	entry.Lock()
+}
+
+func (k *keyedLock) Unlock(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entry, ok := k.locks[key]
+	if !ok {
+		return
+	}
+	entry.refs--
+	if entry.refs <= 0 {
+		delete(k.locks, key)
+	}
+	entry.Unlock()
+}
+
+func (k *keyedLock) size() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.locks)
+}
+
+func instanceLockKey(namespace string, instance string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s/%s", namespace, instance)
+}
+
+// acquireReconcileSlot blocks until a reconcile slot is available or the context is done.
+// When no limit is configured the call returns immediately.
+func (s *SolutionManager) acquireReconcileSlot(ctx context.Context) error {
+	if s.reconcileSlots == nil {
+		return nil
+	}
+	select {
+	case s.reconcileSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SolutionManager) releaseReconcileSlot() {
+	if s.reconcileSlots == nil {
+		return
+	}
+	<-s.reconcileSlots
+}
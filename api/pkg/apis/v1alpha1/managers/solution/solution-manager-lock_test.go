@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLockSameKeySerialized(t *testing.T) {
+	locks := newKeyedLock()
+	var running int32
+	var maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locks.Lock("default/instance1")
+			defer locks.Unlock("default/instance1")
+			n := atomic.AddInt32(&running, 1)
+			if n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxRunning)
+	assert.Equal(t, 0, locks.size())
+}
+func TestKeyedLockDifferentKeysParallel(t *testing.T) {
+	locks := newKeyedLock()
+	locks.Lock("default/instance1")
+	done := make(chan struct{})
+	go func() {
+		locks.Lock("default/instance2")
+		locks.Unlock("default/instance2")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(t, "lock on a different key should not block")
+	}
+	locks.Unlock("default/instance1")
+	assert.Equal(t, 0, locks.size())
+}
+func TestInstanceLockKey(t *testing.T) {
+	assert.Equal(t, "default/instance1", instanceLockKey("", "instance1"))
+	assert.Equal(t, "ns1/instance1", instanceLockKey("ns1", "instance1"))
+}
+func TestReconcileSlotsUnlimited(t *testing.T) {
+	manager := SolutionManager{}
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, manager.acquireReconcileSlot(context.Background()))
+	}
+}
+func TestReconcileSlotsLimited(t *testing.T) {
+	manager := SolutionManager{
+		reconcileSlots: make(chan struct{}, 1),
+	}
+	assert.Nil(t, manager.acquireReconcileSlot(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, manager.acquireReconcileSlot(ctx))
+	manager.releaseReconcileSlot()
+	assert.Nil(t, manager.acquireReconcileSlot(context.Background()))
+}
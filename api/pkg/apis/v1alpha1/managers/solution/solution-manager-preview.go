@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+)
+
+// Preview plans a reconcile and calls every provider in dry-run mode. Neither the targets nor the stored
+// deployment state and summary are changed.
+func (s *SolutionManager) Preview(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (model.PlanPreviewSpec, error) {
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "Preview",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Info(" M (Solution): previewing reconcile")
+
+	if deployment.Instance.Spec == nil {
+		err = v1alpha2.NewCOAError(nil, "deployment instance spec is missing", v1alpha2.BadRequest)
+		return model.PlanPreviewSpec{}, err
+	}
+
+	ret := model.PlanPreviewSpec{
+		IsRemoval: remove,
+		Steps:     make([]model.StepPreviewSpec, 0),
+	}
+
+	rp, _, err := s.planReconcile(iCtx, deployment, remove, namespace, targetName)
+	if err != nil {
+		return ret, err
+	}
+	deployment = rp.deployment
+
+	var testState *model.DeploymentState
+	if rp.previousDesiredState != nil {
+		state := MergeDeploymentStates(&rp.previousDesiredState.State, rp.currentState)
+		testState = &state
+	}
+
+	dep := deployment
+	instanceSpec := *deployment.Instance.Spec
+	instanceSpec.Metadata = api_utils.MergeCollection(deployment.Solution.Spec.Metadata, deployment.Instance.Spec.Metadata)
+	dep.Instance.Spec = &instanceSpec
+
+	for _, step := range rp.plan.Steps {
+		if s.IsTarget && !api_utils.ContainsString(s.TargetNames, step.Target) {
+			continue
+		}
+		if targetName != "" && targetName != step.Target {
+			continue
+		}
+		preview := model.StepPreviewSpec{
+			Target:     step.Target,
+			Role:       step.Role,
+			Components: make([]model.ComponentPreviewSpec, 0),
+		}
+
+		provider, pErr := s.getProviderForStep(step, deployment, rp.previousDesiredState)
+		if pErr != nil {
+			log.Errorf(" M (Solution): failed to create provider: %+v", pErr)
+			preview.Error = "failed to create provider: " + pErr.Error()
+			ret.Steps = append(ret.Steps, preview)
+			continue
+		}
+
+		stepDep := deploymentForStep(dep, step)
+		current, gErr := provider.Get(iCtx, stepDep, step.Components)
+		if gErr != nil {
+			log.Errorf(" M (Solution): failed to get current components: %+v", gErr)
+			preview.Error = "failed to get current components: " + gErr.Error()
+		}
+		rule := provider.GetValidationRule(iCtx)
+		for _, c := range step.Components {
+			preview.Components = append(preview.Components, previewComponent(c, current, rule))
+		}
+
+		if testState != nil && s.canSkipStep(iCtx, step, step.Target, provider, rp.previousDesiredState.State.Components, *testState) {
+			preview.Skipped = true
+			ret.Steps = append(ret.Steps, preview)
+			continue
+		}
+
+		results, aErr := provider.Apply(iCtx, stepDep, step, true)
+		preview.DryRunResults = results
+		if aErr != nil {
+			log.Errorf(" M (Solution): dry run of deployment step failed: %+v", aErr)
+			preview.Error = aErr.Error()
+		}
+		ret.Steps = append(ret.Steps, preview)
+	}
+	return ret, nil
+}
+
+func previewComponent(step model.ComponentStep, current []model.ComponentSpec, rule model.ValidationRule) model.ComponentPreviewSpec {
+	ret := model.ComponentPreviewSpec{
+		Name: step.Component.Name,
+	}
+	var existing *model.ComponentSpec
+	for i, c := range current {
+		if c.Name == step.Component.Name {
+			existing = &current[i]
+			break
+		}
+	}
+	if step.Action == model.ComponentDelete {
+		if existing == nil {
+			ret.Action = model.PreviewUnchanged
+		} else {
+			ret.Action = model.PreviewDelete
+		}
+		return ret
+	}
+	if existing == nil {
+		ret.Action = model.PreviewCreate
+		ret.Changes = model.DiffComponents(model.ComponentSpec{}, step.Component)
+		return ret
+	}
+	ret.Changes = model.DiffComponents(*existing, step.Component)
+	if rule.IsComponentChanged(*existing, step.Component) {
+		ret.Action = model.PreviewUpdate
+	} else {
+		ret.Action = model.PreviewUnchanged
+	}
+	return ret
+}
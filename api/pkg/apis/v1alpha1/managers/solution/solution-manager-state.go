@@ -147,3 +147,17 @@ func findComponentProviders(component string, deployment model.DeploymentSpec) m
 	}
 	return ret
 }
+
+func copyDeploymentState(state model.DeploymentState) model.DeploymentState {
+	ret := model.DeploymentState{
+		Components:      make([]model.ComponentSpec, len(state.Components)),
+		Targets:         make([]model.TargetDesc, len(state.Targets)),
+		TargetComponent: make(map[string]string),
+	}
+	copy(ret.Components, state.Components)
+	copy(ret.Targets, state.Targets)
+	for k, v := range state.TargetComponent {
+		ret.TargetComponent[k] = v
+	}
+	return ret
+}
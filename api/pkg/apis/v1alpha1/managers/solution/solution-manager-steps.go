@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"sort"
+)
+
+type stepOutcome struct {
+	index int
+	err   error
+}
+
+// executeSteps runs steps in dependency order, with at most parallelism steps in flight.
+// Once a step fails no new steps are started; steps already running are waited for and the first error is returned.
+func executeSteps(count int, dependencies [][]int, parallelism int, run func(index int) error) error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	inDegrees := make([]int, count)
+	dependents := make([][]int, count)
+	for j := 0; j < count; j++ {
+		inDegrees[j] = len(dependencies[j])
+		for _, i := range dependencies[j] {
+			dependents[i] = append(dependents[i], j)
+		}
+	}
+	ready := make([]int, 0)
+	for j := 0; j < count; j++ {
+		if inDegrees[j] == 0 {
+			ready = append(ready, j)
+		}
+	}
+
+	outcomes := make(chan stepOutcome, count)
+	running := 0
+	var firstErr error
+	for {
+		for firstErr == nil && running < parallelism && len(ready) > 0 {
+			index := ready[0]
+			ready = ready[1:]
+			running++
+			go func(index int) {
+				outcomes <- stepOutcome{index: index, err: run(index)}
+			}(index)
+		}
+		if running == 0 {
+			break
+		}
+		outcome := <-outcomes
+		running--
+		if outcome.err != nil {
+			if firstErr == nil {
+				firstErr = outcome.err
+			}
+			continue
+		}
+		for _, j := range dependents[outcome.index] {
+			inDegrees[j]--
+			if inDegrees[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+		// keep plan order among the steps that are ready
+		sort.Ints(ready)
+	}
+	return firstErr
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteStepsRespectsDependencies(t *testing.T) {
+	// 0 -> 1 -> 2, 3 is independent
+	dependencies := [][]int{{}, {0}, {1}, {}}
+	var order []int
+	var lock sync.Mutex
+	err := executeSteps(4, dependencies, 4, func(index int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		order = append(order, index)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(order))
+	position := make(map[int]int)
+	for i, v := range order {
+		position[v] = i
+	}
+	assert.True(t, position[0] < position[1])
+	assert.True(t, position[1] < position[2])
+}
+func TestExecuteStepsParallelismLimit(t *testing.T) {
+	dependencies := [][]int{{}, {}, {}, {}, {}, {}}
+	var running int32
+	var maxRunning int32
+	err := executeSteps(6, dependencies, 2, func(index int) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), maxRunning)
+}
+func TestExecuteStepsSequentialByDefault(t *testing.T) {
+	dependencies := [][]int{{}, {}, {}}
+	var order []int
+	err := executeSteps(3, dependencies, 0, func(index int) error {
+		order = append(order, index)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2}, order)
+}
+func TestExecuteStepsStopsOnError(t *testing.T) {
+	dependencies := [][]int{{}, {0}, {1}}
+	var ran []int
+	err := executeSteps(3, dependencies, 1, func(index int) error {
+		ran = append(ran, index)
+		if index == 1 {
+			return errors.New("step failed")
+		}
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "step failed", err.Error())
+	assert.Equal(t, []int{0, 1}, ran)
+}
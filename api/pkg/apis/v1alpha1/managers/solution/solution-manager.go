@@ -34,7 +34,7 @@ import (
 )
 
 var log = logger.NewLogger("coa.runtime")
-var lock sync.Mutex
+var instanceLocks = newKeyedLock()
 
 const (
 	SYMPHONY_AGENT string = "/symphony-agent:"
@@ -43,12 +43,16 @@ const (
 
 type SolutionManager struct {
 	managers.Manager
-	TargetProviders map[string]tgt.ITargetProvider
-	StateProvider   states.IStateProvider
-	ConfigProvider  config.IExtConfigProvider
-	SecretProvoider secret.ISecretProvider
-	IsTarget        bool
-	TargetNames     []string
+	TargetProviders   map[string]tgt.ITargetProvider
+	StateProvider     states.IStateProvider
+	ConfigProvider    config.IExtConfigProvider
+	SecretProvoider   secret.ISecretProvider
+	IsTarget          bool
+	TargetNames       []string
+	StepParallelism   int
+	RetryPolicy       model.RetryPolicy
+	RollbackOnFailure bool
+	reconcileSlots    chan struct{}
 }
 
 type SolutionManagerDeploymentState struct {
@@ -101,6 +105,38 @@ func (s *SolutionManager) Init(context *contexts.VendorContext, config managers.
 		s.TargetNames = strings.Split(v, ",")
 	}
 
+	if v, ok := config.Properties["maxConcurrentReconciles"]; ok {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid maxConcurrentReconciles value '%s'", v), v1alpha2.BadConfig)
+		}
+		if i > 0 {
+			s.reconcileSlots = make(chan struct{}, i)
+		}
+	}
+
+	s.RetryPolicy, err = model.DefaultRetryPolicy().WithOverrides(config.Properties)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := config.Properties[model.RollbackOnFailure]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid %s value '%s'", model.RollbackOnFailure, v), v1alpha2.BadConfig)
+		}
+		s.RollbackOnFailure = b
+	}
+
+	s.StepParallelism = 1
+	if v, ok := config.Properties["maxParallelSteps"]; ok {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 1 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid maxParallelSteps value '%s'", v), v1alpha2.BadConfig)
+		}
+		s.StepParallelism = i
+	}
+
 	if s.IsTarget {
 		if len(s.TargetNames) == 0 {
 			sTargetName := os.Getenv("SYMPHONY_TARGET_NAME")
@@ -193,29 +229,21 @@ func (s *SolutionManager) sendHeartbeat(id string, remove bool, stopCh chan stru
 	}
 }
 
-func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (model.SummarySpec, error) {
-	lock.Lock()
-	defer lock.Unlock()
-
-	stopCh := make(chan struct{})
-	defer close(stopCh)
-	go s.sendHeartbeat(deployment.Instance.Spec.Name, remove, stopCh)
-
-	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
-		"method": "Reconcile",
-	})
-	var err error = nil
-	defer observ_utils.CloseSpanWithError(span, &err)
-
-	log.Info(" M (Solution): reconciling")
+type reconcilePlan struct {
+	deployment           model.DeploymentSpec
+	previousDesiredState *SolutionManagerDeploymentState
+	currentState         model.DeploymentState
+	mergedState          model.DeploymentState
+	plan                 model.DeploymentPlan
+}
 
-	summary := model.SummarySpec{
-		TargetResults:       make(map[string]model.TargetResultSpec),
-		TargetCount:         len(deployment.Targets),
-		SuccessCount:        0,
-		AllAssignedDeployed: false,
+// planReconcile evaluates the deployment, merges it with the previous and the current state and plans the steps to apply.
+// When it fails, it also returns the message to put in the summary.
+func (s *SolutionManager) planReconcile(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (reconcilePlan, string, error) {
+	var err error
+	ret := reconcilePlan{
+		deployment: deployment,
 	}
-
 	if s.VendorContext != nil && s.VendorContext.EvaluationContext != nil {
 		context := s.VendorContext.EvaluationContext.Clone()
 		context.DeploymentSpec = deployment
@@ -223,53 +251,96 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		context.Component = ""
 		context.Namespace = namespace
 		deployment, err = api_utils.EvaluateDeployment(*context)
+		ret.deployment = deployment
 	}
 
 	if err != nil {
 		if remove {
 			log.Infof(" M (Solution): skipped failure to evaluate deployment spec: %+v", err)
 		} else {
-			summary.SummaryMessage = "failed to evaluate deployment spec: " + err.Error()
 			log.Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
-			s.saveSummary(iCtx, deployment, summary, namespace)
-			return summary, err
+			return ret, "failed to evaluate deployment spec: " + err.Error(), err
 		}
 	}
 
-	previousDesiredState := s.getPreviousState(iCtx, deployment.Instance.Spec.Name, namespace)
+	ret.previousDesiredState = s.getPreviousState(ctx, deployment.Instance.Spec.Name, namespace)
 
 	currentDesiredState, err := NewDeploymentState(deployment)
 	if err != nil {
-		summary.SummaryMessage = "failed to create target manager state from deployment spec: " + err.Error()
 		log.Errorf(" M (Solution): failed to create target manager state from deployment spec: %+v", err)
-		s.saveSummary(iCtx, deployment, summary, namespace)
-		return summary, err
+		return ret, "failed to create target manager state from deployment spec: " + err.Error(), err
 	}
-	currentState, _, err := s.Get(iCtx, deployment, targetName)
+	ret.currentState, _, err = s.Get(ctx, deployment, targetName)
 	if err != nil {
-		summary.SummaryMessage = "failed to get current state: " + err.Error()
 		log.Errorf(" M (Solution): failed to get current state: %+v", err)
-		s.saveSummary(iCtx, deployment, summary, namespace)
-		return summary, err
+		return ret, "failed to get current state: " + err.Error(), err
 	}
 	desiredState := currentDesiredState
-	if previousDesiredState != nil {
-		desiredState = MergeDeploymentStates(&previousDesiredState.State, currentDesiredState)
+	if ret.previousDesiredState != nil {
+		desiredState = MergeDeploymentStates(&ret.previousDesiredState.State, currentDesiredState)
 	}
 
 	if remove {
 		desiredState.MarkRemoveAll()
 	}
 
-	mergedState := MergeDeploymentStates(&currentState, desiredState)
+	ret.mergedState = MergeDeploymentStates(&ret.currentState, desiredState)
 
-	plan, err := PlanForDeployment(deployment, mergedState)
+	ret.plan, err = PlanForDeployment(deployment, ret.mergedState)
 	if err != nil {
-		summary.SummaryMessage = "failed to plan for deployment: " + err.Error()
 		log.Errorf(" M (Solution): failed to plan for deployment: %+v", err)
+		return ret, "failed to plan for deployment: " + err.Error(), err
+	}
+	return ret, "", nil
+}
+
+func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (model.SummarySpec, error) {
+	if deployment.Instance.Spec == nil {
+		log.Error(" M (Solution): deployment instance spec is missing")
+		return model.SummarySpec{}, v1alpha2.NewCOAError(nil, "deployment instance spec is missing", v1alpha2.BadRequest)
+	}
+
+	// reconciles of the same instance are serialized, different instances run in parallel up to maxConcurrentReconciles
+	lockKey := instanceLockKey(namespace, deployment.Instance.Spec.Name)
+	instanceLocks.Lock(lockKey)
+	defer instanceLocks.Unlock(lockKey)
+
+	if err := s.acquireReconcileSlot(ctx); err != nil {
+		log.Errorf(" M (Solution): failed to acquire reconcile slot for %s: %+v", lockKey, err)
+		return model.SummarySpec{}, v1alpha2.NewCOAError(err, "failed to acquire reconcile slot", v1alpha2.InternalError)
+	}
+	defer s.releaseReconcileSlot()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s.sendHeartbeat(deployment.Instance.Spec.Name, remove, stopCh)
+
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "Reconcile",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Info(" M (Solution): reconciling")
+
+	summary := model.SummarySpec{
+		TargetResults:       make(map[string]model.TargetResultSpec),
+		TargetCount:         len(deployment.Targets),
+		SuccessCount:        0,
+		AllAssignedDeployed: false,
+	}
+
+	rp, message, err := s.planReconcile(iCtx, deployment, remove, namespace, targetName)
+	deployment = rp.deployment
+	if err != nil {
+		summary.SummaryMessage = message
 		s.saveSummary(iCtx, deployment, summary, namespace)
 		return summary, err
 	}
+	previousDesiredState := rp.previousDesiredState
+	currentState := rp.currentState
+	mergedState := rp.mergedState
+	plan := rp.plan
 
 	col := api_utils.MergeCollection(deployment.Solution.Spec.Metadata, deployment.Instance.Spec.Metadata)
 	dep := deployment
@@ -277,59 +348,64 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	someStepsRan := false
 
 	targetResult := make(map[string]int)
+	changedTargets := make(map[string]bool)
 
+	var testState *model.DeploymentState
+	if previousDesiredState != nil {
+		state := MergeDeploymentStates(&previousDesiredState.State, currentState)
+		testState = &state
+	}
+
+	// independent steps are applied in parallel; all bookkeeping below is guarded by resultLock
+	var resultLock sync.Mutex
 	plannedCount := 0
 	planSuccessCount := 0
-	for _, step := range plan.Steps {
+	err = executeSteps(len(plan.Steps), plan.StepDependencies(), s.StepParallelism, func(index int) error {
+		step := plan.Steps[index]
 		if s.IsTarget && !api_utils.ContainsString(s.TargetNames, step.Target) {
-			continue
+			return nil
 		}
 
 		if targetName != "" && targetName != step.Target {
-			continue
+			return nil
 		}
 
+		resultLock.Lock()
 		plannedCount++
+		resultLock.Unlock()
 
-		dep.ActiveTarget = step.Target
-		agent := findAgent(deployment.Targets[step.Target])
-		if agent != "" {
-			col[ENV_NAME] = agent
-		} else {
-			delete(col, ENV_NAME)
-		}
-		var override tgt.ITargetProvider
-		if v, ok := s.TargetProviders[step.Target]; ok {
-			override = v
-		}
-		var provider providers.IProvider
-		if override == nil {
-			targetSpec := s.getTargetStateForStep(step, deployment, previousDesiredState)
-			provider, err = sp.CreateProviderForTargetRole(s.Context, step.Role, targetSpec, override)
-			if err != nil {
-				summary.SummaryMessage = "failed to create provider:" + err.Error()
-				log.Errorf(" M (Solution): failed to create provider: %+v", err)
-				s.saveSummary(ctx, deployment, summary, namespace)
-				return summary, err
-			}
-		} else {
-			provider = override
+		provider, err := s.getProviderForStep(step, deployment, previousDesiredState)
+		if err != nil {
+			log.Errorf(" M (Solution): failed to create provider: %+v", err)
+			resultLock.Lock()
+			summary.SummaryMessage = "failed to create provider:" + err.Error()
+			resultLock.Unlock()
+			return err
 		}
 
-		if previousDesiredState != nil {
-			testState := MergeDeploymentStates(&previousDesiredState.State, currentState)
-			if s.canSkipStep(iCtx, step, step.Target, provider.(tgt.ITargetProvider), previousDesiredState.State.Components, testState) {
+		if testState != nil {
+			if s.canSkipStep(iCtx, step, step.Target, provider, previousDesiredState.State.Components, *testState) {
+				resultLock.Lock()
 				targetResult[step.Target] = 1
 				planSuccessCount++
-				continue
+				resultLock.Unlock()
+				return nil
 			}
 		}
+
+		resultLock.Lock()
 		someStepsRan = true
-		retryCount := 1
-		//TODO: set to 1 for now. Although retrying can help to handle transient errors, in more cases
-		// an error condition can't be resolved quickly.
-		var stepError error
-		var componentResults map[string]model.ComponentResultSpec
+		changedTargets[step.Target] = true
+		resultLock.Unlock()
+
+		policy, err := s.getRetryPolicy(deployment, step)
+		if err != nil {
+			log.Errorf(" M (Solution): invalid retry policy: %+v", err)
+			resultLock.Lock()
+			summary.SummaryMessage = "invalid retry policy: " + err.Error()
+			resultLock.Unlock()
+			return err
+		}
 
 		// for _, component := range step.Components {
 		// 	for k, v := range component.Component.Properties {
@@ -352,34 +428,33 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		// 	}
 		// }
 
-		for i := 0; i < retryCount; i++ {
-			componentResults, stepError = (provider.(tgt.ITargetProvider)).Apply(iCtx, dep, step, false)
-			if stepError == nil {
-				targetResult[step.Target] = 1
-				summary.AllAssignedDeployed = plannedCount == planSuccessCount
-				summary.UpdateTargetResult(step.Target, model.TargetResultSpec{Status: "OK", Message: "", ComponentResults: componentResults})
-				break
-			} else {
-				targetResult[step.Target] = 0
-				summary.AllAssignedDeployed = false
-				summary.UpdateTargetResult(step.Target, model.TargetResultSpec{Status: "Error", Message: stepError.Error(), ComponentResults: componentResults}) // TODO: this keeps only the last error on the target
-				time.Sleep(5 * time.Second)                                                                                                                      //TODO: make this configurable?
-			}
-		}
+		componentResults, stepError := s.applyStep(iCtx, provider, deploymentForStep(dep, step), step, policy)
+
+		resultLock.Lock()
+		defer resultLock.Unlock()
 		if stepError != nil {
 			log.Errorf(" M (Solution): failed to execute deployment step: %+v", stepError)
-
-			successCount := 0
-			for _, v := range targetResult {
-				successCount += v
-			}
-			summary.SuccessCount = successCount
-			summary.AllAssignedDeployed = plannedCount == planSuccessCount
-			s.saveSummary(iCtx, deployment, summary, namespace)
-			err = stepError
-			return summary, err
+			targetResult[step.Target] = 0
+			summary.MergeTargetResult(step.Target, model.TargetResultSpec{Status: "Error", Message: stepError.Error(), ComponentResults: componentResults})
+			return stepError
 		}
+		targetResult[step.Target] = 1
+		summary.MergeTargetResult(step.Target, model.TargetResultSpec{Status: "OK", Message: "", ComponentResults: componentResults})
 		planSuccessCount++
+		return nil
+	})
+	if err != nil {
+		successCount := 0
+		for _, v := range targetResult {
+			successCount += v
+		}
+		summary.SuccessCount = successCount
+		summary.AllAssignedDeployed = plannedCount == planSuccessCount
+		if !remove && previousDesiredState != nil && len(changedTargets) > 0 && s.isRollbackEnabled(deployment) {
+			summary.Rollback = s.rollback(iCtx, previousDesiredState, mergedState, changedTargets)
+		}
+		s.saveSummary(iCtx, deployment, summary, namespace)
+		return summary, err
 	}
 
 	mergedState.ClearAllRemoved()
@@ -437,6 +512,159 @@ func (s *SolutionManager) getTargetStateForStep(step model.DeploymentStep, deplo
 	return targetSpec
 }
 
+// getProviderForStep returns the target provider override registered for the step's target, or creates one for the step's role
+func (s *SolutionManager) getProviderForStep(step model.DeploymentStep, deployment model.DeploymentSpec, previousDesiredState *SolutionManagerDeploymentState) (tgt.ITargetProvider, error) {
+	if v, ok := s.TargetProviders[step.Target]; ok {
+		return v, nil
+	}
+	targetSpec := s.getTargetStateForStep(step, deployment, previousDesiredState)
+	provider, err := sp.CreateProviderForTargetRole(s.Context, step.Role, targetSpec, nil)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := provider.(tgt.ITargetProvider)
+	if !ok {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("provider for role '%s' of target '%s' is not a target provider", step.Role, step.Target), v1alpha2.BadConfig)
+	}
+	return p, nil
+}
+
+// applyStep applies a deployment step, retrying retriable errors as the policy allows
+func (s *SolutionManager) applyStep(ctx context.Context, provider tgt.ITargetProvider, deployment model.DeploymentSpec, step model.DeploymentStep, policy model.RetryPolicy) (map[string]model.ComponentResultSpec, error) {
+	var stepError error
+	var componentResults map[string]model.ComponentResultSpec
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := policy.AttemptContext(ctx)
+		componentResults, stepError = provider.Apply(attemptCtx, deployment, step, false)
+		cancel()
+		if stepError == nil || attempt >= policy.Attempts() || !v1alpha2.IsRetriable(stepError) {
+			break
+		}
+		delay := policy.Backoff(attempt)
+		log.Infof(" M (Solution): attempt %d of %d to apply step on target %s failed, retrying in %v: %+v", attempt, policy.Attempts(), step.Target, delay, stepError)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return componentResults, v1alpha2.NewCOAError(ctx.Err(), "reconcile cancelled while waiting to retry", v1alpha2.InternalError)
+		}
+	}
+	return componentResults, stepError
+}
+
+// isRollbackEnabled checks the solution metadata first and falls back to the manager setting
+func (s *SolutionManager) isRollbackEnabled(deployment model.DeploymentSpec) bool {
+	if deployment.Solution.Spec != nil {
+		if v, ok := deployment.Solution.Spec.Metadata[model.RollbackOnFailure]; ok {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+			log.Infof(" M (Solution): ignored invalid %s value '%s' in solution metadata", model.RollbackOnFailure, v)
+		}
+	}
+	return s.RollbackOnFailure
+}
+
+// rollback re-applies the last-known-good deployment to the targets a failed reconcile has already changed.
+// Components that were added by the failed attempt are removed, components it changed or removed are restored.
+func (s *SolutionManager) rollback(ctx context.Context, previous *SolutionManagerDeploymentState, attemptedState model.DeploymentState, changedTargets map[string]bool) *model.RollbackResultSpec {
+	ret := &model.RollbackResultSpec{}
+	previousSpec := previous.Spec
+	if previousSpec.Instance.Spec == nil || previousSpec.Solution.Spec == nil {
+		ret.Message = "previous deployment spec is incomplete"
+		log.Errorf(" M (Solution): failed to roll back: %s", ret.Message)
+		return ret
+	}
+	log.Infof(" M (Solution): rolling back %d target(s) of instance %s to the last-known-good deployment", len(changedTargets), previousSpec.Instance.Spec.Name)
+
+	rollbackState := MergeDeploymentStates(&attemptedState, copyDeploymentState(previous.State))
+	plan, err := PlanForDeployment(previousSpec, rollbackState)
+	if err != nil {
+		ret.Message = "failed to plan for rollback: " + err.Error()
+		log.Errorf(" M (Solution): failed to plan for rollback: %+v", err)
+		return ret
+	}
+
+	instanceSpec := *previousSpec.Instance.Spec
+	instanceSpec.Metadata = api_utils.MergeCollection(previousSpec.Solution.Spec.Metadata, previousSpec.Instance.Spec.Metadata)
+	previousSpec.Instance.Spec = &instanceSpec
+
+	var resultLock sync.Mutex
+	err = executeSteps(len(plan.Steps), plan.StepDependencies(), s.StepParallelism, func(index int) error {
+		step := plan.Steps[index]
+		if !changedTargets[step.Target] {
+			return nil
+		}
+		provider, err := s.getProviderForStep(step, previousSpec, previous)
+		if err != nil {
+			return err
+		}
+		policy, err := s.getRetryPolicy(previousSpec, step)
+		if err != nil {
+			return err
+		}
+		componentResults, err := s.applyStep(ctx, provider, deploymentForStep(previousSpec, step), step, policy)
+		resultLock.Lock()
+		defer resultLock.Unlock()
+		if err != nil {
+			ret.MergeTargetResult(step.Target, model.TargetResultSpec{Status: "Error", Message: err.Error(), ComponentResults: componentResults})
+			return err
+		}
+		ret.MergeTargetResult(step.Target, model.TargetResultSpec{Status: "OK", ComponentResults: componentResults})
+		return nil
+	})
+	if err != nil {
+		ret.Message = "failed to roll back: " + err.Error()
+		log.Errorf(" M (Solution): failed to roll back: %+v", err)
+		return ret
+	}
+	ret.Succeeded = true
+	log.Infof(" M (Solution): rolled back instance %s", previousSpec.Instance.Spec.Name)
+	return ret
+}
+
+// getRetryPolicy layers the retry.* keys from the solution metadata and then the target metadata on top of the manager's policy
+func (s *SolutionManager) getRetryPolicy(deployment model.DeploymentSpec, step model.DeploymentStep) (model.RetryPolicy, error) {
+	policy := s.RetryPolicy
+	var err error
+	if deployment.Solution.Spec != nil {
+		policy, err = policy.WithOverrides(deployment.Solution.Spec.Metadata)
+		if err != nil {
+			return policy, err
+		}
+	}
+	if target, ok := deployment.Targets[step.Target]; ok && target.Spec != nil {
+		policy, err = policy.WithOverrides(target.Spec.Metadata)
+		if err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+// deploymentForStep makes a copy of the deployment for a single step so that steps running in parallel
+// don't share the active target or the agent address in the instance metadata
+func deploymentForStep(deployment model.DeploymentSpec, step model.DeploymentStep) model.DeploymentSpec {
+	ret := deployment
+	ret.ActiveTarget = step.Target
+	col := make(map[string]string)
+	for k, v := range deployment.Instance.Spec.Metadata {
+		col[k] = v
+	}
+	agent := ""
+	if target, ok := deployment.Targets[step.Target]; ok && target.Spec != nil {
+		agent = findAgent(target)
+	}
+	if agent != "" {
+		col[ENV_NAME] = agent
+	} else {
+		delete(col, ENV_NAME)
+	}
+	instanceSpec := *deployment.Instance.Spec
+	instanceSpec.Metadata = col
+	ret.Instance.Spec = &instanceSpec
+	return ret
+}
+
 func (s *SolutionManager) saveSummary(ctx context.Context, deployment model.DeploymentSpec, summary model.SummarySpec, namespace string) {
 	// TODO: delete this state when time expires. This should probably be invoked by the vendor (via GetSummary method, for instance)
 	s.StateProvider.Upsert(ctx, states.UpsertRequest{
@@ -532,8 +760,14 @@ func (s *SolutionManager) Get(ctx context.Context, deployment model.DeploymentSp
 		} else {
 			provider = override
 		}
+		targetProvider, ok := provider.(tgt.ITargetProvider)
+		if !ok {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("provider for role '%s' of target '%s' is not a target provider", step.Role, step.Target), v1alpha2.BadConfig)
+			log.Errorf(" M (Solution): failed to create provider: %+v", err)
+			return ret, nil, err
+		}
 		var components []model.ComponentSpec
-		components, err = (provider.(tgt.ITargetProvider)).Get(iCtx, deployment, step.Components)
+		components, err = targetProvider.Get(iCtx, deployment, step.Components)
 
 		if err != nil {
 			log.Errorf(" M (Solution): failed to get: %+v", err)
@@ -8,11 +8,14 @@ package solution
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -54,6 +57,12 @@ func TestFindAgentNotMatch(t *testing.T) {
 	})
 	assert.Equal(t, "", agent)
 }
+func TestReconcileMissingInstanceSpec(t *testing.T) {
+	manager := SolutionManager{}
+	_, err := manager.Reconcile(context.Background(), model.DeploymentSpec{}, false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+}
 func TestSortByDepedenciesSingleChain(t *testing.T) {
 	components := []model.ComponentSpec{
 		{
@@ -623,3 +632,375 @@ func TestMockApplyWithError(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, summary.SuccessCount)
 }
+func TestMockApplyParallelSteps(t *testing.T) {
+	id := uuid.New().String()
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{
+				Name: "parallel",
+			},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name: "a",
+						Type: "mock1",
+					},
+					{
+						Name: "b",
+						Type: "mock2",
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}",
+			"T2": "{b}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{
+					Topologies: []model.TopologySpec{
+						{
+							Bindings: []model.BindingSpec{
+								{
+									Role:     "mock1",
+									Provider: "providers.target.mock",
+									Config: map[string]string{
+										"id": id,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"T2": {
+				Spec: &model.TargetSpec{
+					Topologies: []model.TopologySpec{
+						{
+							Bindings: []model.BindingSpec{
+								{
+									Role:     "mock2",
+									Provider: "providers.target.mock",
+									Config: map[string]string{
+										"id": id,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		StateProvider:   stateProvider,
+		StepParallelism: 2,
+	}
+	summary, err := manager.Reconcile(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, summary.SuccessCount)
+	assert.Equal(t, "OK", summary.TargetResults["T1"].Status)
+	assert.Equal(t, "OK", summary.TargetResults["T2"].Status)
+	assert.True(t, summary.AllAssignedDeployed)
+}
+
+type flakyTargetProvider struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyTargetProvider) Init(config providers.IProviderConfig) error {
+	return nil
+}
+func (f *flakyTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	return model.ValidationRule{}
+}
+func (f *flakyTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	return []model.ComponentSpec{}, nil
+}
+func (f *flakyTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return step.PrepareResultMap(), nil
+}
+
+func flakyDeployment() model.DeploymentSpec {
+	return model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{
+				Name: "flaky",
+			},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Metadata: map[string]string{
+					model.RetryMaxAttempts:  "3",
+					model.RetryInitialDelay: "1ms",
+				},
+				Components: []model.ComponentSpec{
+					{
+						Name: "a",
+						Type: "mock",
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{},
+			},
+		},
+	}
+}
+func TestApplyRetriesTransientError(t *testing.T) {
+	targetProvider := &flakyTargetProvider{
+		failures: 2,
+		err:      v1alpha2.NewCOAError(nil, "registry unavailable", v1alpha2.TransientError),
+	}
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"T1": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+	summary, err := manager.Reconcile(context.Background(), flakyDeployment(), false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, targetProvider.calls)
+	assert.Equal(t, 1, summary.SuccessCount)
+}
+func TestApplyDoesNotRetryTerminalError(t *testing.T) {
+	targetProvider := &flakyTargetProvider{
+		failures: 2,
+		err:      v1alpha2.NewCOAError(nil, "bad chart reference", v1alpha2.TerminalError),
+	}
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"T1": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+	summary, err := manager.Reconcile(context.Background(), flakyDeployment(), false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, targetProvider.calls)
+	assert.Equal(t, "Error", summary.TargetResults["T1"].Status)
+}
+func TestApplyTargetRetryPolicyOverridesSolution(t *testing.T) {
+	targetProvider := &flakyTargetProvider{
+		failures: 2,
+		err:      v1alpha2.NewCOAError(nil, "registry unavailable", v1alpha2.TransientError),
+	}
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"T1": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+	deployment := flakyDeployment()
+	deployment.Targets["T1"].Spec.Metadata = map[string]string{
+		model.RetryMaxAttempts: "2",
+	}
+	_, err := manager.Reconcile(context.Background(), deployment, false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, targetProvider.calls)
+}
+
+type recordingTargetProvider struct {
+	lock       sync.Mutex
+	failOn     string
+	components map[string]model.ComponentSpec
+	applied    []model.ComponentStep
+}
+
+func (r *recordingTargetProvider) Init(config providers.IProviderConfig) error {
+	r.components = make(map[string]model.ComponentSpec)
+	return nil
+}
+func (r *recordingTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	return model.ValidationRule{}
+}
+func (r *recordingTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	ret := make([]model.ComponentSpec, 0)
+	for _, ref := range references {
+		if c, ok := r.components[ref.Component.Name]; ok {
+			ret = append(ret, c)
+		}
+	}
+	return ret, nil
+}
+func (r *recordingTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, c := range step.Components {
+		if c.Action == model.ComponentUpdate && c.Component.Name == r.failOn {
+			return nil, v1alpha2.NewCOAError(nil, "failed to deploy "+c.Component.Name, v1alpha2.TerminalError)
+		}
+	}
+	for _, c := range step.Components {
+		r.applied = append(r.applied, c)
+		if c.Action == model.ComponentDelete {
+			delete(r.components, c.Component.Name)
+		} else {
+			r.components[c.Component.Name] = c.Component
+		}
+	}
+	return step.PrepareResultMap(), nil
+}
+
+func TestReconcileRollbackOnFailure(t *testing.T) {
+	targetProvider := &recordingTargetProvider{failOn: "b"}
+	targetProvider.Init(nil)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"T1": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{
+				Name: "rollback",
+			},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Metadata: map[string]string{
+					model.RollbackOnFailure: "true",
+				},
+				Components: []model.ComponentSpec{
+					{
+						Name: "a",
+						Type: "mock",
+						Properties: map[string]interface{}{
+							"version": "1",
+						},
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{},
+			},
+		},
+	}
+	summary, err := manager.Reconcile(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+	assert.Nil(t, summary.Rollback)
+
+	updated := deployment
+	updated.Solution = model.SolutionState{
+		Spec: &model.SolutionSpec{
+			Metadata: map[string]string{
+				model.RollbackOnFailure: "true",
+			},
+			Components: []model.ComponentSpec{
+				{
+					Name: "a",
+					Type: "mock",
+					Properties: map[string]interface{}{
+						"version": "2",
+					},
+				},
+				{
+					Name: "b",
+					Type: "mock2",
+				},
+			},
+		},
+	}
+	updated.Assignments = map[string]string{
+		"T1": "{a}{b}",
+	}
+	summary, err = manager.Reconcile(context.Background(), updated, false, "default", "")
+	assert.NotNil(t, err)
+	assert.NotNil(t, summary.Rollback)
+	assert.True(t, summary.Rollback.Succeeded)
+	assert.Equal(t, "OK", summary.Rollback.TargetResults["T1"].Status)
+	assert.Equal(t, 1, len(targetProvider.components))
+	assert.Equal(t, "1", targetProvider.components["a"].Properties["version"])
+}
+func TestReconcileNoRollbackByDefault(t *testing.T) {
+	targetProvider := &recordingTargetProvider{failOn: "b"}
+	targetProvider.Init(nil)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"T1": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{
+				Name: "no-rollback",
+			},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name: "a",
+						Type: "mock",
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{},
+			},
+		},
+	}
+	_, err := manager.Reconcile(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+
+	updated := deployment
+	updated.Solution = model.SolutionState{
+		Spec: &model.SolutionSpec{
+			Components: []model.ComponentSpec{
+				{
+					Name: "a",
+					Type: "mock",
+				},
+				{
+					Name: "b",
+					Type: "mock2",
+				},
+			},
+		},
+	}
+	updated.Assignments = map[string]string{
+		"T1": "{a}{b}",
+	}
+	summary, err := manager.Reconcile(context.Background(), updated, false, "default", "")
+	assert.NotNil(t, err)
+	assert.Nil(t, summary.Rollback)
+}
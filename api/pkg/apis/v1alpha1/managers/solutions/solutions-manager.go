@@ -98,13 +98,22 @@ func (t *SolutionsManager) UpsertState(ctx context.Context, name string, state m
 }
 
 func (t *SolutionsManager) ListState(ctx context.Context, namespace string) ([]model.SolutionState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the solutions that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *SolutionsManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.SolutionState, string, error) {
 	ctx, span := observability.StartSpan("Solutions Manager", ctx, &map[string]string{
-		"method": "ListSpec",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.SolutionGroup,
@@ -113,20 +122,24 @@ func (t *SolutionsManager) ListState(ctx context.Context, namespace string) ([]m
 			"kind":      "Solution",
 		},
 	}
-	solutions, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	solutions, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.SolutionState, 0)
 	for _, t := range solutions {
 		var rt model.SolutionState
 		rt, err = getSolutionState(t.ID, t.Body)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
 
 func getSolutionState(id string, body interface{}) (model.SolutionState, error) {
@@ -13,6 +13,7 @@ import (
 	"reflect"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	symproviders "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers"
@@ -34,12 +35,24 @@ var log = logger.NewLogger("coa.runtime")
 type StageManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
+	inFlight      inFlightStages
+}
+
+// inFlightStages tracks the stages being processed by activation, so that a pause or a cancel can interrupt them
+type inFlightStages struct {
+	lock    sync.Mutex
+	nextId  int
+	cancels map[string]map[int]context.CancelFunc
 }
 
 type TaskResult struct {
 	Outputs map[string]interface{}
 	Site    string
 	Error   error
+	// pause is set when the site asked the stage to pause, which is recorded by the goroutine that collects results
+	pause bool
+	// retriesExhausted is set when the site still failed after the last attempt the retry policy allows
+	retriesExhausted bool
 }
 
 func (t *TaskResult) GetError() error {
@@ -86,6 +99,8 @@ func (t *TaskResult) GetError() error {
 type PendingTask struct {
 	Sites         []string                          `json:"sites"`
 	OutputContext map[string]map[string]interface{} `json:"outputContext,omitempty"`
+	// Trigger runs the stage again when it's interrupted by a pause
+	Trigger *v1alpha2.ActivationData `json:"trigger,omitempty"`
 }
 
 func (s *StageManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -274,7 +289,9 @@ func (s *StageManager) HandleDirectTriggerEvent(ctx context.Context, triggerData
 		return status
 	}
 
-	outputs, _, err := provider.(stage.IStageProvider).Process(ctx, *s.Manager.Context, triggerData.Inputs)
+	runCtx, untrack := s.trackActivation(ctx, triggerData.Namespace, triggerData.Activation)
+	defer untrack()
+	outputs, _, err := s.processUntilDone(runCtx, provider.(stage.IStageProvider), triggerData.Inputs)
 
 	result := TaskResult{
 		Outputs: outputs,
@@ -303,6 +320,210 @@ func (s *StageManager) HandleDirectTriggerEvent(ctx context.Context, triggerData
 	status.IsActive = false
 	return status
 }
+
+// reportWaves publishes the progress of a stage rollout after each wave, so that it shows in the activation status
+// before the stage is done
+func (s *StageManager) reportWaves(triggerData v1alpha2.ActivationData, waves []model.WaveStatus) {
+	report := make([]model.WaveStatus, len(waves))
+	copy(report, waves)
+	s.Context.Publish("rollout", v1alpha2.Event{
+		Metadata: map[string]string{
+			"activation": triggerData.Activation,
+			"namespace":  triggerData.Namespace,
+		},
+		Body: model.ActivationStatus{
+			Stage:                triggerData.Stage,
+			Status:               v1alpha2.Running,
+			IsActive:             true,
+			ActivationGeneration: triggerData.ActivationGeneration,
+			Waves:                report,
+		},
+	})
+}
+
+// trackActivation derives a context for a stage of the activation, which InterruptActivation cancels. The returned
+// function stops tracking it.
+func (s *StageManager) trackActivation(ctx context.Context, namespace string, activation string) (context.Context, func()) {
+	key := fmt.Sprintf("%s/%s", namespace, activation)
+	runCtx, cancel := context.WithCancel(ctx)
+	s.inFlight.lock.Lock()
+	defer s.inFlight.lock.Unlock()
+	if s.inFlight.cancels == nil {
+		s.inFlight.cancels = make(map[string]map[int]context.CancelFunc)
+	}
+	if _, ok := s.inFlight.cancels[key]; !ok {
+		s.inFlight.cancels[key] = make(map[int]context.CancelFunc)
+	}
+	id := s.inFlight.nextId
+	s.inFlight.nextId++
+	s.inFlight.cancels[key][id] = cancel
+	return runCtx, func() {
+		s.inFlight.lock.Lock()
+		defer s.inFlight.lock.Unlock()
+		delete(s.inFlight.cancels[key], id)
+		if len(s.inFlight.cancels[key]) == 0 {
+			delete(s.inFlight.cancels, key)
+		}
+		cancel()
+	}
+}
+
+// InterruptActivation stops the stages the activation is processing on this site. Sites that haven't started are
+// skipped, and providers that are still running are abandoned. It returns how many stages were interrupted.
+func (s *StageManager) InterruptActivation(namespace string, activation string) int {
+	s.inFlight.lock.Lock()
+	defer s.inFlight.lock.Unlock()
+	cancels := s.inFlight.cancels[fmt.Sprintf("%s/%s", namespace, activation)]
+	for _, cancel := range cancels {
+		cancel()
+	}
+	if len(cancels) > 0 {
+		log.Infof(" M (Stage): interrupted %d stages of activation %s", len(cancels), activation)
+	}
+	return len(cancels)
+}
+
+// InterruptRemoteStages signals the sites of a stage that paused while they process it, which is how remote stages
+// run, to stop processing it. It drops the pending task of the stage and returns the trigger that runs the stage
+// again, or nil when the activation isn't waiting on sites.
+func (s *StageManager) InterruptRemoteStages(ctx context.Context, triggerData v1alpha2.ActivationData) (*v1alpha2.ActivationData, error) {
+	id := fmt.Sprintf("%s-%s-%s", triggerData.Campaign, triggerData.Activation, triggerData.ActivationGeneration)
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID: id,
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	jData, _ := json.Marshal(entry.Body)
+	var p PendingTask
+	err = json.Unmarshal(jData, &p)
+	if err != nil {
+		return nil, err
+	}
+	for _, site := range p.Sites {
+		if site == s.Context.SiteInfo.SiteId {
+			continue
+		}
+		err = s.Context.Publish("remote", v1alpha2.Event{
+			Metadata: map[string]string{
+				"site":       site,
+				"objectType": "task",
+				"origin":     s.Context.SiteInfo.SiteId,
+			},
+			Body: v1alpha2.JobData{
+				Id:     "",
+				Action: v1alpha2.JobCancel,
+				Body: v1alpha2.InputOutputData{
+					Inputs: map[string]interface{}{
+						"__campaign":             triggerData.Campaign,
+						"__namespace":            triggerData.Namespace,
+						"__activation":           triggerData.Activation,
+						"__activationGeneration": triggerData.ActivationGeneration,
+					},
+				},
+			},
+		})
+		if err != nil {
+			log.Errorf(" M (Stage): failed to signal site %s to stop activation %s: %v", site, triggerData.Activation, err)
+		}
+	}
+	err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.Trigger, nil
+}
+
+// HoldTrigger keeps the trigger of a paused activation until it's resumed
+func (s *StageManager) HoldTrigger(ctx context.Context, triggerData v1alpha2.ActivationData) error {
+	_, err := s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   fmt.Sprintf("held-%s-%s", triggerData.Namespace, triggerData.Activation),
+			Body: triggerData,
+		},
+	})
+	return err
+}
+
+// ReleaseTrigger removes and returns the trigger HoldTrigger kept for the activation, or nil when there's none
+func (s *StageManager) ReleaseTrigger(ctx context.Context, namespace string, activation string) (*v1alpha2.ActivationData, error) {
+	id := fmt.Sprintf("held-%s-%s", namespace, activation)
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID: id,
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	jData, _ := json.Marshal(entry.Body)
+	var triggerData v1alpha2.ActivationData
+	err = json.Unmarshal(jData, &triggerData)
+	if err != nil {
+		return nil, err
+	}
+	err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &triggerData, nil
+}
+
+// processWithRetry processes the stage on a site, retrying retriable errors as the policy allows, and returns the
+// number of attempts made. An attempt that runs past the deadline of ctx is abandoned, even when the provider doesn't
+// watch ctx.
+func (s *StageManager) processWithRetry(ctx context.Context, provider stage.IStageProvider, inputs map[string]interface{}, policy model.RetryPolicy, site string) (map[string]interface{}, bool, int, error) {
+	var outputs map[string]interface{}
+	var pause bool
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		attemptCtx, cancel := policy.AttemptContext(ctx)
+		outputs, pause, err = s.processUntilDone(attemptCtx, provider, inputs)
+		cancel()
+		if err == nil || attempt >= policy.Attempts() || !v1alpha2.IsRetriable(err) || ctx.Err() != nil {
+			break
+		}
+		delay := policy.Backoff(attempt)
+		log.Infof(" M (Stage): attempt %d of %d to process stage on site %s failed, retrying in %v: %+v", attempt, policy.Attempts(), site, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return outputs, pause, attempt, v1alpha2.NewCOAError(ctx.Err(), "stage stopped while waiting to retry", v1alpha2.InternalError)
+		}
+	}
+	return outputs, pause, attempt, err
+}
+
+type processResult struct {
+	outputs map[string]interface{}
+	pause   bool
+	err     error
+}
+
+// processUntilDone processes the stage, giving up when ctx is done
+func (s *StageManager) processUntilDone(ctx context.Context, provider stage.IStageProvider, inputs map[string]interface{}) (map[string]interface{}, bool, error) {
+	done := make(chan processResult, 1)
+	go func() {
+		outputs, pause, err := provider.Process(ctx, *s.Manager.Context, inputs)
+		done <- processResult{outputs: outputs, pause: pause, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.outputs, result.pause, result.err
+	case <-ctx.Done():
+		return nil, false, v1alpha2.NewCOAError(ctx.Err(), "stage processing stopped", v1alpha2.InternalError)
+	}
+}
+
 func carryOutPutsToErrorStatus(outputs map[string]interface{}, err error, site string) map[string]interface{} {
 	ret := make(map[string]interface{})
 	statusKey := "__status"
@@ -336,6 +557,14 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	log.Info(" M (Stage): HandleTriggerEvent")
+	runCtx, untrack := s.trackActivation(ctx, triggerData.Namespace, triggerData.Activation)
+	defer untrack()
+	// the stage runs again from this trigger when a pause interrupts it
+	rerun := triggerData
+	rerun.Outputs = make(map[string]map[string]interface{}, len(triggerData.Outputs))
+	for k, v := range triggerData.Outputs {
+		rerun.Outputs[k] = v
+	}
 	status := model.ActivationStatus{
 		Stage:        triggerData.Stage,
 		NextStage:    "",
@@ -452,78 +681,185 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 			log.Errorf(" M (Stage): provider %s does not implement IWithManagerContext", triggerData.Provider)
 		}
 
+		rollout := model.RolloutSpec{}
+		if currentStage.Rollout != nil {
+			rollout = *currentStage.Rollout
+		}
+		var wavePause time.Duration
+		wavePause, err = rollout.GetWavePause()
+		if err != nil {
+			status.Status = v1alpha2.BadRequest
+			status.ErrorMessage = err.Error()
+			status.IsActive = false
+			log.Errorf(" M (Stage): invalid rollout: %v", err)
+			return status, activationData
+		}
+		retryPolicy := model.DefaultRetryPolicy()
+		if currentStage.Retry != nil {
+			retryPolicy, err = currentStage.Retry.GetPolicy()
+			if err != nil {
+				status.Status = v1alpha2.BadRequest
+				status.ErrorMessage = err.Error()
+				status.IsActive = false
+				log.Errorf(" M (Stage): invalid retry: %v", err)
+				return status, activationData
+			}
+		}
+		var timeout time.Duration
+		timeout, err = currentStage.GetTimeout()
+		if err != nil {
+			status.Status = v1alpha2.BadRequest
+			status.ErrorMessage = err.Error()
+			status.IsActive = false
+			log.Errorf(" M (Stage): invalid timeout: %v", err)
+			return status, activationData
+		}
+		// the timeout bounds the processing of the stage on all its sites, but not the bookkeeping after it
+		stageCtx := runCtx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			stageCtx, cancel = context.WithTimeout(runCtx, timeout)
+			defer cancel()
+		}
+
 		numTasks := len(sites)
-		waitGroup := sync.WaitGroup{}
 		results := make(chan TaskResult, numTasks)
 		pauseRequested := false
+		waves := make([]model.WaveStatus, 0)
+		failedSites := 0
+		halted := false
 
-		for _, site := range sites {
-			waitGroup.Add(1)
-			go func(wg *sync.WaitGroup, site string, results chan<- TaskResult) {
-				defer wg.Done()
-				inputCopy := make(map[string]interface{})
-				for k, v := range inputs {
-					inputCopy[k] = v
+		// sites are processed in waves, with at most the rollout's concurrency running at the same time. Without a
+		// rollout, all the sites are processed at once in a single wave.
+		siteWaves := rollout.GetWaves(sites)
+		for i, wave := range siteWaves {
+			waveStatus := model.WaveStatus{
+				Wave:   i + 1,
+				Sites:  wave,
+				Status: v1alpha2.Untouched,
+			}
+			// a scheduled stage only hands its sites to the scheduler here, so there's nothing to wait for
+			if !halted && i > 0 && wavePause > 0 && triggerData.Schedule == nil {
+				log.Infof(" M (Stage): waiting %s before wave %d of stage %s", wavePause, i+1, triggerData.Stage)
+				select {
+				case <-time.After(wavePause):
+				case <-stageCtx.Done():
+					halted = true
 				}
-				inputCopy["__site"] = site
+			}
+			if stageCtx.Err() != nil {
+				halted = true
+			}
+			if halted {
+				waves = append(waves, waveStatus)
+				continue
+			}
 
-				for k, v := range inputCopy {
-					var val interface{}
-					val, err = s.traceValue(v, inputCopy, triggerData.Outputs)
-					if err != nil {
-						status.Status = v1alpha2.InternalError
-						status.ErrorMessage = err.Error()
-						status.IsActive = false
-						log.Errorf(" M (Stage): failed to evaluate input: %v", err)
+			if _, ok := provider.(*remote.RemoteStageProvider); ok {
+				provider.(*remote.RemoteStageProvider).SetOutputsContext(triggerData.Outputs)
+			}
+
+			// site goroutines report back only through waveResults; err, status and pauseRequested are owned by this loop
+			waitGroup := sync.WaitGroup{}
+			waveResults := make(chan TaskResult, len(wave))
+			slots := make(chan struct{}, rollout.GetConcurrency(len(wave)))
+			for _, site := range wave {
+				waitGroup.Add(1)
+				go func(wg *sync.WaitGroup, site string, results chan<- TaskResult) {
+					defer wg.Done()
+					slots <- struct{}{}
+					defer func() { <-slots }()
+					if stageCtx.Err() != nil {
 						results <- TaskResult{
 							Outputs: nil,
-							Error:   err,
+							Error:   v1alpha2.NewCOAError(stageCtx.Err(), fmt.Sprintf("stage %s is stopped before site %s started", triggerData.Stage, site), v1alpha2.InternalError),
 							Site:    site,
 						}
 						return
 					}
-					inputCopy[k] = val
-				}
-
-				if _, ok := provider.(*remote.RemoteStageProvider); ok {
-					provider.(*remote.RemoteStageProvider).SetOutputsContext(triggerData.Outputs)
-				}
-
-				if triggerData.Schedule != nil {
-					s.Context.Publish("schedule", v1alpha2.Event{
-						Body: triggerData,
-					})
-					pauseRequested = true
-					results <- TaskResult{
-						Outputs: nil,
-						Error:   nil,
-						Site:    site,
+					inputCopy := make(map[string]interface{})
+					for k, v := range inputs {
+						inputCopy[k] = v
 					}
-				} else {
-					var outputs map[string]interface{}
-					var pause bool
-					outputs, pause, err = provider.(stage.IStageProvider).Process(ctx, *s.Manager.Context, inputCopy)
+					inputCopy["__site"] = site
 
-					if pause {
-						pauseRequested = true
+					for k, v := range inputCopy {
+						val, err := s.traceValue(v, inputCopy, triggerData.Outputs)
+						if err != nil {
+							log.Errorf(" M (Stage): failed to evaluate input: %v", err)
+							results <- TaskResult{
+								Outputs: nil,
+								Error:   err,
+								Site:    site,
+							}
+							return
+						}
+						inputCopy[k] = val
 					}
-					results <- TaskResult{
-						Outputs: outputs,
-						Error:   err,
-						Site:    site,
+
+					if triggerData.Schedule != nil {
+						s.Context.Publish("schedule", v1alpha2.Event{
+							Body: triggerData,
+						})
+						results <- TaskResult{
+							Outputs: nil,
+							Error:   nil,
+							Site:    site,
+							pause:   true,
+						}
+					} else {
+						outputs, pause, attempts, err := s.processWithRetry(stageCtx, provider.(stage.IStageProvider), inputCopy, retryPolicy, site)
+						results <- TaskResult{
+							Outputs:          outputs,
+							Error:            err,
+							Site:             site,
+							pause:            pause,
+							retriesExhausted: err != nil && attempts > 1 && attempts >= retryPolicy.Attempts(),
+						}
 					}
+				}(&waitGroup, site, waveResults)
+			}
+			waitGroup.Wait()
+			close(waveResults)
+
+			waveStatus.Status = v1alpha2.Done
+			for result := range waveResults {
+				if result.pause {
+					pauseRequested = true
 				}
-			}(&waitGroup, site, results)
+				if result.GetError() != nil {
+					waveStatus.Failed++
+					waveStatus.FailedSites = append(waveStatus.FailedSites, result.Site)
+				} else {
+					waveStatus.Succeeded++
+				}
+				results <- result
+			}
+			if waveStatus.Failed > 0 {
+				waveStatus.Status = v1alpha2.InternalError
+			}
+			waves = append(waves, waveStatus)
+			failedSites += waveStatus.Failed
+			if rollout.FailureThreshold > 0 && failedSites >= rollout.FailureThreshold {
+				log.Errorf(" M (Stage): %d sites failed in stage %s, halting the remaining waves", failedSites, triggerData.Stage)
+				halted = true
+			}
+			// the status of the last wave is reported with the status of the stage
+			if currentStage.Rollout != nil && !halted && !triggerData.NeedsReport && i < len(siteWaves)-1 {
+				s.reportWaves(triggerData, waves)
+			}
 		}
-
-		waitGroup.Wait()
 		close(results)
 
 		outputs := make(map[string]interface{})
 		delayedExit := false
+		retriesExhausted := false
 		for result := range results {
 			err = result.GetError()
 			if err != nil {
+				if result.retriesExhausted {
+					retriesExhausted = true
+				}
 				status.Status = v1alpha2.InternalError
 				status.ErrorMessage = fmt.Sprintf("%s: %s", result.Site, err.Error())
 				status.IsActive = false
@@ -554,6 +890,49 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 				}
 			}
 		}
+		if currentStage.Rollout != nil {
+			status.Waves = waves
+		}
+		if runCtx.Err() != nil && ctx.Err() == nil {
+			status.Status = v1alpha2.InternalError
+			status.ErrorMessage = fmt.Sprintf("stage %s is interrupted", triggerData.Stage)
+			status.IsActive = false
+			status.StopReason = model.StopReasonInterrupted
+			status.Outputs = outputs
+			log.Infof(" M (Stage): stage %s of activation %s is interrupted", triggerData.Stage, triggerData.Activation)
+			return status, &rerun
+		}
+		if halted {
+			status.Status = v1alpha2.InternalError
+			status.ErrorMessage = fmt.Sprintf("rollout of stage %s is halted after %d failed sites", triggerData.Stage, failedSites)
+			if ctx.Err() != nil {
+				status.ErrorMessage = fmt.Sprintf("rollout of stage %s is halted: %v", triggerData.Stage, ctx.Err())
+			}
+			status.IsActive = false
+			delayedExit = true
+		}
+		timedOut := stageCtx.Err() != nil && runCtx.Err() == nil
+		if timedOut && (delayedExit || halted) {
+			status.Status = v1alpha2.InternalError
+			status.ErrorMessage = fmt.Sprintf("stage %s timed out after %s", triggerData.Stage, currentStage.Timeout)
+			status.IsActive = false
+			delayedExit = true
+		} else {
+			timedOut = false
+		}
+		switch {
+		case timedOut:
+			status.StopReason = model.StopReasonTimedOut
+		case halted:
+			status.StopReason = model.StopReasonRolloutHalted
+		case delayedExit && retriesExhausted:
+			status.StopReason = model.StopReasonRetriesExhausted
+		case delayedExit:
+			status.StopReason = model.StopReasonFailed
+		}
+		if status.StopReason != "" {
+			outputs["__stopReason"] = status.StopReason
+		}
 		outputs["__campaign"] = triggerData.Campaign
 		outputs["__namespace"] = triggerData.Namespace
 		outputs["__activation"] = triggerData.Activation
@@ -570,6 +949,7 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 				pendingTask := PendingTask{
 					Sites:         sites,
 					OutputContext: triggerData.Outputs,
+					Trigger:       &rerun,
 				}
 				_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
 					Value: states.StateEntry{
@@ -599,13 +979,19 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 			}
 			eCtx.Outputs = triggerData.Outputs
 			var val interface{}
-			val, err = parser.Eval(*eCtx)
-			if err != nil {
-				status.Status = v1alpha2.InternalError
-				status.ErrorMessage = err.Error()
-				status.IsActive = false
-				log.Errorf(" M (Stage): failed to evaluate stage selector: %v", err)
-				return status, activationData
+			if delayedExit && currentStage.OnFailure != "" {
+				// a failed stage goes to its failure stage instead of the stage its selector picks
+				log.Infof(" M (Stage): stage %s failed (%s), running failure stage %s", triggerData.Stage, status.StopReason, currentStage.OnFailure)
+				val = currentStage.OnFailure
+			} else {
+				val, err = parser.Eval(*eCtx)
+				if err != nil {
+					status.Status = v1alpha2.InternalError
+					status.ErrorMessage = err.Error()
+					status.IsActive = false
+					log.Errorf(" M (Stage): failed to evaluate stage selector: %v", err)
+					return status, activationData
+				}
 			}
 			sVal := ""
 			if val != nil {
@@ -613,7 +999,7 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 			}
 			if sVal != "" {
 				if nextStage, ok := campaign.Stages[sVal]; ok {
-					if !delayedExit || nextStage.HandleErrors {
+					if !delayedExit || nextStage.HandleErrors || sVal == currentStage.OnFailure {
 						status.NextStage = sVal
 						activationData = &v1alpha2.ActivationData{
 							Campaign:             triggerData.Campaign,
@@ -646,7 +1032,10 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 			status.NextStage = sVal
 			if sVal == "" {
 				status.IsActive = false
-				status.Status = v1alpha2.Done
+				// a halted or timed out stage keeps its error status
+				if !halted && !timedOut {
+					status.Status = v1alpha2.Done
+				}
 			} else {
 				if pauseRequested {
 					status.IsActive = false
@@ -659,7 +1048,9 @@ func (s *StageManager) HandleTriggerEvent(ctx context.Context, campaign model.Ca
 			log.Infof(" M (Stage): stage %s is done", triggerData.Stage)
 			return status, activationData
 		} else {
-			status.Status = v1alpha2.Done
+			if !halted && !timedOut {
+				status.Status = v1alpha2.Done
+			}
 			status.NextStage = ""
 			status.IsActive = false
 			log.Infof(" M (Stage): stage %s is done (no next stage)", triggerData.Stage)
@@ -734,6 +1125,10 @@ func (s *StageManager) HandleActivationEvent(ctx context.Context, actData v1alph
 		if activation.Status != nil && activation.Status.Stage != "" && activation.Status.NextStage != stage {
 			return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("stage %s is not the next stage", stage), v1alpha2.BadRequest)
 		}
+		schedule := stageSpec.Schedule
+		if schedule == nil && stage == campaign.FirstStage {
+			schedule = campaign.Schedule
+		}
 		return &v1alpha2.ActivationData{
 			Campaign:             actData.Campaign,
 			Activation:           actData.Activation,
@@ -743,7 +1138,7 @@ func (s *StageManager) HandleActivationEvent(ctx context.Context, actData v1alph
 			Provider:             stageSpec.Provider,
 			Config:               stageSpec.Config,
 			TriggeringStage:      stage,
-			Schedule:             stageSpec.Schedule,
+			Schedule:             schedule,
 		}, nil
 	}
 	return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("stage %s is not found", stage), v1alpha2.BadRequest)
@@ -9,12 +9,18 @@ package stage
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
@@ -1063,6 +1069,48 @@ func TestHandleActivationEvent(t *testing.T) {
 	assert.Equal(t, int(1), output.Inputs["foo"])
 	assert.Equal(t, int(2), output.Inputs["bar"])
 	assert.Equal(t, "providers.stage.mock", output.Provider)
+	assert.Nil(t, output.Schedule)
+}
+func TestHandleActivationEventWithCampaignSchedule(t *testing.T) {
+	manager := StageManager{}
+	campaign := model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Schedule: &v1alpha2.ScheduleSpec{
+			Cron: "0 2 * * SUN",
+		},
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.mock",
+			},
+			"test2": {
+				Provider: "providers.stage.mock",
+				Schedule: &v1alpha2.ScheduleSpec{
+					Interval: "1h",
+				},
+			},
+		},
+	}
+	activationState := model.ActivationState{
+		Spec: &model.ActivationSpec{},
+	}
+	output, err := manager.HandleActivationEvent(context.Background(), v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+	}, campaign, activationState)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", output.Stage)
+	assert.Equal(t, "0 2 * * SUN", output.Schedule.Cron)
+
+	// a stage schedule is used instead of the campaign schedule
+	campaign.FirstStage = "test2"
+	output, err = manager.HandleActivationEvent(context.Background(), v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+	}, campaign, activationState)
+	assert.Nil(t, err)
+	assert.Equal(t, "test2", output.Stage)
+	assert.Equal(t, "1h", output.Schedule.Interval)
 }
 func TestTriggerEventWithSchedule(t *testing.T) {
 	stateProvider := &memorystate.MemoryStateProvider{}
@@ -1117,3 +1165,417 @@ func TestTriggerEventWithSchedule(t *testing.T) {
 	assert.Equal(t, v1alpha2.Paused, status.Status)
 	assert.Equal(t, false, status.IsActive)
 }
+
+func newRolloutTestManager() *StageManager {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := &StageManager{
+		StateProvider: stateProvider,
+	}
+	manager.VendorContext = &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+		},
+	}
+	manager.Context = &contexts.ManagerContext{
+		VencorContext: manager.VendorContext,
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+		},
+	}
+	return manager
+}
+
+func TestTriggerEventWithRolloutWaves(t *testing.T) {
+	manager := newRolloutTestManager()
+	activation := v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Inputs: map[string]interface{}{
+			"context": []interface{}{"site1", "site2", "site3", "site4", "site5"},
+		},
+		Provider: "providers.stage.mock",
+	}
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.mock",
+				Contexts: "${{$val()}}",
+				Rollout: &model.RolloutSpec{
+					WaveSize:       2,
+					MaxConcurrency: 1,
+				},
+			},
+		},
+	}, activation)
+	assert.Equal(t, v1alpha2.Done, status.Status)
+	assert.Equal(t, 3, len(status.Waves))
+	for i, sites := range [][]string{{"site1", "site2"}, {"site3", "site4"}, {"site5"}} {
+		assert.Equal(t, i+1, status.Waves[i].Wave)
+		assert.Equal(t, sites, status.Waves[i].Sites)
+		assert.Equal(t, v1alpha2.Done, status.Waves[i].Status)
+		assert.Equal(t, len(sites), status.Waves[i].Succeeded)
+		assert.Equal(t, 0, status.Waves[i].Failed)
+	}
+	for _, site := range []string{"site1", "site2", "site3", "site4", "site5"} {
+		assert.Equal(t, v1alpha2.OK, status.Outputs[site+".__status"])
+	}
+}
+
+// failingStageProvider fails on the sites listed in its "fail" input and succeeds on the others. The failures are
+// retriable unless the "terminal" input is true.
+type failingStageProvider struct{}
+
+func (p *failingStageProvider) Init(config providers.IProviderConfig) error {
+	return nil
+}
+
+func (p *failingStageProvider) SetContext(ctx *contexts.ManagerContext) {
+}
+
+func (p *failingStageProvider) Process(ctx context.Context, mgrContext contexts.ManagerContext, inputs map[string]interface{}) (map[string]interface{}, bool, error) {
+	if failing, ok := inputs["fail"].([]interface{}); ok {
+		for _, site := range failing {
+			if site == inputs["__site"] {
+				state := v1alpha2.InternalError
+				if inputs["terminal"] == true {
+					state = v1alpha2.BadRequest
+				}
+				return nil, false, v1alpha2.NewCOAError(nil, fmt.Sprintf("site %v failed", site), state)
+			}
+		}
+	}
+	return map[string]interface{}{}, false, nil
+}
+
+func init() {
+	providerfactory.Register("providers.stage.test.failing", func() providers.IProvider { return &failingStageProvider{} }, nil)
+}
+
+func TestTriggerEventWithRolloutFailureThreshold(t *testing.T) {
+	manager := newRolloutTestManager()
+	activation := v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Inputs: map[string]interface{}{
+			"context": []interface{}{"site1", "site2", "site3", "site4"},
+		},
+		Provider: "providers.stage.test.failing",
+	}
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.test.failing",
+				Contexts: "${{$val()}}",
+				Inputs: map[string]interface{}{
+					"fail": []interface{}{"site2"},
+				},
+				Rollout: &model.RolloutSpec{
+					WaveSize:         2,
+					FailureThreshold: 1,
+					WavePause:        "1s",
+				},
+			},
+		},
+	}, activation)
+	assert.Equal(t, v1alpha2.InternalError, status.Status)
+	assert.False(t, status.IsActive)
+	assert.Contains(t, status.ErrorMessage, "halted after 1 failed sites")
+	assert.Equal(t, 2, len(status.Waves))
+	assert.Equal(t, v1alpha2.InternalError, status.Waves[0].Status)
+	assert.Equal(t, 1, status.Waves[0].Succeeded)
+	assert.Equal(t, []string{"site2"}, status.Waves[0].FailedSites)
+	assert.Equal(t, v1alpha2.Untouched, status.Waves[1].Status)
+	assert.Equal(t, []string{"site3", "site4"}, status.Waves[1].Sites)
+	_, ok := status.Outputs["site3.__status"]
+	assert.False(t, ok)
+}
+
+func TestTriggerEventWithInvalidRollout(t *testing.T) {
+	manager := newRolloutTestManager()
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.mock",
+				Rollout: &model.RolloutSpec{
+					WavePause: "soon",
+				},
+			},
+		},
+	}, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Provider:   "providers.stage.mock",
+	})
+	assert.Equal(t, v1alpha2.BadRequest, status.Status)
+	assert.False(t, status.IsActive)
+}
+
+func TestTriggerEventRetriesHungAttempt(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	manager := newRolloutTestManager()
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.http",
+				Inputs: map[string]interface{}{
+					"method": "GET",
+					"url":    server.URL,
+				},
+				Retry: &model.StageRetrySpec{
+					MaxAttempts:    2,
+					InitialDelay:   "10ms",
+					AttemptTimeout: "200ms",
+				},
+			},
+		},
+	}, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Provider:   "providers.stage.http",
+	})
+	assert.Equal(t, v1alpha2.Done, status.Status)
+	assert.Equal(t, "", status.StopReason)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestTriggerEventTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	manager := newRolloutTestManager()
+	start := time.Now()
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.http",
+				Inputs: map[string]interface{}{
+					"method": "GET",
+					"url":    server.URL,
+				},
+				Timeout: "200ms",
+			},
+		},
+	}, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Provider:   "providers.stage.http",
+	})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, v1alpha2.InternalError, status.Status)
+	assert.False(t, status.IsActive)
+	assert.Equal(t, model.StopReasonTimedOut, status.StopReason)
+	assert.Equal(t, "stage test timed out after 200ms", status.ErrorMessage)
+}
+
+func TestTriggerEventRunsFailureStage(t *testing.T) {
+	manager := newRolloutTestManager()
+	campaign := model.CampaignSpec{
+		Name:        "test-campaign",
+		SelfDriving: true,
+		FirstStage:  "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider:      "providers.stage.http",
+				StageSelector: "next",
+				Inputs: map[string]interface{}{
+					"method": "GET",
+					"url":    "bad url",
+				},
+				Retry: &model.StageRetrySpec{
+					MaxAttempts:  2,
+					InitialDelay: "10ms",
+				},
+				OnFailure: "cleanup",
+			},
+			"next": {
+				Provider: "providers.stage.mock",
+			},
+			"cleanup": {
+				Provider: "providers.stage.mock",
+				Inputs: map[string]interface{}{
+					"reason": "${{$output(test,__stopReason)}}",
+				},
+			},
+		},
+	}
+	status, activation := manager.HandleTriggerEvent(context.Background(), campaign, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Provider:   "providers.stage.http",
+	})
+	assert.Equal(t, model.StopReasonRetriesExhausted, status.StopReason)
+	assert.Equal(t, "cleanup", status.NextStage)
+	assert.NotNil(t, activation)
+	assert.Equal(t, "cleanup", activation.Stage)
+
+	status, activation = manager.HandleTriggerEvent(context.Background(), campaign, *activation)
+	assert.Nil(t, activation)
+	assert.Equal(t, v1alpha2.Done, status.Status)
+	assert.Equal(t, model.StopReasonRetriesExhausted, status.Outputs["reason"])
+}
+
+func TestTriggerEventNonRetriableFailureIsNotRetriesExhausted(t *testing.T) {
+	manager := newRolloutTestManager()
+	status, _ := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.test.failing",
+				Inputs: map[string]interface{}{
+					"fail":     []interface{}{"fake"},
+					"terminal": true,
+				},
+				Retry: &model.StageRetrySpec{
+					MaxAttempts:  3,
+					InitialDelay: "10ms",
+				},
+			},
+		},
+	}, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Stage:      "test",
+		Provider:   "providers.stage.test.failing",
+	})
+	assert.Equal(t, model.StopReasonFailed, status.StopReason)
+}
+
+func TestTriggerEventInterrupted(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	manager := newRolloutTestManager()
+	go func() {
+		<-started
+		assert.Equal(t, 1, manager.InterruptActivation("default", "test-activation"))
+	}()
+	status, activation := manager.HandleTriggerEvent(context.Background(), model.CampaignSpec{
+		Name:       "test-campaign",
+		FirstStage: "test",
+		Stages: map[string]model.StageSpec{
+			"test": {
+				Provider: "providers.stage.http",
+				Inputs: map[string]interface{}{
+					"method": "GET",
+					"url":    server.URL,
+				},
+			},
+		},
+	}, v1alpha2.ActivationData{
+		Campaign:   "test-campaign",
+		Activation: "test-activation",
+		Namespace:  "default",
+		Stage:      "test",
+		Provider:   "providers.stage.http",
+	})
+	assert.Equal(t, v1alpha2.InternalError, status.Status)
+	assert.False(t, status.IsActive)
+	assert.Equal(t, model.StopReasonInterrupted, status.StopReason)
+	assert.NotNil(t, activation)
+	assert.Equal(t, "test", activation.Stage)
+	assert.Equal(t, 0, manager.InterruptActivation("default", "test-activation"))
+}
+
+func TestInterruptRemoteStages(t *testing.T) {
+	manager := newRolloutTestManager()
+	pubSubProvider := &memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	manager.Context.PubsubProvider = pubSubProvider
+	sites := make(chan string, 2)
+	manager.Context.Subscribe("remote", func(topic string, event v1alpha2.Event) error {
+		job := event.Body.(v1alpha2.JobData)
+		assert.Equal(t, v1alpha2.JobCancel, job.Action)
+		sites <- event.Metadata["site"]
+		return nil
+	})
+	triggerData := v1alpha2.ActivationData{
+		Campaign:             "test-campaign",
+		Activation:           "test-activation",
+		ActivationGeneration: "1",
+		Namespace:            "default",
+		Stage:                "test",
+	}
+	_, err := manager.StateProvider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: "test-campaign-test-activation-1",
+			Body: PendingTask{
+				Sites:   []string{"site1", "fake"},
+				Trigger: &triggerData,
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	trigger, err := manager.InterruptRemoteStages(context.Background(), triggerData)
+	assert.Nil(t, err)
+	assert.NotNil(t, trigger)
+	assert.Equal(t, "test", trigger.Stage)
+	select {
+	case site := <-sites:
+		assert.Equal(t, "site1", site)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "site1 is not signalled")
+	}
+
+	trigger, err = manager.InterruptRemoteStages(context.Background(), triggerData)
+	assert.Nil(t, err)
+	assert.Nil(t, trigger)
+}
+
+func TestHoldAndReleaseTrigger(t *testing.T) {
+	manager := newRolloutTestManager()
+	err := manager.HoldTrigger(context.Background(), v1alpha2.ActivationData{
+		Activation: "test-activation",
+		Namespace:  "default",
+		Stage:      "test",
+	})
+	assert.Nil(t, err)
+	trigger, err := manager.ReleaseTrigger(context.Background(), "default", "test-activation")
+	assert.Nil(t, err)
+	assert.NotNil(t, trigger)
+	assert.Equal(t, "test", trigger.Stage)
+	trigger, err = manager.ReleaseTrigger(context.Background(), "default", "test-activation")
+	assert.Nil(t, err)
+	assert.Nil(t, trigger)
+}
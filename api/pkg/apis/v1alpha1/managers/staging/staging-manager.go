@@ -8,7 +8,12 @@ package staging
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
@@ -30,6 +35,15 @@ type StagingManager struct {
 	managers.Manager
 	QueueProvider queue.IQueueProvider
 	StateProvider states.IStateProvider
+
+	// batches are the IDs of the jobs last handed out to each site by a reliable queue. They're acknowledged when the
+	// site asks for its next batch, and delivered again if the site doesn't ask before the visibility timeout.
+	batchesLock sync.Mutex
+	batches     map[string][]string
+
+	// progressLock serializes the batches handed out with resume tokens, which read and write back the progress of
+	// the site
+	progressLock sync.Mutex
 }
 
 const Site_Job_Queue = "site-job-queue"
@@ -67,12 +81,46 @@ func (s *StagingManager) Poll() []error {
 	if s.QueueProvider.Size(Site_Job_Queue) == 0 {
 		return nil
 	}
+	if reliableQueue, ok := s.QueueProvider.(queue.IReliableQueueProvider); ok {
+		// the site stays queued until its catalogs are staged, so that a restart doesn't drop it
+		var message queue.QueueMessage
+		message, err = reliableQueue.Receive(Site_Job_Queue, 0)
+		if err != nil {
+			if v1alpha2.IsNotFound(err) {
+				err = nil
+				return nil
+			}
+			log.Errorf(" M (Staging): Failed to poll: %s", err.Error())
+			return []error{err}
+		}
+		siteId, _ := message.Element.(string)
+		err = s.stageCatalogs(ctx, siteId)
+		if err != nil {
+			reliableQueue.Nack(Site_Job_Queue, message.ID)
+			return []error{err}
+		}
+		err = reliableQueue.Ack(Site_Job_Queue, message.ID)
+		if err != nil {
+			log.Errorf(" M (Staging): Failed to acknowledge site %s: %s", siteId, err.Error())
+			return []error{err}
+		}
+		return nil
+	}
 	site, err := s.QueueProvider.Dequeue(Site_Job_Queue)
 	if err != nil {
 		log.Errorf(" M (Staging): Failed to poll: %s", err.Error())
 		return []error{err}
 	}
-	siteId := site.(string)
+	err = s.stageCatalogs(ctx, site.(string))
+	if err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// stageCatalogs queues the catalogs distributed to a site that changed since they were last staged for it, and the
+// deletion of the catalogs that were staged for it before but are gone or aren't distributed to it anymore
+func (s *StagingManager) stageCatalogs(ctx context.Context, siteId string) error {
 	catalogs, err := utils.GetCatalogs(
 		ctx,
 		s.VendorContext.SiteInfo.CurrentSite.BaseUrl,
@@ -80,49 +128,155 @@ func (s *StagingManager) Poll() []error {
 		s.VendorContext.SiteInfo.CurrentSite.Password)
 	if err != nil {
 		log.Errorf(" M (Staging): Failed to get catalogs: %s", err.Error())
-		observ_utils.CloseSpanWithError(span, &err)
-		return []error{err}
+		return err
+	}
+	site, err := utils.GetSite(
+		ctx,
+		s.VendorContext.SiteInfo.CurrentSite.BaseUrl,
+		siteId,
+		s.VendorContext.SiteInfo.CurrentSite.Username,
+		s.VendorContext.SiteInfo.CurrentSite.Password)
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		log.Errorf(" M (Staging): Failed to get site %s: %s", siteId, err.Error())
+		return err
+	}
+	staged, err := s.listStagedCatalogs(ctx, siteId)
+	if err != nil {
+		log.Errorf(" M (Staging): Failed to list catalogs staged for site %s: %s", siteId, err.Error())
+		return err
 	}
 	for _, catalog := range catalogs {
+		var match bool
+		match, err = utils.CatalogMatchesSite(catalog, site)
+		if err != nil {
+			// whether the site still gets the catalog isn't known, so it's neither queued nor deleted, and its record
+			// is kept until the selector is fixed
+			log.Errorf(" M (Staging): Catalog %s has an invalid site selector: %s", catalog.Spec.Name, err.Error())
+			delete(staged, catalog.Spec.Name)
+			continue
+		}
+		if !match {
+			continue
+		}
+		delete(staged, catalog.Spec.Name)
 		cacheId := siteId + "-" + catalog.Spec.Name
 		getRequest := states.GetRequest{
-			ID: cacheId,
-			Metadata: map[string]interface{}{
-				"version":  "v1",
-				"group":    model.FederationGroup,
-				"resource": "catalogs",
-			},
+			ID:       cacheId,
+			Metadata: stagedCatalogsMetadata(),
 		}
 		var entry states.StateEntry
 		entry, err = s.StateProvider.Get(ctx, getRequest)
-		if err == nil && entry.Body != nil && entry.Body.(string) == catalog.Spec.Generation {
+		if err == nil && stagedGeneration(entry.Body) == catalog.Spec.Generation {
 			continue
 		}
 		if err != nil && !v1alpha2.IsNotFound(err) {
 			log.Errorf(" M (Staging): Failed to get catalog %s: %s", catalog.Spec.Name, err.Error())
 		}
-		s.QueueProvider.Enqueue(siteId, v1alpha2.JobData{
+		err = s.QueueProvider.Enqueue(siteId, v1alpha2.JobData{
 			Id:     catalog.Spec.Name,
 			Action: v1alpha2.JobUpdate,
 			Body:   catalog,
 		})
+		if err != nil {
+			// the catalog isn't recorded as staged, so that it's queued again on the next poll
+			log.Errorf(" M (Staging): Failed to queue catalog %s: %s", catalog.Spec.Name, err.Error())
+			return err
+		}
 		_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
 			Value: states.StateEntry{
-				ID:   cacheId,
-				Body: catalog.Spec.Generation,
-			},
-			Metadata: map[string]interface{}{
-				"version":  "v1",
-				"group":    model.FederationGroup,
-				"resource": "catalogs",
+				ID: cacheId,
+				Body: stagedCatalog{
+					Site:       siteId,
+					Name:       catalog.Spec.Name,
+					Type:       catalog.Spec.Type,
+					Generation: catalog.Spec.Generation,
+				},
 			},
+			Metadata: stagedCatalogsMetadata(),
 		})
 		if err != nil {
 			log.Errorf(" M (Staging): Failed to record catalog %s: %s", catalog.Spec.Name, err.Error())
 		}
 	}
+	for name, record := range staged {
+		err = s.QueueProvider.Enqueue(siteId, v1alpha2.JobData{
+			Id:     name,
+			Action: v1alpha2.JobDelete,
+			Body:   model.NewCatalogTombstone(name, record.Type),
+		})
+		if err != nil {
+			// the record is kept, so that the deletion is queued again on the next poll
+			log.Errorf(" M (Staging): Failed to queue deletion of catalog %s: %s", name, err.Error())
+			return err
+		}
+		err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+			ID:       siteId + "-" + name,
+			Metadata: stagedCatalogsMetadata(),
+		})
+		if err != nil && !v1alpha2.IsNotFound(err) {
+			log.Errorf(" M (Staging): Failed to remove record of catalog %s: %s", name, err.Error())
+		}
+	}
 	return nil
 }
+
+// stagedCatalog records the generation of a catalog last staged for a site
+type stagedCatalog struct {
+	Site       string `json:"site"`
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	Generation string `json:"generation"`
+}
+
+func stagedCatalogsMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"version":  "v1",
+		"group":    model.FederationGroup,
+		"resource": "catalogs",
+	}
+}
+
+// readStagedCatalog reads a record of a staged catalog. Records from older versions only carry the generation, and
+// are read as records without a site.
+func readStagedCatalog(body interface{}) (stagedCatalog, bool) {
+	var ret stagedCatalog
+	switch b := body.(type) {
+	case stagedCatalog:
+		return b, true
+	case string:
+		ret.Generation = b
+		return ret, true
+	case map[string]interface{}:
+		data, err := json.Marshal(b)
+		if err != nil || json.Unmarshal(data, &ret) != nil || ret.Name == "" {
+			return ret, false
+		}
+		return ret, true
+	}
+	return ret, false
+}
+
+func stagedGeneration(body interface{}) string {
+	record, _ := readStagedCatalog(body)
+	return record.Generation
+}
+
+// listStagedCatalogs gets the records of the catalogs staged for a site, by catalog name
+func (s *StagingManager) listStagedCatalogs(ctx context.Context, siteId string) (map[string]stagedCatalog, error) {
+	entries, _, err := s.StateProvider.List(ctx, states.ListRequest{
+		Metadata: stagedCatalogsMetadata(),
+	})
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		return nil, err
+	}
+	ret := make(map[string]stagedCatalog)
+	for _, entry := range entries {
+		if record, ok := readStagedCatalog(entry.Body); ok && record.Site == siteId && entry.ID == siteId+"-"+record.Name {
+			ret[record.Name] = record
+		}
+	}
+	return ret, nil
+}
 func (s *StagingManager) Reconcil() []error {
 	return nil
 }
@@ -147,6 +301,9 @@ func (s *StagingManager) HandleJobEvent(ctx context.Context, event v1alpha2.Even
 func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.JobData, error) {
 	//TODO: this should return a group of jobs as optimization
 	s.QueueProvider.Enqueue(Site_Job_Queue, site)
+	if reliableQueue, ok := s.QueueProvider.(queue.IReliableQueueProvider); ok {
+		return s.getABatchFromReliableQueue(reliableQueue, site, count)
+	}
 	if s.QueueProvider.Size(site) == 0 {
 		return nil, nil
 	}
@@ -157,7 +314,7 @@ func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.Jo
 		if err != nil {
 			return nil, err
 		}
-		if job, ok := queueElement.(v1alpha2.JobData); ok {
+		if job, ok := toJobData(queueElement); ok {
 			items = append(items, job)
 			itemCount++
 		} else {
@@ -169,3 +326,352 @@ func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.Jo
 	}
 	return items, nil
 }
+
+// getABatchFromReliableQueue acknowledges the batch last handed out to a site, which the site asking again means it
+// has received, and receives the next batch. The jobs of the batch stay queued until they're acknowledged.
+func (s *StagingManager) getABatchFromReliableQueue(reliableQueue queue.IReliableQueueProvider, site string, count int) ([]v1alpha2.JobData, error) {
+	s.batchesLock.Lock()
+	defer s.batchesLock.Unlock()
+	if s.batches == nil {
+		s.batches = make(map[string][]string)
+	}
+	for _, id := range s.batches[site] {
+		if err := reliableQueue.Ack(site, id); err != nil && !v1alpha2.IsNotFound(err) {
+			log.Errorf(" M (Staging): Failed to acknowledge job %s of site %s: %s", id, site, err.Error())
+		}
+	}
+	delete(s.batches, site)
+
+	items := []v1alpha2.JobData{}
+	ids := []string{}
+	for len(items) < count {
+		message, err := reliableQueue.Receive(site, 0)
+		if err != nil {
+			if v1alpha2.IsNotFound(err) {
+				break
+			}
+			// the jobs received so far are delivered again after the visibility timeout
+			return nil, err
+		}
+		if job, ok := toJobData(message.Element); ok {
+			items = append(items, job)
+			ids = append(ids, message.ID)
+		} else {
+			log.Errorf(" M (Staging): Dropping element of site %s that isn't a job", site)
+			reliableQueue.Ack(site, message.ID)
+		}
+	}
+	if len(ids) > 0 {
+		s.batches[site] = ids
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return items, nil
+}
+
+// ResumeBatchForSite acknowledges the items of the last batch handed out to a site that the site applied, and hands
+// out the next batch with a new resume token. Each job is handed out as an item with an ID of its own, which the site
+// acknowledges it with. The items that the site didn't acknowledge come first in the next batch. All items are
+// acknowledged when applied is nil, and none when the resume token isn't the token of the last batch, such as when the
+// site lost it. Jobs for the same catalog are merged into the last of them, and catalogs are only handed out at
+// generations newer than the generations that the site acknowledged.
+func (s *StagingManager) ResumeBatchForSite(ctx context.Context, site string, count int, resumeToken string, applied []string) ([]model.SyncItem, string, error) {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "ResumeBatchForSite",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	s.QueueProvider.Enqueue(Site_Job_Queue, site)
+	s.progressLock.Lock()
+	defer s.progressLock.Unlock()
+
+	progress, err := s.GetSyncProgress(ctx, site)
+	if err != nil {
+		return nil, "", err
+	}
+	changed := false
+	if resumeToken != "" && resumeToken == progress.ResumeToken() {
+		acknowledgeJobs(&progress, applied)
+		changed = true
+	}
+	jobs := progress.Pending
+	var messageIds []string
+	if len(jobs) < count {
+		var received []v1alpha2.JobData
+		received, messageIds, err = s.receiveJobs(site, count-len(jobs))
+		if err != nil {
+			return nil, "", err
+		}
+		jobs = append([]model.SyncItem{}, jobs...)
+		for _, job := range received {
+			progress.Items++
+			jobs = append(jobs, model.SyncItem{Id: strconv.FormatInt(progress.Items, 10), Job: job})
+		}
+		var recreated []string
+		jobs, recreated = coalesceJobs(jobs)
+		for _, id := range recreated {
+			// the catalog was deleted and created again, and starts over at lower generations
+			delete(progress.Generations, id)
+		}
+	}
+	jobs = newerJobs(jobs, progress.Generations)
+	if len(jobs) == 0 && len(progress.Pending) > 0 {
+		// the pending jobs are all for catalogs that the site already has
+		acknowledgeJobs(&progress, nil)
+		changed = true
+	}
+	if len(jobs) > 0 {
+		if progress.Epoch == "" {
+			progress.Epoch, err = newSyncEpoch()
+			if err != nil {
+				s.nackJobs(site, messageIds)
+				return nil, "", err
+			}
+		}
+		progress.Batch++
+		progress.Pending = jobs
+		changed = true
+	}
+	if changed {
+		_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+			Value: states.StateEntry{
+				ID:   syncProgressId(site),
+				Body: progress,
+			},
+			Metadata: syncProgressMetadata(),
+		})
+		if err != nil {
+			// the received jobs are delivered again, as they aren't recorded as pending
+			log.Errorf(" M (Staging): Failed to record sync progress of site %s: %s", site, err.Error())
+			s.nackJobs(site, messageIds)
+			return nil, "", err
+		}
+	}
+	// the jobs are pending in the progress of the site now, so they're taken off the queue
+	if reliableQueue, ok := s.QueueProvider.(queue.IReliableQueueProvider); ok {
+		for _, id := range messageIds {
+			if err := reliableQueue.Ack(site, id); err != nil && !v1alpha2.IsNotFound(err) {
+				log.Errorf(" M (Staging): Failed to acknowledge job %s of site %s: %s", id, site, err.Error())
+			}
+		}
+	}
+	return jobs, progress.ResumeToken(), nil
+}
+
+// GetSyncProgress gets how far a site got through the jobs staged for it
+func (s *StagingManager) GetSyncProgress(ctx context.Context, site string) (model.SyncProgress, error) {
+	progress := model.SyncProgress{Site: site}
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID:       syncProgressId(site),
+		Metadata: syncProgressMetadata(),
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return progress, nil
+		}
+		return progress, err
+	}
+	data, _ := json.Marshal(entry.Body)
+	err = json.Unmarshal(data, &progress)
+	if err != nil {
+		return progress, v1alpha2.NewCOAError(err, "failed to read sync progress of site "+site, v1alpha2.InternalError)
+	}
+	return progress, nil
+}
+
+// receiveJobs takes up to count jobs of a site from the queue. Jobs from a reliable queue stay queued until the
+// returned messages are acknowledged.
+func (s *StagingManager) receiveJobs(site string, count int) ([]v1alpha2.JobData, []string, error) {
+	jobs := []v1alpha2.JobData{}
+	ids := []string{}
+	reliableQueue, isReliable := s.QueueProvider.(queue.IReliableQueueProvider)
+	for len(jobs) < count {
+		var element interface{}
+		messageId := ""
+		if isReliable {
+			message, err := reliableQueue.Receive(site, 0)
+			if err != nil {
+				if v1alpha2.IsNotFound(err) {
+					break
+				}
+				s.nackJobs(site, ids)
+				return nil, nil, err
+			}
+			element = message.Element
+			messageId = message.ID
+		} else {
+			if s.QueueProvider.Size(site) == 0 {
+				break
+			}
+			var err error
+			element, err = s.QueueProvider.Dequeue(site)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		job, ok := toJobData(element)
+		if !ok {
+			log.Errorf(" M (Staging): Dropping element of site %s that isn't a job", site)
+			if isReliable {
+				reliableQueue.Ack(site, messageId)
+			}
+			continue
+		}
+		jobs = append(jobs, job)
+		if isReliable {
+			ids = append(ids, messageId)
+		}
+	}
+	return jobs, ids, nil
+}
+
+// nackJobs puts the messages of jobs that weren't handed out back on a reliable queue
+func (s *StagingManager) nackJobs(site string, messageIds []string) {
+	if reliableQueue, ok := s.QueueProvider.(queue.IReliableQueueProvider); ok {
+		for _, id := range messageIds {
+			reliableQueue.Nack(site, id)
+		}
+	}
+}
+
+// acknowledgeJobs removes the applied items from the pending items of a site, or all of them when applied is nil, and
+// moves the cursor of the site to the generations of the applied catalogs
+func acknowledgeJobs(progress *model.SyncProgress, applied []string) {
+	ids := make(map[string]bool, len(applied))
+	for _, id := range applied {
+		ids[id] = true
+	}
+	pending := []model.SyncItem{}
+	for _, item := range progress.Pending {
+		if applied != nil && !ids[item.Id] {
+			pending = append(pending, item)
+			continue
+		}
+		job := item.Job
+		if job.Action == v1alpha2.JobDelete {
+			delete(progress.Generations, job.Id)
+		} else if generation := jobGeneration(job); isCatalogJob(job) && generation != "" {
+			if progress.Generations == nil {
+				progress.Generations = make(map[string]string)
+			}
+			if current, ok := progress.Generations[job.Id]; !ok || isNewerGeneration(generation, current) {
+				progress.Generations[job.Id] = generation
+			}
+		}
+	}
+	acknowledged := len(progress.Pending) - len(pending)
+	progress.Pending = pending
+	if len(progress.Pending) == 0 {
+		progress.Pending = nil
+		progress.HighWaterMark = progress.Batch
+	}
+	if acknowledged > 0 {
+		progress.Acknowledged += int64(acknowledged)
+		progress.LastAcknowledged = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// coalesceJobs merges the items for the same catalog into the last of them, since only the latest state of a catalog
+// needs to reach the site. Items that run or cancel activations are kept. It also returns the catalogs that were
+// deleted and then updated, which were created again.
+func coalesceJobs(items []model.SyncItem) ([]model.SyncItem, []string) {
+	last := make(map[string]int)
+	for i, item := range items {
+		if isCatalogJob(item.Job) {
+			last[item.Job.Id] = i
+		}
+	}
+	ret := make([]model.SyncItem, 0, len(items))
+	recreated := []string{}
+	for i, item := range items {
+		if isCatalogJob(item.Job) && last[item.Job.Id] != i {
+			if item.Job.Action == v1alpha2.JobDelete && items[last[item.Job.Id]].Job.Action != v1alpha2.JobDelete {
+				recreated = append(recreated, item.Job.Id)
+			}
+			continue
+		}
+		ret = append(ret, item)
+	}
+	return ret, recreated
+}
+
+// newerJobs drops the items for catalogs at generations that aren't newer than the generations that the site
+// acknowledged
+func newerJobs(items []model.SyncItem, generations map[string]string) []model.SyncItem {
+	ret := make([]model.SyncItem, 0, len(items))
+	for _, item := range items {
+		job := item.Job
+		if isCatalogJob(job) && job.Action != v1alpha2.JobDelete {
+			if acknowledged, ok := generations[job.Id]; ok && !isNewerGeneration(jobGeneration(job), acknowledged) {
+				continue
+			}
+		}
+		ret = append(ret, item)
+	}
+	return ret
+}
+
+// isNewerGeneration compares generations as numbers, which they are with the state providers, or otherwise takes any
+// other generation as newer
+func isNewerGeneration(generation string, than string) bool {
+	g, gErr := strconv.ParseInt(generation, 10, 64)
+	t, tErr := strconv.ParseInt(than, 10, 64)
+	if gErr != nil || tErr != nil {
+		return generation != than
+	}
+	return g > t
+}
+
+// jobGeneration is the generation of the catalog that a job carries
+func jobGeneration(job v1alpha2.JobData) string {
+	var catalog model.CatalogState
+	data, _ := json.Marshal(job.Body)
+	if json.Unmarshal(data, &catalog) != nil || catalog.Spec == nil {
+		return ""
+	}
+	return catalog.Spec.Generation
+}
+
+func isCatalogJob(job v1alpha2.JobData) bool {
+	return job.Action != v1alpha2.JobRun && job.Action != v1alpha2.JobCancel
+}
+
+func newSyncEpoch() (string, error) {
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(epoch), nil
+}
+
+func syncProgressId(site string) string {
+	return "sync-progress-" + site
+}
+
+func syncProgressMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"version":  "v1",
+		"group":    model.FederationGroup,
+		"resource": "syncprogress",
+	}
+}
+
+// toJobData reads a job from a queue element, which is a v1alpha2.JobData or, from a queue that stores elements as
+// JSON, its generic form. Jobs for catalogs need the ID of their catalog, while jobs that run or cancel activations
+// carry none.
+func toJobData(element interface{}) (v1alpha2.JobData, bool) {
+	if job, ok := element.(v1alpha2.JobData); ok {
+		return job, true
+	}
+	if _, ok := element.(map[string]interface{}); !ok {
+		return v1alpha2.JobData{}, false
+	}
+	var job v1alpha2.JobData
+	data, err := json.Marshal(element)
+	if err != nil || json.Unmarshal(data, &job) != nil || (job.Id == "" && isCatalogJob(job)) {
+		return v1alpha2.JobData{}, false
+	}
+	return job, true
+}
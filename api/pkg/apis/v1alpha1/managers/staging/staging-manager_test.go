@@ -11,11 +11,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	bboltqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/bbolt"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
@@ -137,6 +140,543 @@ func TestGetABatchForSite(t *testing.T) {
 	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Action)
 }
 
+func newBboltQueueProvider(t *testing.T) *bboltqueue.BboltQueueProvider {
+	queueProvider := &bboltqueue.BboltQueueProvider{}
+	err := queueProvider.Init(bboltqueue.BboltQueueProviderConfig{
+		Path:                     filepath.Join(t.TempDir(), "queue.db"),
+		VisibilityTimeoutSeconds: 1,
+	})
+	assert.Nil(t, err)
+	return queueProvider
+}
+
+func TestPollReliableQueue(t *testing.T) {
+	ts := InitializeMockSymphonyAPI()
+	defer ts.Close()
+	queueProvider := newBboltQueueProvider(t)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	manager.VendorContext = &contexts.VendorContext{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+	}
+	queueProvider.Enqueue(Site_Job_Queue, "fake")
+	errList := manager.Poll()
+	assert.Nil(t, errList)
+	assert.Equal(t, 0, queueProvider.Size(Site_Job_Queue))
+
+	jobs, err := manager.GetABatchForSite("fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Action)
+}
+
+func TestGetABatchForSiteReliableQueue(t *testing.T) {
+	queueProvider := newBboltQueueProvider(t)
+	manager := StagingManager{
+		QueueProvider: queueProvider,
+	}
+	for _, id := range []string{"catalog1", "catalog2"} {
+		err := manager.HandleJobEvent(context.Background(), v1alpha2.Event{
+			Metadata: map[string]string{
+				"site": "fake",
+			},
+			Body: v1alpha2.JobData{
+				Id:     id,
+				Action: v1alpha2.JobUpdate,
+			},
+		})
+		assert.Nil(t, err)
+	}
+
+	jobs, err := manager.GetABatchForSite("fake", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+
+	// a restart loses the batch before the site acknowledges it, so it's delivered again after the visibility timeout
+	manager = StagingManager{
+		QueueProvider: queueProvider,
+	}
+	jobs, err = manager.GetABatchForSite("fake", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog2", jobs[0].Id)
+	time.Sleep(1100 * time.Millisecond)
+	jobs, err = manager.GetABatchForSite("fake", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+
+	// asking for the next batch acknowledges the last one
+	jobs, err = manager.GetABatchForSite("fake", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+	time.Sleep(1100 * time.Millisecond)
+	jobs, err = manager.GetABatchForSite("fake", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func jobIds(items []model.SyncItem) []string {
+	ret := make([]string, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.Job.Id)
+	}
+	return ret
+}
+
+func itemIds(items []model.SyncItem) []string {
+	ret := make([]string, 0, len(items))
+	for _, item := range items {
+		ret = append(ret, item.Id)
+	}
+	return ret
+}
+
+func TestResumeBatchForSite(t *testing.T) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "1", "")})
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog2", Action: v1alpha2.JobUpdate})
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "2", "")})
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "job1", Action: v1alpha2.JobRun})
+
+	// the updates of a catalog are merged into the last one
+	jobs, token1, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog2", "catalog1", "job1"}, jobIds(jobs))
+	assert.Equal(t, []string{"2", "3", "4"}, itemIds(jobs))
+	assert.NotEqual(t, "", token1)
+
+	// a site that lost its token, or has an older token, gets the batch again
+	jobs, token2, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog2", "catalog1", "job1"}, jobIds(jobs))
+	assert.NotEqual(t, token1, token2)
+	jobs, token3, err := manager.ResumeBatchForSite(ctx, "fake", 10, token1, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(jobs))
+
+	// a site that applied part of the batch gets the rest of it
+	jobs, token4, err := manager.ResumeBatchForSite(ctx, "fake", 10, token3, []string{"2"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1", "job1"}, jobIds(jobs))
+	progress, err := manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), progress.Acknowledged)
+	assert.Equal(t, int64(0), progress.HighWaterMark)
+
+	// a catalog that changes while it's pending is sent once, at its latest state
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "3", "")})
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog3", Action: v1alpha2.JobDelete})
+	jobs, token5, err := manager.ResumeBatchForSite(ctx, "fake", 10, token4, []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"job1", "catalog1", "catalog3"}, jobIds(jobs))
+	assert.Equal(t, "3", jobs[1].Job.Body.(model.CatalogState).Spec.Generation)
+
+	jobs, token6, err := manager.ResumeBatchForSite(ctx, "fake", 10, token5, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+	assert.Equal(t, token5, token6)
+	progress, err = manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), progress.Acknowledged)
+	assert.Equal(t, progress.Batch, progress.HighWaterMark)
+	assert.Equal(t, 0, len(progress.Pending))
+	assert.NotEqual(t, "", progress.LastAcknowledged)
+}
+
+func TestResumeBatchForSiteCount(t *testing.T) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	for _, id := range []string{"catalog1", "catalog2", "catalog3"} {
+		queueProvider.Enqueue("fake", v1alpha2.JobData{Id: id, Action: v1alpha2.JobUpdate})
+	}
+	jobs, token, err := manager.ResumeBatchForSite(ctx, "fake", 2, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1", "catalog2"}, jobIds(jobs))
+
+	// the pending jobs fill the batch before jobs are taken off the queue
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 2, token, []string{"1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog2", "catalog3"}, jobIds(jobs))
+	jobs, _, err = manager.ResumeBatchForSite(ctx, "fake", 2, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func TestResumeBatchForSiteGenerationCursor(t *testing.T) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "2", "")})
+	jobs, token, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+
+	// the site acknowledged generation 2, so the catalog is only sent again at a newer generation
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "2", "")})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+	progress, err := manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", progress.Generations["catalog1"])
+	assert.Equal(t, progress.Batch, progress.HighWaterMark)
+
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "1", "")})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "3", "")})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+
+	// deleting the catalog resets its cursor
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobDelete})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+	_, _, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	progress, err = manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	_, ok := progress.Generations["catalog1"]
+	assert.False(t, ok)
+}
+
+func TestResumeBatchForSiteRecreatedCatalog(t *testing.T) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "5", "")})
+	jobs, token, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+
+	// the catalog is deleted and created again before the site gets the deletion, and its generations start over
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobDelete})
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "1", "")})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Job.Action)
+	assert.Equal(t, "1", jobs[0].Job.Body.(model.CatalogState).Spec.Generation)
+	_, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	progress, err := manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", progress.Generations["catalog1"])
+
+	// the same goes for a deletion that was handed out and isn't acknowledged yet
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobDelete})
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.JobDelete, jobs[0].Job.Action)
+	queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate, Body: newSelectiveCatalog("catalog1", "1", "")})
+	jobs, _, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, []string{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1"}, jobIds(jobs))
+	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Job.Action)
+}
+
+func TestResumeBatchForSiteRemoteJobs(t *testing.T) {
+	queueProvider := newBboltQueueProvider(t)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	// the jobs of remote stages carry no ID, and an activation is run and canceled with the same inputs
+	inputs := v1alpha2.InputOutputData{
+		Inputs: map[string]interface{}{
+			"__campaign":   "campaign1",
+			"__namespace":  "default",
+			"__activation": "activation1",
+		},
+	}
+	for _, action := range []v1alpha2.JobAction{v1alpha2.JobRun, v1alpha2.JobCancel} {
+		err := queueProvider.Enqueue("fake", v1alpha2.JobData{Id: "", Action: action, Body: inputs})
+		assert.Nil(t, err)
+	}
+	jobs, token, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, v1alpha2.JobRun, jobs[0].Job.Action)
+	assert.Equal(t, v1alpha2.JobCancel, jobs[1].Job.Action)
+	assert.NotEqual(t, jobs[0].Id, jobs[1].Id)
+
+	// the jobs are acknowledged one by one
+	jobs, token, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, []string{jobs[0].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, v1alpha2.JobCancel, jobs[0].Job.Action)
+	jobs, _, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, []string{jobs[0].Id})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+	progress, err := manager.GetSyncProgress(ctx, "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), progress.Acknowledged)
+	assert.Equal(t, 0, len(progress.Pending))
+}
+
+func TestResumeBatchForSiteReliableQueue(t *testing.T) {
+	queueProvider := newBboltQueueProvider(t)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	ctx := context.Background()
+	for _, id := range []string{"catalog1", "catalog2"} {
+		err := queueProvider.Enqueue("fake", v1alpha2.JobData{Id: id, Action: v1alpha2.JobUpdate})
+		assert.Nil(t, err)
+	}
+	jobs, _, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1", "catalog2"}, jobIds(jobs))
+
+	// the pending jobs are kept with the progress of the site, so they aren't delivered again by the queue, and they
+	// survive a restart
+	time.Sleep(1100 * time.Millisecond)
+	manager = StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	jobs, token, err := manager.ResumeBatchForSite(ctx, "fake", 10, "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"catalog1", "catalog2"}, jobIds(jobs))
+	jobs, _, err = manager.ResumeBatchForSite(ctx, "fake", 10, token, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func newSelectiveStagingManager(t *testing.T, site model.SiteState, catalogs *[]model.CatalogState) (*StagingManager, *memoryqueue.MemoryQueueProvider) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/catalogs/registry":
+			response = *catalogs
+		case "/federation/registry/" + site.Id:
+			response = site
+		case "/federation/registry/unknown":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		default:
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(ts.Close)
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := &StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	manager.VendorContext = &contexts.VendorContext{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "parent",
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+	}
+	return manager, queueProvider
+}
+
+func newSelectiveCatalog(name string, generation string, selector string) model.CatalogState {
+	catalog := model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name: name,
+		},
+		Spec: &model.CatalogSpec{
+			Name:       name,
+			Type:       "config",
+			Generation: generation,
+		},
+	}
+	if selector != "" {
+		catalog.Spec.Metadata = map[string]string{
+			model.SiteSelector: selector,
+		}
+	}
+	return catalog
+}
+
+func drainJobs(t *testing.T, queueProvider *memoryqueue.MemoryQueueProvider, site string) map[string]v1alpha2.JobAction {
+	ret := make(map[string]v1alpha2.JobAction)
+	for queueProvider.Size(site) > 0 {
+		element, err := queueProvider.Dequeue(site)
+		assert.Nil(t, err)
+		job := element.(v1alpha2.JobData)
+		ret[job.Id] = job.Action
+	}
+	return ret
+}
+
+func TestPollSiteSelector(t *testing.T) {
+	site := model.SiteState{
+		Id: "site1",
+		Spec: &model.SiteSpec{
+			Properties: map[string]string{
+				"region": "eu",
+			},
+		},
+	}
+	catalogs := []model.CatalogState{
+		newSelectiveCatalog("catalog1", "1", ""),
+		newSelectiveCatalog("catalog2", "1", "region=eu"),
+		newSelectiveCatalog("catalog3", "1", "region=us"),
+		newSelectiveCatalog("catalog4", "1", "region=eu,!lab"),
+		newSelectiveCatalog("catalog5", "1", "region=eu,=invalid"),
+	}
+	manager, queueProvider := newSelectiveStagingManager(t, site, &catalogs)
+
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, map[string]v1alpha2.JobAction{
+		"catalog1": v1alpha2.JobUpdate,
+		"catalog2": v1alpha2.JobUpdate,
+		"catalog4": v1alpha2.JobUpdate,
+	}, drainJobs(t, queueProvider, "site1"))
+
+	// a site that isn't registered only gets the catalogs without a selector
+	queueProvider.Enqueue(Site_Job_Queue, "unknown")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, map[string]v1alpha2.JobAction{
+		"catalog1": v1alpha2.JobUpdate,
+	}, drainJobs(t, queueProvider, "unknown"))
+}
+
+func TestPollPropagatesDeletion(t *testing.T) {
+	site := model.SiteState{
+		Id: "site1",
+		Spec: &model.SiteSpec{
+			Properties: map[string]string{
+				"region": "eu",
+			},
+		},
+	}
+	catalogs := []model.CatalogState{
+		newSelectiveCatalog("catalog1", "1", ""),
+		newSelectiveCatalog("catalog2", "1", ""),
+		newSelectiveCatalog("catalog3", "1", "region=eu"),
+	}
+	manager, queueProvider := newSelectiveStagingManager(t, site, &catalogs)
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, 3, len(drainJobs(t, queueProvider, "site1")))
+
+	// catalog2 is deleted, and catalog3 isn't distributed to the site anymore
+	catalogs = []model.CatalogState{
+		newSelectiveCatalog("catalog1", "1", ""),
+		newSelectiveCatalog("catalog3", "2", "region=us"),
+	}
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	element, err := queueProvider.Peek("site1")
+	assert.Nil(t, err)
+	tombstone := element.(v1alpha2.JobData).Body.(model.CatalogState)
+	assert.Equal(t, "config", tombstone.Spec.Type)
+	assert.Equal(t, map[string]v1alpha2.JobAction{
+		"catalog2": v1alpha2.JobDelete,
+		"catalog3": v1alpha2.JobDelete,
+	}, drainJobs(t, queueProvider, "site1"))
+
+	// the deletions are only queued once
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, 0, queueProvider.Size("site1"))
+}
+
+func TestPollInvalidSiteSelector(t *testing.T) {
+	site := model.SiteState{
+		Id: "site1",
+		Spec: &model.SiteSpec{
+			Properties: map[string]string{
+				"region": "eu",
+			},
+		},
+	}
+	catalogs := []model.CatalogState{
+		newSelectiveCatalog("catalog1", "1", "region=eu"),
+	}
+	manager, queueProvider := newSelectiveStagingManager(t, site, &catalogs)
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, map[string]v1alpha2.JobAction{
+		"catalog1": v1alpha2.JobUpdate,
+	}, drainJobs(t, queueProvider, "site1"))
+
+	// a selector that can't be parsed neither updates nor deletes the catalog on the site
+	catalogs = []model.CatalogState{
+		newSelectiveCatalog("catalog1", "2", "region=eu,=invalid"),
+	}
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, 0, queueProvider.Size("site1"))
+
+	// the catalog is still recorded as staged, so removing it later deletes it on the site
+	catalogs = []model.CatalogState{}
+	queueProvider.Enqueue(Site_Job_Queue, "site1")
+	assert.Nil(t, manager.Poll())
+	assert.Equal(t, map[string]v1alpha2.JobAction{
+		"catalog1": v1alpha2.JobDelete,
+	}, drainJobs(t, queueProvider, "site1"))
+}
+
 type AuthResponse struct {
 	AccessToken string   `json:"accessToken"`
 	TokenType   string   `json:"tokenType"`
@@ -8,7 +8,12 @@ package sync
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -16,10 +21,40 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 )
 
+var log = logger.NewLogger("coa.runtime")
+
+// SyncResultTopic is the topic that the handlers of the items of sync batches report on whether they applied an item
+const SyncResultTopic = "sync-result"
+
 type SyncManager struct {
 	managers.Manager
+	// StateProvider keeps the progress of the site through the batches of its parent site. Without a configured state
+	// provider, the progress is kept in memory and lost on restart.
+	StateProvider states.IStateProvider
+	// ApplyTimeout is how long an item of a batch waits for its result before it's published again
+	ApplyTimeout time.Duration
+
+	// lock guards the progress and the items in flight, which the results of items update as they arrive
+	lock     sync.Mutex
+	progress *syncState
+	// inflight are the items that were published and haven't reported their result yet, with when they were published
+	inflight map[string]time.Time
+}
+
+// syncState is the progress of the site through the batches of its parent site
+type syncState struct {
+	// ResumeToken is the token of the last batch from the parent site
+	ResumeToken string `json:"resumeToken,omitempty"`
+	// Applied are the items that were applied since they were last acknowledged to the parent site
+	Applied []string `json:"applied,omitempty"`
+	// Generations are the generations of the catalogs applied from each origin, so that catalogs that are sent again
+	// aren't published again
+	Generations map[string]string `json:"generations,omitempty"`
 }
 
 func (s *SyncManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -30,11 +65,39 @@ func (s *SyncManager) Init(context *contexts.VendorContext, config managers.Mana
 	if s.Context.SiteInfo.SiteId == "" {
 		return v1alpha2.NewCOAError(nil, "siteId is required", v1alpha2.BadConfig)
 	}
-	return nil
+	if _, ok := config.Properties[v1alpha2.ProvidersState]; ok {
+		stateProvider, err := managers.GetStateProvider(config, providers)
+		if err != nil {
+			return err
+		}
+		s.StateProvider = stateProvider
+	} else {
+		log.Info(" M (Sync): state provider is not configured, sync progress is kept in memory")
+		stateProvider := &memorystate.MemoryStateProvider{}
+		err = stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+		if err != nil {
+			return err
+		}
+		s.StateProvider = stateProvider
+	}
+	s.ApplyTimeout = 10 * time.Minute
+	if v, ok := config.Properties["sync.applyTimeout"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid sync.applyTimeout value '%s'", v), v1alpha2.BadConfig)
+		}
+		s.ApplyTimeout = d
+	}
+	s.inflight = make(map[string]time.Time)
+	return s.Context.Subscribe(SyncResultTopic, s.onSyncResult)
 }
 func (s *SyncManager) Enabled() bool {
 	return s.Config.Properties["sync.enabled"] == "true"
 }
+
+// Poll acknowledges the items of the earlier batches that were applied, gets the next batch from the parent site and
+// publishes its items. Items are only acknowledged once their handlers report that they applied them, so the items
+// that are still in flight or failed are sent again by the parent site.
 func (s *SyncManager) Poll() []error {
 	ctx, span := observability.StartSpan("Sync Manager", context.Background(), &map[string]string{
 		"method": "Poll",
@@ -44,38 +107,92 @@ func (s *SyncManager) Poll() []error {
 	if s.VendorContext.SiteInfo.ParentSite.BaseUrl == "" {
 		return nil
 	}
-	batch, err := utils.GetABatchForSite(
+	s.lock.Lock()
+	progress, err := s.getProgress(ctx)
+	if err != nil {
+		s.lock.Unlock()
+		return []error{err}
+	}
+	resumeToken := progress.ResumeToken
+	acknowledged := append([]string{}, progress.Applied...)
+	s.lock.Unlock()
+
+	batch, err := utils.ResumeBatchForSite(
 		ctx,
 		s.VendorContext.SiteInfo.ParentSite.BaseUrl,
 		s.VendorContext.SiteInfo.SiteId,
 		s.VendorContext.SiteInfo.ParentSite.Username,
-		s.VendorContext.SiteInfo.ParentSite.Password)
+		s.VendorContext.SiteInfo.ParentSite.Password,
+		resumeToken,
+		acknowledged)
 	if err != nil {
 		return []error{err}
 	}
-	if batch.Catalogs != nil {
-		for _, catalog := range batch.Catalogs {
-			s.Context.Publish("catalog-sync", v1alpha2.Event{
-				Metadata: map[string]string{
-					"objectType": catalog.Spec.Type,
-					"origin":     batch.Origin,
-				},
-				Body: v1alpha2.JobData{
-					Id:     catalog.Spec.Name,
-					Action: v1alpha2.JobUpdate, //TODO: handle deletion, this probably requires BetBachForSites return flags
-					Body:   catalog,
-				},
-			})
+
+	s.lock.Lock()
+	// results that arrived while the batch was asked for are acknowledged with the next batch
+	progress.Applied = removeItems(progress.Applied, acknowledged)
+	progress.ResumeToken = batch.ResumeToken
+	for id, published := range s.inflight {
+		if time.Since(published) > s.ApplyTimeout {
+			log.Errorf(" M (Sync): Item %s wasn't applied in %s, it's published again", id, s.ApplyTimeout)
+			delete(s.inflight, id)
 		}
 	}
-	if batch.Jobs != nil {
-		for _, job := range batch.Jobs {
-			s.Context.Publish("remote-job", v1alpha2.Event{
-				Metadata: map[string]string{
-					"origin": batch.Origin,
-				},
-				Body: job,
-			})
+	items := make([]syncItem, 0)
+	for i, catalog := range batch.Catalogs {
+		name := syncItemId(catalog)
+		id := packageItemId(batch.CatalogItems, i, name)
+		if s.isPending(progress, id) {
+			continue
+		}
+		if catalog.Spec.Generation != "" && progress.Generations[batch.Origin+"/"+name] == catalog.Spec.Generation {
+			progress.Applied = append(progress.Applied, id)
+			continue
+		}
+		items = append(items, s.newSyncItem("catalog-sync", id, batch.Origin, v1alpha2.JobData{
+			Id:     catalog.Spec.Name,
+			Action: v1alpha2.JobUpdate,
+			Body:   catalog,
+		}, map[string]string{
+			"objectType": catalog.Spec.Type,
+			"generation": catalog.Spec.Generation,
+			"catalog":    name,
+		}))
+	}
+	for i, tombstone := range batch.Tombstones {
+		name := syncItemId(tombstone)
+		id := packageItemId(batch.TombstoneItems, i, name)
+		if s.isPending(progress, id) {
+			continue
+		}
+		items = append(items, s.newSyncItem("catalog-sync", id, batch.Origin, v1alpha2.JobData{
+			Id:     tombstone.Spec.Name,
+			Action: v1alpha2.JobDelete,
+			Body:   tombstone,
+		}, map[string]string{
+			"objectType": tombstone.Spec.Type,
+			"catalog":    name,
+		}))
+	}
+	for i, job := range batch.Jobs {
+		// jobs without an ID of their own or from the batch can't be acknowledged, so they're published each time
+		id := packageItemId(batch.JobItems, i, job.Id)
+		if id != "" && s.isPending(progress, id) {
+			continue
+		}
+		items = append(items, s.newSyncItem("remote-job", id, batch.Origin, job, nil))
+	}
+	// the progress in memory stays current when it fails to be recorded, so the items are still published
+	err = s.saveProgress(ctx, progress)
+	s.lock.Unlock()
+	// the items are published without the lock, which the handlers of their results take
+	for _, item := range items {
+		if pErr := s.Context.Publish(item.topic, item.event); pErr != nil {
+			log.Errorf(" M (Sync): Failed to publish item %s: %s", item.id, pErr.Error())
+			s.lock.Lock()
+			delete(s.inflight, item.id)
+			s.lock.Unlock()
 		}
 	}
 	if err != nil {
@@ -83,6 +200,186 @@ func (s *SyncManager) Poll() []error {
 	}
 	return nil
 }
+
+// syncItem is an item of a batch to publish
+type syncItem struct {
+	topic string
+	id    string
+	event v1alpha2.Event
+}
+
+// newSyncItem makes the event of an item of a batch and marks the item in flight until its handler reports its
+// result. Items that fail to publish aren't acknowledged, so they're sent again. Callers hold s.lock.
+func (s *SyncManager) newSyncItem(topic string, id string, origin string, job v1alpha2.JobData, metadata map[string]string) syncItem {
+	event := v1alpha2.Event{
+		Metadata: map[string]string{
+			"origin":   origin,
+			"syncItem": id,
+			"action":   string(job.Action),
+		},
+		Body: job,
+	}
+	for k, v := range metadata {
+		event.Metadata[k] = v
+	}
+	if id != "" {
+		s.inflight[id] = time.Now()
+	}
+	return syncItem{topic: topic, id: id, event: event}
+}
+
+// isPending checks whether an item that's sent again is still in flight, or was applied and waits to be acknowledged
+func (s *SyncManager) isPending(progress *syncState, id string) bool {
+	if _, ok := s.inflight[id]; ok {
+		return true
+	}
+	for _, applied := range progress.Applied {
+		if applied == id {
+			return true
+		}
+	}
+	return false
+}
+
+// onSyncResult records the result of an item. Applied items are acknowledged with the next batch, and the generations
+// of applied catalogs are kept, while failed items are sent again by the parent site.
+func (s *SyncManager) onSyncResult(topic string, event v1alpha2.Event) error {
+	id := event.Metadata["syncItem"]
+	if id == "" {
+		return nil
+	}
+	ctx := context.TODO()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.inflight, id)
+	if event.Metadata["applied"] != "true" {
+		log.Errorf(" M (Sync): Item %s wasn't applied, it's sent again", id)
+		return nil
+	}
+	progress, err := s.getProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.isPending(progress, id) {
+		progress.Applied = append(progress.Applied, id)
+	}
+	key := event.Metadata["origin"] + "/" + event.Metadata["catalog"]
+	switch v1alpha2.JobAction(event.Metadata["action"]) {
+	case v1alpha2.JobDelete:
+		delete(progress.Generations, key)
+	case v1alpha2.JobUpdate:
+		if generation := event.Metadata["generation"]; generation != "" {
+			progress.Generations[key] = generation
+		}
+	}
+	return s.saveProgress(ctx, progress)
+}
+
+// getProgress reads the progress of the site once, and returns the one in memory after that. Callers hold s.lock.
+func (s *SyncManager) getProgress(ctx context.Context) (*syncState, error) {
+	if s.progress != nil {
+		return s.progress, nil
+	}
+	progress := &syncState{}
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID:       syncStateId(s.VendorContext.SiteInfo.SiteId),
+		Metadata: syncStateMetadata(),
+	})
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		log.Errorf(" M (Sync): Failed to get sync progress: %s", err.Error())
+		return nil, err
+	}
+	if err == nil {
+		data, _ := json.Marshal(entry.Body)
+		if err := json.Unmarshal(data, progress); err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to read sync progress", v1alpha2.InternalError)
+		}
+	}
+	if progress.Generations == nil {
+		progress.Generations = make(map[string]string)
+	}
+	s.progress = progress
+	return progress, nil
+}
+
+// saveProgress writes the progress of the site with the state provider. Callers hold s.lock.
+func (s *SyncManager) saveProgress(ctx context.Context, progress *syncState) error {
+	_, err := s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   syncStateId(s.VendorContext.SiteInfo.SiteId),
+			Body: *progress,
+		},
+		Metadata: syncStateMetadata(),
+	})
+	if err != nil {
+		log.Errorf(" M (Sync): Failed to record sync progress: %s", err.Error())
+	}
+	return err
+}
+
+func syncStateId(site string) string {
+	return "sync-state-" + site
+}
+
+func syncStateMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"version":  "v1",
+		"group":    model.FederationGroup,
+		"resource": "syncstates",
+	}
+}
+
+// removeItems removes the items that were acknowledged
+func removeItems(items []string, acknowledged []string) []string {
+	ids := make(map[string]bool, len(acknowledged))
+	for _, id := range acknowledged {
+		ids[id] = true
+	}
+	ret := make([]string, 0, len(items))
+	for _, id := range items {
+		if !ids[id] {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+// WithSyncResult wraps the handler of a topic that the sync manager publishes the items of batches on, and reports on
+// SyncResultTopic whether the handler applied an item, so that the sync manager only acknowledges applied items
+func WithSyncResult(context *contexts.VendorContext, handler v1alpha2.EventHandler) v1alpha2.EventHandler {
+	return func(topic string, event v1alpha2.Event) error {
+		err := handler(topic, event)
+		if id := event.Metadata["syncItem"]; id != "" {
+			metadata := make(map[string]string, len(event.Metadata)+1)
+			for k, v := range event.Metadata {
+				metadata[k] = v
+			}
+			metadata["applied"] = fmt.Sprintf("%t", err == nil)
+			if pErr := context.Publish(SyncResultTopic, v1alpha2.Event{Metadata: metadata}); pErr != nil {
+				log.Errorf(" M (Sync): Failed to report result of item %s: %s", id, pErr.Error())
+			}
+		}
+		return err
+	}
+}
+
+// syncItemId is the name of a catalog of a sync batch, which its generations are kept by. It's also the ID that the
+// catalog is acknowledged with when the batch doesn't carry the IDs of its items.
+func syncItemId(catalog model.CatalogState) string {
+	if catalog.ObjectMeta.Name != "" {
+		return catalog.ObjectMeta.Name
+	}
+	return catalog.Spec.Name
+}
+
+// packageItemId is the ID of the item at index i of a kind of items of a sync batch, or fallback when the batch doesn't
+// carry the IDs of its items
+func packageItemId(items []string, i int, fallback string) string {
+	if i < len(items) && items[i] != "" {
+		return items[i]
+	}
+	return fallback
+}
 func (s *SyncManager) Reconcil() []error {
 	return nil
 }
@@ -12,13 +12,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	"github.com/stretchr/testify/assert"
@@ -203,3 +208,305 @@ func TestPoll(t *testing.T) {
 	assert.Equal(t, "catalog1", catalog1.Spec.Name)
 	assert.Equal(t, "job1", job1.Id)
 }
+
+func TestPollTombstones(t *testing.T) {
+	siteId := "fake"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/federation/sync/" + siteId:
+			response = model.SyncPackage{
+				Tombstones: []model.CatalogState{
+					model.NewCatalogTombstone("catalog1", "config"),
+				},
+				Origin: "batch-origin",
+			}
+		case "/users/auth":
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	manager := SyncManager{}
+	vendorContext := &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: siteId,
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+		Logger: logger.NewLogger("coa.runtime"),
+	}
+	vendorContext.PubsubProvider = &memory.InMemoryPubSubProvider{}
+	vendorContext.PubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	err := manager.Init(vendorContext, managers.ManagerConfig{}, nil)
+	assert.Nil(t, err)
+
+	sig := make(chan v1alpha2.Event, 1)
+	vendorContext.Subscribe("catalog-sync", func(topic string, event v1alpha2.Event) error {
+		sig <- event
+		return nil
+	})
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+
+	event := <-sig
+	assert.Equal(t, "batch-origin", event.Metadata["origin"])
+	assert.Equal(t, "config", event.Metadata["objectType"])
+	jobData := event.Body.(v1alpha2.JobData)
+	assert.Equal(t, "catalog1", jobData.Id)
+	assert.Equal(t, v1alpha2.JobDelete, jobData.Action)
+}
+
+// newSyncServer is a parent site that hands out the package of each of its batches, with a new resume token every
+// time, and records the queries of the site
+func newSyncServer(siteId string, pack func(batch int) model.SyncPackage) (*httptest.Server, chan url.Values) {
+	queries := make(chan url.Values, 10)
+	batch := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/federation/sync/" + siteId:
+			queries <- r.URL.Query()
+			batch++
+			ret := pack(batch)
+			ret.Origin = "batch-origin"
+			ret.ResumeToken = fmt.Sprintf("epoch.%d", batch)
+			response = ret
+		case "/users/auth":
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	return ts, queries
+}
+
+func newSyncManager(t *testing.T, ts *httptest.Server, stateProvider states.IStateProvider) (*SyncManager, *contexts.VendorContext) {
+	manager := &SyncManager{}
+	vendorContext := &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+		Logger: logger.NewLogger("coa.runtime"),
+	}
+	vendorContext.PubsubProvider = &memory.InMemoryPubSubProvider{}
+	vendorContext.PubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "state",
+		},
+	}, map[string]providers.IProvider{
+		"state": stateProvider,
+	})
+	assert.Nil(t, err)
+	return manager, vendorContext
+}
+
+func newStateProvider() states.IStateProvider {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	return stateProvider
+}
+
+func newCatalog(name string, generation string) model.CatalogState {
+	return model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name: name,
+		},
+		Spec: &model.CatalogSpec{
+			Name:       name,
+			Type:       "config",
+			Generation: generation,
+		},
+	}
+}
+
+// waitForResult waits until the sync manager recorded the result of an item
+func waitForResult(manager *SyncManager, id string) {
+	for i := 0; i < 100; i++ {
+		manager.lock.Lock()
+		_, inflight := manager.inflight[id]
+		manager.lock.Unlock()
+		if !inflight {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPollResume(t *testing.T) {
+	// the catalog is sent again, as if its acknowledgement was lost
+	ts, queries := newSyncServer("fake", func(batch int) model.SyncPackage {
+		return model.SyncPackage{Catalogs: []model.CatalogState{newCatalog("catalog1", "1")}}
+	})
+	defer ts.Close()
+	manager, vendorContext := newSyncManager(t, ts, newStateProvider())
+
+	published := make(chan v1alpha2.Event, 3)
+	vendorContext.Subscribe("catalog-sync", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		published <- event
+		return nil
+	}))
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	query := <-queries
+	assert.True(t, query.Has("resume"))
+	assert.Equal(t, "", query.Get("resume"))
+	<-published
+	waitForResult(manager, "catalog1")
+
+	// the next poll acknowledges the applied catalog, and the catalog that's sent again isn't published again
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query = <-queries
+	assert.Equal(t, "epoch.1", query.Get("resume"))
+	assert.Equal(t, "catalog1", query.Get("applied"))
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query = <-queries
+	assert.Equal(t, "epoch.2", query.Get("resume"))
+	assert.Equal(t, "catalog1", query.Get("applied"))
+	assert.Equal(t, 0, len(published))
+}
+
+func TestPollAcknowledgesAppliedItems(t *testing.T) {
+	ts, queries := newSyncServer("fake", func(batch int) model.SyncPackage {
+		return model.SyncPackage{
+			Catalogs: []model.CatalogState{newCatalog("catalog1", "1")},
+			Jobs:     []v1alpha2.JobData{{Id: "job1", Action: v1alpha2.JobRun}},
+		}
+	})
+	defer ts.Close()
+	manager, vendorContext := newSyncManager(t, ts, newStateProvider())
+
+	// the catalog fails to apply once, and the job runs until it's released
+	catalogs := make(chan int, 3)
+	vendorContext.Subscribe("catalog-sync", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		catalogs <- 1
+		if len(catalogs) == 1 {
+			return v1alpha2.NewCOAError(nil, "failed to upsert catalog", v1alpha2.InternalError)
+		}
+		return nil
+	}))
+	jobs := make(chan int, 3)
+	release := make(chan int)
+	vendorContext.Subscribe("remote-job", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		jobs <- 1
+		<-release
+		return nil
+	}))
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	<-queries
+	waitForResult(manager, "catalog1")
+
+	// neither the failed catalog nor the running job are acknowledged, and only the catalog is published again
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query := <-queries
+	assert.True(t, query.Has("applied"))
+	assert.Equal(t, "", query.Get("applied"))
+	waitForResult(manager, "catalog1")
+	assert.Equal(t, 2, len(catalogs))
+	assert.Equal(t, 1, len(jobs))
+
+	close(release)
+	waitForResult(manager, "job1")
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query = <-queries
+	assert.ElementsMatch(t, []string{"catalog1", "job1"}, strings.Split(query.Get("applied"), ","))
+}
+
+func TestPollKeepsProgress(t *testing.T) {
+	ts, queries := newSyncServer("fake", func(batch int) model.SyncPackage {
+		return model.SyncPackage{Catalogs: []model.CatalogState{newCatalog("catalog1", "1")}}
+	})
+	defer ts.Close()
+	stateProvider := newStateProvider()
+	manager, vendorContext := newSyncManager(t, ts, stateProvider)
+	published := make(chan v1alpha2.Event, 3)
+	vendorContext.Subscribe("catalog-sync", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		published <- event
+		return nil
+	}))
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	<-queries
+	<-published
+	waitForResult(manager, "catalog1")
+
+	// after a restart, the site resumes with its token and acknowledgements, and doesn't apply the catalog again
+	manager, vendorContext = newSyncManager(t, ts, stateProvider)
+	vendorContext.Subscribe("catalog-sync", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		published <- event
+		return nil
+	}))
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query := <-queries
+	assert.Equal(t, "epoch.1", query.Get("resume"))
+	assert.Equal(t, "catalog1", query.Get("applied"))
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	<-queries
+	assert.Equal(t, 0, len(published))
+}
+
+func TestPollAcknowledgesRemoteJobs(t *testing.T) {
+	// the jobs of remote stages carry no ID, and are acknowledged with the IDs of their items
+	ts, queries := newSyncServer("fake", func(batch int) model.SyncPackage {
+		return model.SyncPackage{
+			Jobs: []v1alpha2.JobData{
+				{Id: "", Action: v1alpha2.JobRun},
+				{Id: "", Action: v1alpha2.JobCancel},
+			},
+			JobItems: []string{"1", "2"},
+		}
+	})
+	defer ts.Close()
+	manager, vendorContext := newSyncManager(t, ts, newStateProvider())
+	published := make(chan v1alpha2.Event, 4)
+	vendorContext.Subscribe("remote-job", WithSyncResult(vendorContext, func(topic string, event v1alpha2.Event) error {
+		published <- event
+		return nil
+	}))
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	<-queries
+	actions := make(map[string]string)
+	for i := 0; i < 2; i++ {
+		event := <-published
+		actions[event.Metadata["syncItem"]] = event.Metadata["action"]
+	}
+	assert.Equal(t, map[string]string{"1": string(v1alpha2.JobRun), "2": string(v1alpha2.JobCancel)}, actions)
+	waitForResult(manager, "1")
+	waitForResult(manager, "2")
+
+	// both jobs are acknowledged, and they aren't published again when they're sent again
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	query := <-queries
+	assert.ElementsMatch(t, []string{"1", "2"}, strings.Split(query.Get("applied"), ","))
+	assert.Equal(t, 0, len(published))
+}
@@ -165,13 +165,22 @@ func (t *TargetsManager) ReportState(ctx context.Context, current model.TargetSt
 	return targetState, nil
 }
 func (t *TargetsManager) ListState(ctx context.Context, namespace string) ([]model.TargetState, error) {
+	ret, _, err := t.ListStatePage(ctx, namespace, model.ListOptions{})
+	return ret, err
+}
+
+// ListStatePage lists the targets that match options, one page at a time. It also returns the token of the next page,
+// which is empty on the last page.
+func (t *TargetsManager) ListStatePage(ctx context.Context, namespace string, options model.ListOptions) ([]model.TargetState, string, error) {
 	ctx, span := observability.StartSpan("Targets Manager", ctx, &map[string]string{
-		"method": "ListSpec",
+		"method": "ListStatePage",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
 	listRequest := states.ListRequest{
+		PageSize:          options.Limit,
+		ContinuationToken: options.Continue,
 		Metadata: map[string]interface{}{
 			"version":   "v1",
 			"group":     model.FabricGroup,
@@ -180,20 +189,24 @@ func (t *TargetsManager) ListState(ctx context.Context, namespace string) ([]mod
 			"kind":      "Target",
 		},
 	}
-	targets, _, err := t.StateProvider.List(ctx, listRequest)
+	if options.LabelSelector != "" {
+		listRequest.FilterType = states.FilterTypeLabel
+		listRequest.Filter = options.LabelSelector
+	}
+	targets, token, err := t.StateProvider.List(ctx, listRequest)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	ret := make([]model.TargetState, 0)
 	for _, t := range targets {
 		var rt model.TargetState
 		rt, err = getTargetState(t.ID, t.Body, t.ETag)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, rt)
 	}
-	return ret, nil
+	return ret, token, nil
 }
 
 func getTargetState(id string, body interface{}, etag string) (model.TargetState, error) {
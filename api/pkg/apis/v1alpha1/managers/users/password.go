@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package users
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters of new password hashes, following the recommendations of RFC 9106. Hashes keep the parameters
+// they were made with, so changing these doesn't invalidate existing passwords.
+const (
+	argon2Time    uint32 = 1
+	argon2Memory  uint32 = 64 * 1024
+	argon2Threads uint8  = 4
+	argon2KeyLen  uint32 = 32
+	argon2SaltLen        = 16
+)
+
+const argon2Prefix = "$argon2id$"
+
+// hashPassword hashes a password with argon2id and a random salt, in the PHC string format:
+// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// getDummyHash returns a hash of a random password that logins of unknown users are checked against
+func getDummyHash() string {
+	dummyHashOnce.Do(func() {
+		password := make([]byte, argon2SaltLen)
+		rand.Read(password)
+		dummyHash, _ = hashPassword(base64.RawStdEncoding.EncodeToString(password))
+	})
+	return dummyHash
+}
+
+// verifyPassword checks a password against a hash made by hashPassword or by the legacy FNV hash. It also returns
+// whether the hash is a legacy one, which should be replaced once the password is verified.
+func verifyPassword(name string, password string, hash string) (bool, bool) {
+	if !strings.HasPrefix(hash, argon2Prefix) {
+		return subtle.ConstantTimeCompare([]byte(legacyHash(name, password)), []byte(hash)) == 1, true
+	}
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false
+	}
+	actual := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(actual, key) == 1, false
+}
+
+// legacyHash is the unsalted hash that users were stored with before argon2id. It's only used to verify and migrate
+// existing users.
+func legacyHash(name string, s string) string {
+	h := fnv.New32a()
+	h.Write([]byte(name + "." + s + ".salt"))
+	return fmt.Sprintf("H%d", h.Sum32())
+}
@@ -8,9 +8,13 @@ package users
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -21,16 +25,27 @@ import (
 )
 
 var log = logger.NewLogger("coa.runtime")
+var lock sync.Mutex
 
 type UsersManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
+	// LockoutThreshold is how many failed logins in a row lock a user out. Zero doesn't lock users out.
+	LockoutThreshold int
+	// LockoutDuration is how long a user stays locked out
+	LockoutDuration time.Duration
 }
 
 type UserState struct {
 	Id           string   `json:"id"`
 	PasswordHash string   `json:"passwordHash,omitempty"`
 	Roles        []string `json:"roles,omitempty"`
+	// Disabled users can't log in
+	Disabled bool `json:"disabled,omitempty"`
+	// TokenTTL is how long the tokens issued to the user are valid, such as "8h". Empty uses the default.
+	TokenTTL     string     `json:"tokenTTL,omitempty"`
+	FailedLogins int        `json:"failedLogins,omitempty"`
+	LockedUntil  *time.Time `json:"lockedUntil,omitempty"`
 }
 
 func (s *UsersManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -42,6 +57,22 @@ func (s *UsersManager) Init(context *contexts.VendorContext, config managers.Man
 		return err
 	}
 
+	s.LockoutThreshold = 5
+	if v, ok := config.Properties["lockoutThreshold"]; ok {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid lockoutThreshold value '%s'", v), v1alpha2.BadConfig)
+		}
+		s.LockoutThreshold = i
+	}
+	s.LockoutDuration = 15 * time.Minute
+	if v, ok := config.Properties["lockoutDuration"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid lockoutDuration value '%s'", v), v1alpha2.BadConfig)
+		}
+		s.LockoutDuration = d
+	}
 	return nil
 }
 func (t *UsersManager) DeleteUser(ctx context.Context, name string) error {
@@ -62,12 +93,6 @@ func (t *UsersManager) DeleteUser(ctx context.Context, name string) error {
 	return nil
 }
 
-func hash(name string, s string) string {
-	h := fnv.New32a()
-	h.Write([]byte(name + "." + s + ".salt"))
-	return fmt.Sprintf("H%d", h.Sum32())
-}
-
 func (t *UsersManager) UpsertUser(ctx context.Context, name string, password string, roles []string) error {
 	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
 		"method": "UpsertUser",
@@ -76,46 +101,261 @@ func (t *UsersManager) UpsertUser(ctx context.Context, name string, password str
 	defer observ_utils.CloseSpanWithError(span, &err)
 	log.Infof(" M (Users): UpsertUser name %s, traceId: %s", name, span.SpanContext().TraceID().String())
 
-	upsertRequest := states.UpsertRequest{
-		Value: states.StateEntry{
-			ID: name,
-			Body: UserState{
-				Id:           name,
-				PasswordHash: hash(name, password),
-				Roles:        roles,
-			},
-		},
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		log.Debugf(" M (Users) : failed to hash password %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return err
 	}
-	_, err = t.StateProvider.Upsert(ctx, upsertRequest)
+	lock.Lock()
+	defer lock.Unlock()
+	// an existing user keeps whether it's disabled, its token TTL and its lockout
+	user, err := t.getUser(ctx, name)
+	if err != nil {
+		if !v1alpha2.IsNotFound(err) {
+			log.Debugf(" M (Users) : failed to get user %v, traceId: %s", err, span.SpanContext().TraceID().String())
+			return err
+		}
+		user = UserState{Id: name}
+	}
+	user.PasswordHash = passwordHash
+	user.Roles = roles
+	err = t.saveUser(ctx, user)
 	if err != nil {
 		log.Debugf(" M (Users) : failed to upsert user %v, traceId: %s", err, span.SpanContext().TraceID().String())
 		return err
 	}
 	return nil
 }
+
+// CheckUser returns the roles of the user when the password is correct
 func (t *UsersManager) CheckUser(ctx context.Context, name string, password string) ([]string, bool) {
+	user, err := t.AuthenticateUser(ctx, name, password)
+	if err != nil {
+		return nil, false
+	}
+	return user.Roles, true
+}
+
+// AuthenticateUser checks the password of an enabled user who isn't locked out and returns the user without the password
+// hash. Failed logins count towards the lockout, and a password that is stored with the legacy hash is rehashed.
+func (t *UsersManager) AuthenticateUser(ctx context.Context, name string, password string) (UserState, error) {
 	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
-		"method": "CheckUser",
+		"method": "AuthenticateUser",
 	})
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
-	log.Infof(" M (Users): CheckUser name %s, traceId: %s", name, span.SpanContext().TraceID().String())
+	log.Infof(" M (Users): AuthenticateUser name %s, traceId: %s", name, span.SpanContext().TraceID().String())
 
-	getRequest := states.GetRequest{
-		ID: name,
-	}
-	user, err := t.StateProvider.Get(ctx, getRequest)
+	lock.Lock()
+	defer lock.Unlock()
+	user, err := t.getUser(ctx, name)
 	if err != nil {
 		log.Debugf(" M (Users) : failed to get user %s states, traceId: %s", err, span.SpanContext().TraceID().String())
-		return nil, false
+		// checking a hash anyway keeps unknown users from answering faster than known ones
+		verifyPassword(name, password, getDummyHash())
+		err = v1alpha2.NewCOAError(nil, "login failed", v1alpha2.Unauthorized)
+		return UserState{}, err
+	}
+	if user.Disabled {
+		log.Debugf(" M (Users) : user %s is disabled, traceId: %s", name, span.SpanContext().TraceID().String())
+		err = v1alpha2.NewCOAError(nil, "login failed", v1alpha2.Unauthorized)
+		return UserState{}, err
+	}
+	now := time.Now().UTC()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		log.Debugf(" M (Users) : user %s is locked out until %v, traceId: %s", name, *user.LockedUntil, span.SpanContext().TraceID().String())
+		err = v1alpha2.NewCOAError(nil, "login failed", v1alpha2.Unauthorized)
+		return UserState{}, err
+	}
+
+	ok, legacy := verifyPassword(name, password, user.PasswordHash)
+	if !ok {
+		user.FailedLogins++
+		if t.LockoutThreshold > 0 && user.FailedLogins >= t.LockoutThreshold {
+			lockedUntil := now.Add(t.LockoutDuration)
+			user.LockedUntil = &lockedUntil
+			user.FailedLogins = 0
+			log.Infof(" M (Users): user %s is locked out until %v after too many failed logins", name, lockedUntil)
+		}
+		if sErr := t.saveUser(ctx, user); sErr != nil {
+			log.Errorf(" M (Users) : failed to record failed login of user %s: %v", name, sErr)
+		}
+		log.Debugf(" M (Users) : authentication failed, traceId: %s", span.SpanContext().TraceID().String())
+		err = v1alpha2.NewCOAError(nil, "login failed", v1alpha2.Unauthorized)
+		return UserState{}, err
+	}
+
+	if legacy || user.FailedLogins > 0 || user.LockedUntil != nil {
+		if legacy {
+			passwordHash, hErr := hashPassword(password)
+			if hErr == nil {
+				user.PasswordHash = passwordHash
+				log.Infof(" M (Users): migrated password hash of user %s", name)
+			}
+		}
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+		if sErr := t.saveUser(ctx, user); sErr != nil {
+			log.Errorf(" M (Users) : failed to update user %s after login: %v", name, sErr)
+		}
+	}
+	log.Debugf(" M (Users) : user authenticated, traceId: %s", span.SpanContext().TraceID().String())
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// ChangePassword replaces the password of a user after checking the current one
+func (t *UsersManager) ChangePassword(ctx context.Context, name string, oldPassword string, newPassword string) error {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "ChangePassword",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): ChangePassword name %s, traceId: %s", name, span.SpanContext().TraceID().String())
+
+	_, err = t.AuthenticateUser(ctx, name, oldPassword)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	return t.updateUser(ctx, name, func(user *UserState) error {
+		user.PasswordHash = passwordHash
+		return nil
+	})
+}
+
+// SetUserDisabled disables or enables a user. Enabling a user also lifts a lockout.
+func (t *UsersManager) SetUserDisabled(ctx context.Context, name string, disabled bool) error {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "SetUserDisabled",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): SetUserDisabled name %s, disabled %t, traceId: %s", name, disabled, span.SpanContext().TraceID().String())
+
+	err = t.updateUser(ctx, name, func(user *UserState) error {
+		user.Disabled = disabled
+		if !disabled {
+			user.FailedLogins = 0
+			user.LockedUntil = nil
+		}
+		return nil
+	})
+	return err
+}
+
+// SetTokenTTL sets how long the tokens issued to a user are valid, such as "8h". An empty TTL uses the default.
+func (t *UsersManager) SetTokenTTL(ctx context.Context, name string, ttl string) error {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "SetTokenTTL",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): SetTokenTTL name %s, ttl %s, traceId: %s", name, ttl, span.SpanContext().TraceID().String())
+
+	if ttl != "" {
+		d, pErr := time.ParseDuration(ttl)
+		if pErr != nil || d <= 0 {
+			err = v1alpha2.NewCOAError(pErr, fmt.Sprintf("invalid token TTL '%s'", ttl), v1alpha2.BadRequest)
+			return err
+		}
 	}
+	err = t.updateUser(ctx, name, func(user *UserState) error {
+		user.TokenTTL = ttl
+		return nil
+	})
+	return err
+}
 
-	if v, ok := user.Body.(UserState); ok {
-		if hash(name, password) == v.PasswordHash {
-			log.Debugf(" M (Users) : user authenticated, traceId: %s", span.SpanContext().TraceID().String())
-			return v.Roles, true
+// ListUsers lists the users without their password hashes
+func (t *UsersManager) ListUsers(ctx context.Context) ([]UserState, error) {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "ListUsers",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): ListUsers, traceId: %s", span.SpanContext().TraceID().String())
+
+	entries, _, err := t.StateProvider.List(ctx, states.ListRequest{})
+	if err != nil {
+		log.Debugf(" M (Users) : failed to list users %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	ret := make([]UserState, 0, len(entries))
+	for _, entry := range entries {
+		var user UserState
+		user, err = toUserState(entry.Body)
+		if err != nil {
+			return nil, err
 		}
+		user.PasswordHash = ""
+		ret = append(ret, user)
+	}
+	return ret, nil
+}
+
+// GetUser returns a user without the password hash
+func (t *UsersManager) GetUser(ctx context.Context, name string) (UserState, error) {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "GetUser",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	user, err := t.getUser(ctx, name)
+	if err != nil {
+		return UserState{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (t *UsersManager) updateUser(ctx context.Context, name string, update func(user *UserState) error) error {
+	lock.Lock()
+	defer lock.Unlock()
+	user, err := t.getUser(ctx, name)
+	if err != nil {
+		return err
+	}
+	err = update(&user)
+	if err != nil {
+		return err
+	}
+	return t.saveUser(ctx, user)
+}
+
+func (t *UsersManager) getUser(ctx context.Context, name string) (UserState, error) {
+	entry, err := t.StateProvider.Get(ctx, states.GetRequest{
+		ID: name,
+	})
+	if err != nil {
+		return UserState{}, err
+	}
+	return toUserState(entry.Body)
+}
+
+func (t *UsersManager) saveUser(ctx context.Context, user UserState) error {
+	_, err := t.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   user.Id,
+			Body: user,
+		},
+	})
+	return err
+}
+
+func toUserState(body interface{}) (UserState, error) {
+	if v, ok := body.(UserState); ok {
+		return v, nil
+	}
+	var user UserState
+	jData, _ := json.Marshal(body)
+	err := json.Unmarshal(jData, &user)
+	if err != nil {
+		return UserState{}, err
 	}
-	log.Debugf(" M (Users) : authentication failed, traceId: %s", span.SpanContext().TraceID().String())
-	return nil, false
+	return user, nil
 }
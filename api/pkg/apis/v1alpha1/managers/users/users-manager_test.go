@@ -8,8 +8,11 @@ package users
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
@@ -81,3 +84,195 @@ func TestUpsertAndCheck(t *testing.T) {
 	err = manager.DeleteUser(context.Background(), "test")
 	assert.Nil(t, err)
 }
+
+func TestUpsertHashesWithArgon2id(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.UpsertUser(context.Background(), "test", "password", nil)
+	assert.Nil(t, err)
+	err = manager.UpsertUser(context.Background(), "test2", "password", nil)
+	assert.Nil(t, err)
+	user, err := manager.getUser(context.Background(), "test")
+	assert.Nil(t, err)
+	user2, err := manager.getUser(context.Background(), "test2")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$v=19$"))
+	// salts are random, so the same password doesn't hash the same
+	assert.NotEqual(t, user.PasswordHash, user2.PasswordHash)
+}
+
+func TestCheckUserMigratesLegacyHash(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.saveUser(context.Background(), UserState{
+		Id:           "test",
+		PasswordHash: legacyHash("test", "password"),
+		Roles:        []string{"testrole"},
+	})
+	assert.Nil(t, err)
+	_, res := manager.CheckUser(context.Background(), "test", "wrongpassword")
+	assert.False(t, res)
+	user, err := manager.getUser(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, legacyHash("test", "password"), user.PasswordHash)
+
+	roles, res := manager.CheckUser(context.Background(), "test", "password")
+	assert.True(t, res)
+	assert.Equal(t, []string{"testrole"}, roles)
+	user, err = manager.getUser(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
+	assert.Equal(t, 0, user.FailedLogins)
+	_, res = manager.CheckUser(context.Background(), "test", "password")
+	assert.True(t, res)
+}
+
+func TestLockout(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider: stateProvider,
+	}
+	config := managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":  "StateProvider",
+			"lockoutThreshold": "2",
+			"lockoutDuration":  "1h",
+		},
+	}
+	providers := make(map[string]providers.IProvider)
+	providers["StateProvider"] = stateProvider
+	err := manager.Init(nil, config, providers)
+	assert.Nil(t, err)
+	err = manager.UpsertUser(context.Background(), "test", "password", nil)
+	assert.Nil(t, err)
+
+	_, res := manager.CheckUser(context.Background(), "test", "wrong")
+	assert.False(t, res)
+	_, res = manager.CheckUser(context.Background(), "test", "wrong")
+	assert.False(t, res)
+	// locked out, so even the right password fails
+	_, err = manager.AuthenticateUser(context.Background(), "test", "password")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.Unauthorized, err.(v1alpha2.COAError).State)
+	user, err := manager.GetUser(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.NotNil(t, user.LockedUntil)
+	assert.Empty(t, user.PasswordHash)
+
+	// enabling the user lifts the lockout
+	err = manager.SetUserDisabled(context.Background(), "test", false)
+	assert.Nil(t, err)
+	_, res = manager.CheckUser(context.Background(), "test", "password")
+	assert.True(t, res)
+}
+
+func TestInitWithInvalidLockout(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{}
+	providers := map[string]providers.IProvider{
+		"StateProvider": stateProvider,
+	}
+	err := manager.Init(nil, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":  "StateProvider",
+			"lockoutThreshold": "-1",
+		},
+	}, providers)
+	assert.NotNil(t, err)
+	err = manager.Init(nil, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "StateProvider",
+			"lockoutDuration": "forever",
+		},
+	}, providers)
+	assert.NotNil(t, err)
+}
+
+func TestUpsertKeepsUserSettings(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider:    stateProvider,
+		LockoutThreshold: 5,
+		LockoutDuration:  time.Minute,
+	}
+	err := manager.UpsertUser(context.Background(), "test", "password", nil)
+	assert.Nil(t, err)
+	err = manager.SetTokenTTL(context.Background(), "test", "8h")
+	assert.Nil(t, err)
+	err = manager.SetUserDisabled(context.Background(), "test", true)
+	assert.Nil(t, err)
+	_, res := manager.CheckUser(context.Background(), "test", "password")
+	assert.False(t, res)
+
+	err = manager.UpsertUser(context.Background(), "test", "newpassword", []string{"testrole"})
+	assert.Nil(t, err)
+	user, err := manager.GetUser(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.True(t, user.Disabled)
+	assert.Equal(t, "8h", user.TokenTTL)
+	assert.Equal(t, []string{"testrole"}, user.Roles)
+
+	err = manager.SetUserDisabled(context.Background(), "test", false)
+	assert.Nil(t, err)
+	_, res = manager.CheckUser(context.Background(), "test", "wrong")
+	assert.False(t, res)
+	err = manager.UpsertUser(context.Background(), "test", "newpassword", []string{"testrole"})
+	assert.Nil(t, err)
+	user, err = manager.GetUser(context.Background(), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, user.FailedLogins)
+}
+
+func TestUserLifecycle(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.UpsertUser(context.Background(), "test", "password", []string{"testrole"})
+	assert.Nil(t, err)
+	err = manager.UpsertUser(context.Background(), "test2", "password", nil)
+	assert.Nil(t, err)
+
+	users, err := manager.ListUsers(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(users))
+	for _, user := range users {
+		assert.Empty(t, user.PasswordHash)
+	}
+
+	err = manager.ChangePassword(context.Background(), "test", "wrong", "newpassword")
+	assert.NotNil(t, err)
+	err = manager.ChangePassword(context.Background(), "test", "password", "newpassword")
+	assert.Nil(t, err)
+	_, res := manager.CheckUser(context.Background(), "test", "password")
+	assert.False(t, res)
+	roles, res := manager.CheckUser(context.Background(), "test", "newpassword")
+	assert.True(t, res)
+	assert.Equal(t, []string{"testrole"}, roles)
+
+	err = manager.SetTokenTTL(context.Background(), "test", "8h")
+	assert.Nil(t, err)
+	err = manager.SetTokenTTL(context.Background(), "test", "-1h")
+	assert.NotNil(t, err)
+	user, err := manager.AuthenticateUser(context.Background(), "test", "newpassword")
+	assert.Nil(t, err)
+	assert.Equal(t, "8h", user.TokenTTL)
+
+	err = manager.SetUserDisabled(context.Background(), "test", true)
+	assert.Nil(t, err)
+	_, res = manager.CheckUser(context.Background(), "test", "newpassword")
+	assert.False(t, res)
+
+	err = manager.SetUserDisabled(context.Background(), "missing", true)
+	assert.NotNil(t, err)
+}
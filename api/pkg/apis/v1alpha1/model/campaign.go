@@ -8,7 +8,9 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 )
@@ -34,6 +36,12 @@ type StageSpec struct {
 	Inputs        map[string]interface{} `json:"inputs,omitempty"`
 	HandleErrors  bool                   `json:"handleErrors,omitempty"`
 	Schedule      *v1alpha2.ScheduleSpec `json:"schedule,omitempty"`
+	Rollout       *RolloutSpec           `json:"rollout,omitempty"`
+	// Timeout is the deadline of the stage on all its sites, retries included, such as "30m"
+	Timeout string          `json:"timeout,omitempty"`
+	Retry   *StageRetrySpec `json:"retry,omitempty"`
+	// OnFailure is the stage that runs instead of the stage selector when the stage fails
+	OnFailure string `json:"onFailure,omitempty"`
 }
 
 func (s StageSpec) DeepEquals(other IDeepEquals) (bool, error) {
@@ -66,9 +74,61 @@ func (s StageSpec) DeepEquals(other IDeepEquals) (bool, error) {
 		return false, nil
 	}
 
+	if !reflect.DeepEqual(s.Rollout, otherS.Rollout) {
+		return false, nil
+	}
+
+	if s.Timeout != otherS.Timeout {
+		return false, nil
+	}
+
+	if !reflect.DeepEqual(s.Retry, otherS.Retry) {
+		return false, nil
+	}
+
+	if s.OnFailure != otherS.OnFailure {
+		return false, nil
+	}
+
 	return true, nil
 }
 
+// Reasons a stage stopped before it was done, which are reported in ActivationStatus.StopReason
+const (
+	// StopReasonFailed is reported when a site failed to process the stage
+	StopReasonFailed = "failed"
+	// StopReasonRetriesExhausted is reported when a site still failed after all the attempts of the stage's retry
+	StopReasonRetriesExhausted = "retriesExhausted"
+	// StopReasonTimedOut is reported when the stage ran past its timeout
+	StopReasonTimedOut = "timedOut"
+	// StopReasonRolloutHalted is reported when the failed sites of a rollout reached its failure threshold
+	StopReasonRolloutHalted = "rolloutHalted"
+	// StopReasonInterrupted is reported when the activation was paused or cancelled while the stage was running
+	StopReasonInterrupted = "interrupted"
+)
+
+// Operator requests on a running activation, which are reported in ActivationStatus.Control
+const (
+	// ActivationPause stops the activation at its next checkpoint, keeping where it stopped so that it can resume
+	ActivationPause = "pause"
+	// ActivationResume continues a paused activation
+	ActivationResume = "resume"
+	// ActivationCancel stops the activation for good
+	ActivationCancel = "cancel"
+)
+
+// GetTimeout parses Timeout. An empty Timeout doesn't time out.
+func (s StageSpec) GetTimeout() (time.Duration, error) {
+	if s.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid timeout '%s' of stage %s", s.Timeout, s.Name), v1alpha2.BadRequest)
+	}
+	return timeout, nil
+}
+
 type ActivationStatus struct {
 	Stage                string                 `json:"stage"`
 	NextStage            string                 `json:"nextStage,omitempty"`
@@ -79,6 +139,12 @@ type ActivationStatus struct {
 	IsActive             bool                   `json:"isActive,omitempty"`
 	ActivationGeneration string                 `json:"activationGeneration,omitempty"`
 	UpdateTime           string                 `json:"updateTime,omitempty"`
+	// Waves has the results of the waves of a stage with a rollout
+	Waves []WaveStatus `json:"waves,omitempty"`
+	// StopReason is why a stage stopped before it was done, such as StopReasonTimedOut
+	StopReason string `json:"stopReason,omitempty"`
+	// Control is the last operator request on the activation, such as ActivationPause
+	Control string `json:"control,omitempty"`
 }
 
 type ActivationSpec struct {
@@ -144,6 +210,44 @@ type CampaignSpec struct {
 	FirstStage  string               `json:"firstStage,omitempty"`
 	Stages      map[string]StageSpec `json:"stages,omitempty"`
 	SelfDriving bool                 `json:"selfDriving,omitempty"`
+	// Schedule is used for the first stage when that stage doesn't have its own schedule
+	Schedule *v1alpha2.ScheduleSpec `json:"schedule,omitempty"`
+}
+
+// Validate checks the schedules of the campaign and its stages, and the rollouts, timeouts, retries and failure
+// stages of its stages
+func (c CampaignSpec) Validate() error {
+	if c.Schedule != nil {
+		if err := c.Schedule.Validate(); err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid schedule of campaign %s", c.Name), v1alpha2.BadRequest)
+		}
+	}
+	for name, stage := range c.Stages {
+		if stage.Schedule != nil {
+			if err := stage.Schedule.Validate(); err != nil {
+				return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid schedule of stage %s", name), v1alpha2.BadRequest)
+			}
+		}
+		if stage.Rollout != nil {
+			if err := stage.Rollout.Validate(); err != nil {
+				return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid rollout of stage %s", name), v1alpha2.BadRequest)
+			}
+		}
+		if _, err := stage.GetTimeout(); err != nil {
+			return err
+		}
+		if stage.Retry != nil {
+			if _, err := stage.Retry.GetPolicy(); err != nil {
+				return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid retry of stage %s", name), v1alpha2.BadRequest)
+			}
+		}
+		if stage.OnFailure != "" {
+			if _, ok := c.Stages[stage.OnFailure]; !ok {
+				return v1alpha2.NewCOAError(nil, fmt.Sprintf("failure stage %s of stage %s is not found", stage.OnFailure, name), v1alpha2.BadRequest)
+			}
+		}
+	}
+	return nil
 }
 
 func (c CampaignSpec) DeepEquals(other IDeepEquals) (bool, error) {
@@ -164,6 +268,10 @@ func (c CampaignSpec) DeepEquals(other IDeepEquals) (bool, error) {
 		return false, nil
 	}
 
+	if !reflect.DeepEqual(c.Schedule, otherC.Schedule) {
+		return false, nil
+	}
+
 	if len(c.Stages) != len(otherC.Stages) {
 		return false, nil
 	}
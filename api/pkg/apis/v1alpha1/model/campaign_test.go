@@ -112,6 +112,93 @@ func TestCampaignSelfDrivingNotMatch(t *testing.T) {
 	assert.False(t, equal)
 }
 
+func TestCampaignScheduleNotMatch(t *testing.T) {
+	campaign1 := CampaignSpec{
+		Name:     "name",
+		Schedule: &v1alpha2.ScheduleSpec{Cron: "0 2 * * SUN"},
+	}
+	campaign2 := CampaignSpec{
+		Name:     "name",
+		Schedule: &v1alpha2.ScheduleSpec{Cron: "0 3 * * SUN"},
+	}
+	equal, err := campaign1.DeepEquals(campaign2)
+	assert.Nil(t, err)
+	assert.False(t, equal)
+}
+
+func TestCampaignValidate(t *testing.T) {
+	campaign := CampaignSpec{
+		Name:     "name",
+		Schedule: &v1alpha2.ScheduleSpec{Cron: "0 2 * * SUN", Zone: "America/New_York"},
+		Stages: map[string]StageSpec{
+			"deploy": {
+				Name:     "deploy",
+				Schedule: &v1alpha2.ScheduleSpec{Interval: "6h", MissedRunPolicy: v1alpha2.MissedRunCatchUpOnce},
+			},
+		},
+	}
+	assert.Nil(t, campaign.Validate())
+
+	campaign.Stages["deploy"] = StageSpec{
+		Name:     "deploy",
+		Schedule: &v1alpha2.ScheduleSpec{Interval: "6h", MissedRunPolicy: "sometimes"},
+	}
+	err := campaign.Validate()
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+
+	campaign.Stages = nil
+	campaign.Schedule = &v1alpha2.ScheduleSpec{Cron: "every sunday"}
+	assert.NotNil(t, campaign.Validate())
+}
+
+func TestCampaignValidateStagePolicies(t *testing.T) {
+	campaign := CampaignSpec{
+		Name: "name",
+		Stages: map[string]StageSpec{
+			"deploy": {
+				Name:      "deploy",
+				Timeout:   "30m",
+				Retry:     &StageRetrySpec{MaxAttempts: 3, InitialDelay: "10s", AttemptTimeout: "1m"},
+				OnFailure: "rollback",
+			},
+			"rollback": {
+				Name: "rollback",
+			},
+		},
+	}
+	assert.Nil(t, campaign.Validate())
+
+	invalid := []StageSpec{
+		{Name: "deploy", Timeout: "soon"},
+		{Name: "deploy", Timeout: "-1m"},
+		{Name: "deploy", Retry: &StageRetrySpec{MaxAttempts: -1}},
+		{Name: "deploy", Retry: &StageRetrySpec{InitialDelay: "later"}},
+		{Name: "deploy", OnFailure: "missing"},
+	}
+	for _, stage := range invalid {
+		campaign.Stages["deploy"] = stage
+		err := campaign.Validate()
+		coaErr, ok := err.(v1alpha2.COAError)
+		assert.True(t, ok, "%+v", stage)
+		assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+	}
+}
+
+func TestStageDeepEqualsPolicies(t *testing.T) {
+	stage1 := StageSpec{Name: "deploy", Timeout: "30m", Retry: &StageRetrySpec{MaxAttempts: 3}, OnFailure: "rollback"}
+	stage2 := StageSpec{Name: "deploy", Timeout: "30m", Retry: &StageRetrySpec{MaxAttempts: 3}, OnFailure: "rollback"}
+	equal, err := stage1.DeepEquals(stage2)
+	assert.Nil(t, err)
+	assert.True(t, equal)
+
+	stage2.Retry = &StageRetrySpec{MaxAttempts: 2}
+	equal, err = stage1.DeepEquals(stage2)
+	assert.Nil(t, err)
+	assert.False(t, equal)
+}
+
 func TestCampaignStagesLengthNotMatch(t *testing.T) {
 	campaign1 := CampaignSpec{
 		Name:        "name",
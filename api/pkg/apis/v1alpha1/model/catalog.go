@@ -41,6 +41,25 @@ type CatalogSpec struct {
 	Generation string                 `json:"generation,omitempty"`
 }
 
+// SiteSelector is the catalog metadata key of a selector, such as "region=eu,tier!=lab,!disabled", that limits the
+// child sites a catalog is distributed to. It's matched against the properties of a site. A catalog without a selector
+// is distributed to every child site.
+const SiteSelector = "site.selector"
+
+// NewCatalogTombstone makes the tombstone that tells a child site to remove a catalog. It only carries the name and
+// type of the catalog.
+func NewCatalogTombstone(name string, catalogType string) CatalogState {
+	return CatalogState{
+		ObjectMeta: ObjectMeta{
+			Name: name,
+		},
+		Spec: &CatalogSpec{
+			Name: name,
+			Type: catalogType,
+		},
+	}
+}
+
 type CatalogStatus struct {
 	Properties map[string]string `json:"properties"`
 }
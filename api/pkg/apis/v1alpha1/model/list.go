@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// Query parameters and response metadata keys used by list endpoints
+const (
+	ListLimit         = "limit"
+	ListContinue      = "continue"
+	ListLabelSelector = "labelSelector"
+)
+
+// ListOptions pages and filters a list request. A zero Limit returns all objects.
+// A non-empty continuation token returned with a page is passed back in Continue to get the next page.
+type ListOptions struct {
+	Limit         int    `json:"limit,omitempty"`
+	Continue      string `json:"continue,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// ListOptionsFromParameters reads list options from request parameters
+func ListOptionsFromParameters(parameters map[string]string) (ListOptions, error) {
+	ret := ListOptions{
+		Continue:      parameters[ListContinue],
+		LabelSelector: parameters[ListLabelSelector],
+	}
+	if v, ok := parameters[ListLimit]; ok && v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			return ret, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid %s value '%s'", ListLimit, v), v1alpha2.BadRequest)
+		}
+		ret.Limit = i
+	}
+	return ret, nil
+}
+
+// Encode appends the options to query as query parameters
+func (o ListOptions) Encode(query url.Values) {
+	if o.Limit > 0 {
+		query.Set(ListLimit, strconv.Itoa(o.Limit))
+	}
+	if o.Continue != "" {
+		query.Set(ListContinue, o.Continue)
+	}
+	if o.LabelSelector != "" {
+		query.Set(ListLabelSelector, o.LabelSelector)
+	}
+}
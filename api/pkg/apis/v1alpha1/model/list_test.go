@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListOptionsFromParameters(t *testing.T) {
+	options, err := ListOptionsFromParameters(map[string]string{
+		"limit":         "50",
+		"continue":      "abc",
+		"labelSelector": "env=prod",
+		"namespace":     "default",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ListOptions{Limit: 50, Continue: "abc", LabelSelector: "env=prod"}, options)
+}
+
+func TestListOptionsFromParametersEmpty(t *testing.T) {
+	options, err := ListOptionsFromParameters(map[string]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, ListOptions{}, options)
+}
+
+func TestListOptionsFromParametersInvalidLimit(t *testing.T) {
+	for _, limit := range []string{"many", "-1"} {
+		_, err := ListOptionsFromParameters(map[string]string{"limit": limit})
+		coaErr, ok := err.(v1alpha2.COAError)
+		assert.True(t, ok)
+		assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+	}
+}
+
+func TestListOptionsEncode(t *testing.T) {
+	query := url.Values{}
+	ListOptions{Limit: 10, Continue: "abc", LabelSelector: "env=prod"}.Encode(query)
+	assert.Equal(t, "continue=abc&labelSelector=env%3Dprod&limit=10", query.Encode())
+
+	query = url.Values{}
+	ListOptions{}.Encode(query)
+	assert.Equal(t, "", query.Encode())
+}
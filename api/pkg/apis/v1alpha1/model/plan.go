@@ -191,3 +191,35 @@ func makeReversedDeletionStep(step DeploymentStep) DeploymentStep {
 	}
 	return ret
 }
+
+// StepDependencies returns, for each step, the indexes of the earlier steps it has to wait for.
+// A step waits for an earlier step when both go to the same target, or when a component in one of
+// them depends on a component in the other. Steps are already ordered by component dependencies, so
+// edges only point backwards and the result is always acyclic.
+func (p DeploymentPlan) StepDependencies() [][]int {
+	ret := make([][]int, len(p.Steps))
+	for j := range p.Steps {
+		ret[j] = make([]int, 0)
+		for i := 0; i < j; i++ {
+			if p.Steps[i].Target == p.Steps[j].Target || stepsAreLinked(p.Steps[i], p.Steps[j]) {
+				ret[j] = append(ret[j], i)
+			}
+		}
+	}
+	return ret
+}
+func stepsAreLinked(a DeploymentStep, b DeploymentStep) bool {
+	return stepDependsOn(a, b) || stepDependsOn(b, a)
+}
+func stepDependsOn(a DeploymentStep, b DeploymentStep) bool {
+	for _, ca := range a.Components {
+		for _, d := range ca.Component.Dependencies {
+			for _, cb := range b.Components {
+				if cb.Component.Name == d {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -378,3 +378,41 @@ func TestRevisedForDeletion(t *testing.T) {
 	assert.Equal(t, p.Steps[1].Components[1].Component.Type, "instance")
 	assert.Equal(t, p.Steps[1].Components[1].Component.Properties["file.content"], "hello world")
 }
+
+func TestStepDependencies(t *testing.T) {
+	p := DeploymentPlan{
+		Steps: []DeploymentStep{
+			{
+				Target: "T1",
+				Components: []ComponentStep{
+					{Action: ComponentUpdate, Component: ComponentSpec{Name: "a"}},
+				},
+			},
+			{
+				Target: "T2",
+				Components: []ComponentStep{
+					{Action: ComponentUpdate, Component: ComponentSpec{Name: "b"}},
+				},
+			},
+			{
+				Target: "T3",
+				Components: []ComponentStep{
+					{Action: ComponentUpdate, Component: ComponentSpec{Name: "c", Dependencies: []string{"a"}}},
+				},
+			},
+			{
+				Target: "T2",
+				Role:   "helm.v3",
+				Components: []ComponentStep{
+					{Action: ComponentUpdate, Component: ComponentSpec{Name: "d"}},
+				},
+			},
+		},
+	}
+	deps := p.StepDependencies()
+	assert.Equal(t, 4, len(deps))
+	assert.Equal(t, []int{}, deps[0])
+	assert.Equal(t, []int{}, deps[1])
+	assert.Equal(t, []int{0}, deps[2])
+	assert.Equal(t, []int{1}, deps[3])
+}
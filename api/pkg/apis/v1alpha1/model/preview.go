@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"reflect"
+	"sort"
+)
+
+type PreviewAction string
+
+const (
+	PreviewCreate    PreviewAction = "create"
+	PreviewUpdate    PreviewAction = "update"
+	PreviewDelete    PreviewAction = "delete"
+	PreviewUnchanged PreviewAction = "unchanged"
+)
+
+// PlanPreviewSpec describes what a reconcile would do without changing any target
+type PlanPreviewSpec struct {
+	IsRemoval bool              `json:"isRemoval"`
+	Steps     []StepPreviewSpec `json:"steps"`
+}
+type StepPreviewSpec struct {
+	Target        string                         `json:"target"`
+	Role          string                         `json:"role"`
+	Skipped       bool                           `json:"skipped,omitempty"`
+	Components    []ComponentPreviewSpec         `json:"components"`
+	DryRunResults map[string]ComponentResultSpec `json:"dryRunResults,omitempty"`
+	Error         string                         `json:"error,omitempty"`
+}
+type ComponentPreviewSpec struct {
+	Name    string                `json:"name"`
+	Action  PreviewAction         `json:"action"`
+	Changes []ComponentChangeSpec `json:"changes,omitempty"`
+}
+type ComponentChangeSpec struct {
+	Path     string      `json:"path"`
+	OldValue interface{} `json:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+// DiffComponents lists the type, property and metadata differences between the current and the desired component
+func DiffComponents(current ComponentSpec, desired ComponentSpec) []ComponentChangeSpec {
+	ret := make([]ComponentChangeSpec, 0)
+	if current.Type != desired.Type {
+		ret = append(ret, ComponentChangeSpec{Path: "type", OldValue: current.Type, NewValue: desired.Type})
+	}
+	for _, k := range unionKeys(current.Properties, desired.Properties) {
+		oldValue, oldOk := current.Properties[k]
+		newValue, newOk := desired.Properties[k]
+		if oldOk != newOk || !reflect.DeepEqual(oldValue, newValue) {
+			ret = append(ret, ComponentChangeSpec{Path: "properties." + k, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	currentMetadata := convertMapStringToStringInterface(current.Metadata)
+	desiredMetadata := convertMapStringToStringInterface(desired.Metadata)
+	for _, k := range unionKeys(currentMetadata, desiredMetadata) {
+		oldValue, oldOk := currentMetadata[k]
+		newValue, newOk := desiredMetadata[k]
+		if oldOk != newOk || oldValue != newValue {
+			ret = append(ret, ComponentChangeSpec{Path: "metadata." + k, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return ret
+}
+
+func unionKeys(a map[string]interface{}, b map[string]interface{}) []string {
+	keys := make(map[string]bool)
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	ret := make([]string, 0, len(keys))
+	for k := range keys {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
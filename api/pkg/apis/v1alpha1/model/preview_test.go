@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffComponentsNoChanges(t *testing.T) {
+	c := ComponentSpec{
+		Name: "a",
+		Type: "helm.v3",
+		Properties: map[string]interface{}{
+			"chart": "redis",
+		},
+	}
+	assert.Equal(t, 0, len(DiffComponents(c, c)))
+}
+func TestDiffComponents(t *testing.T) {
+	current := ComponentSpec{
+		Name: "a",
+		Type: "helm.v3",
+		Properties: map[string]interface{}{
+			"chart":   "redis",
+			"version": "1.0",
+		},
+		Metadata: map[string]string{
+			"owner": "team1",
+		},
+	}
+	desired := ComponentSpec{
+		Name: "a",
+		Type: "helm.v3",
+		Properties: map[string]interface{}{
+			"chart":  "redis",
+			"values": "x",
+		},
+		Metadata: map[string]string{
+			"owner": "team2",
+		},
+	}
+	changes := DiffComponents(current, desired)
+	assert.Equal(t, 3, len(changes))
+	assert.Equal(t, "properties.values", changes[0].Path)
+	assert.Nil(t, changes[0].OldValue)
+	assert.Equal(t, "x", changes[0].NewValue)
+	assert.Equal(t, "properties.version", changes[1].Path)
+	assert.Equal(t, "1.0", changes[1].OldValue)
+	assert.Nil(t, changes[1].NewValue)
+	assert.Equal(t, "metadata.owner", changes[2].Path)
+	assert.Equal(t, "team2", changes[2].NewValue)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// Keys used in manager properties, solution metadata and target metadata to configure retries
+const (
+	RetryMaxAttempts    = "retry.maxAttempts"
+	RetryInitialDelay   = "retry.initialDelay"
+	RetryMaxDelay       = "retry.maxDelay"
+	RetryJitter         = "retry.jitter"
+	RetryAttemptTimeout = "retry.attemptTimeout"
+)
+
+// RetryPolicy controls how many times a deployment step is attempted and how long to wait between attempts.
+// Delays grow exponentially from InitialDelay up to MaxDelay, with a random jitter of +/- Jitter (a fraction of the delay).
+type RetryPolicy struct {
+	MaxAttempts    int           `json:"maxAttempts,omitempty"`
+	InitialDelay   time.Duration `json:"initialDelay,omitempty"`
+	MaxDelay       time.Duration `json:"maxDelay,omitempty"`
+	Jitter         float64       `json:"jitter,omitempty"`
+	AttemptTimeout time.Duration `json:"attemptTimeout,omitempty"`
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  1,
+		InitialDelay: 5 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Jitter:       0.2,
+	}
+}
+
+// WithOverrides returns a copy of the policy with the retry.* keys found in properties applied
+func (p RetryPolicy) WithOverrides(properties map[string]string) (RetryPolicy, error) {
+	ret := p
+	if v, ok := properties[RetryMaxAttempts]; ok {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 1 {
+			return p, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid %s value '%s'", RetryMaxAttempts, v), v1alpha2.BadConfig)
+		}
+		ret.MaxAttempts = i
+	}
+	durations := map[string]*time.Duration{
+		RetryInitialDelay:   &ret.InitialDelay,
+		RetryMaxDelay:       &ret.MaxDelay,
+		RetryAttemptTimeout: &ret.AttemptTimeout,
+	}
+	for k, d := range durations {
+		if v, ok := properties[k]; ok {
+			duration, err := time.ParseDuration(v)
+			if err != nil || duration < 0 {
+				return p, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid %s value '%s'", k, v), v1alpha2.BadConfig)
+			}
+			*d = duration
+		}
+	}
+	if v, ok := properties[RetryJitter]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return p, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid %s value '%s'", RetryJitter, v), v1alpha2.BadConfig)
+		}
+		ret.Jitter = f
+	}
+	return ret, nil
+}
+
+// Attempts returns the number of attempts to make, which is at least one
+func (p RetryPolicy) Attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Backoff returns the delay to wait after the given (1-based) failed attempt
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			delay = p.MaxDelay
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		delta := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - delta + rand.Float64()*2*delta)
+	}
+	return delay
+}
+
+// AttemptContext derives the context for a single attempt, bounded by AttemptTimeout when it's set
+func (p RetryPolicy) AttemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.AttemptTimeout > 0 {
+		return context.WithTimeout(ctx, p.AttemptTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// StageRetrySpec is the retry of a campaign stage. A site that fails to process the stage with a retriable error is
+// retried following the RetryPolicy it converts to.
+type StageRetrySpec struct {
+	// MaxAttempts is the number of attempts, including the first one
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialDelay is the time to wait before the first retry, such as "10s"
+	InitialDelay string `json:"initialDelay,omitempty"`
+	// MaxDelay caps the time to wait between attempts, such as "5m"
+	MaxDelay string `json:"maxDelay,omitempty"`
+	// AttemptTimeout bounds each attempt, such as "1m", so that a hung attempt is retried
+	AttemptTimeout string `json:"attemptTimeout,omitempty"`
+}
+
+// GetPolicy applies the retry settings to the default retry policy
+func (r StageRetrySpec) GetPolicy() (RetryPolicy, error) {
+	overrides := map[string]string{}
+	if r.MaxAttempts != 0 {
+		overrides[RetryMaxAttempts] = strconv.Itoa(r.MaxAttempts)
+	}
+	if r.InitialDelay != "" {
+		overrides[RetryInitialDelay] = r.InitialDelay
+	}
+	if r.MaxDelay != "" {
+		overrides[RetryMaxDelay] = r.MaxDelay
+	}
+	if r.AttemptTimeout != "" {
+		overrides[RetryAttemptTimeout] = r.AttemptTimeout
+	}
+	return DefaultRetryPolicy().WithOverrides(overrides)
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	p := DefaultRetryPolicy()
+	assert.Equal(t, 1, p.Attempts())
+	assert.Equal(t, 5*time.Second, p.InitialDelay)
+}
+func TestRetryPolicyWithOverrides(t *testing.T) {
+	p, err := DefaultRetryPolicy().WithOverrides(map[string]string{
+		RetryMaxAttempts:    "4",
+		RetryInitialDelay:   "100ms",
+		RetryMaxDelay:       "1s",
+		RetryJitter:         "0",
+		RetryAttemptTimeout: "30s",
+		"unrelated":         "value",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, p.Attempts())
+	assert.Equal(t, 100*time.Millisecond, p.InitialDelay)
+	assert.Equal(t, time.Second, p.MaxDelay)
+	assert.Equal(t, 0.0, p.Jitter)
+	assert.Equal(t, 30*time.Second, p.AttemptTimeout)
+}
+func TestRetryPolicyWithInvalidOverrides(t *testing.T) {
+	_, err := DefaultRetryPolicy().WithOverrides(map[string]string{
+		RetryMaxAttempts: "0",
+	})
+	assert.NotNil(t, err)
+	_, err = DefaultRetryPolicy().WithOverrides(map[string]string{
+		RetryInitialDelay: "soon",
+	})
+	assert.NotNil(t, err)
+	_, err = DefaultRetryPolicy().WithOverrides(map[string]string{
+		RetryJitter: "1.5",
+	})
+	assert.NotNil(t, err)
+}
+func TestRetryPolicyZeroValue(t *testing.T) {
+	p := RetryPolicy{}
+	assert.Equal(t, 1, p.Attempts())
+	assert.Equal(t, time.Duration(0), p.Backoff(1))
+}
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     500 * time.Millisecond,
+	}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, 500*time.Millisecond, p.Backoff(4))
+	assert.Equal(t, 500*time.Millisecond, p.Backoff(10))
+}
+func TestRetryPolicyBackoffJitter(t *testing.T) {
+	p := RetryPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		Jitter:       0.5,
+	}
+	for i := 0; i < 20; i++ {
+		d := p.Backoff(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 150*time.Millisecond)
+	}
+}
+func TestRetryPolicyAttemptContext(t *testing.T) {
+	p := RetryPolicy{
+		AttemptTimeout: time.Millisecond,
+	}
+	ctx, cancel := p.AttemptContext(context.Background())
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.True(t, ok)
+
+	p = RetryPolicy{}
+	ctx, cancel = p.AttemptContext(context.Background())
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestStageRetrySpecGetPolicy(t *testing.T) {
+	p, err := StageRetrySpec{}.GetPolicy()
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultRetryPolicy(), p)
+
+	p, err = StageRetrySpec{
+		MaxAttempts:    3,
+		InitialDelay:   "10s",
+		MaxDelay:       "1m",
+		AttemptTimeout: "30s",
+	}.GetPolicy()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, p.Attempts())
+	assert.Equal(t, 10*time.Second, p.InitialDelay)
+	assert.Equal(t, time.Minute, p.MaxDelay)
+	assert.Equal(t, 30*time.Second, p.AttemptTimeout)
+
+	_, err = StageRetrySpec{AttemptTimeout: "soon"}.GetPolicy()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// RolloutSpec controls how a stage fans out to the sites its contexts resolve to. The sites are processed in waves,
+// one wave after the other.
+type RolloutSpec struct {
+	// MaxConcurrency is the most sites processed at the same time. 0 processes all the sites of a wave at once.
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// WaveSize is the number of sites in a wave. 0 puts all the sites in a single wave.
+	WaveSize int `json:"waveSize,omitempty"`
+	// FailureThreshold is the number of failed sites that halts the waves that haven't started. 0 never halts.
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// WavePause is the time to wait between waves, such as "10m"
+	WavePause string `json:"wavePause,omitempty"`
+}
+
+// WaveStatus is the result of a wave of a stage rollout
+type WaveStatus struct {
+	Wave        int            `json:"wave"`
+	Sites       []string       `json:"sites"`
+	Status      v1alpha2.State `json:"status"`
+	Succeeded   int            `json:"succeeded"`
+	Failed      int            `json:"failed"`
+	FailedSites []string       `json:"failedSites,omitempty"`
+}
+
+// Validate checks the rollout settings are usable
+func (r RolloutSpec) Validate() error {
+	if r.MaxConcurrency < 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid rollout max concurrency %d", r.MaxConcurrency), v1alpha2.BadRequest)
+	}
+	if r.WaveSize < 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid rollout wave size %d", r.WaveSize), v1alpha2.BadRequest)
+	}
+	if r.FailureThreshold < 0 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid rollout failure threshold %d", r.FailureThreshold), v1alpha2.BadRequest)
+	}
+	_, err := r.GetWavePause()
+	return err
+}
+
+// GetWavePause parses WavePause. An empty WavePause doesn't pause.
+func (r RolloutSpec) GetWavePause() (time.Duration, error) {
+	if r.WavePause == "" {
+		return 0, nil
+	}
+	pause, err := time.ParseDuration(r.WavePause)
+	if err != nil || pause < 0 {
+		return 0, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid rollout wave pause '%s'", r.WavePause), v1alpha2.BadRequest)
+	}
+	return pause, nil
+}
+
+// GetWaves splits the sites into waves of WaveSize sites, keeping their order
+func (r RolloutSpec) GetWaves(sites []string) [][]string {
+	size := r.WaveSize
+	if size <= 0 || size > len(sites) {
+		size = len(sites)
+	}
+	ret := make([][]string, 0)
+	for start := 0; start < len(sites); start += size {
+		end := start + size
+		if end > len(sites) {
+			end = len(sites)
+		}
+		ret = append(ret, sites[start:end])
+	}
+	return ret
+}
+
+// GetConcurrency returns how many of the given number of sites are processed at the same time
+func (r RolloutSpec) GetConcurrency(sites int) int {
+	if r.MaxConcurrency <= 0 || r.MaxConcurrency > sites {
+		return sites
+	}
+	return r.MaxConcurrency
+}
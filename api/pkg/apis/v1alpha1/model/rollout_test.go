@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolloutWaves(t *testing.T) {
+	sites := []string{"s1", "s2", "s3", "s4", "s5"}
+	waves := RolloutSpec{WaveSize: 2}.GetWaves(sites)
+	assert.Equal(t, [][]string{{"s1", "s2"}, {"s3", "s4"}, {"s5"}}, waves)
+
+	waves = RolloutSpec{}.GetWaves(sites)
+	assert.Equal(t, [][]string{sites}, waves)
+
+	waves = RolloutSpec{WaveSize: 10}.GetWaves(sites)
+	assert.Equal(t, [][]string{sites}, waves)
+
+	waves = RolloutSpec{WaveSize: 2}.GetWaves([]string{})
+	assert.Empty(t, waves)
+}
+
+func TestRolloutConcurrency(t *testing.T) {
+	assert.Equal(t, 5, RolloutSpec{}.GetConcurrency(5))
+	assert.Equal(t, 2, RolloutSpec{MaxConcurrency: 2}.GetConcurrency(5))
+	assert.Equal(t, 5, RolloutSpec{MaxConcurrency: 10}.GetConcurrency(5))
+}
+
+func TestRolloutWavePause(t *testing.T) {
+	pause, err := RolloutSpec{}.GetWavePause()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), pause)
+
+	pause, err = RolloutSpec{WavePause: "10m"}.GetWavePause()
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, pause)
+}
+
+func TestRolloutValidate(t *testing.T) {
+	assert.Nil(t, RolloutSpec{MaxConcurrency: 10, WaveSize: 100, FailureThreshold: 5, WavePause: "5m"}.Validate())
+	invalid := []RolloutSpec{
+		{MaxConcurrency: -1},
+		{WaveSize: -1},
+		{FailureThreshold: -1},
+		{WavePause: "later"},
+		{WavePause: "-1m"},
+	}
+	for _, r := range invalid {
+		err := r.Validate()
+		coaErr, ok := err.(v1alpha2.COAError)
+		assert.True(t, ok, "%+v", r)
+		assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+	}
+}
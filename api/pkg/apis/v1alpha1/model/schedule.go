@@ -0,0 +1,24 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// ScheduledStage is a campaign stage that waits on a schedule, with the next times it will fire
+type ScheduledStage struct {
+	Id                string                 `json:"id"`
+	Campaign          string                 `json:"campaign"`
+	Activation        string                 `json:"activation"`
+	Namespace         string                 `json:"namespace,omitempty"`
+	Stage             string                 `json:"stage"`
+	Schedule          *v1alpha2.ScheduleSpec `json:"schedule"`
+	UpcomingFireTimes []time.Time            `json:"upcomingFireTimes"`
+}
@@ -33,6 +33,26 @@ type SiteStatus struct {
 	TargetStatuses   map[string]SiteTargetStatus   `json:"targetStatuses,omitempty"`
 	InstanceStatuses map[string]SiteInstanceStatus `json:"instanceStatuses,omitempty"`
 	LastReported     string                        `json:"lastReported,omitempty"`
+	Enrollment       *SiteEnrollment               `json:"enrollment,omitempty"`
+}
+
+// SiteEnrollment is the token that a site was enrolled with. Only the token whose ID hashes to TokenHash is accepted as
+// the identity of the site, so enrolling a site again replaces its token.
+// +kubebuilder:object:generate=true
+type SiteEnrollment struct {
+	TokenHash string `json:"tokenHash,omitempty"`
+	IssuedAt  string `json:"issuedAt,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	Revoked   bool   `json:"revoked,omitempty"`
+	RevokedAt string `json:"revokedAt,omitempty"`
+}
+
+// SiteToken is a token that a site is enrolled with. The site authenticates to its parent with it.
+type SiteToken struct {
+	SiteId      string `json:"siteId"`
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
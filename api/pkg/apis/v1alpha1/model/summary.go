@@ -21,6 +21,16 @@ type TargetResultSpec struct {
 	Message          string                         `json:"message,omitempty"`
 	ComponentResults map[string]ComponentResultSpec `json:"components,omitempty"`
 }
+
+// RollbackOnFailure is the manager property or solution metadata key that turns on rollback to the
+// last-known-good deployment when a deployment step fails
+const RollbackOnFailure = "rollback.onFailure"
+
+type RollbackResultSpec struct {
+	Succeeded     bool                        `json:"succeeded"`
+	Message       string                      `json:"message,omitempty"`
+	TargetResults map[string]TargetResultSpec `json:"targets,omitempty"`
+}
 type SummarySpec struct {
 	TargetCount         int                         `json:"targetCount"`
 	SuccessCount        int                         `json:"successCount"`
@@ -29,6 +39,7 @@ type SummarySpec struct {
 	Skipped             bool                        `json:"skipped"`
 	IsRemoval           bool                        `json:"isRemoval"`
 	AllAssignedDeployed bool                        `json:"allAssignedDeployed"`
+	Rollback            *RollbackResultSpec         `json:"rollback,omitempty"`
 }
 type SummaryResult struct {
 	Summary    SummarySpec `json:"summary"`
@@ -39,3 +50,44 @@ type SummaryResult struct {
 func (s *SummarySpec) UpdateTargetResult(target string, spec TargetResultSpec) {
 	s.TargetResults[target] = spec
 }
+
+// MergeTargetResult combines the result of another step on the same target with the existing result.
+// Any error status wins, messages are concatenated and component results are merged.
+func (s *SummarySpec) MergeTargetResult(target string, spec TargetResultSpec) {
+	mergeTargetResult(s.TargetResults, target, spec)
+}
+
+// MergeTargetResult records the result of a rollback step the same way SummarySpec.MergeTargetResult does
+func (r *RollbackResultSpec) MergeTargetResult(target string, spec TargetResultSpec) {
+	if r.TargetResults == nil {
+		r.TargetResults = make(map[string]TargetResultSpec)
+	}
+	mergeTargetResult(r.TargetResults, target, spec)
+}
+
+func mergeTargetResult(results map[string]TargetResultSpec, target string, spec TargetResultSpec) {
+	existing, ok := results[target]
+	if !ok {
+		results[target] = spec
+		return
+	}
+	if spec.Status != "OK" {
+		existing.Status = spec.Status
+	}
+	if spec.Message != "" {
+		if existing.Message != "" {
+			existing.Message += "; " + spec.Message
+		} else {
+			existing.Message = spec.Message
+		}
+	}
+	if len(spec.ComponentResults) > 0 {
+		if existing.ComponentResults == nil {
+			existing.ComponentResults = make(map[string]ComponentResultSpec)
+		}
+		for k, v := range spec.ComponentResults {
+			existing.ComponentResults[k] = v
+		}
+	}
+	results[target] = existing
+}
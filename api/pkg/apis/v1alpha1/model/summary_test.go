@@ -9,6 +9,7 @@ package model
 import (
 	"testing"
 
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +32,25 @@ func TestUpdateTargetResult(t *testing.T) {
 	})
 	assert.Equal(t, 0, s.SuccessCount) //ver 0.48.1: UpdateTargetResult no longer updates success count
 }
+
+func TestMergeTargetResult(t *testing.T) {
+	s := &SummarySpec{
+		TargetResults: map[string]TargetResultSpec{},
+	}
+	s.MergeTargetResult("target1", TargetResultSpec{
+		Status: "OK",
+		ComponentResults: map[string]ComponentResultSpec{
+			"a": {Status: v1alpha2.Updated},
+		},
+	})
+	s.MergeTargetResult("target1", TargetResultSpec{
+		Status:  "Error",
+		Message: "failed",
+		ComponentResults: map[string]ComponentResultSpec{
+			"b": {Status: v1alpha2.UpdateFailed},
+		},
+	})
+	assert.Equal(t, "Error", s.TargetResults["target1"].Status)
+	assert.Equal(t, "failed", s.TargetResults["target1"].Message)
+	assert.Equal(t, 2, len(s.TargetResults["target1"].ComponentResults))
+}
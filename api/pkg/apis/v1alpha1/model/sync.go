@@ -6,10 +6,61 @@
 
 package model
 
-import "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
 
 type SyncPackage struct {
 	Origin   string             `json:"origin,omitempty"`
 	Catalogs []CatalogState     `json:"catalogs,omitempty"`
 	Jobs     []v1alpha2.JobData `json:"jobs,omitempty"`
+	// Tombstones are the catalogs to remove from the site, because they were deleted or aren't distributed to the
+	// site anymore. A tombstone only carries the name and type of the catalog.
+	Tombstones []CatalogState `json:"tombstones,omitempty"`
+	// ResumeToken identifies the batch. The site acknowledges the items of the batch that it applied with the token
+	// when it asks for its next batch, and the items that it doesn't acknowledge are sent again.
+	ResumeToken string `json:"resumeToken,omitempty"`
+	// CatalogItems, JobItems and TombstoneItems are the IDs that the site acknowledges the catalogs, jobs and
+	// tombstones of a resumable batch with, in the same order. Each staged job has an ID of its own, as jobs that run
+	// or cancel activations carry no ID, and more than one of them can be for the same activation.
+	CatalogItems   []string `json:"catalogItems,omitempty"`
+	JobItems       []string `json:"jobItems,omitempty"`
+	TombstoneItems []string `json:"tombstoneItems,omitempty"`
+}
+
+// SyncItem is a staged job handed out to a site, with the ID that the site acknowledges it with
+type SyncItem struct {
+	Id  string           `json:"id"`
+	Job v1alpha2.JobData `json:"job"`
+}
+
+// SyncProgress is how far a site got through the jobs staged for it
+type SyncProgress struct {
+	Site string `json:"site"`
+	// Epoch tells resume tokens apart from the tokens of earlier progress of the site, which was lost
+	Epoch string `json:"epoch"`
+	// Batch is the sequence number of the last batch handed out to the site
+	Batch int64 `json:"batch"`
+	// HighWaterMark is the sequence number of the last batch that the site acknowledged all items of
+	HighWaterMark int64 `json:"highWaterMark"`
+	// Acknowledged is how many items the site acknowledged
+	Acknowledged     int64  `json:"acknowledged"`
+	LastAcknowledged string `json:"lastAcknowledged,omitempty"`
+	// Items is the sequence number of the last item handed out to the site, which the IDs of the items are taken from
+	Items int64 `json:"items"`
+	// Pending are the items of the last batch that the site hasn't acknowledged
+	Pending []SyncItem `json:"pending,omitempty"`
+	// Generations are the generations of the catalogs that the site acknowledged, by catalog. They're the cursor of the
+	// site: only newer generations of the catalogs are sent to it.
+	Generations map[string]string `json:"generations,omitempty"`
+}
+
+// ResumeToken is the token of the last batch handed out to the site
+func (p SyncProgress) ResumeToken() string {
+	if p.Epoch == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%d", p.Epoch, p.Batch)
 }
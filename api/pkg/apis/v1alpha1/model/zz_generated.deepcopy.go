@@ -345,6 +345,21 @@ func (in *RouteSpec) DeepCopy() *RouteSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteEnrollment) DeepCopyInto(out *SiteEnrollment) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteEnrollment.
+func (in *SiteEnrollment) DeepCopy() *SiteEnrollment {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteEnrollment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SiteSpec) DeepCopyInto(out *SiteSpec) {
 	*out = *in
@@ -384,6 +399,11 @@ func (in *SiteStatus) DeepCopyInto(out *SiteStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Enrollment != nil {
+		in, out := &in.Enrollment, &out.Enrollment
+		*out = new(SiteEnrollment)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteStatus.
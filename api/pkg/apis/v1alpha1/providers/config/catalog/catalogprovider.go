@@ -252,7 +252,7 @@ func (m *CatalogConfigProvider) Remove(object string, field string) error {
 	return utils.UpsertCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, data)
 }
 func (m *CatalogConfigProvider) RemoveObject(object string) error {
-	return utils.DeleteCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password)
+	return utils.DeleteCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, "")
 }
 
 func (m *CatalogConfigProvider) getCatalogInDefaultNamespace(context context.Context, baseUrl string, catalog string, user string, password string) (model.CatalogState, error) {
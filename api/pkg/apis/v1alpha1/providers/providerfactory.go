@@ -29,6 +29,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/azure/iotedge"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/configmap"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/docker"
+	targetgrpc "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/grpc"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/helm"
 	targethttp "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/http"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/ingress"
@@ -42,12 +43,14 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/win10/sideload"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
 	cp "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	mockconfig "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config/mock"
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/probe/rtsp"
 	mempubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	reidspubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/redis"
+	bboltqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/bbolt"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	cvref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/customvision"
 	httpref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/http"
@@ -55,6 +58,7 @@ import (
 	httpreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/http"
 	k8sreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/k8s"
 	mocksecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/bboltstate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/httpstate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/uploader/azure/blob"
@@ -64,6 +68,260 @@ import (
 type SymphonyProviderFactory struct {
 }
 
+// overridableTargetProviders are the provider types that are replaced by the override passed to CreateProviderForTargetRole
+var overridableTargetProviders = map[string]bool{
+	"providers.target.proxy": true,
+	"providers.target.mqtt":  true,
+}
+
+func init() {
+	providerfactory.Register("providers.state.memory",
+		func() cp.IProvider { return &memorystate.MemoryStateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return memorystate.MemoryStateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.state.bbolt",
+		func() cp.IProvider { return &bboltstate.BboltStateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return bboltstate.BboltStateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.state.k8s",
+		func() cp.IProvider { return &k8sstate.K8sStateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return k8sstate.K8sStateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.config.k8scatalog",
+		func() cp.IProvider { return &k8sstate.K8sStateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return k8sstate.K8sStateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.state.http",
+		func() cp.IProvider { return &httpstate.HttpStateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return httpstate.HttpStateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.reference.k8s",
+		func() cp.IProvider { return &k8sref.K8sReferenceProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return k8sref.K8sReferenceProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.reference.customvision",
+		func() cp.IProvider { return &cvref.CustomVisionReferenceProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return cvref.CustomVisionReferenceProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.reference.http",
+		func() cp.IProvider { return &httpref.HTTPReferenceProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return httpref.HTTPReferenceProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.reporter.k8s",
+		func() cp.IProvider { return &k8sreporter.K8sReporter{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return k8sreporter.K8sReporterConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.reporter.http",
+		func() cp.IProvider { return &httpreporter.HTTPReporter{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return httpreporter.HTTPReporterConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.probe.rtsp",
+		func() cp.IProvider { return &rtsp.RTSPProbeProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return rtsp.RTSPProbeProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.uploader.azure.blob",
+		func() cp.IProvider { return &blob.AzureBlobUploader{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return blob.AzureBlobUploaderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.ledger.mock",
+		func() cp.IProvider { return &mockledger.MockLedgerProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mockledger.MockLedgerProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.counter",
+		func() cp.IProvider { return &counterstage.CounterStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return counterstage.MockStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.azure.iotedge",
+		func() cp.IProvider { return &iotedge.IoTEdgeTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return iotedge.IoTEdgeTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.azure.adu",
+		func() cp.IProvider { return &adu.ADUTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return adu.ADUTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.k8s",
+		func() cp.IProvider { return &k8s.K8sTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return k8s.K8sTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.docker",
+		func() cp.IProvider { return &docker.DockerTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return docker.DockerTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.ingress",
+		func() cp.IProvider { return &ingress.IngressTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return ingress.IngressTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.kubectl",
+		func() cp.IProvider { return &kubectl.KubectlTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return kubectl.KubectlTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.staging",
+		func() cp.IProvider { return &staging.StagingTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return staging.StagingProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.script",
+		func() cp.IProvider { return &script.ScriptProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return script.ScriptProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.http",
+		func() cp.IProvider { return &targethttp.HttpTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return targethttp.HttpTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.win10.sideload",
+		func() cp.IProvider { return &sideload.Win10SideLoadProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return sideload.Win10SideLoadProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.adb",
+		func() cp.IProvider { return &adb.AdbProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return adb.AdbProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.proxy",
+		func() cp.IProvider { return &proxy.ProxyUpdateProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return proxy.ProxyUpdateProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.mqtt",
+		func() cp.IProvider { return &mqtt.MQTTTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mqtt.MQTTTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.grpc",
+		func() cp.IProvider { return &targetgrpc.GrpcTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return targetgrpc.GrpcTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.mock",
+		func() cp.IProvider { return &tgtmock.MockTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return tgtmock.MockTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.configmap",
+		func() cp.IProvider { return &configmap.ConfigMapTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return configmap.ConfigMapTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.target.helm",
+		func() cp.IProvider { return &helm.HelmTargetProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return helm.HelmTargetProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.config.mock",
+		func() cp.IProvider { return &mockconfig.MockConfigProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mockconfig.MockConfigProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.config.catalog",
+		func() cp.IProvider { return &catalogconfig.CatalogConfigProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return catalogconfig.CatalogConfigProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.secret.mock",
+		func() cp.IProvider { return &mocksecret.MockSecretProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mocksecret.MockSecretProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.pubsub.memory",
+		func() cp.IProvider { return &mempubsub.InMemoryPubSubProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mempubsub.InMemoryPubSubConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.pubsub.redis",
+		func() cp.IProvider { return &reidspubsub.RedisPubSubProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return reidspubsub.RedisPubSubProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.mock",
+		func() cp.IProvider { return &mockstage.MockStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return mockstage.MockStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.http",
+		func() cp.IProvider { return &httpstage.HttpStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return httpstage.MockStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.create",
+		func() cp.IProvider { return &symphonystage.CreateStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return symphonystage.SymphonyStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.script",
+		func() cp.IProvider { return &scriptstage.ScriptStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return scriptstage.ScriptProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.patch",
+		func() cp.IProvider { return &patchstage.PatchStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return patchstage.SymphonyStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.list",
+		func() cp.IProvider { return &liststage.ListStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return liststage.ListStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.remote",
+		func() cp.IProvider { return &remotestage.RemoteStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return remotestage.MockStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.wait",
+		func() cp.IProvider { return &waitstage.WaitStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return waitstage.WaitStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.delay",
+		func() cp.IProvider { return &delaystage.DelayStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return delaystage.MockStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.stage.materialize",
+		func() cp.IProvider { return &materialize.MaterializeStageProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return materialize.MaterialieStageProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.queue.memory",
+		func() cp.IProvider { return &memoryqueue.MemoryQueueProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return memoryqueue.MemoryQueueProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.queue.bbolt",
+		func() cp.IProvider { return &bboltqueue.BboltQueueProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return bboltqueue.BboltQueueProviderConfigFromMap(properties)
+		})
+	providerfactory.Register("providers.graph.memory",
+		func() cp.IProvider { return &memorygraph.MemoryGraphProvider{} },
+		func(properties map[string]string) (cp.IProviderConfig, error) {
+			return memorygraph.MemoryhGraphProviderConfigFromMap(properties)
+		})
+}
+
 // CreateProviders initializes the config for the providers from the vendor config
 func (c SymphonyProviderFactory) CreateProviders(config vendors.VendorConfig) (map[string]map[string]cp.IProvider, error) {
 	ret := make(map[string]map[string]cp.IProvider)
@@ -83,280 +341,10 @@ func (c SymphonyProviderFactory) CreateProviders(config vendors.VendorConfig) (m
 }
 
 func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.IProviderConfig) (cp.IProvider, error) {
-	var err error
-	switch providerType {
-	case "providers.state.memory":
-		mProvider := &memorystate.MemoryStateProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.state.k8s":
-		mProvider := &k8sstate.K8sStateProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.config.k8scatalog":
-		mProvider := &k8sstate.K8sStateProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.state.http":
-		mProvider := &httpstate.HttpStateProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.reference.k8s":
-		mProvider := &k8sref.K8sReferenceProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.reference.customvision":
-		mProvider := &cvref.CustomVisionReferenceProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.reference.http":
-		mProvider := &httpref.HTTPReferenceProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.reporter.k8s":
-		mProvider := &k8sreporter.K8sReporter{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.reporter.http":
-		mProvider := &httpreporter.HTTPReporter{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.probe.rtsp":
-		mProvider := &rtsp.RTSPProbeProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.uploader.azure.blob":
-		mProvider := &blob.AzureBlobUploader{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.ledger.mock":
-		mProvider := &mockledger.MockLedgerProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.counter":
-		mProvider := &counterstage.CounterStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.azure.iotedge":
-		mProvider := &iotedge.IoTEdgeTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.azure.adu":
-		mProvider := &adu.ADUTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.k8s":
-		mProvider := &k8s.K8sTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.docker":
-		mProvider := &docker.DockerTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.ingress":
-		mProvider := &ingress.IngressTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.kubectl":
-		mProvider := &kubectl.KubectlTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.staging":
-		mProvider := &staging.StagingTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.script":
-		mProvider := &script.ScriptProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.http":
-		mProvider := &targethttp.HttpTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.win10.sideload":
-		mProvider := &sideload.Win10SideLoadProvider{}
-		err := mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.adb":
-		mProvider := &adb.AdbProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.proxy":
-		mProvider := &proxy.ProxyUpdateProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.mqtt":
-		mProvider := &mqtt.MQTTTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.mock":
-		mProvider := &tgtmock.MockTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.target.configmap":
-		mProvider := &configmap.ConfigMapTargetProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.config.mock":
-		mProvider := &mockconfig.MockConfigProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.config.catalog":
-		mProvider := &catalogconfig.CatalogConfigProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.secret.mock":
-		mProvider := &mocksecret.MockSecretProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.pubsub.memory":
-		mProvider := &mempubsub.InMemoryPubSubProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.pubsub.redis":
-		mProvider := &reidspubsub.RedisPubSubProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.mock":
-		mProvider := &mockstage.MockStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.http":
-		mProvider := &httpstage.HttpStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.create":
-		mProvider := &symphonystage.CreateStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.script":
-		mProvider := &scriptstage.ScriptStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.patch":
-		mProvider := &patchstage.PatchStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.list":
-		mProvider := &liststage.ListStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.remote":
-		mProvider := &remotestage.RemoteStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.wait":
-		mProvider := &waitstage.WaitStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.delay":
-		mProvider := &delaystage.DelayStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.stage.materialize":
-		mProvider := &materialize.MaterializeStageProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.queue.memory":
-		mProvider := &memoryqueue.MemoryQueueProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
-	case "providers.graph.memory":
-		mProvider := &memorygraph.MemoryGraphProvider{}
-		err = mProvider.Init(config)
-		if err == nil {
-			return mProvider, nil
-		}
+	if _, ok := providerfactory.Lookup(providerType); !ok {
+		return nil, nil //TODO: in current design, factory doesn't return errors on unrecognized provider types as there could be other factories. We may want to change this.
 	}
-	return nil, err //TODO: in current design, factory doesn't return errors on unrecognized provider types as there could be other factories. We may want to change this.
+	return providerfactory.CreateRegisteredProvider(providerType, config)
 }
 
 func CreateProviderForTargetRole(context *contexts.ManagerContext, role string, target model.TargetState, override cp.IProvider) (cp.IProvider, error) {
@@ -367,368 +355,12 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 				testRole = "instance"
 			}
 			if binding.Role == testRole {
-				switch binding.Provider {
-				case "providers.target.azure.iotedge":
-					provider := &iotedge.IoTEdgeTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.azure.adu":
-					provider := &adu.ADUTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.k8s":
-					provider := &k8s.K8sTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.docker":
-					provider := &docker.DockerTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.ingress":
-					provider := &ingress.IngressTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.kubectl":
-					provider := &kubectl.KubectlTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.staging":
-					provider := &staging.StagingTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.script":
-					provider := &script.ScriptProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.http":
-					provider := &targethttp.HttpTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.win10.sideload":
-					provider := &sideload.Win10SideLoadProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.adb":
-					provider := &adb.AdbProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.proxy":
-					if override == nil {
-						provider := &proxy.ProxyUpdateProvider{}
-						err := provider.InitWithMap(binding.Config)
-						if err != nil {
-							return nil, err
-						}
-						provider.Context = context
-						return provider, nil
-					} else {
-						return override, nil
-					}
-				case "providers.target.mqtt":
-					if override == nil {
-						provider := &mqtt.MQTTTargetProvider{}
-						err := provider.InitWithMap(binding.Config)
-						if err != nil {
-							return nil, err
-						}
-						provider.Context = context
-						return provider, nil
-					} else {
-						return override, nil
-					}
-				case "providers.target.configmap":
-					provider := &configmap.ConfigMapTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.state.memory":
-					provider := &memorystate.MemoryStateProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.state.k8s":
-					provider := &k8sstate.K8sStateProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					return provider, nil
-				case "providers.ledger.mock":
-					provider := &mockledger.MockLedgerProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.config.k8scatalog":
-					provider := &k8sstate.K8sStateProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.state.http":
-					provider := &httpstate.HttpStateProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.reference.k8s":
-					provider := &k8sref.K8sReferenceProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.reference.customvision":
-					provider := &cvref.CustomVisionReferenceProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.reference.http":
-					provider := &httpref.HTTPReferenceProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.reporter.k8s":
-					provider := &k8sreporter.K8sReporter{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.reporter.http":
-					provider := &httpreporter.HTTPReporter{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.helm":
-					provider := &helm.HelmTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.delay":
-					provider := &delaystage.DelayStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.target.mock":
-					provider := &tgtmock.MockTargetProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.config.mock":
-					provider := &mockconfig.MockConfigProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.config.catalog":
-					provider := &catalogconfig.CatalogConfigProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.secret.mock":
-					provider := &mocksecret.MockSecretProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.mock":
-					provider := &mockstage.MockStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.script":
-					provider := &scriptstage.ScriptStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.patch":
-					provider := &patchstage.PatchStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.counter":
-					provider := &counterstage.CounterStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.remote":
-					provider := &remotestage.RemoteStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.http":
-					provider := &httpstage.HttpStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.create":
-					provider := &symphonystage.CreateStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.list":
-					provider := &liststage.ListStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.wait":
-					provider := &waitstage.WaitStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.stage.materialize":
-					provider := &materialize.MaterializeStageProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.queue.memory":
-					provider := &memoryqueue.MemoryQueueProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.graph.memory":
-					provider := &memorygraph.MemoryGraphProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.pubsub.memory":
-					provider := &mempubsub.InMemoryPubSubProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
-				case "providers.pubsub.redis":
-					provider := &reidspubsub.RedisPubSubProvider{}
-					err := provider.InitWithMap(binding.Config)
-					if err != nil {
-						return nil, err
-					}
-					provider.Context = context
-					return provider, nil
+				if override != nil && overridableTargetProviders[binding.Provider] {
+					return override, nil
+				}
+				if _, ok := providerfactory.Lookup(binding.Provider); ok {
+					return providerfactory.CreateRegisteredProviderFromMap(binding.Provider, binding.Config, context)
 				}
-
 			}
 		}
 	}
@@ -7,6 +7,7 @@
 package providers
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -38,10 +39,12 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/script"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/staging"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/win10/sideload"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
 	mockconfig "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config/mock"
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/probe/rtsp"
 	mempubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	bboltqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/bbolt"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	cvref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/customvision"
 	httpref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/http"
@@ -225,6 +228,10 @@ func TestCreateProvider(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*memoryqueue.MemoryQueueProvider))
 
+	provider, err = providerfactory.CreateProvider("providers.queue.bbolt", bboltqueue.BboltQueueProviderConfig{Path: filepath.Join(t.TempDir(), "queue.db")})
+	assert.Nil(t, err)
+	assert.NotNil(t, *provider.(*bboltqueue.BboltQueueProvider))
+
 	provider, err = providerfactory.CreateProvider("providers.graph.memory", memorygraph.MemoryGraphProviderConfig{})
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*memorygraph.MemoryGraphProvider))
@@ -686,3 +693,69 @@ func TestCreateProviderForTargetRole(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*k8sreporter.K8sReporter))
 }
+
+func TestBuiltInProvidersAreRegistered(t *testing.T) {
+	types := providerfactory.RegisteredTypes()
+	assert.Contains(t, types, "providers.state.memory")
+	assert.Contains(t, types, "providers.state.bbolt")
+	assert.Contains(t, types, "providers.queue.bbolt")
+	assert.Contains(t, types, "providers.target.helm")
+	assert.Contains(t, types, "providers.uploader.azure.blob")
+
+	provider, err := SymphonyProviderFactory{}.CreateProvider("providers.target.mock", tgtmock.MockTargetProviderConfig{})
+	assert.Nil(t, err)
+	assert.NotNil(t, provider.(*tgtmock.MockTargetProvider))
+}
+
+func TestCreateProviderUnknownType(t *testing.T) {
+	provider, err := SymphonyProviderFactory{}.CreateProvider("providers.unknown", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, provider)
+
+	_, err = CreateProviderForTargetRole(nil, "instance", model.TargetState{
+		Spec: &model.TargetSpec{
+			Topologies: []model.TopologySpec{
+				{
+					Bindings: []model.BindingSpec{
+						{
+							Role:     "instance",
+							Provider: "providers.unknown",
+						},
+					},
+				},
+			},
+		},
+	}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCreateProviderForTargetRoleOverride(t *testing.T) {
+	targetState := model.TargetState{
+		Spec: &model.TargetSpec{
+			Topologies: []model.TopologySpec{
+				{
+					Bindings: []model.BindingSpec{
+						{
+							Role:     "instance",
+							Provider: "providers.target.mqtt",
+							Config:   map[string]string{},
+						},
+						{
+							Role:     "mock",
+							Provider: "providers.target.mock",
+							Config:   map[string]string{},
+						},
+					},
+				},
+			},
+		},
+	}
+	override := &tgtmock.MockTargetProvider{}
+	provider, err := CreateProviderForTargetRole(nil, "", targetState, override)
+	assert.Nil(t, err)
+	assert.Same(t, override, provider)
+
+	provider, err = CreateProviderForTargetRole(nil, "mock", targetState, override)
+	assert.Nil(t, err)
+	assert.NotSame(t, override, provider)
+}
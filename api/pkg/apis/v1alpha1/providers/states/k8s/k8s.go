@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -311,6 +312,46 @@ func (s *K8sStateProvider) List(ctx context.Context, request states.ListRequest)
 	version := model.ReadPropertyCompat(request.Metadata, "version", nil)
 	resource := model.ReadPropertyCompat(request.Metadata, "resource", nil)
 
+	filter, err := states.NewEntryFilter(request.FilterType, request.Filter)
+	if err != nil {
+		sLog.Errorf("  P (K8s State): failed to parse filter: %v", err)
+		return nil, "", err
+	}
+	listOptions := metav1.ListOptions{
+		Limit:    int64(request.PageSize),
+		Continue: request.ContinuationToken,
+	}
+	if request.FilterType == states.FilterTypeLabel {
+		// label selectors are a subset of the Kubernetes syntax, so the API server can narrow the list down first
+		listOptions.LabelSelector = request.Filter
+	}
+	resourceId := schema.GroupVersionResource{
+		Group:    group,
+		Version:  version,
+		Resource: resource,
+	}
+
+	if request.PageSize > 0 || request.ContinuationToken != "" {
+		// continuation tokens come from a single list call, so paged lists are scoped to one namespace, which defaults
+		// to "default" as in Get and Delete. Filters other than labels are applied to each page afterwards, which can
+		// make pages shorter than the page size.
+		if namespace == "" {
+			namespace = "default"
+		}
+		items, err := s.DynamicClient.Resource(resourceId).Namespace(namespace).List(ctx, listOptions)
+		if err != nil {
+			sLog.Errorf("  P (K8s State): failed to list objects in namespace %s: %v ", namespace, err)
+			return nil, "", err
+		}
+		for _, v := range items.Items {
+			entry := toStateEntry(&v)
+			if filter(entry) {
+				entities = append(entities, entry)
+			}
+		}
+		return entities, items.GetContinue(), nil
+	}
+
 	var namespaces []string
 	if namespace == "" {
 		ret, err := s.ListAllNamespaces(ctx, version)
@@ -323,32 +364,15 @@ func (s *K8sStateProvider) List(ctx context.Context, request states.ListRequest)
 		namespaces = []string{namespace}
 	}
 	for _, namespace := range namespaces {
-		resourceId := schema.GroupVersionResource{
-			Group:    group,
-			Version:  version,
-			Resource: resource,
-		}
-		items, err := s.DynamicClient.Resource(resourceId).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		items, err := s.DynamicClient.Resource(resourceId).Namespace(namespace).List(ctx, listOptions)
 		if err != nil {
 			sLog.Errorf("  P (K8s State): failed to list objects in namespace %s: %v ", namespace, err)
 			return nil, "", err
 		}
 		for _, v := range items.Items {
-			generation := v.GetGeneration()
-			metadata := model.ObjectMeta{
-				Name:        v.GetName(),
-				Namespace:   v.GetNamespace(),
-				Labels:      v.GetLabels(),
-				Annotations: v.GetAnnotations(),
-			}
-			entry := states.StateEntry{
-				ETag: strconv.FormatInt(generation, 10),
-				ID:   v.GetName(),
-				Body: map[string]interface{}{
-					"spec":     v.Object["spec"],
-					"status":   v.Object["status"],
-					"metadata": metadata,
-				},
+			entry := toStateEntry(&v)
+			if !filter(entry) {
+				continue
 			}
 			entities = append(entities, entry)
 		}
@@ -431,25 +455,104 @@ func (s *K8sStateProvider) Get(ctx context.Context, request states.GetRequest) (
 		sLog.Errorf("  P (K8s State %v", coaError.Error())
 		return states.StateEntry{}, coaError
 	}
-	generation := item.GetGeneration()
+	ret := toStateEntry(item)
+	ret.ID = request.ID
+	return ret, nil
+}
 
+// Watch streams changes to the objects of the resource in request.Metadata. An empty namespace watches all namespaces.
+func (s *K8sStateProvider) Watch(ctx context.Context, request states.WatchRequest) (<-chan states.WatchEvent, error) {
+	ctx, span := observability.StartSpan("K8s State Provider", ctx, &map[string]string{
+		"method": "Watch",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Info("  P (K8s State): watch state")
+
+	namespace := model.ReadPropertyCompat(request.Metadata, "namespace", nil)
+	group := model.ReadPropertyCompat(request.Metadata, "group", nil)
+	version := model.ReadPropertyCompat(request.Metadata, "version", nil)
+	resource := model.ReadPropertyCompat(request.Metadata, "resource", nil)
+
+	resourceId := schema.GroupVersionResource{
+		Group:    group,
+		Version:  version,
+		Resource: resource,
+	}
+	var resourceClient dynamic.ResourceInterface = s.DynamicClient.Resource(resourceId)
+	if namespace != "" {
+		resourceClient = s.DynamicClient.Resource(resourceId).Namespace(namespace)
+	}
+	watcher, err := resourceClient.Watch(ctx, metav1.ListOptions{})
+	if err != nil {
+		sLog.Errorf("  P (K8s State): failed to watch objects: %v", err)
+		return nil, err
+	}
+
+	ch := make(chan states.WatchEvent)
+	go func() {
+		defer close(ch)
+		defer watcher.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+				var eventType states.WatchEventType
+				switch e.Type {
+				case watch.Added:
+					eventType = states.WatchEventCreated
+				case watch.Modified:
+					eventType = states.WatchEventUpdated
+				case watch.Deleted:
+					eventType = states.WatchEventDeleted
+				default:
+					if e.Type == watch.Error {
+						sLog.Errorf("  P (K8s State): watch failed: %v", k8s_errors.FromObject(e.Object))
+						return
+					}
+					continue
+				}
+				item, ok := e.Object.(*unstructured.Unstructured)
+				if !ok {
+					continue
+				}
+				event := states.WatchEvent{
+					Type:            eventType,
+					Entry:           toStateEntry(item),
+					ResourceVersion: item.GetResourceVersion(),
+				}
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func toStateEntry(item *unstructured.Unstructured) states.StateEntry {
 	metadata := model.ObjectMeta{
 		Name:        item.GetName(),
 		Namespace:   item.GetNamespace(),
 		Labels:      item.GetLabels(),
 		Annotations: item.GetAnnotations(),
 	}
-
-	ret := states.StateEntry{
-		ID:   request.ID,
-		ETag: strconv.FormatInt(generation, 10),
+	return states.StateEntry{
+		ID:   item.GetName(),
+		ETag: strconv.FormatInt(item.GetGeneration(), 10),
 		Body: map[string]interface{}{
 			"spec":     item.Object["spec"],
 			"status":   item.Object["status"],
 			"metadata": metadata,
 		},
 	}
-	return ret, nil
 }
 
 // Implmeement the IConfigProvider interface
@@ -18,6 +18,11 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
 )
 
 func TestK8sStateProviderConfigFromMapNil(t *testing.T) {
@@ -718,3 +723,53 @@ func TestTargetUpSertWithStateOnly(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+
+func TestWatch(t *testing.T) {
+	resourceId := schema.GroupVersionResource{Group: model.SolutionGroup, Version: "v1", Resource: "instances"}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		resourceId: "InstanceList",
+	})
+	provider := K8sStateProvider{
+		DynamicClient: client,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := provider.Watch(ctx, states.WatchRequest{
+		Metadata: map[string]interface{}{
+			"namespace": "default",
+			"group":     model.SolutionGroup,
+			"version":   "v1",
+			"resource":  "instances",
+		},
+	})
+	assert.Nil(t, err)
+
+	instance := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": model.SolutionGroup + "/v1",
+			"kind":       "Instance",
+			"metadata": map[string]interface{}{
+				"name":      "i1",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"solution": "s1",
+			},
+		},
+	}
+	_, err = client.Resource(resourceId).Namespace("default").Create(ctx, instance, metav1.CreateOptions{})
+	assert.Nil(t, err)
+	event := <-events
+	assert.Equal(t, states.WatchEventCreated, event.Type)
+	assert.Equal(t, "i1", event.Entry.ID)
+	assert.Equal(t, "s1", event.Entry.Body.(map[string]interface{})["spec"].(map[string]interface{})["solution"])
+
+	err = client.Resource(resourceId).Namespace("default").Delete(ctx, "i1", metav1.DeleteOptions{})
+	assert.Nil(t, err)
+	event = <-events
+	assert.Equal(t, states.WatchEventDeleted, event.Type)
+	assert.Equal(t, "i1", event.Entry.ID)
+
+	cancel()
+	for range events {
+	}
+}
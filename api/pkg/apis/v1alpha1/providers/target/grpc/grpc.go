@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative targetprovider.proto
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+var sLog = logger.NewLogger("coa.runtime")
+
+type GrpcTargetProviderConfig struct {
+	Name                       string            `json:"name"`
+	SocketPath                 string            `json:"socketPath"`
+	Command                    string            `json:"command,omitempty"`
+	Args                       []string          `json:"args,omitempty"`
+	StartTimeoutSeconds        int               `json:"startTimeoutSeconds,omitempty"`
+	HealthCheckIntervalSeconds int               `json:"healthCheckIntervalSeconds,omitempty"`
+	PluginConfig               map[string]string `json:"pluginConfig,omitempty"`
+}
+
+// GrpcTargetProvider forwards ITargetProvider calls to an out-of-process plugin over gRPC (see targetprovider.proto)
+type GrpcTargetProvider struct {
+	Config  GrpcTargetProviderConfig
+	Context *contexts.ManagerContext
+	host    *pluginHost
+}
+
+var reservedKeys = map[string]bool{
+	"name":                       true,
+	"socketPath":                 true,
+	"command":                    true,
+	"args":                       true,
+	"startTimeoutSeconds":        true,
+	"healthCheckIntervalSeconds": true,
+}
+
+// GrpcTargetProviderConfigFromMap reads the provider settings from properties. Keys that aren't provider settings
+// are passed on to the plugin's Init.
+func GrpcTargetProviderConfigFromMap(properties map[string]string) (GrpcTargetProviderConfig, error) {
+	ret := GrpcTargetProviderConfig{
+		PluginConfig: make(map[string]string),
+	}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["socketPath"]; ok {
+		ret.SocketPath = v
+	} else {
+		return ret, v1alpha2.NewCOAError(nil, "'socketPath' is missing in gRPC provider config", v1alpha2.BadConfig)
+	}
+	if v, ok := properties["command"]; ok {
+		ret.Command = v
+	}
+	if v, ok := properties["args"]; ok {
+		ret.Args = strings.Fields(v)
+	}
+	if v, ok := properties["startTimeoutSeconds"]; ok {
+		if num, err := strconv.Atoi(v); err == nil {
+			ret.StartTimeoutSeconds = num
+		} else {
+			return ret, v1alpha2.NewCOAError(nil, "'startTimeoutSeconds' is not an integer in gRPC provider config", v1alpha2.BadConfig)
+		}
+	}
+	if v, ok := properties["healthCheckIntervalSeconds"]; ok {
+		if num, err := strconv.Atoi(v); err == nil {
+			ret.HealthCheckIntervalSeconds = num
+		} else {
+			return ret, v1alpha2.NewCOAError(nil, "'healthCheckIntervalSeconds' is not an integer in gRPC provider config", v1alpha2.BadConfig)
+		}
+	}
+	for k, v := range properties {
+		if !reservedKeys[k] {
+			ret.PluginConfig[k] = v
+		}
+	}
+	return ret, nil
+}
+
+func (i *GrpcTargetProvider) InitWithMap(properties map[string]string) error {
+	config, err := GrpcTargetProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func (s *GrpcTargetProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *GrpcTargetProvider) Init(config providers.IProviderConfig) error {
+	ctx, span := observability.StartSpan("gRPC Target Provider", context.TODO(), &map[string]string{
+		"method": "Init",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	sLog.Info("  P (gRPC Target): Init()")
+
+	updateConfig, err := toGrpcTargetProviderConfig(config)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): expected GrpcTargetProviderConfig: %+v", err)
+		err = v1alpha2.NewCOAError(err, "expected GrpcTargetProviderConfig", v1alpha2.BadConfig)
+		return err
+	}
+	if updateConfig.SocketPath == "" {
+		err = v1alpha2.NewCOAError(nil, "'socketPath' is missing in gRPC provider config", v1alpha2.BadConfig)
+		return err
+	}
+	if updateConfig.StartTimeoutSeconds <= 0 {
+		updateConfig.StartTimeoutSeconds = 10
+	}
+	if updateConfig.HealthCheckIntervalSeconds <= 0 {
+		updateConfig.HealthCheckIntervalSeconds = 10
+	}
+	i.Config = updateConfig
+
+	i.host, err = getPluginHost(i.Config)
+	if err != nil {
+		return err
+	}
+	err = i.host.initPlugin(ctx, i.Config.PluginConfig)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to initialize plugin: %+v", err)
+		return err
+	}
+	return nil
+}
+
+func toGrpcTargetProviderConfig(config providers.IProviderConfig) (GrpcTargetProviderConfig, error) {
+	ret := GrpcTargetProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (i *GrpcTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	ret := model.ValidationRule{}
+	client, err := i.getClient()
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to get validation rule: %+v", err)
+		return ret
+	}
+	resp, err := client.GetValidationRule(ctx, &GetValidationRuleRequest{})
+	if err != nil {
+		err = fromStatusError(err)
+		sLog.Errorf("  P (gRPC Target): failed to get validation rule: %+v", err)
+		return ret
+	}
+	if err = unmarshalIfSet(resp.Rule, &ret); err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to unmarshal validation rule: %+v", err)
+	}
+	return ret
+}
+
+func (i *GrpcTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	ctx, span := observability.StartSpan("gRPC Target Provider", ctx, &map[string]string{
+		"method": "Get",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (gRPC Target): getting artifacts: %s - %s, traceId: %s", deployment.Instance.Spec.Scope, deployment.Instance.Spec.Name, span.SpanContext().TraceID().String())
+
+	client, err := i.getClient()
+	if err != nil {
+		return nil, err
+	}
+	request := &GetRequest{}
+	request.Deployment, _ = json.Marshal(deployment)
+	request.References, _ = json.Marshal(references)
+	resp, err := client.Get(ctx, request)
+	if err != nil {
+		err = fromStatusError(err)
+		sLog.Errorf("  P (gRPC Target): failed to get components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	err = fromPluginError(resp.Error)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): plugin failed to get components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	ret := make([]model.ComponentSpec, 0)
+	err = unmarshalIfSet(resp.Components, &ret)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to unmarshal components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (i *GrpcTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan("gRPC Target Provider", ctx, &map[string]string{
+		"method": "Apply",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (gRPC Target): applying artifacts: %s - %s, traceId: %s", deployment.Instance.Spec.Scope, deployment.Instance.Spec.Name, span.SpanContext().TraceID().String())
+
+	components := step.GetComponents()
+	err = i.GetValidationRule(ctx).Validate(components)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to validate components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+
+	client, err := i.getClient()
+	if err != nil {
+		return nil, err
+	}
+	request := &ApplyRequest{
+		IsDryRun: isDryRun,
+	}
+	request.Deployment, _ = json.Marshal(deployment)
+	request.Step, _ = json.Marshal(step)
+	resp, err := client.Apply(ctx, request)
+	if err != nil {
+		err = fromStatusError(err)
+		sLog.Errorf("  P (gRPC Target): failed to apply components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return step.PrepareResultMap(), err
+	}
+	ret := step.PrepareResultMap()
+	if uErr := unmarshalIfSet(resp.Results, &ret); uErr != nil {
+		sLog.Errorf("  P (gRPC Target): failed to unmarshal apply results: %+v, traceId: %s", uErr, span.SpanContext().TraceID().String())
+	}
+	err = fromPluginError(resp.Error)
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): plugin failed to apply components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return ret, err
+	}
+	return ret, nil
+}
+
+func (i *GrpcTargetProvider) getClient() (TargetProviderClient, error) {
+	if i.host == nil {
+		return nil, v1alpha2.NewCOAError(nil, "gRPC provider is not initialized", v1alpha2.BadConfig)
+	}
+	return i.host.getClient()
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingTargetProvider struct {
+	mock.MockTargetProvider
+}
+
+func (f *failingTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	ret := step.PrepareResultMap()
+	for k := range ret {
+		ret[k] = model.ComponentResultSpec{Status: v1alpha2.UpdateFailed, Message: "failed"}
+	}
+	return ret, v1alpha2.NewCOAError(nil, "device is offline", v1alpha2.TransientError)
+}
+
+// servePlugin serves provider on a unix socket in a temporary folder and returns the socket path
+func servePlugin(t *testing.T, provider target.ITargetProvider) string {
+	socketPath := filepath.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socketPath)
+	assert.Nil(t, err)
+	server := NewPluginServer(provider)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	t.Cleanup(ShutdownPluginHosts)
+	return socketPath
+}
+
+func TestGrpcTargetProviderConfigFromMap(t *testing.T) {
+	config, err := GrpcTargetProviderConfigFromMap(map[string]string{
+		"name":                "grpc",
+		"socketPath":          "/tmp/plugin.sock",
+		"command":             "/usr/bin/plugin",
+		"args":                "--verbose --port 0",
+		"startTimeoutSeconds": "5",
+		"id":                  "plugin-id",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "/tmp/plugin.sock", config.SocketPath)
+	assert.Equal(t, []string{"--verbose", "--port", "0"}, config.Args)
+	assert.Equal(t, 5, config.StartTimeoutSeconds)
+	assert.Equal(t, map[string]string{"id": "plugin-id"}, config.PluginConfig)
+}
+
+func TestGrpcTargetProviderConfigFromMapMissingSocket(t *testing.T) {
+	_, err := GrpcTargetProviderConfigFromMap(map[string]string{
+		"name": "grpc",
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func TestGrpcTargetProviderConfigFromMapInvalidNumber(t *testing.T) {
+	_, err := GrpcTargetProviderConfigFromMap(map[string]string{
+		"socketPath":                 "/tmp/plugin.sock",
+		"healthCheckIntervalSeconds": "often",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestGrpcTargetProviderApplyGet(t *testing.T) {
+	socketPath := servePlugin(t, &mock.MockTargetProvider{})
+
+	provider := GrpcTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"socketPath": socketPath,
+		"id":         "grpc-apply-get",
+	})
+	assert.Nil(t, err)
+
+	component := model.ComponentSpec{
+		Name: "c1",
+		Type: "mock",
+	}
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{
+				Name: "instance1",
+			},
+		},
+	}
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action:    model.ComponentUpdate,
+				Component: component,
+			},
+		},
+	}
+	results, err := provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.OK, results["c1"].Status)
+
+	components, err := provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "c1", components[0].Name)
+}
+
+func TestGrpcTargetProviderApplyError(t *testing.T) {
+	socketPath := servePlugin(t, &failingTargetProvider{})
+
+	provider := GrpcTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"socketPath": socketPath,
+	})
+	assert.Nil(t, err)
+
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action:    model.ComponentUpdate,
+				Component: model.ComponentSpec{Name: "c1"},
+			},
+		},
+	}
+	results, err := provider.Apply(context.Background(), model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{},
+		},
+	}, step, false)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.TransientError, coaErr.State)
+	assert.True(t, v1alpha2.IsRetriable(err))
+	assert.Equal(t, v1alpha2.UpdateFailed, results["c1"].Status)
+}
+
+func TestGrpcTargetProviderUnreachable(t *testing.T) {
+	provider := GrpcTargetProvider{}
+	err := provider.Init(GrpcTargetProviderConfig{
+		SocketPath:          filepath.Join(t.TempDir(), "missing.sock"),
+		StartTimeoutSeconds: 1,
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.TransientError, coaErr.State)
+}
+
+func TestGrpcTargetProviderConcurrentInit(t *testing.T) {
+	socketPath := servePlugin(t, &mock.MockTargetProvider{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			provider := GrpcTargetProvider{}
+			errs <- provider.InitWithMap(map[string]string{
+				"socketPath": socketPath,
+				"id":         fmt.Sprintf("grpc-%d", i%2),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+}
+
+func TestShutdownPluginHosts(t *testing.T) {
+	socketPath := servePlugin(t, &mock.MockTargetProvider{})
+
+	provider := GrpcTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"socketPath": socketPath,
+	})
+	assert.Nil(t, err)
+	host := provider.host
+
+	ShutdownPluginHosts()
+	<-host.done
+	_, err = provider.getClient()
+	assert.NotNil(t, err)
+
+	// a new provider gets a fresh host
+	provider = GrpcTargetProvider{}
+	err = provider.InitWithMap(map[string]string{
+		"socketPath": socketPath,
+	})
+	assert.Nil(t, err)
+	assert.True(t, host != provider.host)
+}
+
+func TestGetPluginHostReplacedOnNewSettings(t *testing.T) {
+	socketPath := servePlugin(t, &mock.MockTargetProvider{})
+
+	provider := GrpcTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"socketPath": socketPath,
+	})
+	assert.Nil(t, err)
+	host := provider.host
+
+	provider = GrpcTargetProvider{}
+	err = provider.InitWithMap(map[string]string{
+		"socketPath":                 socketPath,
+		"healthCheckIntervalSeconds": "30",
+	})
+	assert.Nil(t, err)
+	assert.True(t, host != provider.host)
+	<-host.done
+}
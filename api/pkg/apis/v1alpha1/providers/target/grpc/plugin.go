@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// pluginHost owns the connection to a plugin listening on a unix socket. Provider instances are created per deployment
+// step, so hosts are shared by socket path and outlive them. When a command is configured the host launches the plugin
+// and restarts it when the process exits or stops answering health checks.
+type pluginHost struct {
+	config     GrpcTargetProviderConfig
+	lock       sync.Mutex
+	cmd        *exec.Cmd
+	exited     chan struct{}
+	conn       *grpclib.ClientConn
+	client     TargetProviderClient
+	initConfig map[string]string
+	restarts   int
+	stop       chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
+}
+
+var (
+	hostsLock sync.Mutex
+	hosts     = make(map[string]*pluginHost)
+)
+
+// getPluginHost returns the host for the config's socket path. A host launched with different settings is shut down
+// and replaced, so a changed command or health check interval takes effect.
+func getPluginHost(config GrpcTargetProviderConfig) (*pluginHost, error) {
+	hostsLock.Lock()
+	defer hostsLock.Unlock()
+	if h, ok := hosts[config.SocketPath]; ok {
+		if sameLaunchSettings(h.config, config) {
+			return h, nil
+		}
+		sLog.Infof("  P (gRPC Target): launch settings of plugin at %s changed, replacing it", config.SocketPath)
+		delete(hosts, config.SocketPath)
+		h.shutdown()
+	}
+	h := &pluginHost{
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	h.lock.Lock()
+	err := h.start()
+	h.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	hosts[config.SocketPath] = h
+	go h.supervise()
+	return h, nil
+}
+
+// ShutdownPluginHosts stops supervising all plugins, kills the ones Symphony launched and closes their connections
+func ShutdownPluginHosts() {
+	hostsLock.Lock()
+	defer hostsLock.Unlock()
+	for k, h := range hosts {
+		h.shutdown()
+		delete(hosts, k)
+	}
+}
+
+func sameLaunchSettings(a GrpcTargetProviderConfig, b GrpcTargetProviderConfig) bool {
+	return a.Command == b.Command &&
+		reflect.DeepEqual(a.Args, b.Args) &&
+		a.StartTimeoutSeconds == b.StartTimeoutSeconds &&
+		a.HealthCheckIntervalSeconds == b.HealthCheckIntervalSeconds
+}
+
+// start launches the plugin when there's a command and connects to its socket. Callers hold h.lock.
+func (h *pluginHost) start() error {
+	if h.config.Command != "" {
+		// a socket left over from a previous run would make the plugin fail to listen
+		os.Remove(h.config.SocketPath)
+		cmd := exec.Command(h.config.Command, h.config.Args...)
+		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", PluginSocketEnv, h.config.SocketPath))
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			sLog.Errorf("  P (gRPC Target): failed to launch plugin %s: %+v", h.config.Command, err)
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to launch plugin %s", h.config.Command), v1alpha2.InternalError)
+		}
+		exited := make(chan struct{})
+		go func() {
+			cmd.Wait()
+			close(exited)
+		}()
+		h.cmd = cmd
+		h.exited = exited
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.config.StartTimeoutSeconds)*time.Second)
+	defer cancel()
+	conn, err := grpclib.DialContext(ctx, "unix:"+h.config.SocketPath,
+		grpclib.WithTransportCredentials(insecure.NewCredentials()),
+		grpclib.WithBlock())
+	if err != nil {
+		sLog.Errorf("  P (gRPC Target): failed to connect to plugin at %s: %+v", h.config.SocketPath, err)
+		h.stopProcess()
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to connect to plugin at %s", h.config.SocketPath), v1alpha2.TransientError)
+	}
+	h.conn = conn
+	h.client = NewTargetProviderClient(conn)
+	return nil
+}
+
+// stopProcess kills a launched plugin. Callers hold h.lock.
+func (h *pluginHost) stopProcess() {
+	if h.cmd != nil && h.cmd.Process != nil {
+		h.cmd.Process.Kill()
+		<-h.exited
+	}
+	h.cmd = nil
+	h.exited = nil
+}
+
+// disconnect closes the plugin connection. Callers hold h.lock.
+func (h *pluginHost) disconnect() {
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+		h.client = nil
+	}
+}
+
+// shutdown stops the supervise goroutine, then kills a launched plugin and closes the connection
+func (h *pluginHost) shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.stop)
+	})
+	<-h.done
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.disconnect()
+	h.stopProcess()
+}
+
+func (h *pluginHost) restart() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.restarts++
+	sLog.Infof("  P (gRPC Target): restarting plugin at %s (restart %d)", h.config.SocketPath, h.restarts)
+	h.disconnect()
+	h.stopProcess()
+	if err := h.start(); err != nil {
+		return
+	}
+	if h.initConfig != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.config.StartTimeoutSeconds)*time.Second)
+		defer cancel()
+		if err := callInit(ctx, h.client, h.initConfig); err != nil {
+			sLog.Errorf("  P (gRPC Target): failed to initialize restarted plugin at %s: %+v", h.config.SocketPath, err)
+		}
+	}
+}
+
+// supervise checks the plugin's health on every interval until the host is shut down. Only launched plugins are
+// restarted; for plugins managed elsewhere the gRPC connection reconnects on its own.
+func (h *pluginHost) supervise() {
+	defer close(h.done)
+	interval := time.Duration(h.config.HealthCheckIntervalSeconds) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	launched := h.config.Command != ""
+	for {
+		h.lock.Lock()
+		exited := h.exited
+		h.lock.Unlock()
+
+		select {
+		case <-h.stop:
+			return
+		case <-exited:
+			sLog.Errorf("  P (gRPC Target): plugin at %s exited", h.config.SocketPath)
+			h.restart()
+		case <-ticker.C:
+			h.lock.Lock()
+			conn := h.conn
+			h.lock.Unlock()
+			var err error = v1alpha2.NewCOAError(nil, fmt.Sprintf("plugin at %s is not connected", h.config.SocketPath), v1alpha2.TransientError)
+			if conn != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err = checkHealth(ctx, conn)
+				cancel()
+			}
+			if err != nil {
+				sLog.Errorf("  P (gRPC Target): plugin at %s failed health check: %+v", h.config.SocketPath, err)
+				if launched {
+					h.restart()
+				}
+			}
+		}
+	}
+}
+
+func (h *pluginHost) getClient() (TargetProviderClient, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.client == nil {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("plugin at %s is not connected", h.config.SocketPath), v1alpha2.TransientError)
+	}
+	return h.client, nil
+}
+
+// initPlugin sends the plugin its configuration and remembers it for restarts. It holds h.lock for the call so
+// concurrent providers and restarts don't interleave Init calls, and skips the call when the plugin already has
+// the same configuration.
+func (h *pluginHost) initPlugin(ctx context.Context, config map[string]string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.client == nil {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("plugin at %s is not connected", h.config.SocketPath), v1alpha2.TransientError)
+	}
+	if h.initConfig != nil && reflect.DeepEqual(h.initConfig, config) {
+		return nil
+	}
+	if err := callInit(ctx, h.client, config); err != nil {
+		return err
+	}
+	h.initConfig = make(map[string]string, len(config))
+	for k, v := range config {
+		h.initConfig[k] = v
+	}
+	return nil
+}
+
+func callInit(ctx context.Context, client TargetProviderClient, config map[string]string) error {
+	resp, err := client.Init(ctx, &InitRequest{Config: config})
+	if err != nil {
+		return fromStatusError(err)
+	}
+	return fromPluginError(resp.Error)
+}
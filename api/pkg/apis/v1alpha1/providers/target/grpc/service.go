@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// PluginSocketEnv is the environment variable that carries the socket path to plugins launched by Symphony
+const PluginSocketEnv = "SYMPHONY_PLUGIN_SOCKET"
+
+func checkHealth(ctx context.Context, conn *grpclib.ClientConn) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			// the plugin doesn't serve health checks, answering at all is good enough
+			return nil
+		}
+		return fromStatusError(err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return v1alpha2.NewCOAError(nil, "plugin is "+resp.Status.String(), v1alpha2.TransientError)
+	}
+	return nil
+}
+
+// NewPluginServer returns a gRPC server that serves provider through the TargetProvider service along with the
+// standard health service. It lets target providers written in Go run as plugins.
+func NewPluginServer(provider target.ITargetProvider, opts ...grpclib.ServerOption) *grpclib.Server {
+	server := grpclib.NewServer(opts...)
+	RegisterTargetProviderServer(server, &targetProviderServer{provider: provider})
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	return server
+}
+
+// targetProviderServer adapts an ITargetProvider to the generated TargetProviderServer interface
+type targetProviderServer struct {
+	UnimplementedTargetProviderServer
+	provider target.ITargetProvider
+}
+
+func (s *targetProviderServer) Init(ctx context.Context, req *InitRequest) (*InitResponse, error) {
+	var err error
+	if p, ok := s.provider.(interface {
+		InitWithMap(properties map[string]string) error
+	}); ok {
+		err = p.InitWithMap(req.Config)
+	} else {
+		err = s.provider.Init(req.Config)
+	}
+	return &InitResponse{Error: toPluginError(err)}, nil
+}
+
+func (s *targetProviderServer) GetValidationRule(ctx context.Context, req *GetValidationRuleRequest) (*GetValidationRuleResponse, error) {
+	data, _ := json.Marshal(s.provider.GetValidationRule(ctx))
+	return &GetValidationRuleResponse{Rule: data}, nil
+}
+
+func (s *targetProviderServer) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	var deployment model.DeploymentSpec
+	var references []model.ComponentStep
+	if err := unmarshalIfSet(req.Deployment, &deployment); err != nil {
+		return &GetResponse{Error: toPluginError(err)}, nil
+	}
+	if err := unmarshalIfSet(req.References, &references); err != nil {
+		return &GetResponse{Error: toPluginError(err)}, nil
+	}
+	components, err := s.provider.Get(ctx, deployment, references)
+	data, _ := json.Marshal(components)
+	return &GetResponse{Components: data, Error: toPluginError(err)}, nil
+}
+
+func (s *targetProviderServer) Apply(ctx context.Context, req *ApplyRequest) (*ApplyResponse, error) {
+	var deployment model.DeploymentSpec
+	var step model.DeploymentStep
+	if err := unmarshalIfSet(req.Deployment, &deployment); err != nil {
+		return &ApplyResponse{Error: toPluginError(err)}, nil
+	}
+	if err := unmarshalIfSet(req.Step, &step); err != nil {
+		return &ApplyResponse{Error: toPluginError(err)}, nil
+	}
+	results, err := s.provider.Apply(ctx, deployment, step, req.IsDryRun)
+	data, _ := json.Marshal(results)
+	return &ApplyResponse{Results: data, Error: toPluginError(err)}, nil
+}
+
+func unmarshalIfSet(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return v1alpha2.NewCOAError(err, "failed to unmarshal request", v1alpha2.BadRequest)
+	}
+	return nil
+}
+
+func toPluginError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	state := v1alpha2.InternalError
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		state = coaErr.State
+	}
+	return &Error{
+		State:   int32(state),
+		Message: err.Error(),
+	}
+}
+
+func fromPluginError(e *Error) error {
+	if e == nil {
+		return nil
+	}
+	state := v1alpha2.State(e.State)
+	if state == 0 {
+		state = v1alpha2.InternalError
+	}
+	return v1alpha2.NewCOAError(nil, e.Message, state)
+}
+
+// fromStatusError maps gRPC transport failures to COA errors, marking the ones worth retrying as transient
+func fromStatusError(err error) error {
+	s := status.Convert(err)
+	switch s.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return v1alpha2.NewCOAError(err, "plugin call failed: "+s.Message(), v1alpha2.TransientError)
+	case codes.Unimplemented:
+		return v1alpha2.NewCOAError(err, "plugin call failed: "+s.Message(), v1alpha2.NotImplemented)
+	case codes.InvalidArgument:
+		return v1alpha2.NewCOAError(err, "plugin call failed: "+s.Message(), v1alpha2.BadRequest)
+	}
+	return v1alpha2.NewCOAError(err, "plugin call failed: "+s.Message(), v1alpha2.InternalError)
+}
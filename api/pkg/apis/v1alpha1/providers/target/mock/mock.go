@@ -114,6 +114,10 @@ func (m *MockTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
+	if isDryRun {
+		return step.PrepareResultMap(), nil
+	}
+
 	mLock.Lock()
 	defer mLock.Unlock()
 	if cache[m.Config.ID] == nil {
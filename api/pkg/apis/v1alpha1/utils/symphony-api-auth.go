@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/client"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// Methods that calls to a Symphony API authenticate with
+const (
+	// AuthMethodPassword signs in with a user name and a password to get a token
+	AuthMethodPassword = "password"
+	// AuthMethodServiceAccountToken sends a bearer token read from a file, such as a Kubernetes service account token
+	AuthMethodServiceAccountToken = "serviceAccountToken"
+	// AuthMethodMTLS authenticates with a client certificate
+	AuthMethodMTLS = "mTLS"
+)
+
+// APIAuth configures how the calls to a Symphony API at a base URL authenticate
+type APIAuth struct {
+	// Method is password (default), serviceAccountToken or mTLS
+	Method string
+	// TokenPath is the file of the bearer token of serviceAccountToken. It's read on every call, so that rotated tokens
+	// are picked up.
+	TokenPath string
+	// TLSConfig verifies the API with a CA, and carries the client certificate of mTLS
+	TLSConfig *tls.Config
+}
+
+type apiClient struct {
+	auth   APIAuth
+	client *http.Client
+}
+
+var (
+	apiClientsLock sync.RWMutex
+	apiClients     = make(map[string]apiClient)
+
+	// tokenClients cache the tokens of the users that calls sign in as, by base URL, user and password
+	tokenClientsLock sync.Mutex
+	tokenClients     = make(map[tokenClientKey]*client.Client)
+)
+
+type tokenClientKey struct {
+	baseUrl  string
+	user     string
+	password string
+}
+
+// SetAPIAuth sets how the calls to the Symphony API at a base URL authenticate. Calls to base URLs without an APIAuth
+// sign in with a password.
+func SetAPIAuth(baseUrl string, auth APIAuth) error {
+	switch auth.Method {
+	case "", AuthMethodPassword:
+	case AuthMethodServiceAccountToken:
+		if auth.TokenPath == "" {
+			return v1alpha2.NewCOAError(nil, "service account token auth requires a token path", v1alpha2.BadConfig)
+		}
+	case AuthMethodMTLS:
+		if auth.TLSConfig == nil || len(auth.TLSConfig.Certificates) == 0 {
+			return v1alpha2.NewCOAError(nil, "mTLS auth requires a client certificate", v1alpha2.BadConfig)
+		}
+	default:
+		return v1alpha2.NewCOAError(nil, "auth method '"+auth.Method+"' is not supported", v1alpha2.BadConfig)
+	}
+	httpClient := &http.Client{}
+	if auth.TLSConfig != nil {
+		httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: auth.TLSConfig,
+		}
+	}
+	apiClientsLock.Lock()
+	apiClients[baseUrl] = apiClient{auth: auth, client: httpClient}
+	apiClientsLock.Unlock()
+
+	// tokens are signed in for again with the new HTTP client
+	tokenClientsLock.Lock()
+	defer tokenClientsLock.Unlock()
+	for key := range tokenClients {
+		if key.baseUrl == baseUrl {
+			delete(tokenClients, key)
+		}
+	}
+	return nil
+}
+
+// NewAPITLSConfig makes the TLS configuration of an APIAuth from PEM data. The CA verifies the API in place of the
+// system roots when it's set, and the certificate and key are the client certificate of mTLS.
+func NewAPITLSConfig(ca []byte, cert []byte, key []byte) (*tls.Config, error) {
+	ret := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(ca) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, v1alpha2.NewCOAError(nil, "failed to parse CA certificate", v1alpha2.BadConfig)
+		}
+		ret.RootCAs = pool
+	}
+	if len(cert) > 0 || len(key) > 0 {
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to parse client certificate", v1alpha2.BadConfig)
+		}
+		ret.Certificates = []tls.Certificate{certificate}
+	}
+	return ret, nil
+}
+
+func getAPIClient(baseUrl string) (apiClient, bool) {
+	apiClientsLock.RLock()
+	defer apiClientsLock.RUnlock()
+	client, ok := apiClients[baseUrl]
+	return client, ok
+}
+
+func getHTTPClient(baseUrl string) *http.Client {
+	if client, ok := getAPIClient(baseUrl); ok {
+		return client.client
+	}
+	return &http.Client{}
+}
+
+// getAuthToken returns the bearer token of an APIAuth that doesn't sign in with a password. The bool is false for
+// password auth.
+func getAuthToken(baseUrl string) (string, bool, error) {
+	client, ok := getAPIClient(baseUrl)
+	if !ok {
+		return "", false, nil
+	}
+	switch client.auth.Method {
+	case AuthMethodServiceAccountToken:
+		data, err := os.ReadFile(client.auth.TokenPath)
+		if err != nil {
+			return "", true, v1alpha2.NewCOAError(err, "failed to read service account token", v1alpha2.InternalError)
+		}
+		return strings.TrimSpace(string(data)), true, nil
+	case AuthMethodMTLS:
+		return "", true, nil
+	}
+	return "", false, nil
+}
+
+// getTokenClient returns the client that signs in to a Symphony API as a user. Its token is cached until shortly
+// before it expires, so that calls don't sign in every time.
+func getTokenClient(baseUrl string, user string, password string) (*client.Client, error) {
+	key := tokenClientKey{baseUrl: baseUrl, user: user, password: password}
+	tokenClientsLock.Lock()
+	defer tokenClientsLock.Unlock()
+	if c, ok := tokenClients[key]; ok {
+		return c, nil
+	}
+	c, err := client.NewClient(client.Options{
+		BaseUrl:    baseUrl,
+		User:       user,
+		Password:   password,
+		Retries:    -1,
+		HTTPClient: getHTTPClient(baseUrl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tokenClients[key] = c
+	return c, nil
+}
+
+// invalidateToken drops a cached token that a Symphony API rejected, so that the next call signs in again
+func invalidateToken(baseUrl string, token string) {
+	tokenClientsLock.Lock()
+	defer tokenClientsLock.Unlock()
+	for key, c := range tokenClients {
+		if key.baseUrl == baseUrl {
+			c.InvalidateToken(token)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/require"
+)
+
+// newClientCertificate makes a self-signed client certificate and its key in PEM
+func newClientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "symphony-controller"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func serverCA(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestSetAPIAuthInvalid(t *testing.T) {
+	err := SetAPIAuth("http://invalid/v1alpha2/", APIAuth{Method: AuthMethodServiceAccountToken})
+	require.Error(t, err)
+	require.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+
+	err = SetAPIAuth("http://invalid/v1alpha2/", APIAuth{Method: AuthMethodMTLS})
+	require.Error(t, err)
+
+	err = SetAPIAuth("http://invalid/v1alpha2/", APIAuth{Method: "kerberos"})
+	require.Error(t, err)
+}
+
+func TestNewAPITLSConfigInvalidCA(t *testing.T) {
+	_, err := NewAPITLSConfig([]byte("not a certificate"), nil, nil)
+	require.Error(t, err)
+}
+
+func TestServiceAccountTokenAuth(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1alpha2/users/auth" {
+			t.Errorf("unexpected sign in")
+		}
+		if r.Header.Get("Authorization") != "Bearer service-account-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("service-account-token\n"), 0600))
+	tlsConfig, err := NewAPITLSConfig(serverCA(server), nil, nil)
+	require.NoError(t, err)
+	url := server.URL + "/v1alpha2/"
+	require.NoError(t, SetAPIAuth(url, APIAuth{Method: AuthMethodServiceAccountToken, TokenPath: tokenPath, TLSConfig: tlsConfig}))
+
+	_, err = GetSummary(context.Background(), url, "", "", "instance1", "default")
+	require.NoError(t, err)
+
+	// a rotated token is picked up
+	require.NoError(t, os.WriteFile(tokenPath, []byte("rotated-token"), 0600))
+	_, err = GetSummary(context.Background(), url, "", "", "instance1", "default")
+	require.Error(t, err)
+}
+
+func TestMTLSAuth(t *testing.T) {
+	cert, key := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	require.True(t, clientCAs.AppendCertsFromPEM(cert))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1alpha2/users/auth" {
+			t.Errorf("unexpected sign in")
+		}
+		require.Equal(t, "symphony-controller", r.TLS.PeerCertificates[0].Subject.CommonName)
+		w.Write([]byte("{}"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	tlsConfig, err := NewAPITLSConfig(serverCA(server), cert, key)
+	require.NoError(t, err)
+	url := server.URL + "/v1alpha2/"
+	require.NoError(t, SetAPIAuth(url, APIAuth{Method: AuthMethodMTLS, TLSConfig: tlsConfig}))
+
+	err = QueueJob(context.Background(), url, "", "", "instance1", "default", false, false)
+	require.NoError(t, err)
+}
+
+func TestPasswordAuthWithCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1alpha2/users/auth" {
+			w.Write([]byte(`{"accessToken":"user-token","tokenType":"Bearer"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer user-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/v1alpha2/"
+	// the server isn't trusted without its CA
+	_, err := GetSummary(context.Background(), url, "admin", "", "instance1", "default")
+	require.Error(t, err)
+
+	tlsConfig, err := NewAPITLSConfig(serverCA(server), nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, SetAPIAuth(url, APIAuth{TLSConfig: tlsConfig}))
+	_, err = GetSummary(context.Background(), url, "admin", "", "instance1", "default")
+	require.NoError(t, err)
+}
+
+func TestPasswordAuthTokenIsCached(t *testing.T) {
+	signIns := 0
+	token := "token1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1alpha2/users/auth" {
+			signIns++
+			w.Write([]byte(`{"accessToken":"` + token + `","tokenType":"Bearer"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/v1alpha2/"
+	for i := 0; i < 2; i++ {
+		_, err := GetSummary(context.Background(), url, "admin", "", "instance1", "default")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, signIns)
+
+	// a rejected token is dropped, so that the next call signs in again
+	token = "token2"
+	_, err := GetSummary(context.Background(), url, "admin", "", "instance1", "default")
+	require.Error(t, err)
+	_, err = GetSummary(context.Background(), url, "admin", "", "instance1", "default")
+	require.NoError(t, err)
+	require.Equal(t, 2, signIns)
+}
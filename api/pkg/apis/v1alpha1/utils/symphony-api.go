@@ -13,31 +13,24 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 )
 
 const (
 	SymphonyAPIAddressBase = "http://symphony-service:8080/v1alpha2/"
+	// listPageSize is the page size used when a client call gets all objects of a type
+	listPageSize = 500
 )
 
-type authRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
-type authResponse struct {
-	AccessToken string   `json:"accessToken"`
-	TokenType   string   `json:"tokenType"`
-	Username    string   `json:"username"`
-	Roles       []string `json:"roles"`
-}
-
 // We shouldn't use specific error types
 // SummarySpecError represents an error that includes a SummarySpec in its message
 // field.
@@ -57,32 +50,33 @@ type authResponse struct {
 var log = logger.NewLogger("coa.runtime")
 
 func GetInstancesForAllNamespaces(context context.Context, baseUrl string, user string, password string) ([]model.InstanceState, error) {
-	ret := make([]model.InstanceState, 0)
-	token, err := auth(context, baseUrl, user, password)
-	if err != nil {
-		return ret, err
-	}
+	return GetInstances(context, baseUrl, user, password, "")
+}
 
-	response, err := callRestAPI(context, baseUrl, "instances", "GET", nil, token)
+func GetInstances(context context.Context, baseUrl string, user string, password string, namespace string) ([]model.InstanceState, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return make([]model.InstanceState, 0), err
 	}
+	return listAll[model.InstanceState](context, baseUrl, "instances", namespace, token)
+}
 
-	err = json.Unmarshal(response, &ret)
+// GetInstancesPage gets one page of instances. An empty namespace lists instances in all namespaces.
+func GetInstancesPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.InstanceState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return nil, "", err
 	}
-	return ret, nil
+	return listPage[model.InstanceState](context, baseUrl, "instances", namespace, token, options)
 }
-
-func GetInstances(context context.Context, baseUrl string, user string, password string, namespace string) ([]model.InstanceState, error) {
-	ret := make([]model.InstanceState, 0)
+func GetSites(context context.Context, baseUrl string, user string, password string) ([]model.SiteState, error) {
+	ret := make([]model.SiteState, 0)
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
 		return ret, err
 	}
-	path := "instances?namespace=" + namespace
-	response, err := callRestAPI(context, baseUrl, path, "GET", nil, token)
+
+	response, err := callRestAPI(context, baseUrl, "federation/registry", "GET", nil, token)
 	if err != nil {
 		return ret, err
 	}
@@ -91,16 +85,17 @@ func GetInstances(context context.Context, baseUrl string, user string, password
 	if err != nil {
 		return ret, err
 	}
+
 	return ret, nil
 }
-func GetSites(context context.Context, baseUrl string, user string, password string) ([]model.SiteState, error) {
-	ret := make([]model.SiteState, 0)
+func GetSite(context context.Context, baseUrl string, site string, user string, password string) (model.SiteState, error) {
+	ret := model.SiteState{}
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
 		return ret, err
 	}
 
-	response, err := callRestAPI(context, baseUrl, "federation/registry", "GET", nil, token)
+	response, err := callRestAPI(context, baseUrl, "federation/registry/"+site, "GET", nil, token)
 	if err != nil {
 		return ret, err
 	}
@@ -127,23 +122,38 @@ func SyncActivationStatus(context context.Context, baseUrl string, user string,
 	return nil
 }
 func GetCatalogs(context context.Context, baseUrl string, user string, password string) ([]model.CatalogState, error) {
-	ret := make([]model.CatalogState, 0)
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return make([]model.CatalogState, 0), err
 	}
+	return listAll[model.CatalogState](context, baseUrl, "catalogs/registry", "", token)
+}
 
-	response, err := callRestAPI(context, baseUrl, "catalogs/registry", "GET", nil, token)
+// GetCatalogsPage gets one page of catalogs. An empty namespace lists catalogs in all namespaces.
+func GetCatalogsPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.CatalogState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return nil, "", err
 	}
+	return listPage[model.CatalogState](context, baseUrl, "catalogs/registry", namespace, token, options)
+}
 
-	err = json.Unmarshal(response, &ret)
+// GetCampaignsPage gets one page of campaigns. An empty namespace lists campaigns in all namespaces.
+func GetCampaignsPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.CampaignState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return nil, "", err
 	}
+	return listPage[model.CampaignState](context, baseUrl, "campaigns", namespace, token, options)
+}
 
-	return ret, nil
+// GetActivationsPage gets one page of activations. An empty namespace lists activations in all namespaces.
+func GetActivationsPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.ActivationState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return nil, "", err
+	}
+	return listPage[model.ActivationState](context, baseUrl, "activations/registry", namespace, token, options)
 }
 func GetCatalog(context context.Context, baseUrl string, catalog string, user string, password string, namespace string) (model.CatalogState, error) {
 	ret := model.CatalogState{}
@@ -191,6 +201,34 @@ func GetCampaign(context context.Context, baseUrl string, campaign string, user
 	}
 	return ret, nil
 }
+func UpsertCampaign(context context.Context, baseUrl string, campaign string, user string, password string, payload []byte) error {
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = callRestAPI(context, baseUrl, "campaigns/"+campaign, "POST", payload, token)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func DeleteCampaign(context context.Context, baseUrl string, campaign string, user string, password string, namespace string) error {
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return err
+	}
+
+	path := "campaigns/" + campaign
+	if namespace != "" {
+		path = path + "?namespace=" + namespace
+	}
+	_, err = callRestAPI(context, baseUrl, path, "DELETE", nil, token)
+	if err != nil {
+		return err
+	}
+	return nil
+}
 func PublishActivationEvent(context context.Context, baseUrl string, user string, password string, event v1alpha2.ActivationData) error {
 	token, err := auth(context, baseUrl, user, password)
 
@@ -224,6 +262,35 @@ func GetABatchForSite(context context.Context, baseUrl string, site string, user
 	}
 	return ret, nil
 }
+
+// ResumeBatchForSite gets the next sync batch of a site. It acknowledges the items of the last batch that the site
+// applied with the resume token of the batch, or all of them when applied is nil. An empty resume token acknowledges
+// nothing, so that the items of the last batch are sent again.
+func ResumeBatchForSite(context context.Context, baseUrl string, site string, user string, password string, resumeToken string, applied []string) (model.SyncPackage, error) {
+	ret := model.SyncPackage{}
+	token, err := auth(context, baseUrl, user, password)
+
+	if err != nil {
+		return ret, err
+	}
+
+	query := url.Values{}
+	query.Set("count", "10")
+	query.Set("resume", resumeToken)
+	if applied != nil {
+		query.Set("applied", strings.Join(applied, ","))
+	}
+	response, err := callRestAPI(context, baseUrl, "federation/sync/"+site+"?"+query.Encode(), "GET", nil, token)
+	if err != nil {
+		return ret, err
+	}
+
+	err = json.Unmarshal(response, &ret)
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
 func GetActivation(context context.Context, baseUrl string, activation string, user string, password string) (model.ActivationState, error) {
 	ret := model.ActivationState{}
 	token, err := auth(context, baseUrl, user, password)
@@ -306,13 +373,17 @@ func CreateInstance(context context.Context, baseUrl string, instance string, us
 	return nil
 }
 
-func DeleteCatalog(context context.Context, baseUrl string, catalog string, user string, password string) error {
+func DeleteCatalog(context context.Context, baseUrl string, catalog string, user string, password string, namespace string) error {
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
 		return err
 	}
 
-	_, err = callRestAPI(context, baseUrl, "catalogs/registry/"+catalog, "DELETE", nil, token)
+	path := "catalogs/registry/" + catalog
+	if namespace != "" {
+		path = path + "?namespace=" + namespace
+	}
+	_, err = callRestAPI(context, baseUrl, path, "DELETE", nil, token)
 	if err != nil {
 		return err
 	}
@@ -348,41 +419,24 @@ func DeleteTarget(context context.Context, baseUrl string, target string, user s
 }
 
 func GetSolutionsForAllNamespaces(context context.Context, baseUrl string, user string, password string) ([]model.SolutionState, error) {
-	ret := make([]model.SolutionState, 0)
-	token, err := auth(context, baseUrl, user, password)
-	if err != nil {
-		return ret, err
-	}
-
-	response, err := callRestAPI(context, baseUrl, "solutions", "GET", nil, token)
-	if err != nil {
-		return ret, err
-	}
-
-	err = json.Unmarshal(response, &ret)
-	if err != nil {
-		return ret, err
-	}
-	return ret, nil
+	return GetSolutions(context, baseUrl, user, password, "")
 }
 
 func GetSolutions(context context.Context, baseUrl string, user string, password string, namespace string) ([]model.SolutionState, error) {
-	ret := make([]model.SolutionState, 0)
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
-	}
-	path := "solution" + "?namespace=" + namespace
-	response, err := callRestAPI(context, baseUrl, path, "GET", nil, token)
-	if err != nil {
-		return ret, err
+		return make([]model.SolutionState, 0), err
 	}
+	return listAll[model.SolutionState](context, baseUrl, "solutions", namespace, token)
+}
 
-	err = json.Unmarshal(response, &ret)
+// GetSolutionsPage gets one page of solutions. An empty namespace lists solutions in all namespaces.
+func GetSolutionsPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.SolutionState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return nil, "", err
 	}
-	return ret, nil
+	return listPage[model.SolutionState](context, baseUrl, "solutions", namespace, token, options)
 }
 
 func GetSolution(context context.Context, baseUrl string, solution string, user string, password string, namespace string) (model.SolutionState, error) {
@@ -468,42 +522,24 @@ func GetTarget(context context.Context, baseUrl string, target string, user stri
 }
 
 func GetTargetsForAllNamespaces(context context.Context, baseUrl string, user string, password string) ([]model.TargetState, error) {
-	ret := []model.TargetState{}
-	token, err := auth(context, baseUrl, user, password)
-	if err != nil {
-		return ret, err
-	}
-
-	response, err := callRestAPI(context, baseUrl, "targets/registry", "GET", nil, token)
-	if err != nil {
-		return ret, err
-	}
-
-	err = json.Unmarshal(response, &ret)
-	if err != nil {
-		return ret, err
-	}
-	return ret, nil
+	return GetTargets(context, baseUrl, user, password, "")
 }
 
 func GetTargets(context context.Context, baseUrl string, user string, password string, namespace string) ([]model.TargetState, error) {
-	ret := []model.TargetState{}
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
-	}
-	path := "targets/registry"
-	path = path + "?namespace=" + namespace
-	response, err := callRestAPI(context, baseUrl, path, "GET", nil, token)
-	if err != nil {
-		return ret, err
+		return make([]model.TargetState, 0), err
 	}
+	return listAll[model.TargetState](context, baseUrl, "targets/registry", namespace, token)
+}
 
-	err = json.Unmarshal(response, &ret)
+// GetTargetsPage gets one page of targets. An empty namespace lists targets in all namespaces.
+func GetTargetsPage(context context.Context, baseUrl string, user string, password string, namespace string, options model.ListOptions) ([]model.TargetState, string, error) {
+	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
-		return ret, err
+		return nil, "", err
 	}
-	return ret, nil
+	return listPage[model.TargetState](context, baseUrl, "targets/registry", namespace, token, options)
 }
 
 func SendVisualizationPacket(context context.Context, baseUrl string, user string, password string, payload []byte) error {
@@ -579,6 +615,26 @@ func MatchTargets(instance model.InstanceState, targets []model.TargetState) []m
 	return slice
 }
 
+// CatalogMatchesSite tells whether a catalog is distributed to a child site, by matching the site selector of the
+// catalog against the properties of the site
+func CatalogMatchesSite(catalog model.CatalogState, site model.SiteState) (bool, error) {
+	filter, err := states.NewEntryFilter(states.FilterTypeLabel, catalog.Spec.Metadata[model.SiteSelector])
+	if err != nil {
+		return false, err
+	}
+	labels := map[string]string{}
+	if site.Spec != nil {
+		labels = site.Spec.Properties
+	}
+	return filter(states.StateEntry{
+		Body: map[string]interface{}{
+			"metadata": model.ObjectMeta{
+				Labels: labels,
+			},
+		},
+	}), nil
+}
+
 func CreateSymphonyDeploymentFromTarget(target model.TargetState) (model.DeploymentSpec, error) {
 	key := fmt.Sprintf("%s-%s", "target-runtime", target.ObjectMeta.Name)
 	scope := target.Spec.Scope
@@ -774,23 +830,96 @@ func Reconcile(context context.Context, baseUrl string, user string, password st
 	}
 	return summary, nil
 }
+
+// PlanDeployment previews what reconciling a deployment would change on its targets, without changing them
+func PlanDeployment(context context.Context, baseUrl string, user string, password string, deployment model.DeploymentSpec, namespace string, isDelete bool) (model.PlanPreviewSpec, error) {
+	preview := model.PlanPreviewSpec{}
+	payload, _ := json.Marshal(deployment)
+
+	path := "solution/plan" + "?namespace=" + namespace
+	if isDelete {
+		path = path + "&delete=true"
+	}
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return preview, err
+	}
+	ret, err := callRestAPI(context, baseUrl, path, "POST", payload, token)
+	if err != nil {
+		return preview, err
+	}
+	if ret != nil {
+		err = json.Unmarshal(ret, &preview)
+		if err != nil {
+			return preview, err
+		}
+	}
+	return preview, nil
+}
 func auth(context context.Context, baseUrl string, user string, password string) (string, error) {
-	request := authRequest{Username: user, Password: password}
-	requestData, _ := json.Marshal(request)
-	ret, err := callRestAPI(context, baseUrl, "users/auth", "POST", requestData, "")
+	if token, ok, err := getAuthToken(baseUrl); ok {
+		return token, err
+	}
+	client, err := getTokenClient(baseUrl, user, password)
 	if err != nil {
 		return "", err
 	}
+	return client.Token(context)
+}
+func callRestAPI(context context.Context, baseUrl string, route string, method string, payload []byte, token string) ([]byte, error) {
+	ret, _, err := callRestAPIWithMetadata(context, baseUrl, route, method, payload, token)
+	return ret, err
+}
+
+// listAll gets every object from a list route, one page at a time. Pages are scoped to a namespace, so a list across
+// all namespaces is a single request.
+func listAll[T any](context context.Context, baseUrl string, route string, namespace string, token string) ([]T, error) {
+	ret := make([]T, 0)
+	if namespace == "" {
+		page, _, err := listPage[T](context, baseUrl, route, namespace, token, model.ListOptions{})
+		if err != nil {
+			return ret, err
+		}
+		return append(ret, page...), nil
+	}
+	options := model.ListOptions{Limit: listPageSize}
+	for {
+		page, continueToken, err := listPage[T](context, baseUrl, route, namespace, token, options)
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, page...)
+		if continueToken == "" {
+			return ret, nil
+		}
+		options.Continue = continueToken
+	}
+}
 
-	var response authResponse
-	err = json.Unmarshal(ret, &response)
+// listPage gets one page of objects from a list route and the continuation token of the next page
+func listPage[T any](context context.Context, baseUrl string, route string, namespace string, token string, options model.ListOptions) ([]T, string, error) {
+	query := url.Values{}
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	options.Encode(query)
+	if len(query) > 0 {
+		route += "?" + query.Encode()
+	}
+	response, metadata, err := callRestAPIWithMetadata(context, baseUrl, route, "GET", nil, token)
 	if err != nil {
-		return "", err
+		return nil, "", err
 	}
-
-	return response.AccessToken, nil
+	ret := make([]T, 0)
+	err = json.Unmarshal(response, &ret)
+	if err != nil {
+		return nil, "", err
+	}
+	return ret, metadata[model.ListContinue], nil
 }
-func callRestAPI(context context.Context, baseUrl string, route string, method string, payload []byte, token string) ([]byte, error) {
+
+// callRestAPIWithMetadata calls a Symphony API and also returns the metadata the API attached to the response
+func callRestAPIWithMetadata(context context.Context, baseUrl string, route string, method string, payload []byte, token string) ([]byte, map[string]string, error) {
 	context, span := observability.StartSpan("Symphony-API-Client", context, &map[string]string{
 		"method":      "callRestAPI",
 		"http.method": method,
@@ -801,21 +930,21 @@ func callRestAPI(context context.Context, baseUrl string, route string, method s
 
 	log.Infof("Calling Symphony API: %s %s, spanId: %s, traceId: %s", method, baseUrl+route, span.SpanContext().SpanID().String(), span.SpanContext().TraceID().String())
 
-	client := &http.Client{}
+	client := getHTTPClient(baseUrl)
 	rUrl := baseUrl + route
 	var req *http.Request
 	if payload != nil {
 		req, err = http.NewRequestWithContext(context, method, rUrl, bytes.NewBuffer(payload))
 		observ_utils.PropagateSpanContextToHttpRequestHeader(req)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		req.Header.Set("Content-Type", "application/json")
 	} else {
 		req, err = http.NewRequestWithContext(context, method, rUrl, nil)
 		observ_utils.PropagateSpanContextToHttpRequestHeader(req)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -826,13 +955,13 @@ func callRestAPI(context context.Context, baseUrl string, route string, method s
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if resp.StatusCode >= 300 {
@@ -841,11 +970,18 @@ func callRestAPI(context context.Context, baseUrl string, route string, method s
 		// if resp.StatusCode == 404 { // API service is already gone
 		// 	return nil, nil
 		// }
+		if token != "" && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			invalidateToken(baseUrl, token)
+		}
 		err = v1alpha2.FromHTTPResponseCode(resp.StatusCode, bodyBytes)
-		return nil, err
+		return nil, nil, err
 	}
 	err = nil
 	log.Infof("Symphony API succeeded: %s %s, spanId: %s, traceId: %s", method, baseUrl+route, span.SpanContext().SpanID().String(), span.SpanContext().TraceID().String())
 
-	return bodyBytes, nil
+	var metadata map[string]string
+	if meta := resp.Header.Get(v1alpha2.COAMetaHeader); meta != "" {
+		json.Unmarshal([]byte(meta), &metadata)
+	}
+	return bodyBytes, metadata, nil
 }
@@ -9,11 +9,15 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/require"
 )
 
@@ -345,6 +349,167 @@ func TestGetTargetsWithSomeTargets(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// pagingServer serves solutions s0..s<count-1> in pages, the way the solutions vendor does
+func pagingServer(t *testing.T, count int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/auth":
+			json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+		case "/solutions":
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			query := r.URL.Query()
+			start, _ := strconv.Atoi(query.Get("continue"))
+			end := count
+			if limit, _ := strconv.Atoi(query.Get("limit")); limit > 0 && start+limit < count {
+				end = start + limit
+				data, _ := json.Marshal(map[string]string{"continue": strconv.Itoa(end)})
+				w.Header().Set(v1alpha2.COAMetaHeader, string(data))
+			}
+			solutions := make([]model.SolutionState, 0)
+			for i := start; i < end; i++ {
+				solutions = append(solutions, model.SolutionState{
+					ObjectMeta: model.ObjectMeta{Name: "s" + strconv.Itoa(i), Namespace: query.Get("namespace")},
+				})
+			}
+			json.NewEncoder(w).Encode(solutions)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGetSolutionsPage(t *testing.T) {
+	ts := pagingServer(t, 5)
+	defer ts.Close()
+
+	solutions, continueToken, err := GetSolutionsPage(context.Background(), ts.URL+"/", user, password, "default", model.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(solutions))
+	require.Equal(t, "default", solutions[0].ObjectMeta.Namespace)
+	require.Equal(t, "2", continueToken)
+
+	solutions, continueToken, err = GetSolutionsPage(context.Background(), ts.URL+"/", user, password, "default", model.ListOptions{Limit: 10, Continue: continueToken})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(solutions))
+	require.Equal(t, "s2", solutions[0].ObjectMeta.Name)
+	require.Equal(t, "", continueToken)
+}
+
+func TestGetSolutionsAllPages(t *testing.T) {
+	ts := pagingServer(t, listPageSize*2+1)
+	defer ts.Close()
+
+	solutions, err := GetSolutions(context.Background(), ts.URL+"/", user, password, "default")
+	require.NoError(t, err)
+	require.Equal(t, listPageSize*2+1, len(solutions))
+	require.Equal(t, "s"+strconv.Itoa(listPageSize*2), solutions[listPageSize*2].ObjectMeta.Name)
+}
+
+func TestGetSolutionsAllNamespacesUnpaged(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/auth":
+			json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+		case "/solutions":
+			requests++
+			require.Equal(t, "", r.URL.RawQuery)
+			json.NewEncoder(w).Encode([]model.SolutionState{{ObjectMeta: model.ObjectMeta{Name: "s0", Namespace: "ns1"}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	solutions, err := GetSolutionsForAllNamespaces(context.Background(), ts.URL+"/", user, password)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(solutions))
+	require.Equal(t, 1, requests)
+}
+
+func TestPlanDeployment(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/auth":
+			json.NewEncoder(w).Encode(map[string]string{"accessToken": "token"})
+		case "/solution/plan":
+			require.Equal(t, http.MethodPost, r.Method)
+			require.Equal(t, "ns1", r.URL.Query().Get("namespace"))
+			var deployment model.DeploymentSpec
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deployment))
+			json.NewEncoder(w).Encode(model.PlanPreviewSpec{
+				IsRemoval: r.URL.Query().Get("delete") == "true",
+				Steps: []model.StepPreviewSpec{
+					{
+						Target:     "target1",
+						Components: []model.ComponentPreviewSpec{{Name: deployment.Solution.Spec.Components[0].Name, Action: model.PreviewCreate}},
+					},
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	deployment := model.DeploymentSpec{
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{Components: []model.ComponentSpec{{Name: "component1"}}},
+		},
+	}
+	preview, err := PlanDeployment(context.Background(), ts.URL+"/", user, password, deployment, "ns1", false)
+	require.NoError(t, err)
+	require.False(t, preview.IsRemoval)
+	require.Equal(t, "component1", preview.Steps[0].Components[0].Name)
+	require.Equal(t, model.PreviewCreate, preview.Steps[0].Components[0].Action)
+
+	preview, err = PlanDeployment(context.Background(), ts.URL+"/", user, password, deployment, "ns1", true)
+	require.NoError(t, err)
+	require.True(t, preview.IsRemoval)
+}
+
+func TestCatalogMatchesSite(t *testing.T) {
+	site := model.SiteState{
+		Id: "site1",
+		Spec: &model.SiteSpec{
+			Properties: map[string]string{
+				"region": "eu",
+				"tier":   "edge",
+			},
+		},
+	}
+	for selector, expected := range map[string]bool{
+		"":                 true,
+		"region=eu":        true,
+		"region=eu,tier":   true,
+		"region=us":        false,
+		"tier!=edge":       false,
+		"!lab":             true,
+		"region=eu,lab":    false,
+		"region=eu,!tier":  false,
+		"region==eu,tier=": false,
+	} {
+		catalog := model.CatalogState{
+			Spec: &model.CatalogSpec{
+				Metadata: map[string]string{
+					model.SiteSelector: selector,
+				},
+			},
+		}
+		match, err := CatalogMatchesSite(catalog, site)
+		require.Nil(t, err)
+		require.Equal(t, expected, match, selector)
+	}
+
+	// a site without properties only matches catalogs without selectors or with negative ones
+	match, err := CatalogMatchesSite(model.CatalogState{Spec: &model.CatalogSpec{Metadata: map[string]string{model.SiteSelector: "!lab"}}}, model.SiteState{})
+	require.Nil(t, err)
+	require.True(t, match)
+
+	_, err = CatalogMatchesSite(model.CatalogState{Spec: &model.CatalogSpec{Metadata: map[string]string{model.SiteSelector: "region=eu,=edge"}}}, site)
+	require.NotNil(t, err)
+}
+
 func TestMatchTargetsWithTargetName(t *testing.T) {
 	res := MatchTargets(model.InstanceState{
 		ObjectMeta: model.ObjectMeta{
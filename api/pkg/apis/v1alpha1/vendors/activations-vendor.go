@@ -75,9 +75,85 @@ func (o *ActivationsVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Handler:    o.onStatus,
 			Parameters: []string{"name?"},
 		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/pause",
+			Version:    o.Version,
+			Handler:    o.onPause,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/resume",
+			Version:    o.Version,
+			Handler:    o.onResume,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/cancel",
+			Version:    o.Version,
+			Handler:    o.onCancel,
+			Parameters: []string{"name?"},
+		},
 	}
 }
 
+func (c *ActivationsVendor) onPause(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	return c.onControl(request, model.ActivationPause)
+}
+func (c *ActivationsVendor) onResume(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	return c.onControl(request, model.ActivationResume)
+}
+func (c *ActivationsVendor) onCancel(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	return c.onControl(request, model.ActivationCancel)
+}
+
+// onControl applies an operator's pause, resume or cancel to an activation and returns the activation
+func (c *ActivationsVendor) onControl(request v1alpha2.COARequest, control string) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Activations Vendor", request.Context, &map[string]string{
+		"method": "onControl",
+	})
+	defer span.End()
+
+	vLog.Infof("V (Activations Vendor): onControl, control: %s, method: %s, traceId: %s", control, string(request.Method), span.SpanContext().TraceID().String())
+	namespace, exist := request.Parameters["namespace"]
+	if !exist {
+		namespace = "default"
+	}
+	switch request.Method {
+	case fasthttp.MethodPost:
+		ctx, span := observability.StartSpan("onControl-POST", pCtx, nil)
+		id := request.Parameters["__name"]
+		activation, err := c.ActivationsManager.ControlActivation(ctx, id, namespace, control)
+		if err != nil {
+			vLog.Infof("V (Activations Vendor): onControl failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			state := v1alpha2.InternalError
+			if cErr, ok := err.(v1alpha2.COAError); ok {
+				state = cErr.State
+			}
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: state,
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := json.Marshal(activation)
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+	}
+	vLog.Infof("V (Activations Vendor): onControl failed - 405 method not allowed, traceId: %s", span.SpanContext().TraceID().String())
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+
 func (c *ActivationsVendor) onStatus(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	pCtx, span := observability.StartSpan("Activations Vendor", request.Context, &map[string]string{
 		"method": "onStatus",
@@ -139,11 +215,20 @@ func (c *ActivationsVendor) onActivations(request v1alpha2.COARequest) v1alpha2.
 		var err error
 		var state interface{}
 		isArray := false
+		continueToken := ""
 		if id == "" {
 			if !namespaceSupplied {
 				namespace = ""
 			}
-			state, err = c.ActivationsManager.ListState(ctx, namespace)
+			var options model.ListOptions
+			options, err = listOptions(request)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+			state, continueToken, err = c.ActivationsManager.ListStatePage(ctx, namespace, options)
 			isArray = true
 		} else {
 			state, err = c.ActivationsManager.GetState(ctx, id, namespace)
@@ -161,6 +246,9 @@ func (c *ActivationsVendor) onActivations(request v1alpha2.COARequest) v1alpha2.
 			Body:        jData,
 			ContentType: "application/json",
 		})
+		if continueToken != "" {
+			resp.Metadata = map[string]string{model.ListContinue: continueToken}
+		}
 		if request.Parameters["doc-type"] == "yaml" {
 			resp.ContentType = "application/text"
 		}
@@ -36,7 +36,7 @@ func TestActivationsEndpoints(t *testing.T) {
 	vendor := createActivationsVendor()
 	vendor.Route = "activations"
 	endpoints := vendor.GetEndpoints()
-	assert.Equal(t, 2, len(endpoints))
+	assert.Equal(t, 5, len(endpoints))
 }
 func TestActivationsInfo(t *testing.T) {
 	vendor := createActivationsVendor()
@@ -190,3 +190,72 @@ func TestActivationsWrongMethod(t *testing.T) {
 	})
 	assert.Equal(t, v1alpha2.MethodNotAllowed, resp.State)
 }
+func TestActivationsOnControl(t *testing.T) {
+	vendor := createActivationsVendor()
+	vendor.ActivationsManager.Context = &contexts.ManagerContext{}
+	activationState := model.ActivationState{
+		Spec: &model.ActivationSpec{
+			Campaign: "campaign1",
+		},
+	}
+	err := vendor.ActivationsManager.UpsertState(context.Background(), "activation1", activationState)
+	assert.Nil(t, err)
+	err = vendor.ActivationsManager.ReportStatus(context.Background(), "activation1", model.ActivationStatus{
+		Stage:    "deploy",
+		Status:   v1alpha2.Running,
+		IsActive: true,
+	})
+	assert.Nil(t, err)
+
+	resp := vendor.onResume(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Parameters: map[string]string{
+			"__name": "activation1",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.Conflict, resp.State)
+
+	resp = vendor.onPause(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Parameters: map[string]string{
+			"__name": "activation1",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var activation model.ActivationState
+	err = json.Unmarshal(resp.Body, &activation)
+	assert.Nil(t, err)
+	assert.Equal(t, model.ActivationPause, activation.Status.Control)
+
+	resp = vendor.onCancel(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Parameters: map[string]string{
+			"__name": "activation1",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	err = json.Unmarshal(resp.Body, &activation)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Cancelled, activation.Status.Status)
+
+	resp = vendor.onPause(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Parameters: map[string]string{
+			"__name": "activation2",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.NotFound, resp.State)
+
+	resp = vendor.onCancel(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"__name": "activation1",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.MethodNotAllowed, resp.State)
+}
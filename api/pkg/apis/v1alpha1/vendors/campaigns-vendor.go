@@ -89,11 +89,20 @@ func (c *CampaignsVendor) onCampaigns(request v1alpha2.COARequest) v1alpha2.COAR
 		var err error
 		var state interface{}
 		isArray := false
+		continueToken := ""
 		if id == "" {
 			if !namespaceSupplied {
 				namespace = ""
 			}
-			state, err = c.CampaignsManager.ListState(ctx, namespace)
+			var options model.ListOptions
+			options, err = listOptions(request)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+			state, continueToken, err = c.CampaignsManager.ListStatePage(ctx, namespace, options)
 			isArray = true
 		} else {
 			state, err = c.CampaignsManager.GetState(ctx, id, namespace)
@@ -111,6 +120,9 @@ func (c *CampaignsVendor) onCampaigns(request v1alpha2.COARequest) v1alpha2.COAR
 			Body:        jData,
 			ContentType: "application/json",
 		})
+		if continueToken != "" {
+			resp.Metadata = map[string]string{model.ListContinue: continueToken}
+		}
 		if request.Parameters["doc-type"] == "yaml" {
 			resp.ContentType = "application/text"
 		}
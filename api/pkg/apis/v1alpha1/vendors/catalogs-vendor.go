@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/sync"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -52,7 +53,7 @@ func (e *CatalogsVendor) Init(config vendors.VendorConfig, factories []managers.
 	if e.CatalogsManager == nil {
 		return v1alpha2.NewCOAError(nil, "catalogs manager is not supplied", v1alpha2.MissingConfig)
 	}
-	e.Vendor.Context.Subscribe("catalog-sync", func(topic string, event v1alpha2.Event) error {
+	e.Vendor.Context.Subscribe("catalog-sync", sync.WithSyncResult(e.Vendor.Context, func(topic string, event v1alpha2.Event) error {
 		jData, _ := json.Marshal(event.Body)
 		var job v1alpha2.JobData
 		err := json.Unmarshal(jData, &job)
@@ -61,7 +62,17 @@ func (e *CatalogsVendor) Init(config vendors.VendorConfig, factories []managers.
 			jData, _ = json.Marshal(job.Body)
 			err = json.Unmarshal(jData, &catalog)
 			origin := event.Metadata["origin"]
-			if err == nil {
+			if err == nil && job.Action == v1alpha2.JobDelete {
+				name := fmt.Sprintf("%s-%s", origin, job.Id)
+				namespace := catalog.ObjectMeta.Namespace
+				if namespace == "" {
+					namespace = "default"
+				}
+				err := e.CatalogsManager.DeleteState(context.TODO(), name, namespace)
+				if err != nil && !v1alpha2.IsNotFound(err) {
+					return v1alpha2.NewCOAError(err, "failed to delete catalog", v1alpha2.InternalError)
+				}
+			} else if err == nil {
 				name := fmt.Sprintf("%s-%s", origin, catalog.Spec.Name)
 				catalog.ObjectMeta.Name = name
 				catalog.Spec.Name = name
@@ -81,7 +92,7 @@ func (e *CatalogsVendor) Init(config vendors.VendorConfig, factories []managers.
 			return err
 		}
 		return nil
-	})
+	}))
 	return nil
 }
 func (e *CatalogsVendor) GetEndpoints() []v1alpha2.Endpoint {
@@ -243,11 +254,20 @@ func (e *CatalogsVendor) onCatalogs(request v1alpha2.COARequest) v1alpha2.COARes
 		var err error
 		var state interface{}
 		isArray := false
+		continueToken := ""
 		if id == "" {
 			if !namesapceSupplied {
 				namespace = ""
 			}
-			state, err = e.CatalogsManager.ListState(ctx, namespace)
+			var options model.ListOptions
+			options, err = listOptions(request)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+			state, continueToken, err = e.CatalogsManager.ListStatePage(ctx, namespace, options)
 			isArray = true
 		} else {
 			state, err = e.CatalogsManager.GetState(ctx, id, namespace)
@@ -271,6 +291,9 @@ func (e *CatalogsVendor) onCatalogs(request v1alpha2.COARequest) v1alpha2.COARes
 			Body:        jData,
 			ContentType: "application/json",
 		})
+		if continueToken != "" {
+			resp.Metadata = map[string]string{model.ListContinue: continueToken}
+		}
 		if request.Parameters["doc-type"] == "yaml" {
 			resp.ContentType = "application/text"
 		}
@@ -527,3 +527,50 @@ func TestCatalogSubscribe(t *testing.T) {
 	}
 	assert.Equal(t, v1alpha2.OK, response.State)
 }
+
+func TestCatalogSubscribeDelete(t *testing.T) {
+	vendor := CatalogVendorInit()
+	origin := "parent"
+	vendor.Context.Publish("catalog-sync", v1alpha2.Event{
+		Metadata: map[string]string{
+			"objectType": catalogState.Spec.Type,
+			"origin":     origin,
+		},
+		Body: v1alpha2.JobData{
+			Id:     catalogState.ObjectMeta.Name,
+			Action: v1alpha2.JobUpdate,
+			Body:   catalogState,
+		},
+	})
+	requestGet := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__name": fmt.Sprintf("%s-%s", origin, catalogState.Spec.Name),
+		},
+	}
+	response := vendor.onCatalogs(*requestGet)
+	for i := 0; i < 10 && response.State != v1alpha2.OK; i++ {
+		time.Sleep(time.Second)
+		response = vendor.onCatalogs(*requestGet)
+	}
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	vendor.Context.Publish("catalog-sync", v1alpha2.Event{
+		Metadata: map[string]string{
+			"objectType": catalogState.Spec.Type,
+			"origin":     origin,
+		},
+		Body: v1alpha2.JobData{
+			Id:     catalogState.Spec.Name,
+			Action: v1alpha2.JobDelete,
+			Body:   model.NewCatalogTombstone(catalogState.Spec.Name, catalogState.Spec.Type),
+		},
+	})
+	response = vendor.onCatalogs(*requestGet)
+	for i := 0; i < 10 && response.State != v1alpha2.NotFound; i++ {
+		time.Sleep(time.Second)
+		response = vendor.onCatalogs(*requestGet)
+	}
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+}
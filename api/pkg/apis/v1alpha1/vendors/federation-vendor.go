@@ -8,8 +8,14 @@ package vendors
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/sites"
@@ -19,6 +25,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
@@ -26,6 +33,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/valyala/fasthttp"
 )
 
@@ -38,6 +46,22 @@ type FederationVendor struct {
 	StagingManager  *staging.StagingManager
 	SyncManager     *sync.SyncManager
 	TrailsManager   *trails.TrailsManager
+	// SiteTokenKey signs the tokens that sites are enrolled with. It must be the key that the JWT middleware verifies
+	// tokens with. Sites can't be enrolled without it.
+	SiteTokenKey string
+	// SiteTokenTTL is how long the tokens that sites are enrolled with are valid for. Tokens don't expire when it's 0.
+	SiteTokenTTL time.Duration
+	// RequireSiteIdentity rejects the requests of sites that aren't made with a site token, also for sites that aren't
+	// enrolled
+	RequireSiteIdentity bool
+	// AdminRole is the role that may enroll sites and revoke their tokens
+	AdminRole string
+}
+
+// SiteClaims are the claims of the tokens that sites are enrolled with
+type SiteClaims struct {
+	Site string `json:"site"`
+	jwt.RegisteredClaims
 }
 
 func (f *FederationVendor) GetInfo() vendors.VendorInfo {
@@ -78,13 +102,45 @@ func (f *FederationVendor) Init(config vendors.VendorConfig, factories []manager
 	if f.CatalogsManager == nil {
 		return v1alpha2.NewCOAError(nil, "catalogs manager is not supplied", v1alpha2.MissingConfig)
 	}
+	f.SiteTokenKey = config.Properties["siteTokenKey"]
+	f.AdminRole = defaultAdminRole
+	if v, ok := config.Properties["adminRole"]; ok && v != "" {
+		f.AdminRole = v
+	}
+	if v, ok := config.Properties["siteTokenTTL"]; ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid siteTokenTTL value '%s'", v), v1alpha2.BadConfig)
+		}
+		f.SiteTokenTTL = d
+	}
+	f.RequireSiteIdentity = config.Properties["requireSiteIdentity"] == "true"
+	if parent := f.Context.SiteInfo.ParentSite; parent.BaseUrl != "" && parent.TokenPath != "" {
+		// this site authenticates to its parent with the token that it's enrolled with
+		err = utils.SetAPIAuth(parent.BaseUrl, utils.APIAuth{
+			Method:    utils.AuthMethodServiceAccountToken,
+			TokenPath: parent.TokenPath,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	f.Vendor.Context.Subscribe("catalog", func(topic string, event v1alpha2.Event) error {
 		sites, err := f.SitesManager.ListSpec(context.TODO())
 		if err != nil {
 			return err
 		}
+		catalog, isCatalog := eventCatalog(event)
 		for _, site := range sites {
 			if site.Spec.Name != f.Vendor.Context.SiteInfo.SiteId {
+				if isCatalog {
+					if match, err := utils.CatalogMatchesSite(catalog, site); !match {
+						if err != nil {
+							fLog.Errorf("V (Federation): catalog %s has an invalid site selector: %v", catalog.Spec.Name, err)
+						}
+						continue
+					}
+				}
 				event.Metadata["site"] = site.Spec.Name
 				f.StagingManager.HandleJobEvent(context.TODO(), event) //TODO: how to handle errors in this case?
 			}
@@ -147,6 +203,7 @@ func (f *FederationVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Version:    f.Version,
 			Handler:    f.onSync,
 			Parameters: []string{"site?"},
+			Compress:   true,
 		},
 		{
 			Methods:    []string{fasthttp.MethodPost, fasthttp.MethodGet},
@@ -168,6 +225,13 @@ func (f *FederationVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Version: f.Version,
 			Handler: f.onTrail,
 		},
+		{
+			Methods:    []string{fasthttp.MethodPost, fasthttp.MethodDelete},
+			Route:      route + "/enroll",
+			Version:    f.Version,
+			Handler:    f.onEnroll,
+			Parameters: []string{"name"},
+		},
 		{
 			Methods: []string{fasthttp.MethodPost},
 			Route:   route + "/k8shook",
@@ -188,6 +252,20 @@ func (c *FederationVendor) onStatus(request v1alpha2.COARequest) v1alpha2.COARes
 		var state model.SiteState
 		json.Unmarshal(request.Body, &state)
 
+		id := request.Parameters["__name"]
+		if state.Id == "" {
+			state.Id = id
+		}
+		if state.Id != id {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(fmt.Sprintf("status of site '%s' can't be reported for site '%s'", state.Id, id)),
+			})
+		}
+		if err := c.authorizeSite(pCtx, id); err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+		}
+
 		err := c.SitesManager.ReportState(pCtx, state)
 
 		if err != nil {
@@ -315,6 +393,19 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 				Body:  []byte(err.Error()),
 			})
 		}
+		// the status is reported for the site in its outputs, which has to be the site that the request acts for
+		statusSite, _ := status.Outputs["__site"].(string)
+		site, err := recordSite(pCtx, statusSite)
+		if err == nil {
+			err = f.authorizeSite(pCtx, site)
+		}
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+		}
+		if status.Outputs == nil {
+			status.Outputs = make(map[string]interface{})
+		}
+		status.Outputs["__site"] = site
 		err = f.Vendor.Context.Publish("job-report", v1alpha2.Event{
 			Body: status,
 		})
@@ -332,6 +423,9 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 	case fasthttp.MethodGet:
 		ctx, span := observability.StartSpan("onSync-GET", pCtx, nil)
 		id := request.Parameters["__site"]
+		if err := f.authorizeSite(ctx, id); err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+		}
 		count := request.Parameters["count"]
 		namespace, exist := request.Parameters["namespace"]
 		if !exist {
@@ -347,11 +441,29 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 				Body:  []byte(err.Error()),
 			})
 		}
-		batch, err := f.StagingManager.GetABatchForSite(id, intCount)
-
 		pack := model.SyncPackage{
 			Origin: f.Context.SiteInfo.SiteId,
 		}
+		var batch []model.SyncItem
+		if resumeToken, ok := request.Parameters["resume"]; ok {
+			// the site acknowledges the items of its last batch that it applied, all of them unless they're listed
+			var applied []string
+			if v, ok := request.Parameters["applied"]; ok {
+				applied = make([]string, 0)
+				for _, item := range strings.Split(v, ",") {
+					if item != "" {
+						applied = append(applied, item)
+					}
+				}
+			}
+			batch, pack.ResumeToken, err = f.StagingManager.ResumeBatchForSite(ctx, id, intCount, resumeToken, applied)
+		} else {
+			var jobs []v1alpha2.JobData
+			jobs, err = f.StagingManager.GetABatchForSite(id, intCount)
+			for _, job := range jobs {
+				batch = append(batch, model.SyncItem{Job: job})
+			}
+		}
 
 		if err != nil {
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
@@ -361,22 +473,46 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 		}
 		catalogs := make([]model.CatalogState, 0)
 		jobs := make([]v1alpha2.JobData, 0)
-		for _, c := range batch {
-			if c.Action == v1alpha2.JobRun { //TODO: I don't really like this
+		tombstones := make([]model.CatalogState, 0)
+		// the items of a resumable batch are acknowledged with their IDs, in the order of the catalogs, jobs and
+		// tombstones
+		var catalogItems, jobItems, tombstoneItems []string
+		for _, item := range batch {
+			c := item.Job
+			if c.Action == v1alpha2.JobRun || c.Action == v1alpha2.JobCancel { //TODO: I don't really like this
 				jobs = append(jobs, c)
+				jobItems = append(jobItems, item.Id)
+			} else if c.Action == v1alpha2.JobDelete {
+				tombstones = append(tombstones, catalogTombstone(c))
+				tombstoneItems = append(tombstoneItems, item.Id)
 			} else {
 				catalog, err := f.CatalogsManager.GetState(ctx, c.Id, namespace)
 				if err != nil {
+					if v1alpha2.IsNotFound(err) {
+						// the catalog was deleted after it was staged
+						tombstones = append(tombstones, catalogTombstone(c))
+						tombstoneItems = append(tombstoneItems, item.Id)
+						continue
+					}
 					return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
 						State: v1alpha2.InternalError,
 						Body:  []byte(err.Error()),
 					})
 				}
 				catalogs = append(catalogs, catalog)
+				catalogItems = append(catalogItems, item.Id)
 			}
 		}
 		pack.Catalogs = catalogs
 		pack.Jobs = jobs
+		if len(tombstones) > 0 {
+			pack.Tombstones = tombstones
+		}
+		if pack.ResumeToken != "" {
+			pack.CatalogItems = catalogItems
+			pack.JobItems = jobItems
+			pack.TombstoneItems = tombstoneItems
+		}
 		jData, _ := utils.FormatObject(pack, true, request.Parameters["path"], request.Parameters["doc-type"])
 		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
 			State:       v1alpha2.OK,
@@ -396,19 +532,279 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
 	return resp
 }
+
+// eventCatalog reads the catalog that a catalog event carries. It's false when the event doesn't carry a catalog.
+func eventCatalog(event v1alpha2.Event) (model.CatalogState, bool) {
+	var job v1alpha2.JobData
+	var catalog model.CatalogState
+	data, _ := json.Marshal(event.Body)
+	if json.Unmarshal(data, &job) != nil || job.Body == nil {
+		return catalog, false
+	}
+	data, _ = json.Marshal(job.Body)
+	if json.Unmarshal(data, &catalog) != nil || catalog.Spec == nil {
+		return catalog, false
+	}
+	return catalog, true
+}
+
+// catalogTombstone makes the tombstone of the catalog of a staged job, with the type of the catalog that the job
+// carries
+func catalogTombstone(job v1alpha2.JobData) model.CatalogState {
+	var catalog model.CatalogState
+	data, _ := json.Marshal(job.Body)
+	if json.Unmarshal(data, &catalog) == nil && catalog.Spec != nil {
+		return model.NewCatalogTombstone(job.Id, catalog.Spec.Type)
+	}
+	return model.NewCatalogTombstone(job.Id, "")
+}
 func (f *FederationVendor) onTrail(request v1alpha2.COARequest) v1alpha2.COAResponse {
-	_, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+	pCtx, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
 		"method": "onTrail",
 	})
 	defer span.End()
 
+	tLog.Info("V (Federation): onTrail")
+	switch request.Method {
+	case fasthttp.MethodPost:
+		var trails []v1alpha2.Trail
+		err := json.Unmarshal(request.Body, &trails)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(err.Error()),
+			})
+		}
+		// each trail is recorded for the site it originates from, which has to be the site that the request acts for
+		authorized := make(map[string]bool)
+		for i := range trails {
+			trails[i].Origin, err = recordSite(pCtx, trails[i].Origin)
+			if err == nil && !authorized[trails[i].Origin] {
+				err = f.authorizeSite(pCtx, trails[i].Origin)
+				authorized[trails[i].Origin] = true
+			}
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+			}
+		}
+		if len(trails) == 0 {
+			if err := f.authorizeSite(pCtx, ""); err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+			}
+		}
+		if f.TrailsManager != nil {
+			err = f.TrailsManager.Append(pCtx, trails)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.InternalError,
+					Body:  []byte(err.Error()),
+				})
+			}
+		}
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.OK,
+		})
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+
+// onEnroll enrolls a site with a new token, which replaces the token that the site was enrolled with, or revokes the
+// token of a site
+func (f *FederationVendor) onEnroll(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+		"method": "onEnroll",
+	})
+	defer span.End()
+
+	tLog.Info("V (Federation): onEnroll")
+	id := request.Parameters["__name"]
+	if _, ok := authz.IdentityFromContext(request.Context).Claims["site"]; ok {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.Unauthorized,
+			Body:  []byte("sites can't be enrolled with a site token"),
+		})
+	}
+	if !hasRole(request.Context, f.AdminRole) {
+		return observ_utils.CloseSpanWithCOAResponse(span, roleRequiredResponse(f.AdminRole))
+	}
+	switch request.Method {
+	case fasthttp.MethodPost:
+		ctx, span := observability.StartSpan("onEnroll-POST", pCtx, nil)
+		token, enrollment, err := f.issueSiteToken(id)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, siteErrorResponse(err))
+		}
+		err = f.SitesManager.SetEnrollment(ctx, id, enrollment)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := json.Marshal(token)
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+	case fasthttp.MethodDelete:
+		ctx, span := observability.StartSpan("onEnroll-DELETE", pCtx, nil)
+		site, err := f.SitesManager.GetSpec(ctx, id)
+		if err != nil {
+			state := v1alpha2.InternalError
+			if v1alpha2.IsNotFound(err) {
+				state = v1alpha2.NotFound
+			}
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: state,
+				Body:  []byte(err.Error()),
+			})
+		}
+		enrollment := model.SiteEnrollment{}
+		if site.Status != nil && site.Status.Enrollment != nil {
+			enrollment = *site.Status.Enrollment
+		}
+		enrollment.Revoked = true
+		enrollment.RevokedAt = time.Now().UTC().Format(time.RFC3339)
+		err = f.SitesManager.SetEnrollment(ctx, id, enrollment)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.OK,
+		})
+	}
 	resp := v1alpha2.COAResponse{
 		State:       v1alpha2.MethodNotAllowed,
 		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
 		ContentType: "application/json",
 	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
 	return resp
 }
+
+// issueSiteToken signs a new token for a site. The token has a random ID, and only the hash of the ID is kept in the
+// enrollment of the site.
+func (f *FederationVendor) issueSiteToken(siteId string) (model.SiteToken, model.SiteEnrollment, error) {
+	if f.SiteTokenKey == "" {
+		return model.SiteToken{}, model.SiteEnrollment{}, v1alpha2.NewCOAError(nil, "siteTokenKey is not configured, sites can't be enrolled", v1alpha2.MissingConfig)
+	}
+	tokenId := make([]byte, 16)
+	if _, err := rand.Read(tokenId); err != nil {
+		return model.SiteToken{}, model.SiteEnrollment{}, err
+	}
+	now := time.Now().UTC()
+	claims := SiteClaims{
+		Site: siteId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(now),
+			Issuer:   "symphony",
+			Subject:  "site:" + siteId,
+			ID:       hex.EncodeToString(tokenId),
+		},
+	}
+	enrollment := model.SiteEnrollment{
+		TokenHash: siteTokenHash(claims.ID),
+		IssuedAt:  now.Format(time.RFC3339),
+	}
+	if f.SiteTokenTTL > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(f.SiteTokenTTL))
+		enrollment.ExpiresAt = claims.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	ss, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(f.SiteTokenKey))
+	if err != nil {
+		return model.SiteToken{}, model.SiteEnrollment{}, err
+	}
+	return model.SiteToken{
+		SiteId:      siteId,
+		AccessToken: ss,
+		TokenType:   "Bearer",
+		ExpiresAt:   enrollment.ExpiresAt,
+	}, enrollment, nil
+}
+
+// authorizeSite checks that a request that acts for a site is made with the current token of the site. Without a
+// site ID, which is only for requests that don't carry anything of a site, it only checks that the site token of the
+// request, if any, is current. Requests without a site token are rejected for sites that are enrolled, or for all
+// sites when site identity is required.
+func (f *FederationVendor) authorizeSite(ctx context.Context, siteId string) error {
+	identity := authz.IdentityFromContext(ctx)
+	tokenSite, _ := identity.Claims["site"].(string)
+	if tokenSite == "" {
+		if f.RequireSiteIdentity {
+			return v1alpha2.NewCOAError(nil, "requests of sites require a site token", v1alpha2.Unauthorized)
+		}
+		if siteId == "" {
+			return nil
+		}
+		site, err := f.SitesManager.GetSpec(ctx, siteId)
+		if err != nil {
+			if v1alpha2.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if site.Status != nil && site.Status.Enrollment != nil {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("site '%s' is enrolled, its requests require its site token", siteId), v1alpha2.Unauthorized)
+		}
+		return nil
+	}
+	if siteId != "" && siteId != tokenSite {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("token of site '%s' can't act for site '%s'", tokenSite, siteId), v1alpha2.Unauthorized)
+	}
+	site, err := f.SitesManager.GetSpec(ctx, tokenSite)
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("site '%s' isn't enrolled", tokenSite), v1alpha2.Unauthorized)
+		}
+		return err
+	}
+	tokenId, _ := identity.Claims["jti"].(string)
+	if site.Status == nil || site.Status.Enrollment == nil || site.Status.Enrollment.Revoked ||
+		site.Status.Enrollment.TokenHash != siteTokenHash(tokenId) {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("token of site '%s' is revoked", tokenSite), v1alpha2.Unauthorized)
+	}
+	return nil
+}
+
+// recordSite is the site that a record that a site posts, such as an activation status or a trail, is for. Records
+// that don't name their site are for the site of the site token of the request, and records that requests without a
+// site token post have to name their site.
+func recordSite(ctx context.Context, site string) (string, error) {
+	if site != "" {
+		return site, nil
+	}
+	if tokenSite, _ := authz.IdentityFromContext(ctx).Claims["site"].(string); tokenSite != "" {
+		return tokenSite, nil
+	}
+	return "", v1alpha2.NewCOAError(nil, "site of the record is not supplied", v1alpha2.BadRequest)
+}
+
+func siteTokenHash(tokenId string) string {
+	hash := sha256.Sum256([]byte(tokenId))
+	return hex.EncodeToString(hash[:])
+}
+
+// siteErrorResponse is the response to a request that a site isn't authorized for
+func siteErrorResponse(err error) v1alpha2.COAResponse {
+	state := v1alpha2.InternalError
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		state = coaErr.State
+	}
+	return v1alpha2.COAResponse{
+		State: state,
+		Body:  []byte(err.Error()),
+	}
+}
 func (f *FederationVendor) onK8sHook(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	_, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
 		"method": "onK8sHook",
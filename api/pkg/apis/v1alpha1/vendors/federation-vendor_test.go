@@ -10,6 +10,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	memorygraph "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/graph/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
@@ -17,6 +18,7 @@ import (
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
 )
@@ -60,7 +62,8 @@ func federationVendorInit() FederationVendor {
 	vendor := FederationVendor{}
 	vendor.Init(vendors.VendorConfig{
 		Properties: map[string]string{
-			"test": "true",
+			"test":         "true",
+			"siteTokenKey": "SymphonyKey",
 		},
 		SiteInfo: v1alpha2.SiteInfo{
 			SiteId: "exampleSiteId",
@@ -241,6 +244,7 @@ func TestFederationOnSyncPost(t *testing.T) {
 		Outputs: map[string]interface{}{
 			"output1": "value1",
 			"output2": "value2",
+			"__site":  "child1",
 		},
 		Status:               v1alpha2.OK,
 		IsActive:             true,
@@ -362,7 +366,14 @@ func TestFederationOnSyncGet(t *testing.T) {
 			},
 		}
 		response = vendor.onSync(*requestGet)
-		if response.State == v1alpha2.InternalError {
+		assert.Equal(t, v1alpha2.OK, response.State)
+		var summary model.SyncPackage
+		err = json.Unmarshal(response.Body, &summary)
+		assert.Nil(t, err)
+		// a catalog that was deleted after it was staged is sent as a tombstone
+		if len(summary.Tombstones) == 1 {
+			assert.Equal(t, "catalog1", summary.Tombstones[0].Spec.Name)
+			assert.Empty(t, summary.Catalogs)
 			break
 		} else {
 			time.Sleep(time.Second)
@@ -507,3 +518,263 @@ func TestFederationOnK8SHook(t *testing.T) {
 	response = vendor.onK8sHook(*requestPatch)
 	assert.Equal(t, v1alpha2.MethodNotAllowed, response.State)
 }
+
+// siteContext is the context of a request that the JWT middleware authenticated with a site token
+func siteContext(t *testing.T, token string) context.Context {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("SymphonyKey"), nil
+	})
+	assert.Nil(t, err)
+	return authz.WithIdentity(context.Background(), authz.Identity{User: claims["sub"].(string), Claims: claims})
+}
+
+// adminContext is the context of a request of an administrator
+func adminContext() context.Context {
+	return authz.WithIdentity(context.Background(), authz.Identity{User: "admin", Roles: []string{"administrator"}})
+}
+
+func enrollSite(t *testing.T, vendor *FederationVendor, site string) model.SiteToken {
+	response := vendor.onEnroll(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    adminContext(),
+		Parameters: map[string]string{"__name": site},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var token model.SiteToken
+	err := json.Unmarshal(response.Body, &token)
+	assert.Nil(t, err)
+	assert.Equal(t, site, token.SiteId)
+	assert.Equal(t, "Bearer", token.TokenType)
+	return token
+}
+
+func syncRequest(ctx context.Context, site string) v1alpha2.COARequest {
+	return v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    ctx,
+		Parameters: map[string]string{"__site": site},
+	}
+}
+
+func TestFederationOnEnroll(t *testing.T) {
+	vendor := federationVendorInit()
+	token := enrollSite(t, &vendor, "child1")
+	assert.Equal(t, "", token.ExpiresAt)
+
+	site, err := vendor.SitesManager.GetSpec(context.Background(), "child1")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", site.Status.Enrollment.TokenHash)
+	assert.NotContains(t, token.AccessToken, site.Status.Enrollment.TokenHash)
+
+	// the site can only sync with its token, which can't act for other sites
+	ctx := siteContext(t, token.AccessToken)
+	response := vendor.onSync(syncRequest(context.Background(), "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onSync(syncRequest(ctx, "child1"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onSync(syncRequest(ctx, "child2"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	b, _ := json.Marshal(model.SiteState{Id: "child2", Status: &model.SiteStatus{IsOnline: true}})
+	response = vendor.onStatus(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    ctx,
+		Parameters: map[string]string{"__name": "child1"},
+		Body:       b,
+	})
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+	response = vendor.onStatus(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    ctx,
+		Parameters: map[string]string{"__name": "child2"},
+		Body:       b,
+	})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	// sites can't enroll themselves or other sites
+	response = vendor.onEnroll(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    ctx,
+		Parameters: map[string]string{"__name": "child2"},
+	})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	// enrolling the site again replaces its token
+	newToken := enrollSite(t, &vendor, "child1")
+	response = vendor.onSync(syncRequest(ctx, "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onSync(syncRequest(siteContext(t, newToken.AccessToken), "child1"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestFederationOnEnrollRevoke(t *testing.T) {
+	vendor := federationVendorInit()
+	token := enrollSite(t, &vendor, "child1")
+	ctx := siteContext(t, token.AccessToken)
+
+	response := vendor.onEnroll(v1alpha2.COARequest{
+		Method:     fasthttp.MethodDelete,
+		Context:    adminContext(),
+		Parameters: map[string]string{"__name": "child1"},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	site, err := vendor.SitesManager.GetSpec(context.Background(), "child1")
+	assert.Nil(t, err)
+	assert.True(t, site.Status.Enrollment.Revoked)
+
+	response = vendor.onSync(syncRequest(ctx, "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onSync(syncRequest(context.Background(), "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onTrail(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: ctx,
+		Body:    []byte("[]"),
+	})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	response = vendor.onEnroll(v1alpha2.COARequest{
+		Method:     fasthttp.MethodDelete,
+		Context:    adminContext(),
+		Parameters: map[string]string{"__name": "unknown"},
+	})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+}
+
+func TestFederationOnEnrollRequiresAdminRole(t *testing.T) {
+	vendor := federationVendorInit()
+	reader := authz.WithIdentity(context.Background(), authz.Identity{User: "reader", Roles: []string{authz.RoleReader}})
+	for _, method := range []string{fasthttp.MethodPost, fasthttp.MethodDelete} {
+		for _, ctx := range []context.Context{context.Background(), reader} {
+			response := vendor.onEnroll(v1alpha2.COARequest{
+				Method:     method,
+				Context:    ctx,
+				Parameters: map[string]string{"__name": "child1"},
+			})
+			assert.Equal(t, v1alpha2.Forbidden, response.State)
+		}
+	}
+	_, err := vendor.SitesManager.GetSpec(context.Background(), "child1")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestFederationOnEnrollWithoutSiteTokenKey(t *testing.T) {
+	vendor := federationVendorInit()
+	vendor.SiteTokenKey = ""
+	response := vendor.onEnroll(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    adminContext(),
+		Parameters: map[string]string{"__name": "child1"},
+	})
+	assert.Equal(t, v1alpha2.MissingConfig, response.State)
+}
+
+func TestFederationRecordsAreBoundToSite(t *testing.T) {
+	vendor := federationVendorInit()
+	token := enrollSite(t, &vendor, "child1")
+	ctx := siteContext(t, token.AccessToken)
+	statusRequest := func(ctx context.Context, site string) v1alpha2.COARequest {
+		outputs := map[string]interface{}{}
+		if site != "" {
+			outputs["__site"] = site
+		}
+		b, _ := json.Marshal(model.ActivationStatus{Stage: "stage1", Outputs: outputs})
+		return v1alpha2.COARequest{Method: fasthttp.MethodPost, Context: ctx, Body: b}
+	}
+	trailRequest := func(ctx context.Context, origin string) v1alpha2.COARequest {
+		b, _ := json.Marshal([]v1alpha2.Trail{{Origin: origin, Catalog: "catalog1"}})
+		return v1alpha2.COARequest{Method: fasthttp.MethodPost, Context: ctx, Body: b}
+	}
+
+	// the site token can only report for its own site, and records without a site are for the site of the token
+	response := vendor.onSync(statusRequest(ctx, "child1"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onSync(statusRequest(ctx, ""))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onSync(statusRequest(ctx, "child2"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onTrail(trailRequest(ctx, "child1"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onTrail(trailRequest(ctx, "child2"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	// requests without a site token can't report for the enrolled site, and have to name their site
+	response = vendor.onSync(statusRequest(context.Background(), "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onTrail(trailRequest(context.Background(), "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onSync(statusRequest(context.Background(), ""))
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+	response = vendor.onSync(statusRequest(context.Background(), "child2"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onTrail(trailRequest(context.Background(), "child2"))
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestFederationRequireSiteIdentity(t *testing.T) {
+	vendor := federationVendorInit()
+	vendor.RequireSiteIdentity = true
+	vendor.SiteTokenTTL = time.Hour
+
+	response := vendor.onSync(syncRequest(context.Background(), "child1"))
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+	response = vendor.onTrail(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: context.Background(),
+		Body:    []byte("[]"),
+	})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	token := enrollSite(t, &vendor, "child1")
+	assert.NotEqual(t, "", token.ExpiresAt)
+	response = vendor.onTrail(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: siteContext(t, token.AccessToken),
+		Body:    []byte("[]"),
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestFederationOnSyncGetResume(t *testing.T) {
+	vendor := federationVendorInit()
+	for _, id := range []string{"job1", "job2"} {
+		err := vendor.StagingManager.QueueProvider.Enqueue("child1", v1alpha2.JobData{
+			Id:     id,
+			Action: v1alpha2.JobRun,
+		})
+		assert.Nil(t, err)
+	}
+	sync := func(parameters map[string]string) model.SyncPackage {
+		parameters["__site"] = "child1"
+		parameters["count"] = "10"
+		response := vendor.onSync(v1alpha2.COARequest{
+			Method:     fasthttp.MethodGet,
+			Context:    context.Background(),
+			Parameters: parameters,
+		})
+		assert.Equal(t, v1alpha2.OK, response.State)
+		var pack model.SyncPackage
+		err := json.Unmarshal(response.Body, &pack)
+		assert.Nil(t, err)
+		return pack
+	}
+
+	pack := sync(map[string]string{"resume": ""})
+	assert.Equal(t, 2, len(pack.Jobs))
+	assert.Equal(t, 2, len(pack.JobItems))
+	assert.NotEqual(t, "", pack.ResumeToken)
+
+	// the items that the site didn't apply are sent again
+	pack = sync(map[string]string{"resume": pack.ResumeToken, "applied": pack.JobItems[0]})
+	assert.Equal(t, 1, len(pack.Jobs))
+	assert.Equal(t, "job2", pack.Jobs[0].Id)
+	assert.Equal(t, 1, len(pack.JobItems))
+
+	pack = sync(map[string]string{"resume": pack.ResumeToken})
+	assert.Equal(t, 0, len(pack.Jobs))
+	progress, err := vendor.StagingManager.GetSyncProgress(context.Background(), "child1")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), progress.Acknowledged)
+	assert.Equal(t, progress.Batch, progress.HighWaterMark)
+}
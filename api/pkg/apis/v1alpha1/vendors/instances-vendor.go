@@ -89,12 +89,21 @@ func (c *InstancesVendor) onInstances(request v1alpha2.COARequest) v1alpha2.COAR
 		var err error
 		var state interface{}
 		isArray := false
+		continueToken := ""
 		if id == "" {
 			// Change partition back to empty to indicate ListSpec need to query all namespaces
 			if !exist {
 				namespace = ""
 			}
-			state, err = c.InstancesManager.ListState(ctx, namespace)
+			var options model.ListOptions
+			options, err = listOptions(request)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+			state, continueToken, err = c.InstancesManager.ListStatePage(ctx, namespace, options)
 			isArray = true
 		} else {
 			state, err = c.InstancesManager.GetState(ctx, id, namespace)
@@ -112,6 +121,9 @@ func (c *InstancesVendor) onInstances(request v1alpha2.COARequest) v1alpha2.COAR
 			Body:        jData,
 			ContentType: "application/json",
 		})
+		if continueToken != "" {
+			resp.Metadata = map[string]string{model.ListContinue: continueToken}
+		}
 		if request.Parameters["doc-type"] == "yaml" {
 			resp.ContentType = "application/text"
 		}
@@ -9,8 +9,11 @@ package vendors
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/jobs"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -93,6 +96,12 @@ func (o *JobVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Version: o.Version,
 			Handler: o.onHello,
 		},
+		{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   route + "/schedules",
+			Version: o.Version,
+			Handler: o.onSchedules,
+		},
 	}
 }
 
@@ -132,3 +141,63 @@ func (c *JobVendor) onHello(request v1alpha2.COARequest) v1alpha2.COAResponse {
 
 	return resp
 }
+
+// onSchedules lists the stages waiting on a schedule with their upcoming fire times. The count parameter sets how
+// many fire times are listed for each stage, up to v1alpha2.MaxUpcomingFireTimes.
+func (c *JobVendor) onSchedules(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Job Vendor", request.Context, &map[string]string{
+		"method": "onSchedules",
+	})
+	defer span.End()
+
+	jLog.Infof("V (Job): onSchedules, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+	switch request.Method {
+	case fasthttp.MethodGet:
+		count := request.Parameters["count"]
+		if count == "" {
+			count = "5"
+		}
+		intCount, err := strconv.Atoi(count)
+		if err != nil || intCount < 0 {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State:       v1alpha2.BadRequest,
+				Body:        []byte(fmt.Sprintf("{\"result\":\"400 - invalid count '%s'\"}", count)),
+				ContentType: "application/json",
+			})
+		}
+		if intCount > v1alpha2.MaxUpcomingFireTimes {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State:       v1alpha2.BadRequest,
+				Body:        []byte(fmt.Sprintf("{\"result\":\"400 - count can't be more than %d\"}", v1alpha2.MaxUpcomingFireTimes)),
+				ContentType: "application/json",
+			})
+		}
+		schedules, err := c.JobsManager.ListSchedules(ctx, intCount)
+		if err != nil {
+			jLog.Errorf("V (Job): onSchedules failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := utils.FormatObject(schedules, true, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	jLog.Errorf("V (Job): onSchedules failed - 405 method not allowed, traceId: %s", span.SpanContext().TraceID().String())
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+
+	return resp
+}
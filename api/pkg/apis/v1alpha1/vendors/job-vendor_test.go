@@ -14,6 +14,7 @@ import (
 
 	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/jobs"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
@@ -40,7 +41,7 @@ func TestJobsEndpoints(t *testing.T) {
 	vendor := createJobVendor()
 	vendor.Route = "instances"
 	endpoints := vendor.GetEndpoints()
-	assert.Equal(t, 1, len(endpoints))
+	assert.Equal(t, 2, len(endpoints))
 }
 func TestJobsInfo(t *testing.T) {
 	vendor := createJobVendor()
@@ -123,3 +124,43 @@ func TestJobWrongMethod(t *testing.T) {
 	})
 	assert.Equal(t, v1alpha2.MethodNotAllowed, resp.State)
 }
+func TestJobsOnSchedules(t *testing.T) {
+	vendor := createJobVendor()
+	err := vendor.JobsManager.HandleScheduleEvent(context.Background(), v1alpha2.Event{
+		Body: v1alpha2.ActivationData{
+			Activation: "activation1",
+			Campaign:   "campaign1",
+			Stage:      "stage1",
+			Schedule:   &v1alpha2.ScheduleSpec{Interval: "1h"},
+		},
+	})
+	assert.Nil(t, err)
+
+	resp := vendor.onSchedules(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Parameters: map[string]string{"count": "2"},
+		Context:    context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var schedules []model.ScheduledStage
+	err = json.Unmarshal(resp.Body, &schedules)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(schedules))
+	assert.Equal(t, "activation1", schedules[0].Activation)
+	assert.Equal(t, 2, len(schedules[0].UpcomingFireTimes))
+	assert.Equal(t, time.Hour, schedules[0].UpcomingFireTimes[1].Sub(schedules[0].UpcomingFireTimes[0]))
+
+	resp = vendor.onSchedules(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Parameters: map[string]string{"count": "many"},
+		Context:    context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+
+	resp = vendor.onSchedules(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Parameters: map[string]string{"count": "1000000"},
+		Context:    context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
@@ -0,0 +1,26 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package vendors
+
+import (
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+// listOptions reads the paging and label selector parameters of a list request, so that a malformed
+// parameter is reported as a bad request instead of failing in the state provider
+func listOptions(request v1alpha2.COARequest) (model.ListOptions, error) {
+	ret, err := model.ListOptionsFromParameters(request.Parameters)
+	if err != nil {
+		return ret, err
+	}
+	if _, err := states.NewEntryFilter(states.FilterTypeLabel, ret.LabelSelector); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
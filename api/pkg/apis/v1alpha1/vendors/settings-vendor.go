@@ -15,6 +15,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
@@ -63,6 +64,12 @@ func (o *SettingsVendor) GetEndpoints() []v1alpha2.Endpoint {
 		route = o.Route
 	}
 	return []v1alpha2.Endpoint{
+		{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   route + "/providers",
+			Version: o.Version,
+			Handler: o.onProviders,
+		},
 		{
 			Methods:    []string{fasthttp.MethodGet},
 			Route:      route + "/config",
@@ -73,6 +80,33 @@ func (o *SettingsVendor) GetEndpoints() []v1alpha2.Endpoint {
 	}
 }
 
+func (c *SettingsVendor) onProviders(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	_, span := observability.StartSpan("Settings Vendor", request.Context, &map[string]string{
+		"method": "onProviders",
+	})
+	defer span.End()
+	csLog.Infof("V (Settings): onProviders %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+
+	switch request.Method {
+	case fasthttp.MethodGet:
+		data, _ := json.Marshal(providerfactory.RegisteredTypes())
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        data,
+			ContentType: "application/json",
+		})
+	}
+
+	log.Infof("V (Settings): onProviders returned MethodNotAllowed, traceId: %s", span.SpanContext().TraceID().String())
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+
 func (c *SettingsVendor) onConfig(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	_, span := observability.StartSpan("Settings Vendor", request.Context, &map[string]string{
 		"method": "onConfig",
@@ -8,12 +8,14 @@ package vendors
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/configs"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providerfactory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config"
 	memory "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config/memoryconfig"
@@ -148,3 +150,24 @@ func TestConfigGetField(t *testing.T) {
 	res = vendor.onConfig(*request)
 	assert.Equal(t, v1alpha2.InternalError, res.State)
 }
+
+func TestProvidersGet(t *testing.T) {
+	providerfactory.Register("providers.test.settings", func() providers.IProvider {
+		return &memorystate.MemoryStateProvider{}
+	}, nil)
+	vendor := createSettingsVendor()
+	request := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+	}
+	res := vendor.onProviders(*request)
+	assert.Equal(t, v1alpha2.OK, res.State)
+	var types []string
+	err := json.Unmarshal(res.Body, &types)
+	assert.Nil(t, err)
+	assert.Contains(t, types, "providers.test.settings")
+
+	request.Method = fasthttp.MethodPost
+	res = vendor.onProviders(*request)
+	assert.Equal(t, v1alpha2.MethodNotAllowed, res.State)
+}
@@ -70,6 +70,13 @@ func (o *SolutionVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Parameters: []string{"delete?"},
 			Handler:    o.onReconcile,
 		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/plan",
+			Version:    o.Version,
+			Parameters: []string{"delete?"},
+			Handler:    o.onPlan,
+		},
 		{
 			Methods: []string{fasthttp.MethodGet, fasthttp.MethodPost},
 			Route:   route + "/queue",
@@ -224,6 +231,47 @@ func (c *SolutionVendor) onReconcile(request v1alpha2.COARequest) v1alpha2.COARe
 	})
 }
 
+func (c *SolutionVendor) onPlan(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	rContext, span := observability.StartSpan("Solution Vendor", request.Context, &map[string]string{
+		"method": "onPlan",
+	})
+	defer span.End()
+
+	sLog.Infof("V (Solution): onPlan, method: %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+	namespace, exist := request.Parameters["namespace"]
+	if !exist {
+		namespace = "default"
+	}
+	switch request.Method {
+	case fasthttp.MethodPost:
+		ctx, span := observability.StartSpan("onPlan-POST", rContext, nil)
+		defer span.End()
+		var deployment model.DeploymentSpec
+		err := json.Unmarshal(request.Body, &deployment)
+		if err != nil {
+			sLog.Infof("V (Solution): onPlan failed POST - unmarshal request %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(err.Error()),
+			})
+		}
+		targetName := ""
+		if request.Metadata != nil {
+			if v, ok := request.Metadata["active-target"]; ok {
+				targetName = v
+			}
+		}
+		response := c.doPreview(ctx, deployment, request.Parameters["delete"] == "true", namespace, targetName)
+		return observ_utils.CloseSpanWithCOAResponse(span, response)
+	}
+	sLog.Infof("V (Solution): onPlan failed - 405 method not allowed, traceId: %s", span.SpanContext().TraceID().String())
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	})
+}
+
 func (c *SolutionVendor) onApplyDeployment(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	_, span := observability.StartSpan("Solution Vendor", request.Context, &map[string]string{
 		"method": "onApplyDeployment",
@@ -254,6 +302,10 @@ func (c *SolutionVendor) onApplyDeployment(request v1alpha2.COARequest) v1alpha2
 				Body:  []byte(err.Error()),
 			}
 		}
+		if request.Parameters["dryRun"] == "true" {
+			response := c.doPreview(ctx, *deployment, false, namespace, targetName)
+			return observ_utils.CloseSpanWithCOAResponse(span, response)
+		}
 		response := c.doDeploy(ctx, *deployment, namespace, targetName)
 		return observ_utils.CloseSpanWithCOAResponse(span, response)
 	case fasthttp.MethodGet:
@@ -320,6 +372,31 @@ func (c *SolutionVendor) doGet(ctx context.Context, deployment model.DeploymentS
 	observ_utils.UpdateSpanStatusFromCOAResponse(span, response)
 	return response
 }
+func (c *SolutionVendor) doPreview(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Solution Vendor", ctx, &map[string]string{
+		"method": "doPreview",
+	})
+	defer span.End()
+	sLog.Infof("V (Solution): doPreview, traceId: %s", span.SpanContext().TraceID().String())
+	preview, err := c.SolutionManager.Preview(ctx, deployment, remove, namespace, targetName)
+	if err != nil {
+		sLog.Infof("V (Solution): doPreview failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+		response := v1alpha2.COAResponse{
+			State: v1alpha2.InternalError,
+			Body:  []byte(err.Error()),
+		}
+		observ_utils.UpdateSpanStatusFromCOAResponse(span, response)
+		return response
+	}
+	data, _ := json.Marshal(preview)
+	response := v1alpha2.COAResponse{
+		State:       v1alpha2.OK,
+		Body:        data,
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, response)
+	return response
+}
 func (c *SolutionVendor) doDeploy(ctx context.Context, deployment model.DeploymentSpec, namespace string, targetName string) v1alpha2.COAResponse {
 	ctx, span := observability.StartSpan("Solution Vendor", ctx, &map[string]string{
 		"method": "doDeploy",
@@ -169,7 +169,7 @@ func TestSolutionEndpoints(t *testing.T) {
 	vendor := createSolutionVendor()
 	vendor.Route = "solution"
 	endpoints := vendor.GetEndpoints()
-	assert.Equal(t, 3, len(endpoints))
+	assert.Equal(t, 4, len(endpoints))
 }
 
 func TestSolutionInfo(t *testing.T) {
@@ -247,6 +247,66 @@ func TestSolutionApply(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(components))
 }
+func TestSolutionPlan(t *testing.T) {
+	vendor := createSolutionVendor()
+	deployment := createDeployment2Mocks1Target(uuid.New().String())
+	deployment.Instance.Spec.Name = "instance-plan"
+	data, _ := json.Marshal(deployment)
+	resp := vendor.onPlan(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var preview model.PlanPreviewSpec
+	err := json.Unmarshal(resp.Body, &preview)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(preview.Steps))
+	assert.Equal(t, "T1", preview.Steps[0].Target)
+	assert.Equal(t, 2, len(preview.Steps[0].Components))
+	assert.Equal(t, model.PreviewCreate, preview.Steps[0].Components[0].Action)
+	assert.Equal(t, model.PreviewCreate, preview.Steps[0].Components[1].Action)
+
+	// the preview must not have deployed anything
+	resp = vendor.onApplyDeployment(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var components []model.ComponentSpec
+	err = json.Unmarshal(resp.Body, &components)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+}
+func TestSolutionApplyDryRun(t *testing.T) {
+	vendor := createSolutionVendor()
+	deployment := createDeployment2Mocks1Target(uuid.New().String())
+	deployment.Instance.Spec.Name = "instance-dry-run"
+	data, _ := json.Marshal(deployment)
+	resp := vendor.onApplyDeployment(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+
+	resp = vendor.onApplyDeployment(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"dryRun": "true",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var preview model.PlanPreviewSpec
+	err := json.Unmarshal(resp.Body, &preview)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(preview.Steps))
+	assert.True(t, preview.Steps[0].Skipped)
+	assert.Equal(t, model.PreviewUnchanged, preview.Steps[0].Components[0].Action)
+}
 func TestSolutionRemove(t *testing.T) {
 	vendor := createSolutionVendor()
 	deployment := createDeployment2Mocks1Target(uuid.New().String())
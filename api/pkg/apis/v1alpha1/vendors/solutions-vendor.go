@@ -59,7 +59,7 @@ func (o *SolutionsVendor) GetEndpoints() []v1alpha2.Endpoint {
 	if o.Route != "" {
 		route = o.Route
 	}
-	return []v1alpha2.Endpoint{
+	endpoints := []v1alpha2.Endpoint{
 		{
 			Methods:    []string{fasthttp.MethodGet, fasthttp.MethodPost, fasthttp.MethodDelete},
 			Route:      route,
@@ -68,6 +68,16 @@ func (o *SolutionsVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Parameters: []string{"name?"},
 		},
 	}
+	if o.Route == "" {
+		// older clients list solutions on the "solution" route
+		endpoints = append(endpoints, v1alpha2.Endpoint{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   "solution",
+			Version: o.Version,
+			Handler: o.onSolutions,
+		})
+	}
+	return endpoints
 }
 
 func (c *SolutionsVendor) onSolutions(request v1alpha2.COARequest) v1alpha2.COAResponse {
@@ -87,12 +97,21 @@ func (c *SolutionsVendor) onSolutions(request v1alpha2.COARequest) v1alpha2.COAR
 		var err error
 		var state interface{}
 		isArray := false
+		continueToken := ""
 		if id == "" {
 			// Change namespace back to empty to indicate ListSpec need to query all namespaces
 			if !exist {
 				namespace = ""
 			}
-			state, err = c.SolutionsManager.ListState(ctx, namespace)
+			var options model.ListOptions
+			options, err = listOptions(request)
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+			state, continueToken, err = c.SolutionsManager.ListStatePage(ctx, namespace, options)
 			isArray = true
 		} else {
 			state, err = c.SolutionsManager.GetState(ctx, id, namespace)
@@ -110,6 +129,9 @@ func (c *SolutionsVendor) onSolutions(request v1alpha2.COARequest) v1alpha2.COAR
 			Body:        jData,
 			ContentType: "application/json",
 		})
+		if continueToken != "" {
+			resp.Metadata = map[string]string{model.ListContinue: continueToken}
+		}
 		if request.Parameters["doc-type"] == "yaml" {
 			resp.ContentType = "application/text"
 		}
@@ -31,6 +31,15 @@ func TestSolutionsEndpoints(t *testing.T) {
 	assert.Equal(t, 1, len(endpoints))
 }
 
+func TestSolutionsEndpointsDefaultRoute(t *testing.T) {
+	vendor := createSolutionsVendor()
+	endpoints := vendor.GetEndpoints()
+	assert.Equal(t, 2, len(endpoints))
+	assert.Equal(t, "solutions", endpoints[0].Route)
+	assert.Equal(t, "solution", endpoints[1].Route)
+	assert.Equal(t, []string{fasthttp.MethodGet}, endpoints[1].Methods)
+}
+
 func TestSolutionsInfo(t *testing.T) {
 	vendor := createSolutionsVendor()
 	vendor.Version = "1.0"
@@ -136,3 +145,93 @@ func TestSolutionsOnSolutions(t *testing.T) {
 	})
 	assert.Equal(t, v1alpha2.OK, resp.State)
 }
+
+func TestSolutionsOnSolutionsPaging(t *testing.T) {
+	vendor := createSolutionsVendor()
+	vendor.Context = &contexts.VendorContext{}
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor.Context.Init(&pubSubProvider)
+	for _, name := range []string{"solution1", "solution2", "solution3"} {
+		env := "prod"
+		if name == "solution2" {
+			env = "dev"
+		}
+		data, _ := json.Marshal(model.SolutionState{
+			ObjectMeta: model.ObjectMeta{
+				Labels: map[string]string{"env": env},
+			},
+			Spec: &model.SolutionSpec{},
+		})
+		resp := vendor.onSolutions(v1alpha2.COARequest{
+			Method:     fasthttp.MethodPost,
+			Body:       data,
+			Parameters: map[string]string{"__name": name},
+			Context:    context.Background(),
+		})
+		assert.Equal(t, v1alpha2.OK, resp.State)
+	}
+
+	resp := vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"limit": "2",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var solutionsList []model.SolutionState
+	err := json.Unmarshal(resp.Body, &solutionsList)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(solutionsList))
+	continueToken := resp.Metadata["continue"]
+	assert.NotEqual(t, "", continueToken)
+
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"limit":    "2",
+			"continue": continueToken,
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	err = json.Unmarshal(resp.Body, &solutionsList)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(solutionsList))
+	assert.Equal(t, "solution3", solutionsList[0].ObjectMeta.Name)
+	assert.Equal(t, "", resp.Metadata["continue"])
+
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"labelSelector": "env=prod",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	err = json.Unmarshal(resp.Body, &solutionsList)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(solutionsList))
+	for _, solution := range solutionsList {
+		assert.Equal(t, "prod", solution.ObjectMeta.Labels["env"])
+	}
+
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"limit": "a few",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodGet,
+		Parameters: map[string]string{
+			"labelSelector": "env=prod,=web",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
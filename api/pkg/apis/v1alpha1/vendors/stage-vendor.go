@@ -14,6 +14,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/activations"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/campaigns"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/stage"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/sync"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/stage/materialize"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/stage/mock"
@@ -103,6 +104,44 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 		}
 		return nil
 	})
+	s.Vendor.Context.Subscribe("activation-control", func(topic string, event v1alpha2.Event) error {
+		var actData v1alpha2.ActivationData
+		jData, _ := json.Marshal(event.Body)
+		err := json.Unmarshal(jData, &actData)
+		if err != nil {
+			return v1alpha2.NewCOAError(nil, "event body is not an activation job", v1alpha2.BadRequest)
+		}
+		control := event.Metadata["control"]
+		sLog.Infof("V (Stage): handling %s of activation %s", control, actData.Activation)
+		switch control {
+		case model.ActivationPause, model.ActivationCancel:
+			s.StageManager.InterruptActivation(actData.Namespace, actData.Activation)
+			trigger, err := s.StageManager.InterruptRemoteStages(context.TODO(), actData)
+			if err != nil {
+				sLog.Errorf("V (Stage): failed to interrupt remote stages of activation %s: %v", actData.Activation, err)
+				return err
+			}
+			if control == model.ActivationCancel {
+				_, err = s.StageManager.ReleaseTrigger(context.TODO(), actData.Namespace, actData.Activation)
+				return err
+			}
+			if trigger != nil {
+				return s.holdActivation(*trigger)
+			}
+		case model.ActivationResume:
+			trigger, err := s.StageManager.ReleaseTrigger(context.TODO(), actData.Namespace, actData.Activation)
+			if err != nil {
+				sLog.Errorf("V (Stage): failed to release activation %s: %v", actData.Activation, err)
+				return err
+			}
+			if trigger != nil {
+				s.Vendor.Context.Publish("trigger", v1alpha2.Event{
+					Body: *trigger,
+				})
+			}
+		}
+		return nil
+	})
 	s.Vendor.Context.Subscribe("trigger", func(topic string, event v1alpha2.Event) error {
 		log.Info("V (Stage): handling trigger event")
 		status := model.ActivationStatus{
@@ -138,6 +177,15 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 				sLog.Errorf("V (Stage): failed to report error status: %v (%v)", status.ErrorMessage, err)
 			}
 		}
+		if !triggerData.NeedsReport {
+			switch s.getActivationControl(triggerData) {
+			case model.ActivationPause:
+				return s.holdActivation(triggerData)
+			case model.ActivationCancel:
+				sLog.Infof("V (Stage): skipped stage %s of cancelled activation %s", triggerData.Stage, triggerData.Activation)
+				return nil
+			}
+		}
 		status.Stage = triggerData.Stage
 		status.ActivationGeneration = triggerData.ActivationGeneration
 		status.ErrorMessage = ""
@@ -164,6 +212,23 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 			})
 
 		} else {
+			// the activation may have been paused or cancelled while the stage was running
+			switch s.getActivationControl(triggerData) {
+			case model.ActivationPause:
+				if activation != nil {
+					return s.holdActivation(*activation)
+				}
+			case model.ActivationCancel:
+				return nil
+			default:
+				if status.StopReason == model.StopReasonInterrupted && activation != nil {
+					// resumed before the stage stopped, so it runs again
+					s.Vendor.Context.Publish("trigger", v1alpha2.Event{
+						Body: *activation,
+					})
+					return nil
+				}
+			}
 			err = s.ActivationsManager.ReportStatus(context.TODO(), triggerData.Activation, status)
 			if err != nil {
 				sLog.Errorf("V (Stage): failed to report status: %v (%v)", status.ErrorMessage, err)
@@ -183,6 +248,17 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 		jData, _ := json.Marshal(event.Body)
 		var status model.ActivationStatus
 		json.Unmarshal(jData, &status)
+		activationName, _ := status.Outputs["__activation"].(string)
+		namespace, _ := status.Outputs["__namespace"].(string)
+		control := s.getActivationControl(v1alpha2.ActivationData{
+			Activation:           activationName,
+			ActivationGeneration: status.ActivationGeneration,
+			Namespace:            namespace,
+		})
+		if control == model.ActivationPause || control == model.ActivationCancel {
+			sLog.Debugf("V (Stage): dropped job report of activation %s, which is %sd", activationName, control)
+			return nil
+		}
 		if status.Status == v1alpha2.Done || status.Status == v1alpha2.OK {
 			campaign, err := s.CampaignsManager.GetState(context.TODO(), status.Outputs["__campaign"].(string), status.Outputs["__namespace"].(string))
 			if err != nil {
@@ -213,7 +289,34 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 		}
 		return nil
 	})
-	s.Vendor.Context.Subscribe("remote-job", func(topic string, event v1alpha2.Event) error {
+	s.Vendor.Context.Subscribe("rollout", func(topic string, event v1alpha2.Event) error {
+		sLog.Debugf("V (Stage): handling rollout event: %v", event)
+		jData, _ := json.Marshal(event.Body)
+		var status model.ActivationStatus
+		err := json.Unmarshal(jData, &status)
+		if err != nil {
+			sLog.Errorf("V (Stage): failed to deserialize rollout status: %v", err)
+			return v1alpha2.NewCOAError(err, "event body is not an activation status", v1alpha2.BadRequest)
+		}
+		activation, err := s.ActivationsManager.GetState(context.TODO(), event.Metadata["activation"], event.Metadata["namespace"])
+		if err != nil {
+			sLog.Errorf("V (Stage): failed to get activation '%s': %v", event.Metadata["activation"], err)
+			return err
+		}
+		// events aren't ordered, so skip the progress of a stage that has already moved on
+		current := activation.Status
+		if current == nil || current.Stage != status.Stage || current.ActivationGeneration != status.ActivationGeneration ||
+			current.Status != v1alpha2.Running || len(current.Waves) >= len(status.Waves) {
+			return nil
+		}
+		err = s.ActivationsManager.ReportStatus(context.TODO(), event.Metadata["activation"], status)
+		if err != nil {
+			sLog.Errorf("V (Stage): failed to report rollout status: %v", err)
+			return err
+		}
+		return nil
+	})
+	s.Vendor.Context.Subscribe("remote-job", sync.WithSyncResult(s.Vendor.Context, func(topic string, event v1alpha2.Event) error {
 		// Unwrap data package from event body
 		jData, _ := json.Marshal(event.Body)
 		var job v1alpha2.JobData
@@ -225,6 +328,13 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 			return err
 		}
 
+		if job.Action == v1alpha2.JobCancel {
+			namespace, _ := dataPackage.Inputs["__namespace"].(string)
+			activation, _ := dataPackage.Inputs["__activation"].(string)
+			s.StageManager.InterruptActivation(namespace, activation)
+			return nil
+		}
+
 		// restore schedule
 		var schedule *v1alpha2.ScheduleSpec
 		if v, ok := dataPackage.Inputs["__schedule"]; ok {
@@ -246,6 +356,9 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 		}
 
 		triggerData.Inputs["__origin"] = event.Metadata["origin"]
+		if v, ok := dataPackage.Inputs["__namespace"].(string); ok {
+			triggerData.Namespace = v
+		}
 
 		switch dataPackage.Inputs["operation"] {
 		case "wait":
@@ -278,6 +391,40 @@ func (s *StageVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 			Body: status,
 		})
 		return nil
-	})
+	}))
 	return nil
 }
+
+// getActivationControl returns the operator request on the activation of the trigger, such as model.ActivationPause
+func (s *StageVendor) getActivationControl(triggerData v1alpha2.ActivationData) string {
+	activation, err := s.ActivationsManager.GetState(context.TODO(), triggerData.Activation, triggerData.Namespace)
+	if err != nil || activation.Status == nil {
+		return ""
+	}
+	if triggerData.ActivationGeneration != "" && activation.Status.ActivationGeneration != triggerData.ActivationGeneration {
+		return ""
+	}
+	return activation.Status.Control
+}
+
+// holdActivation keeps the trigger of a paused activation until it's resumed and reports it as paused
+func (s *StageVendor) holdActivation(triggerData v1alpha2.ActivationData) error {
+	err := s.StageManager.HoldTrigger(context.TODO(), triggerData)
+	if err != nil {
+		sLog.Errorf("V (Stage): failed to hold activation %s: %v", triggerData.Activation, err)
+		return err
+	}
+	err = s.ActivationsManager.ReportStatus(context.TODO(), triggerData.Activation, model.ActivationStatus{
+		Stage:                triggerData.Stage,
+		ActivationGeneration: triggerData.ActivationGeneration,
+		Status:               v1alpha2.Paused,
+		ErrorMessage:         fmt.Sprintf("activation %s is paused before stage %s", triggerData.Activation, triggerData.Stage),
+		IsActive:             false,
+		StopReason:           model.StopReasonInterrupted,
+		Control:              model.ActivationPause,
+	})
+	if err != nil {
+		sLog.Errorf("V (Stage): failed to report paused status: %v", err)
+	}
+	return err
+}
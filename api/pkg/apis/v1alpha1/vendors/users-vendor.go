@@ -14,6 +14,7 @@ import (
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/users"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
@@ -27,9 +28,26 @@ import (
 
 var rLog = logger.NewLogger("coa.runtime")
 
+// defaultAdminRole is the role that may manage other users unless the vendor is configured with another one
+const defaultAdminRole = authz.RoleAdministrator
+
 type UsersVendor struct {
 	vendors.Vendor
 	UsersManager *users.UsersManager
+	// TokenTTL is how long issued tokens are valid for users who don't have their own TTL
+	TokenTTL time.Duration
+	// AdminRole is the role that may list, disable and enable users and set their token TTLs
+	AdminRole string
+}
+
+type ChangePasswordRequest struct {
+	UserName    string `json:"username"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type TokenTTLRequest struct {
+	TokenTTL string `json:"tokenTTL"`
 }
 
 func (o *UsersVendor) GetInfo() vendors.VendorInfo {
@@ -53,9 +71,21 @@ func (e *UsersVendor) Init(config vendors.VendorConfig, factories []managers.IMa
 	if e.UsersManager == nil {
 		return v1alpha2.NewCOAError(nil, "users manager is not supplied", v1alpha2.MissingConfig)
 	}
+	e.TokenTTL = 24 * time.Hour
+	if v, ok := config.Properties["tokenTTL"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid tokenTTL value '%s'", v), v1alpha2.BadConfig)
+		}
+		e.TokenTTL = d
+	}
+	e.AdminRole = defaultAdminRole
+	if v, ok := config.Properties["adminRole"]; ok && v != "" {
+		e.AdminRole = v
+	}
 	if config.Properties != nil && config.Properties["test-users"] == "true" {
-		e.UsersManager.UpsertUser(context.Background(), "admin", "", nil)
-		e.UsersManager.UpsertUser(context.Background(), "reader", "", nil)
+		e.UsersManager.UpsertUser(context.Background(), "admin", "", []string{authz.RoleAdministrator})
+		e.UsersManager.UpsertUser(context.Background(), "reader", "", []string{authz.RoleReader})
 		e.UsersManager.UpsertUser(context.Background(), "developer", "", nil)
 		e.UsersManager.UpsertUser(context.Background(), "device-manager", "", nil)
 		e.UsersManager.UpsertUser(context.Background(), "operator", "", nil)
@@ -70,6 +100,40 @@ func (o *UsersVendor) GetEndpoints() []v1alpha2.Endpoint {
 		route = o.Route
 	}
 	return []v1alpha2.Endpoint{
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/registry",
+			Version:    o.Version,
+			Handler:    o.onRegistry,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods: []string{fasthttp.MethodPost},
+			Route:   route + "/password",
+			Version: o.Version,
+			Handler: o.onPassword,
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/disable",
+			Version:    o.Version,
+			Handler:    o.onDisable,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/enable",
+			Version:    o.Version,
+			Handler:    o.onEnable,
+			Parameters: []string{"name?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/token-ttl",
+			Version:    o.Version,
+			Handler:    o.onTokenTTL,
+			Parameters: []string{"name?"},
+		},
 		{
 			Methods: []string{fasthttp.MethodPost},
 			Route:   route + "/auth",
@@ -95,20 +159,30 @@ func (c *UsersVendor) onAuth(request v1alpha2.COARequest) v1alpha2.COAResponse {
 			Body:  []byte(err.Error()),
 		})
 	}
-	roles, b := c.UsersManager.CheckUser(ctx, authRequest.UserName, authRequest.Password)
-	if !b {
+	user, err := c.UsersManager.AuthenticateUser(ctx, authRequest.UserName, authRequest.Password)
+	if err != nil {
 		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
 			State: v1alpha2.Unauthorized,
 			Body:  []byte("login failed"),
 		})
 	}
+	roles := user.Roles
+	ttl := c.TokenTTL
+	if user.TokenTTL != "" {
+		if d, err := time.ParseDuration(user.TokenTTL); err == nil {
+			ttl = d
+		}
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
 
 	mySigningKey := []byte("SymphonyKey")
 	claims := MyCustomClaims{
 		authRequest.UserName,
 		jwt.RegisteredClaims{
 			// A usual scenario is to set the expiration time relative to the current time
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "symphony",
@@ -131,3 +205,158 @@ func (c *UsersVendor) onAuth(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
 	return resp
 }
+
+func (c *UsersVendor) onRegistry(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Users Vendor", request.Context, &map[string]string{
+		"method": "onRegistry",
+	})
+	defer span.End()
+	log.Infof("V (Users): onRegistry, method: %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+
+	if request.Method != fasthttp.MethodGet {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.MethodNotAllowed,
+			Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+			ContentType: "application/json",
+		})
+	}
+	if !hasRole(ctx, c.AdminRole) {
+		return observ_utils.CloseSpanWithCOAResponse(span, roleRequiredResponse(c.AdminRole))
+	}
+	var state interface{}
+	var err error
+	if id := request.Parameters["__name"]; id != "" {
+		state, err = c.UsersManager.GetUser(ctx, id)
+	} else {
+		state, err = c.UsersManager.ListUsers(ctx)
+	}
+	if err != nil {
+		log.Errorf("V (Users): onRegistry failed, error: %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, usersErrorResponse(err))
+	}
+	jData, _ := json.Marshal(state)
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.OK,
+		Body:        jData,
+		ContentType: "application/json",
+	})
+}
+
+func (c *UsersVendor) onPassword(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Users Vendor", request.Context, &map[string]string{
+		"method": "onPassword",
+	})
+	defer span.End()
+	log.Infof("V (Users): onPassword, method: %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+
+	var passwordRequest ChangePasswordRequest
+	err := json.Unmarshal(request.Body, &passwordRequest)
+	if err != nil {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte(err.Error()),
+		})
+	}
+	// users can only change their own password, as the old password is all that is checked
+	if user := authz.IdentityFromContext(ctx).User; user == "" || user != passwordRequest.UserName {
+		log.Errorf("V (Users): onPassword rejected a password change of user %s by %s, traceId: %s", passwordRequest.UserName, user, span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.Forbidden,
+			Body:        []byte("{\"result\":\"403 - users can only change their own password\"}"),
+			ContentType: "application/json",
+		})
+	}
+	err = c.UsersManager.ChangePassword(ctx, passwordRequest.UserName, passwordRequest.OldPassword, passwordRequest.NewPassword)
+	if err != nil {
+		log.Errorf("V (Users): onPassword failed, error: %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, usersErrorResponse(err))
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State: v1alpha2.OK,
+	})
+}
+
+func (c *UsersVendor) onDisable(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	return c.setUserDisabled(request, true)
+}
+
+func (c *UsersVendor) onEnable(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	return c.setUserDisabled(request, false)
+}
+
+func (c *UsersVendor) setUserDisabled(request v1alpha2.COARequest, disabled bool) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Users Vendor", request.Context, &map[string]string{
+		"method": "setUserDisabled",
+	})
+	defer span.End()
+	log.Infof("V (Users): setUserDisabled %t, method: %s, traceId: %s", disabled, request.Method, span.SpanContext().TraceID().String())
+
+	if !hasRole(ctx, c.AdminRole) {
+		return observ_utils.CloseSpanWithCOAResponse(span, roleRequiredResponse(c.AdminRole))
+	}
+	err := c.UsersManager.SetUserDisabled(ctx, request.Parameters["__name"], disabled)
+	if err != nil {
+		log.Errorf("V (Users): setUserDisabled failed, error: %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, usersErrorResponse(err))
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State: v1alpha2.OK,
+	})
+}
+
+func (c *UsersVendor) onTokenTTL(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Users Vendor", request.Context, &map[string]string{
+		"method": "onTokenTTL",
+	})
+	defer span.End()
+	log.Infof("V (Users): onTokenTTL, method: %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+
+	if !hasRole(ctx, c.AdminRole) {
+		return observ_utils.CloseSpanWithCOAResponse(span, roleRequiredResponse(c.AdminRole))
+	}
+	var ttlRequest TokenTTLRequest
+	err := json.Unmarshal(request.Body, &ttlRequest)
+	if err != nil {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte(err.Error()),
+		})
+	}
+	err = c.UsersManager.SetTokenTTL(ctx, request.Parameters["__name"], ttlRequest.TokenTTL)
+	if err != nil {
+		log.Errorf("V (Users): onTokenTTL failed, error: %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, usersErrorResponse(err))
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State: v1alpha2.OK,
+	})
+}
+
+// hasRole checks whether the identity that the request was authenticated with has a role
+func hasRole(ctx context.Context, role string) bool {
+	for _, r := range authz.IdentityFromContext(ctx).Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func roleRequiredResponse(role string) v1alpha2.COAResponse {
+	return v1alpha2.COAResponse{
+		State:       v1alpha2.Forbidden,
+		Body:        []byte(fmt.Sprintf("{\"result\":\"403 - role %s is required\"}", role)),
+		ContentType: "application/json",
+	}
+}
+
+func usersErrorResponse(err error) v1alpha2.COAResponse {
+	state := v1alpha2.InternalError
+	if cErr, ok := err.(v1alpha2.COAError); ok {
+		state = cErr.State
+	}
+	return v1alpha2.COAResponse{
+		State: state,
+		Body:  []byte(err.Error()),
+	}
+}
@@ -10,13 +10,17 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/users"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -95,3 +99,161 @@ func TestUsersEndpoints(t *testing.T) {
 	assert.NotNil(t, endpoints)
 	assert.Equal(t, "user/auth", endpoints[len(endpoints)-1].Route)
 }
+
+func TestUsersLifecycle(t *testing.T) {
+	vendor := initVendor(t)
+	adminCtx := authz.WithIdentity(context.Background(), authz.Identity{User: "admin", Roles: []string{authz.RoleAdministrator}})
+	response := vendor.onRegistry(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "GET",
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var list []users.UserState
+	err := json.Unmarshal(response.Body, &list)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(list))
+	for _, user := range list {
+		assert.Empty(t, user.PasswordHash)
+	}
+
+	response = vendor.onRegistry(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "GET",
+		Parameters: map[string]string{
+			"__name": "missing",
+		},
+	})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+
+	data, _ := json.Marshal(ChangePasswordRequest{
+		UserName:    "admin",
+		OldPassword: "",
+		NewPassword: "new-password",
+	})
+	response = vendor.onPassword(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	data, _ = json.Marshal(TokenTTLRequest{TokenTTL: "1h"})
+	response = vendor.onTokenTTL(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "POST",
+		Body:    data,
+		Parameters: map[string]string{
+			"__name": "admin",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	data, _ = json.Marshal(TokenTTLRequest{TokenTTL: "soon"})
+	response = vendor.onTokenTTL(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "POST",
+		Body:    data,
+		Parameters: map[string]string{
+			"__name": "admin",
+		},
+	})
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+
+	data, _ = json.Marshal(AuthRequest{
+		UserName: "admin",
+		Password: "new-password",
+	})
+	response = vendor.onAuth(v1alpha2.COARequest{
+		Context: context.Background(),
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var authResponse map[string]interface{}
+	err = json.Unmarshal(response.Body, &authResponse)
+	assert.Nil(t, err)
+	claims := MyCustomClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(authResponse["accessToken"].(string), &claims)
+	assert.Nil(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), claims.ExpiresAt.Sub(claims.IssuedAt.Time).Seconds(), 1)
+
+	response = vendor.onDisable(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "POST",
+		Parameters: map[string]string{
+			"__name": "admin",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onAuth(v1alpha2.COARequest{
+		Context: context.Background(),
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.Unauthorized, response.State)
+
+	response = vendor.onEnable(v1alpha2.COARequest{
+		Context: adminCtx,
+		Method:  "POST",
+		Parameters: map[string]string{
+			"__name": "admin",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = vendor.onAuth(v1alpha2.COARequest{
+		Context: context.Background(),
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestUsersRequireAdminRole(t *testing.T) {
+	vendor := initVendor(t)
+	readerCtx := authz.WithIdentity(context.Background(), authz.Identity{User: "reader", Roles: []string{authz.RoleReader}})
+	response := vendor.onRegistry(v1alpha2.COARequest{
+		Context: readerCtx,
+		Method:  "GET",
+	})
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+	response = vendor.onDisable(v1alpha2.COARequest{
+		Context: readerCtx,
+		Method:  "POST",
+		Parameters: map[string]string{
+			"__name": "admin",
+		},
+	})
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+	data, _ := json.Marshal(TokenTTLRequest{TokenTTL: "1h"})
+	response = vendor.onTokenTTL(v1alpha2.COARequest{
+		Context: readerCtx,
+		Method:  "POST",
+		Body:    data,
+		Parameters: map[string]string{
+			"__name": "reader",
+		},
+	})
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+
+	data, _ = json.Marshal(ChangePasswordRequest{
+		UserName:    "admin",
+		OldPassword: "",
+		NewPassword: "new-password",
+	})
+	response = vendor.onPassword(v1alpha2.COARequest{
+		Context: readerCtx,
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+	data, _ = json.Marshal(ChangePasswordRequest{
+		UserName:    "reader",
+		OldPassword: "",
+		NewPassword: "new-password",
+	})
+	response = vendor.onPassword(v1alpha2.COARequest{
+		Context: readerCtx,
+		Method:  "POST",
+		Body:    data,
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
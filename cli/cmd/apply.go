@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestPaths     []string
+	manifestNamespace string
+)
+var ApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update Symphony objects from YAML or JSON files",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getMaestroContext()
+		manifests, err := utils.ReadManifests(manifestPaths, manifestNamespace)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		for _, m := range manifests {
+			err = utils.ApplyManifest(c.Url, c.User, c.Secret, m)
+			if err != nil {
+				fmt.Printf("\n%s  failed to apply %s '%s': %s%s\n\n", utils.ColorRed(), m.Kind, m.Name, err.Error(), utils.ColorReset())
+				return
+			}
+			fmt.Printf("%s/%s applied\n", m.Kind, m.Name)
+		}
+	},
+}
+
+// getMaestroContext gets the context of the --context flag, or the default context of the Maestro CLI config
+func getMaestroContext() config.MaestroContext {
+	c := config.GetMaestroConfig(configFile)
+	ctx := c.DefaultContext
+	if configContext != "" {
+		ctx = configContext
+	}
+
+	if ctx == "" {
+		ctx = "default"
+	}
+	return c.Contexts[ctx]
+}
+
+func init() {
+	ApplyCmd.Flags().StringArrayVarP(&manifestPaths, "file", "f", nil, "YAML or JSON file, or directory of files, with the objects to apply. Use - for stdin")
+	ApplyCmd.Flags().StringVarP(&manifestNamespace, "namespace", "", "default", "Namespace of objects that don't have one")
+	ApplyCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	ApplyCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	ApplyCmd.MarkFlagRequired("file")
+	RootCmd.AddCommand(ApplyCmd)
+}
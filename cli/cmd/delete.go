@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var DeleteCmd = &cobra.Command{
+	Use:   "delete [type name...]",
+	Short: "Delete Symphony objects by type and name, or the objects in YAML or JSON files",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getMaestroContext()
+		manifests := make([]utils.Manifest, 0)
+		if len(manifestPaths) > 0 {
+			read, err := utils.ReadManifests(manifestPaths, manifestNamespace)
+			if err != nil {
+				fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+				return
+			}
+			manifests = append(manifests, read...)
+		}
+		if len(args) == 1 {
+			fmt.Printf("\n%s  object name is missing%s\n\n", utils.ColorRed(), utils.ColorReset())
+			return
+		}
+		for i := 1; i < len(args); i++ {
+			manifests = append(manifests, utils.Manifest{
+				Kind:      utils.NormalizeKind(args[0]),
+				Name:      args[i],
+				Namespace: manifestNamespace,
+			})
+		}
+		if len(manifests) == 0 {
+			fmt.Printf("\n%s  specify the objects to delete by type and name, or with --file%s\n\n", utils.ColorRed(), utils.ColorReset())
+			return
+		}
+		// objects are deleted before the ones they depend on, such as instances before their solutions
+		for i := len(manifests) - 1; i >= 0; i-- {
+			m := manifests[i]
+			err := utils.DeleteObject(c.Url, c.User, c.Secret, m.Kind, m.Name, m.Namespace)
+			if err != nil {
+				fmt.Printf("\n%s  failed to delete %s '%s': %s%s\n\n", utils.ColorRed(), m.Kind, m.Name, err.Error(), utils.ColorReset())
+				return
+			}
+			if m.Kind == "instance" || m.Kind == "target" {
+				fmt.Printf("%s/%s removal queued\n", m.Kind, m.Name)
+			} else {
+				fmt.Printf("%s/%s deleted\n", m.Kind, m.Name)
+			}
+		}
+	},
+}
+
+func init() {
+	DeleteCmd.Flags().StringArrayVarP(&manifestPaths, "file", "f", nil, "YAML or JSON file, or directory of files, with the objects to delete. Use - for stdin")
+	DeleteCmd.Flags().StringVarP(&manifestNamespace, "namespace", "", "default", "Namespace of objects that don't have one")
+	DeleteCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	DeleteCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(DeleteCmd)
+}
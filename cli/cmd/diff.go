@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffShowUnchanged bool
+)
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the changes that applying YAML or JSON files would make on the targets",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getMaestroContext()
+		manifests, err := utils.ReadManifests(manifestPaths, manifestNamespace)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		plans, err := utils.PlanManifests(c.Url, c.User, c.Secret, manifests)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		if len(plans) == 0 {
+			fmt.Println("No instances are changed")
+			return
+		}
+		for _, plan := range plans {
+			outputPlan(plan)
+		}
+	},
+}
+
+func outputPlan(plan utils.InstancePlan) {
+	fmt.Printf("%sinstance %s (namespace %s)%s\n", utils.ColorCyan(), plan.Name, plan.Namespace, utils.ColorReset())
+	if len(plan.Preview.Steps) == 0 {
+		fmt.Println("  no targets")
+	}
+	for _, step := range plan.Preview.Steps {
+		fmt.Printf("  target %s:", step.Target)
+		if step.Skipped {
+			fmt.Print(" up to date")
+		}
+		fmt.Println()
+		if step.Error != "" {
+			fmt.Printf("    %serror: %s%s\n", utils.ColorRed(), step.Error, utils.ColorReset())
+		}
+		for _, component := range step.Components {
+			outputComponentPreview(component)
+		}
+	}
+	fmt.Println()
+}
+
+func outputComponentPreview(component model.ComponentPreviewSpec) {
+	switch component.Action {
+	case model.PreviewCreate:
+		fmt.Printf("    %s+ %s%s\n", utils.ColorGreen(), component.Name, utils.ColorReset())
+	case model.PreviewUpdate:
+		fmt.Printf("    %s~ %s%s\n", utils.ColorYellow(), component.Name, utils.ColorReset())
+	case model.PreviewDelete:
+		fmt.Printf("    %s- %s%s\n", utils.ColorRed(), component.Name, utils.ColorReset())
+		return
+	default:
+		if diffShowUnchanged {
+			fmt.Printf("      %s\n", component.Name)
+		}
+		return
+	}
+	for _, change := range component.Changes {
+		if component.Action == model.PreviewCreate {
+			fmt.Printf("        %s: %s\n", change.Path, formatPreviewValue(change.NewValue))
+		} else {
+			fmt.Printf("        %s: %s -> %s\n", change.Path, formatPreviewValue(change.OldValue), formatPreviewValue(change.NewValue))
+		}
+	}
+}
+
+func formatPreviewValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func init() {
+	DiffCmd.Flags().StringArrayVarP(&manifestPaths, "file", "f", nil, "YAML or JSON file, or directory of files, with the objects to compare. Use - for stdin")
+	DiffCmd.Flags().StringVarP(&manifestNamespace, "namespace", "", "default", "Namespace of objects that don't have one")
+	DiffCmd.Flags().BoolVar(&diffShowUnchanged, "show-unchanged", false, "Also list components that aren't changed")
+	DiffCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	DiffCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	DiffCmd.MarkFlagRequired("file")
+	RootCmd.AddCommand(DiffCmd)
+}
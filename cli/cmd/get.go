@@ -27,6 +27,9 @@ var (
 	jsonPath      string
 	docType       string
 	configContext string
+	labelSelector string
+	listLimit     int
+	listContinue  string
 )
 var GetCmd = &cobra.Command{
 	Use:   "get",
@@ -43,19 +46,27 @@ var GetCmd = &cobra.Command{
 		}
 
 		for _, a := range args {
-			list, err := utils.Get(
+			list, continueToken, err := utils.Get(
 				c.Contexts[ctx].Url,
 				c.Contexts[ctx].User,
 				c.Contexts[ctx].Secret,
 				a,
 				jsonPath,
 				docType,
-				objectName)
+				objectName,
+				model.ListOptions{
+					Limit:         listLimit,
+					Continue:      listContinue,
+					LabelSelector: labelSelector,
+				})
 			if err != nil {
 				fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
 				return
 			}
 			outputList(list, a, jsonPath)
+			if continueToken != "" {
+				fmt.Printf("\nMore %s are available, use --continue %s to get the next page\n\n", a, continueToken)
+			}
 		}
 	},
 }
@@ -260,6 +271,9 @@ func init() {
 	GetCmd.Flags().StringVarP(&jsonPath, "json-path", "", "", "Jason Path query to be applied on results")
 	GetCmd.Flags().StringVarP(&docType, "doc-type", "", "", "Result type (Json or Yaml)")
 	GetCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	GetCmd.Flags().StringVarP(&labelSelector, "selector", "l", "", "Label selector to filter on, such as env=prod,tier!=web")
+	GetCmd.Flags().IntVarP(&listLimit, "limit", "", 0, "Maximum number of objects to return, 0 returns all objects")
+	GetCmd.Flags().StringVarP(&listContinue, "continue", "", "", "Continuation token returned with the previous page")
 	RootCmd.AddCommand(GetCmd)
 }
 
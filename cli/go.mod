@@ -10,11 +10,12 @@ require github.com/spf13/cobra v1.6.1
 
 require (
 	github.com/cenkalti/backoff/v4 v4.2.1 // indirect
-	github.com/eclipse-symphony/symphony/coa v0.0.0 // indirect
+	github.com/eclipse-symphony/symphony/coa v0.0.0
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
 	golang.org/x/exp v0.0.0-20220929160808-de9c53c655b9 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 	helm.sh/helm/v3 v3.10.0 // indirect
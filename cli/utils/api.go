@@ -13,7 +13,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	neturl "net/url"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"sigs.k8s.io/yaml"
 )
 
@@ -96,10 +99,12 @@ func yamlToJson(payload []byte) ([]byte, error) {
 	return json.Marshal(o.Spec)
 }
 
-func Get(url string, username string, password string, objType string, path string, docType string, objName string) ([]interface{}, error) {
+// Get lists objects of objType, or gets the object named objName. When a list is paged, it also returns the
+// continuation token of the next page, which is empty on the last page.
+func Get(url string, username string, password string, objType string, path string, docType string, objName string, options model.ListOptions) ([]interface{}, string, error) {
 	token, err := Login(url, username, password)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	route := ""
 	switch objType {
@@ -111,6 +116,12 @@ func Get(url string, username string, password string, objType string, path stri
 		route = "/solutions"
 	case "instance", "instances":
 		route = "/instances"
+	case "catalog", "catalogs":
+		route = "/catalogs/registry"
+	case "campaign", "campaigns":
+		route = "/campaigns"
+	case "activation", "activations":
+		route = "/activations/registry"
 	}
 	if objName != "" {
 		route += "/" + objName
@@ -122,25 +133,32 @@ func Get(url string, username string, password string, objType string, path stri
 	if docType != "" {
 		params["doc-type"] = docType
 	}
-	resp, err := callRestAPI(url, route, "GET", nil, token, params)
+	if objName == "" {
+		query := neturl.Values{}
+		options.Encode(query)
+		for k := range query {
+			params[k] = query.Get(k)
+		}
+	}
+	resp, metadata, err := callRestAPIWithMetadata(url, route, "GET", nil, token, params)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	var ret []interface{}
 	if objName != "" {
 		var obj interface{}
 		err = json.Unmarshal(resp, &obj)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		ret = append(ret, obj)
 	} else {
 		err = json.Unmarshal(resp, &ret)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
-	return ret, nil
+	return ret, metadata[model.ListContinue], nil
 }
 
 func Login(url string, username string, password string) (string, error) {
@@ -161,11 +179,17 @@ func Login(url string, username string, password string) (string, error) {
 }
 
 func callRestAPI(url string, route string, method string, payload []byte, token string, parameters map[string]string) ([]byte, error) {
+	ret, _, err := callRestAPIWithMetadata(url, route, method, payload, token, parameters)
+	return ret, err
+}
+
+// callRestAPIWithMetadata calls a Symphony API and also returns the metadata the API attached to the response
+func callRestAPIWithMetadata(url string, route string, method string, payload []byte, token string, parameters map[string]string) ([]byte, map[string]string, error) {
 	client := &http.Client{}
 	rUrl := url + route
 	req, err := http.NewRequest(method, rUrl, bytes.NewBuffer(payload))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -183,18 +207,22 @@ func callRestAPI(url string, route string, method string, payload []byte, token
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if resp.StatusCode >= 300 {
 		if resp.StatusCode == 404 { // API service is already gone
-			return nil, nil
+			return nil, nil, nil
 		}
-		return nil, fmt.Errorf("failed to invoke Symphony API: [%d] - %v", resp.StatusCode, string(bodyBytes))
+		return nil, nil, fmt.Errorf("failed to invoke Symphony API: [%d] - %v", resp.StatusCode, string(bodyBytes))
+	}
+	var metadata map[string]string
+	if meta := resp.Header.Get(v1alpha2.COAMetaHeader); meta != "" {
+		json.Unmarshal([]byte(meta), &metadata)
 	}
-	return bodyBytes, nil
+	return bodyBytes, metadata, nil
 }
@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+)
+
+// InstancePlan is what reconciling an instance would change on its targets
+type InstancePlan struct {
+	Name      string
+	Namespace string
+	Preview   model.PlanPreviewSpec
+}
+
+// apiBaseUrl makes a context URL, such as http://localhost:8080/v1alpha2, a base URL of the Symphony API client
+func apiBaseUrl(url string) string {
+	if !strings.HasSuffix(url, "/") {
+		return url + "/"
+	}
+	return url
+}
+
+// ApplyManifest creates or updates the object of a manifest
+func ApplyManifest(url string, username string, password string, manifest Manifest) error {
+	ctx := context.Background()
+	baseUrl := apiBaseUrl(url)
+	switch manifest.Kind {
+	case "solution":
+		return api_utils.UpsertSolution(ctx, baseUrl, manifest.Name, username, password, manifest.Payload, manifest.Namespace)
+	case "instance":
+		return api_utils.CreateInstance(ctx, baseUrl, manifest.Name, username, password, manifest.Payload, manifest.Namespace)
+	case "target":
+		return api_utils.UpsertTarget(ctx, baseUrl, manifest.Name, username, password, manifest.Payload, manifest.Namespace)
+	case "catalog":
+		return api_utils.UpsertCatalog(ctx, baseUrl, manifest.Name, username, password, manifest.Payload)
+	case "campaign":
+		return api_utils.UpsertCampaign(ctx, baseUrl, manifest.Name, username, password, manifest.Payload)
+	}
+	return fmt.Errorf("kind '%s' is not supported", manifest.Kind)
+}
+
+// DeleteObject deletes an object. Instances and targets are deleted after their components are removed, by a job
+// that the Symphony API runs in the background.
+func DeleteObject(url string, username string, password string, kind string, name string, namespace string) error {
+	ctx := context.Background()
+	baseUrl := apiBaseUrl(url)
+	switch kind {
+	case "solution":
+		return api_utils.DeleteSolution(ctx, baseUrl, name, username, password, namespace)
+	case "instance":
+		return api_utils.QueueJob(ctx, baseUrl, username, password, name, namespace, true, false)
+	case "target":
+		return api_utils.QueueJob(ctx, baseUrl, username, password, name, namespace, true, true)
+	case "catalog":
+		return api_utils.DeleteCatalog(ctx, baseUrl, name, username, password, namespace)
+	case "campaign":
+		return api_utils.DeleteCampaign(ctx, baseUrl, name, username, password, namespace)
+	}
+	return fmt.Errorf("kind '%s' is not supported", kind)
+}
+
+// PlanManifests previews what applying manifests would change on the targets. It plans the instances of the
+// manifests, and the instances on the server that use the solutions or match the targets of the manifests, with
+// the solutions and targets of the manifests in place of the ones on the server.
+func PlanManifests(url string, username string, password string, manifests []Manifest) ([]InstancePlan, error) {
+	ctx := context.Background()
+	baseUrl := apiBaseUrl(url)
+
+	solutions := make(map[string]model.SolutionState)
+	targets := make(map[string]model.TargetState)
+	instances := make([]model.InstanceState, 0)
+	planned := make(map[string]bool)
+	for _, m := range manifests {
+		key := m.Namespace + "/" + m.Name
+		var err error
+		switch m.Kind {
+		case "solution":
+			var solution model.SolutionState
+			err = json.Unmarshal(m.Payload, &solution)
+			solutions[key] = solution
+		case "target":
+			var target model.TargetState
+			err = json.Unmarshal(m.Payload, &target)
+			targets[key] = target
+		case "instance":
+			var instance model.InstanceState
+			err = json.Unmarshal(m.Payload, &instance)
+			instances = append(instances, instance)
+			planned[key] = true
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", m.Source, err.Error())
+		}
+	}
+
+	// instances on the server that the solutions and targets of the manifests change
+	namespaces := make(map[string]bool)
+	for key := range solutions {
+		namespaces[strings.SplitN(key, "/", 2)[0]] = true
+	}
+	for key := range targets {
+		namespaces[strings.SplitN(key, "/", 2)[0]] = true
+	}
+	for namespace := range namespaces {
+		existing, err := api_utils.GetInstances(ctx, baseUrl, username, password, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range existing {
+			key := namespace + "/" + instance.ObjectMeta.Name
+			if planned[key] || instance.Spec == nil {
+				continue
+			}
+			_, usesSolution := solutions[namespace+"/"+instance.Spec.Solution]
+			if usesSolution || len(api_utils.MatchTargets(instance, namespaceTargets(targets, namespace))) > 0 {
+				instance.ObjectMeta.Namespace = namespace
+				instances = append(instances, instance)
+				planned[key] = true
+			}
+		}
+	}
+
+	ret := make([]InstancePlan, 0)
+	for _, instance := range instances {
+		namespace := instance.ObjectMeta.Namespace
+		if instance.Spec == nil {
+			instance.Spec = &model.InstanceSpec{}
+		}
+		solution, ok := solutions[namespace+"/"+instance.Spec.Solution]
+		if !ok {
+			var err error
+			solution, err = api_utils.GetSolution(ctx, baseUrl, instance.Spec.Solution, username, password, namespace)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get solution '%s' of instance '%s': %s", instance.Spec.Solution, instance.ObjectMeta.Name, err.Error())
+			}
+		}
+		serverTargets, err := api_utils.GetTargets(ctx, baseUrl, username, password, namespace)
+		if err != nil {
+			return nil, err
+		}
+		candidates := namespaceTargets(targets, namespace)
+		for _, t := range serverTargets {
+			if _, ok := targets[namespace+"/"+t.ObjectMeta.Name]; !ok {
+				candidates = append(candidates, t)
+			}
+		}
+		deployment, err := api_utils.CreateSymphonyDeployment(instance, solution, api_utils.MatchTargets(instance, candidates), nil)
+		if err != nil {
+			return nil, err
+		}
+		preview, err := api_utils.PlanDeployment(ctx, baseUrl, username, password, deployment, namespace, false)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, InstancePlan{
+			Name:      instance.ObjectMeta.Name,
+			Namespace: namespace,
+			Preview:   preview,
+		})
+	}
+	return ret, nil
+}
+
+func namespaceTargets(targets map[string]model.TargetState, namespace string) []model.TargetState {
+	ret := make([]model.TargetState, 0)
+	for key, t := range targets {
+		if strings.HasPrefix(key, namespace+"/") {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Kinds of objects that manifests can carry, in the order they're applied
+var manifestKinds = []string{"catalog", "campaign", "solution", "target", "instance"}
+
+// Manifest is a Symphony object read from a YAML or JSON document
+type Manifest struct {
+	// Kind is catalog, campaign, solution, target or instance
+	Kind      string
+	Name      string
+	Namespace string
+	// Payload is the object as the Symphony API takes it, with its metadata and spec
+	Payload []byte
+	// Source is the file the object was read from
+	Source string
+}
+
+type manifestDocument struct {
+	APIVersion string                 `json:"apiVersion,omitempty"`
+	Kind       string                 `json:"kind"`
+	Metadata   map[string]interface{} `json:"metadata"`
+	Spec       interface{}            `json:"spec"`
+}
+
+// ReadManifests reads the objects of files, directories or "-" for stdin. A directory contributes its .yaml, .yml and
+// .json files, a YAML file can have multiple documents separated by "---" and a JSON file can have an array of
+// objects. Objects without a namespace get the given one. The objects are sorted so that the ones that others
+// depend on, such as solutions of instances, come first.
+func ReadManifests(paths []string, namespace string) ([]Manifest, error) {
+	ret := make([]Manifest, 0)
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			var data []byte
+			if file == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = ioutil.ReadFile(file)
+			}
+			if err != nil {
+				return nil, err
+			}
+			manifests, err := parseManifests(data, file, namespace)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, manifests...)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return kindOrder(ret[i].Kind) < kindOrder(ret[j].Kind)
+	})
+	return ret, nil
+}
+
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0)
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				ret = append(ret, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return ret, nil
+}
+
+func parseManifests(data []byte, source string, namespace string) ([]Manifest, error) {
+	ret := make([]Manifest, 0)
+	for _, doc := range splitDocuments(data) {
+		jData, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", source, err.Error())
+		}
+		jData = bytes.TrimSpace(jData)
+		if len(jData) == 0 || string(jData) == "null" {
+			continue
+		}
+		var docs []manifestDocument
+		if jData[0] == '[' {
+			err = json.Unmarshal(jData, &docs)
+		} else {
+			var d manifestDocument
+			err = json.Unmarshal(jData, &d)
+			docs = append(docs, d)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", source, err.Error())
+		}
+		for _, d := range docs {
+			manifest, err := newManifest(d, source, namespace)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, manifest)
+		}
+	}
+	return ret, nil
+}
+
+// splitDocuments splits YAML documents on "---" separator lines
+func splitDocuments(data []byte) [][]byte {
+	ret := make([][]byte, 0)
+	var doc bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(strings.TrimPrefix(line, "---")) == "" {
+			ret = append(ret, append([]byte{}, doc.Bytes()...))
+			doc.Reset()
+			continue
+		}
+		doc.WriteString(line)
+		doc.WriteString("\n")
+	}
+	return append(ret, doc.Bytes())
+}
+
+func newManifest(d manifestDocument, source string, namespace string) (Manifest, error) {
+	ret := Manifest{
+		Kind:   NormalizeKind(d.Kind),
+		Source: source,
+	}
+	if kindOrder(ret.Kind) == len(manifestKinds) {
+		return ret, fmt.Errorf("%s: kind '%s' is not supported, use one of Solution, Instance, Target, Catalog or Campaign", source, d.Kind)
+	}
+	if d.Metadata == nil {
+		d.Metadata = make(map[string]interface{})
+	}
+	if name, ok := d.Metadata["name"].(string); ok {
+		ret.Name = name
+	}
+	if ret.Name == "" {
+		return ret, fmt.Errorf("%s: %s name is missing", source, ret.Kind)
+	}
+	if ns, ok := d.Metadata["namespace"].(string); ok && ns != "" {
+		ret.Namespace = ns
+	} else {
+		ret.Namespace = namespace
+		d.Metadata["namespace"] = namespace
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"metadata": d.Metadata,
+		"spec":     d.Spec,
+	})
+	if err != nil {
+		return ret, err
+	}
+	ret.Payload = payload
+	return ret, nil
+}
+
+// NormalizeKind maps an object type, such as Instance or instances, to the kind of manifests
+func NormalizeKind(objType string) string {
+	return strings.TrimSuffix(strings.ToLower(objType), "s")
+}
+
+func kindOrder(kind string) int {
+	for i, k := range manifestKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(manifestKinds)
+}
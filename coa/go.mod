@@ -12,10 +12,12 @@ require (
 	github.com/google/uuid v1.3.0
 	github.com/microsoft/ApplicationInsights-Go v0.4.4
 	github.com/pkg/errors v0.9.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.8.0
 	github.com/valyala/fasthttp v1.40.0
 	github.com/yalp/jsonpath v0.0.0-20180802001716-5cc68e5049a0
+	go.etcd.io/bbolt v1.3.7
 	go.opentelemetry.io/otel v1.11.1
 	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.11.1
 	go.opentelemetry.io/otel/exporters/zipkin v1.11.1
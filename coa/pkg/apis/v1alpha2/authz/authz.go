@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+// decisions are logged under their own scope, so that they can be collected apart from other logs
+var log = logger.NewLogger("coa.authz")
+
+// Verbs that requests are authorized for
+const (
+	VerbGet       = "get"
+	VerbList      = "list"
+	VerbCreate    = "create"
+	VerbDelete    = "delete"
+	VerbReconcile = "reconcile"
+	VerbEnroll    = "enroll"
+)
+
+// Roles of the default policy
+const (
+	RoleAdministrator = "administrator"
+	RoleReader        = "reader"
+)
+
+// Decision log levels
+const (
+	DecisionLogAll  = "all"
+	DecisionLogDeny = "deny"
+	DecisionLogNone = "none"
+)
+
+// IdentityKey is the context key of the Identity that bindings authenticated a request with
+const IdentityKey = "coa-identity"
+
+// Identity is who makes a request
+type Identity struct {
+	User  string
+	Roles []string
+	// Public requests are on routes that don't require authentication, and aren't authorized
+	Public bool
+	// Claims are the verified claims of the token that the request was authenticated with
+	Claims map[string]interface{}
+}
+
+// Rule grants verbs on resource kinds in namespaces. "*" matches any verb, resource or namespace, and a rule without
+// namespaces applies to all namespaces.
+type Rule struct {
+	Verbs      []string `json:"verbs"`
+	Resources  []string `json:"resources"`
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Route overrides the resource kind and the verb that a route is authorized for
+type Route struct {
+	Resource string `json:"resource,omitempty"`
+	Verb     string `json:"verb,omitempty"`
+}
+
+// Config is the authorization policy of a binding
+type Config struct {
+	// Roles maps role names to the rules they grant
+	Roles map[string][]Rule `json:"roles"`
+	// Routes overrides how requests are mapped to resources and verbs. The keys are routes, such as "solution/reconcile",
+	// optionally prefixed with a method, such as "POST solution/queue".
+	Routes map[string]Route `json:"routes,omitempty"`
+	// DecisionLog is which decisions are logged: all (default), deny or none
+	DecisionLog string `json:"decisionLog,omitempty"`
+}
+
+// Request is what a request asks to do
+type Request struct {
+	Verb      string
+	Resource  string
+	Namespace string
+}
+
+// Decision is the outcome of authorizing a request
+type Decision struct {
+	Allowed bool
+	User    string
+	Roles   []string
+	Request Request
+	// Role is the role that allowed the request
+	Role   string
+	Reason string
+}
+
+// defaultRoutes map the routes whose resource or verb can't be told from their path and method
+var defaultRoutes = map[string]Route{
+	"solution/reconcile":  {Resource: "instances", Verb: VerbReconcile},
+	"solution/plan":       {Resource: "instances", Verb: VerbGet},
+	"GET solution/queue":  {Resource: "instances", Verb: VerbGet},
+	"POST solution/queue": {Resource: "instances", Verb: VerbReconcile},
+	"solution/instances":  {Resource: "instances"},
+	"federation/enroll":   {Resource: "sites", Verb: VerbEnroll},
+}
+
+// Authorizer enforces a Config on the endpoints of a binding
+type Authorizer struct {
+	Config Config
+}
+
+func NewAuthorizer(config Config) (*Authorizer, error) {
+	switch config.DecisionLog {
+	case "":
+		config.DecisionLog = DecisionLogAll
+	case DecisionLogAll, DecisionLogDeny, DecisionLogNone:
+	default:
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid decision log '%s'", config.DecisionLog), v1alpha2.BadConfig)
+	}
+	for role, rules := range config.Roles {
+		for _, rule := range rules {
+			if len(rule.Verbs) == 0 || len(rule.Resources) == 0 {
+				return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("a rule of role '%s' doesn't have verbs or resources", role), v1alpha2.BadConfig)
+			}
+		}
+	}
+	return &Authorizer{Config: config}, nil
+}
+
+// Wrap authorizes the requests to an endpoint before they reach its handler. Denied requests are rejected with
+// Forbidden, and requests whose body namespace differs from the namespace they are authorized for are rejected with
+// BadRequest.
+func (a *Authorizer) Wrap(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler) v1alpha2.COAHandler {
+	return func(request v1alpha2.COARequest) v1alpha2.COAResponse {
+		identity := IdentityFromContext(request.Context)
+		if identity.Public {
+			return handler(request)
+		}
+		if err := checkNamespace(request); err != nil {
+			return v1alpha2.COAResponse{
+				State:       v1alpha2.BadRequest,
+				Body:        []byte(fmt.Sprintf("{\"result\":\"400 - %s\"}", err.Error())),
+				ContentType: "application/json",
+			}
+		}
+		decision := a.Authorize(identity, a.GetRequest(endpoint, request))
+		a.logDecision(decision, request.Route)
+		if !decision.Allowed {
+			return v1alpha2.COAResponse{
+				State:       v1alpha2.Forbidden,
+				Body:        []byte(fmt.Sprintf("{\"result\":\"403 - %s\"}", decision.Reason)),
+				ContentType: "application/json",
+			}
+		}
+		return handler(request)
+	}
+}
+
+// Authorize decides whether any of the roles of the identity grants the request
+func (a *Authorizer) Authorize(identity Identity, request Request) Decision {
+	decision := Decision{
+		User:    identity.User,
+		Roles:   identity.Roles,
+		Request: request,
+	}
+	for _, role := range identity.Roles {
+		for _, rule := range a.Config.Roles[role] {
+			if matches(rule.Verbs, request.Verb) && matches(rule.Resources, request.Resource) && matchesNamespace(rule.Namespaces, request.Namespace) {
+				decision.Allowed = true
+				decision.Role = role
+				decision.Reason = fmt.Sprintf("allowed by role %s", role)
+				return decision
+			}
+		}
+	}
+	if len(identity.Roles) == 0 {
+		decision.Reason = "no roles"
+	} else {
+		decision.Reason = fmt.Sprintf("no role grants %s on %s", request.Verb, request.Resource)
+	}
+	return decision
+}
+
+// GetRequest finds the verb, resource kind and namespace of a request to an endpoint. The resource is the first
+// segment of the route, and the verb follows from the method: GET is get with a name and list without one, POST and
+// PUT are create and DELETE is delete. The namespace is the namespace parameter, as that is the namespace handlers
+// use. A list without a namespace is on all namespaces, and other requests default to the default namespace.
+func (a *Authorizer) GetRequest(endpoint v1alpha2.Endpoint, request v1alpha2.COARequest) Request {
+	ret := Request{
+		Resource: strings.SplitN(endpoint.Route, "/", 2)[0],
+	}
+	switch request.Method {
+	case "GET", "HEAD":
+		ret.Verb = VerbList
+		if request.Parameters["__name"] != "" {
+			ret.Verb = VerbGet
+		}
+	case "POST", "PUT", "PATCH":
+		ret.Verb = VerbCreate
+	case "DELETE":
+		ret.Verb = VerbDelete
+	default:
+		ret.Verb = strings.ToLower(request.Method)
+	}
+	if route, ok := a.getRoute(request.Method, endpoint.Route); ok {
+		if route.Resource != "" {
+			ret.Resource = route.Resource
+		}
+		if route.Verb != "" {
+			ret.Verb = route.Verb
+		}
+	}
+
+	if namespace, ok := request.Parameters["namespace"]; ok {
+		ret.Namespace = namespace
+	} else if ret.Verb != VerbList {
+		ret.Namespace = "default"
+	}
+	return ret
+}
+
+// checkNamespace checks that the namespace in the metadata of the body of a request is the namespace parameter, or
+// the default namespace without one. Requests are authorized for that namespace, which is also where handlers write,
+// so a body that names another namespace would be authorized for one namespace and claim another.
+func checkNamespace(request v1alpha2.COARequest) error {
+	namespace, ok := request.Parameters["namespace"]
+	if !ok {
+		namespace = "default"
+	}
+	if bodyNamespace := getBodyNamespace(request.Body); bodyNamespace != "" && bodyNamespace != namespace {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("namespace '%s' in metadata doesn't match namespace '%s' of the request", bodyNamespace, namespace), v1alpha2.BadRequest)
+	}
+	return nil
+}
+
+func (a *Authorizer) getRoute(method string, route string) (Route, bool) {
+	for _, routes := range []map[string]Route{a.Config.Routes, defaultRoutes} {
+		if r, ok := routes[method+" "+route]; ok {
+			return r, true
+		}
+		if r, ok := routes[route]; ok {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
+
+func (a *Authorizer) logDecision(decision Decision, route string) {
+	if a.Config.DecisionLog == DecisionLogNone || (decision.Allowed && a.Config.DecisionLog == DecisionLogDeny) {
+		return
+	}
+	result := "deny"
+	if decision.Allowed {
+		result = "allow"
+	}
+	namespace := decision.Request.Namespace
+	if namespace == "" {
+		namespace = "*"
+	}
+	log.Infof("decision: %s, user: %s, roles: %v, verb: %s, resource: %s, namespace: %s, route: %s, reason: %s",
+		result, decision.User, decision.Roles, decision.Request.Verb, decision.Request.Resource, namespace, route, decision.Reason)
+}
+
+// IdentityFromContext returns the identity that a binding stored in the context of a request
+func IdentityFromContext(ctx context.Context) Identity {
+	if ctx == nil {
+		return Identity{}
+	}
+	if identity, ok := ctx.Value(IdentityKey).(Identity); ok {
+		return identity
+	}
+	return Identity{}
+}
+
+// WithIdentity returns a context that carries the identity of a request
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, IdentityKey, identity)
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNamespace checks whether a rule applies to a namespace. An empty namespace stands for all namespaces, which
+// only rules on all namespaces apply to.
+func matchesNamespace(namespaces []string, namespace string) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+	if namespace == "" {
+		return matches(namespaces, "*")
+	}
+	return matches(namespaces, namespace)
+}
+
+func getBodyNamespace(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var object struct {
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return ""
+	}
+	return object.Metadata.Namespace
+}
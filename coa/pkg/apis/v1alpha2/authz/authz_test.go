@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() Config {
+	return Config{
+		Roles: map[string][]Rule{
+			"administrator": {
+				{Verbs: []string{"*"}, Resources: []string{"*"}},
+			},
+			"reader": {
+				{Verbs: []string{VerbGet, VerbList}, Resources: []string{"*"}, Namespaces: []string{"*"}},
+			},
+			"operator": {
+				{Verbs: []string{VerbGet, VerbReconcile}, Resources: []string{"instances"}, Namespaces: []string{"team-a"}},
+			},
+		},
+	}
+}
+
+func testEndpoint(route string, methods ...string) v1alpha2.Endpoint {
+	return v1alpha2.Endpoint{
+		Route:      route,
+		Methods:    methods,
+		Parameters: []string{"name?"},
+		Handler: func(request v1alpha2.COARequest) v1alpha2.COAResponse {
+			return v1alpha2.COAResponse{State: v1alpha2.OK}
+		},
+	}
+}
+
+func testRequest(identity Identity, method string, params map[string]string, body string) v1alpha2.COARequest {
+	return v1alpha2.COARequest{
+		Context:    WithIdentity(context.Background(), identity),
+		Method:     method,
+		Parameters: params,
+		Body:       []byte(body),
+	}
+}
+
+func TestNewAuthorizerInvalidDecisionLog(t *testing.T) {
+	_, err := NewAuthorizer(Config{DecisionLog: "some"})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestNewAuthorizerEmptyRule(t *testing.T) {
+	_, err := NewAuthorizer(Config{Roles: map[string][]Rule{"reader": {{Resources: []string{"*"}}}}})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestNewAuthorizerDefaultDecisionLog(t *testing.T) {
+	a, err := NewAuthorizer(testConfig())
+	assert.Nil(t, err)
+	assert.Equal(t, DecisionLogAll, a.Config.DecisionLog)
+}
+
+func TestGetRequestVerbs(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	endpoint := testEndpoint("solutions", "GET", "POST", "DELETE")
+	assert.Equal(t, Request{Verb: VerbGet, Resource: "solutions", Namespace: "default"},
+		a.GetRequest(endpoint, testRequest(Identity{}, "GET", map[string]string{"__name": "s1"}, "")))
+	assert.Equal(t, Request{Verb: VerbList, Resource: "solutions"},
+		a.GetRequest(endpoint, testRequest(Identity{}, "GET", map[string]string{"__name": ""}, "")))
+	assert.Equal(t, Request{Verb: VerbCreate, Resource: "solutions", Namespace: "default"},
+		a.GetRequest(endpoint, testRequest(Identity{}, "POST", map[string]string{"__name": "s1"}, "")))
+	assert.Equal(t, Request{Verb: VerbDelete, Resource: "solutions", Namespace: "team-a"},
+		a.GetRequest(endpoint, testRequest(Identity{}, "DELETE", map[string]string{"__name": "s1", "namespace": "team-a"}, "")))
+}
+
+func TestGetRequestIgnoresBodyNamespace(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	request := a.GetRequest(testEndpoint("targets/registry", "POST"),
+		testRequest(Identity{}, "POST", map[string]string{"__name": "t1"}, `{"metadata":{"name":"t1","namespace":"team-b"}}`))
+	assert.Equal(t, Request{Verb: VerbCreate, Resource: "targets", Namespace: "default"}, request)
+}
+
+func TestGetRequestDefaultRoutes(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	assert.Equal(t, Request{Verb: VerbReconcile, Resource: "instances", Namespace: "default"},
+		a.GetRequest(testEndpoint("solution/reconcile", "POST"), testRequest(Identity{}, "POST", map[string]string{}, "")))
+	assert.Equal(t, Request{Verb: VerbReconcile, Resource: "instances", Namespace: "default"},
+		a.GetRequest(testEndpoint("solution/queue", "GET", "POST"), testRequest(Identity{}, "POST", map[string]string{}, "")))
+	assert.Equal(t, Request{Verb: VerbGet, Resource: "instances", Namespace: "default"},
+		a.GetRequest(testEndpoint("solution/queue", "GET", "POST"), testRequest(Identity{}, "GET", map[string]string{}, "")))
+	assert.Equal(t, Request{Verb: VerbEnroll, Resource: "sites", Namespace: "default"},
+		a.GetRequest(testEndpoint("federation/enroll", "POST", "DELETE"), testRequest(Identity{}, "DELETE", map[string]string{"__name": "child1"}, "")))
+}
+
+func TestGetRequestConfiguredRoutes(t *testing.T) {
+	config := testConfig()
+	config.Routes = map[string]Route{
+		"POST catalogs/check": {Verb: VerbGet},
+	}
+	a, _ := NewAuthorizer(config)
+	assert.Equal(t, Request{Verb: VerbGet, Resource: "catalogs", Namespace: "default"},
+		a.GetRequest(testEndpoint("catalogs/check", "POST"), testRequest(Identity{}, "POST", map[string]string{}, "")))
+}
+
+func TestAuthorize(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	admin := Identity{User: "admin", Roles: []string{"administrator"}}
+	reader := Identity{User: "reader", Roles: []string{"reader"}}
+	operator := Identity{User: "operator", Roles: []string{"operator"}}
+
+	decision := a.Authorize(admin, Request{Verb: VerbDelete, Resource: "campaigns", Namespace: "team-a"})
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, "administrator", decision.Role)
+
+	assert.True(t, a.Authorize(reader, Request{Verb: VerbList, Resource: "solutions"}).Allowed)
+	assert.False(t, a.Authorize(reader, Request{Verb: VerbCreate, Resource: "solutions", Namespace: "default"}).Allowed)
+
+	assert.True(t, a.Authorize(operator, Request{Verb: VerbReconcile, Resource: "instances", Namespace: "team-a"}).Allowed)
+	assert.False(t, a.Authorize(operator, Request{Verb: VerbReconcile, Resource: "instances", Namespace: "team-b"}).Allowed)
+	// a list on all namespaces needs a rule on all namespaces
+	assert.False(t, a.Authorize(operator, Request{Verb: VerbList, Resource: "instances"}).Allowed)
+
+	decision = a.Authorize(Identity{User: "nobody"}, Request{Verb: VerbGet, Resource: "solutions", Namespace: "default"})
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "no roles", decision.Reason)
+}
+
+func TestWrap(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	endpoint := testEndpoint("instances", "GET", "POST")
+	handler := a.Wrap(endpoint, endpoint.Handler)
+
+	response := handler(testRequest(Identity{User: "operator", Roles: []string{"operator"}}, "GET", map[string]string{"__name": "i1", "namespace": "team-a"}, ""))
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	response = handler(testRequest(Identity{User: "operator", Roles: []string{"operator"}}, "POST", map[string]string{"__name": "i1", "namespace": "team-a"}, ""))
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+	assert.Contains(t, string(response.Body), "no role grants create on instances")
+}
+
+func TestWrapNamespaceMismatch(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	endpoint := testEndpoint("instances", "POST")
+	handler := a.Wrap(endpoint, endpoint.Handler)
+	identity := Identity{User: "admin", Roles: []string{"administrator"}}
+
+	response := handler(testRequest(identity, "POST", map[string]string{"__name": "i1", "namespace": "team-a"}, `{"metadata":{"name":"i1","namespace":"team-b"}}`))
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+	assert.Contains(t, string(response.Body), "namespace 'team-b' in metadata doesn't match namespace 'team-a'")
+
+	response = handler(testRequest(identity, "POST", map[string]string{"__name": "i1", "namespace": "team-b"}, `{"metadata":{"name":"i1","namespace":"team-b"}}`))
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	// without a namespace parameter, handlers write to the default namespace
+	response = handler(testRequest(identity, "POST", map[string]string{"__name": "i1"}, `{"metadata":{"name":"i1","namespace":"team-b"}}`))
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+	response = handler(testRequest(identity, "POST", map[string]string{"__name": "i1"}, `{"metadata":{"name":"i1","namespace":"default"}}`))
+	assert.Equal(t, v1alpha2.OK, response.State)
+	response = handler(testRequest(identity, "POST", map[string]string{"__name": "i1"}, `{"metadata":{"name":"i1"}}`))
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestWrapPublic(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	endpoint := testEndpoint("greetings", "GET")
+	handler := a.Wrap(endpoint, endpoint.Handler)
+	response := handler(testRequest(Identity{Public: true}, "GET", map[string]string{}, ""))
+	assert.Equal(t, v1alpha2.OK, response.State)
+}
+
+func TestWrapWithoutIdentity(t *testing.T) {
+	a, _ := NewAuthorizer(testConfig())
+	endpoint := testEndpoint("solutions", "GET")
+	handler := a.Wrap(endpoint, endpoint.Handler)
+	response := handler(v1alpha2.COARequest{Context: context.Background(), Method: "GET", Parameters: map[string]string{}})
+	assert.Equal(t, v1alpha2.Forbidden, response.State)
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs"
 	autogen "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs/autogen"
@@ -38,15 +39,25 @@ type HttpBindingConfig struct {
 	Pipeline     []MiddlewareConfig `json:"pipeline"`
 	TLS          bool               `json:"tls"`
 	CertProvider CertProviderConfig `json:"certProvider"`
+	// Authorization enforces role-based policies on the endpoints. Requests aren't authorized when it's not set.
+	Authorization *authz.Config `json:"authorization,omitempty"`
 }
 
 // HttpBinding provides service endpoints as a fasthttp web server
 type HttpBinding struct {
 	CertProvider certs.ICertProvider
+	Authorizer   *authz.Authorizer
 }
 
 // Launch fasthttp server
 func (h *HttpBinding) Launch(config HttpBindingConfig, endpoints []v1alpha2.Endpoint, pubsubProvider pubsub.IPubSubProvider) error {
+	if config.Authorization != nil {
+		authorizer, err := authz.NewAuthorizer(*config.Authorization)
+		if err != nil {
+			return err
+		}
+		h.Authorizer = authorizer
+	}
 	handler := h.useRouter(endpoints)
 
 	pipeline, err := BuildPipeline(config, pubsubProvider)
@@ -91,8 +102,12 @@ func (h *HttpBinding) getRouter(endpoints []v1alpha2.Endpoint) *routing.Router {
 		for _, p := range e.Parameters {
 			path += "/{" + p + "}"
 		}
+		handler := e.Handler
+		if h.Authorizer != nil {
+			handler = h.Authorizer.Wrap(e, handler)
+		}
 		for _, m := range e.Methods {
-			router.Handle(m, path, wrapAsHTTPHandler(e, e.Handler))
+			router.Handle(m, path, wrapAsHTTPHandler(e, handler))
 		}
 	}
 	return router
@@ -142,7 +157,12 @@ func wrapAsHTTPHandler(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler)
 				reqCtx.Response.Header.Set(v1alpha2.COAMetaHeader, string(data))
 			}
 			reqCtx.SetContentType(resp.ContentType)
-			reqCtx.SetBody(resp.Body)
+			if endpoint.Compress && len(resp.Body) > 0 && reqCtx.Request.Header.HasAcceptEncoding("gzip") {
+				reqCtx.Response.Header.Set(fasthttp.HeaderContentEncoding, "gzip")
+				reqCtx.SetBody(fasthttp.AppendGzipBytes(nil, resp.Body))
+			} else {
+				reqCtx.SetBody(resp.Body)
+			}
 			reqCtx.SetStatusCode(int(resp.State))
 		}
 	}
@@ -56,6 +56,9 @@ func BuildPipeline(config HttpBindingConfig, pubsubProvider pubsub.IPubSubProvid
 			if jwts.AuthHeader == "" {
 				jwts.AuthHeader = "Authorization"
 			}
+			if err := jwts.initIssuers(); err != nil {
+				return ret, err
+			}
 			ret.Handlers = append(ret.Handlers, jwts.JWT)
 		case "middleware.http.tracing":
 			tracing := Tracing{
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	observability "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	autogen "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs/autogen"
@@ -423,3 +424,89 @@ func TestHTTPEchoWithPipeline(t *testing.T) {
 
 	time.Sleep(5 * time.Second) // wait for telemetry to send data
 }
+
+func TestHTTPRouterWithAuthorization(t *testing.T) {
+	authorizer, err := authz.NewAuthorizer(authz.Config{
+		Roles: map[string][]authz.Rule{
+			"reader": {{Verbs: []string{authz.VerbGet, authz.VerbList}, Resources: []string{"solutions"}}},
+		},
+	})
+	assert.Nil(t, err)
+	binding := HttpBinding{Authorizer: authorizer}
+	router := binding.getRouter([]v1alpha2.Endpoint{
+		{
+			Methods:    []string{"GET", "POST"},
+			Route:      "solutions",
+			Version:    "v1",
+			Parameters: []string{"name?"},
+			Handler: func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+				return v1alpha2.COAResponse{
+					State: v1alpha2.OK,
+					Body:  []byte("Hi there!!"),
+				}
+			},
+		},
+	})
+
+	send := func(method string) int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI("/v1/solutions/s1")
+		ctx.SetUserValue(authz.IdentityKey, authz.Identity{User: "reader", Roles: []string{"reader"}})
+		router.Handler(ctx)
+		return ctx.Response.StatusCode()
+	}
+	assert.Equal(t, 200, send(fasthttp.MethodGet))
+	assert.Equal(t, 403, send(fasthttp.MethodPost))
+}
+
+func TestHTTPRouterCompress(t *testing.T) {
+	binding := HttpBinding{}
+	body := bytes.Repeat([]byte("Hi there!!"), 100)
+	handler := func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+		return v1alpha2.COAResponse{
+			State: v1alpha2.OK,
+			Body:  body,
+		}
+	}
+	router := binding.getRouter([]v1alpha2.Endpoint{
+		{
+			Methods:  []string{"GET"},
+			Route:    "sync",
+			Version:  "v1",
+			Handler:  handler,
+			Compress: true,
+		},
+		{
+			Methods: []string{"GET"},
+			Route:   "greetings",
+			Version: "v1",
+			Handler: handler,
+		},
+	})
+
+	send := func(uri string, acceptEncoding string) *fasthttp.Response {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+		ctx.Request.SetRequestURI(uri)
+		if acceptEncoding != "" {
+			ctx.Request.Header.Set(fasthttp.HeaderAcceptEncoding, acceptEncoding)
+		}
+		router.Handler(ctx)
+		return &ctx.Response
+	}
+	resp := send("/v1/sync", "gzip, deflate")
+	assert.Equal(t, "gzip", string(resp.Header.Peek(fasthttp.HeaderContentEncoding)))
+	assert.Less(t, len(resp.Body()), len(body))
+	data, err := resp.BodyGunzip()
+	assert.Nil(t, err)
+	assert.Equal(t, body, data)
+
+	// the response isn't compressed for clients that don't accept gzip, or on endpoints that don't compress
+	resp = send("/v1/sync", "")
+	assert.Equal(t, "", string(resp.Header.Peek(fasthttp.HeaderContentEncoding)))
+	assert.Equal(t, body, resp.Body())
+	resp = send("/v1/greetings", "gzip")
+	assert.Equal(t, "", string(resp.Header.Peek(fasthttp.HeaderContentEncoding)))
+	assert.Equal(t, body, resp.Body())
+}
@@ -11,8 +11,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/valyala/fasthttp"
 )
@@ -27,7 +29,19 @@ type JWT struct {
 	Roles       []ClaimRoleMap    `json:"roles,omitempty"`
 	EnableRBAC  bool              `json:"enableRBAC,omitempty"`
 	Policy      map[string]Policy `json:"policy,omitempty"`
+	// Issuers are the OpenID Connect issuers whose tokens are verified with the keys they publish
+	Issuers []TrustedIssuer `json:"issuers,omitempty"`
+	// ClockSkew is how far the exp, nbf and iat claims of issuer tokens may be off, such as 1m (default)
+	ClockSkew string `json:"clockSkew,omitempty"`
+	// SitePaths are the path prefixes on which tokens with a site claim, which the federation vendor issues to the
+	// sites it enrolls, are accepted. It defaults to the federation endpoints.
+	SitePaths []string `json:"sitePaths,omitempty"`
+	clockSkew time.Duration
+	keySets   map[string]*keySet
 }
+
+var defaultSitePaths = []string{"/v1alpha2/federation/"}
+
 type ClaimRoleMap struct {
 	Role  string `json:"role"`
 	Claim string `json:"claim"`
@@ -42,6 +56,7 @@ func (j JWT) JWT(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		if j.IgnorePaths != nil {
 			for _, p := range j.IgnorePaths {
 				if p == string(ctx.Path()) {
+					ctx.SetUserValue(authz.IdentityKey, authz.Identity{Public: true})
 					next(ctx)
 					return
 				}
@@ -55,10 +70,14 @@ func (j JWT) JWT(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		if tokenStr == "" {
 			ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
 		} else {
-			_, roles, err := j.validateToken(tokenStr)
+			claims, roles, err := j.validateToken(tokenStr)
 			if err != nil {
 				ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
+			} else if _, ok := claims["site"]; ok && !j.isSitePath(string(ctx.Path())) {
+				// site tokens are signed with the same key as user tokens, but only sites' own endpoints accept them
+				ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
 			} else {
+				ctx.SetUserValue(authz.IdentityKey, authz.Identity{User: getUser(claims), Roles: roles, Claims: claims})
 				if j.EnableRBAC {
 					path := string(ctx.Path())
 					method := string(ctx.Method())
@@ -82,6 +101,18 @@ func (j JWT) JWT(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		}
 	}
 }
+func (j JWT) isSitePath(path string) bool {
+	paths := j.SitePaths
+	if len(paths) == 0 {
+		paths = defaultSitePaths
+	}
+	for _, p := range paths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
 func (j JWT) readAuthHeader(ctx *fasthttp.RequestCtx) string {
 	v := ctx.Request.Header.Peek(j.AuthHeader)
 	if v != nil {
@@ -98,6 +129,22 @@ func (j JWT) readAuthHeader(ctx *fasthttp.RequestCtx) string {
 func (j *JWT) validateToken(tokenStr string) (map[string]interface{}, []string, error) {
 	ret := make(map[string]interface{})
 	claims := jwt.MapClaims{}
+	if len(j.Issuers) > 0 {
+		set, err := j.getIssuerKeySet(tokenStr)
+		if err != nil {
+			return ret, nil, err
+		}
+		if set != nil {
+			claims, err = j.parseIssuerToken(tokenStr, set)
+			if err != nil {
+				return ret, nil, err
+			}
+			return j.validateClaims(claims)
+		}
+		if j.VerifyKey == "" {
+			return ret, nil, errors.New("token is not issued by a trusted issuer")
+		}
+	}
 	token, err := jwt.ParseWithClaims(
 		tokenStr,
 		claims,
@@ -124,6 +171,12 @@ func (j *JWT) validateToken(tokenStr string) (map[string]interface{}, []string,
 	if !token.Valid {
 		return ret, nil, errors.New("invalid token")
 	}
+	return j.validateClaims(claims)
+}
+
+// validateClaims checks the required claims of a verified token and maps its claims to roles
+func (j *JWT) validateClaims(claims jwt.MapClaims) (map[string]interface{}, []string, error) {
+	ret := make(map[string]interface{})
 	for k, v := range claims {
 		ret[k] = v
 	}
@@ -146,7 +199,7 @@ func (j *JWT) validateToken(tokenStr string) (map[string]interface{}, []string,
 		}
 	}
 	var roles []string
-	if j.EnableRBAC {
+	if j.EnableRBAC || len(j.Roles) > 0 {
 		roles = make([]string, 0)
 		for _, m := range j.Roles {
 			if v, ok := ret[m.Claim]; ok {
@@ -159,3 +212,13 @@ func (j *JWT) validateToken(tokenStr string) (map[string]interface{}, []string,
 	}
 	return ret, roles, nil
 }
+
+// getUser returns the user that a token is issued to
+func getUser(claims map[string]interface{}) string {
+	for _, k := range []string{"user", "sub"} {
+		if v, ok := claims[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -14,8 +14,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	jwt "github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
 )
 
 func generateJWTToken(signingKey interface{}, method jwt.SigningMethod, userName string, expiresAt time.Time, issuedAt time.Time, notAfter time.Time, issuer string, subject string, audiences []string) (string, error) {
@@ -142,3 +144,81 @@ func TestValidateWithTokenWithCert(t *testing.T) {
 	_, _, err = j.validateToken(token)
 	assert.Nil(t, err)
 }
+
+func TestJWTSetsIdentity(t *testing.T) {
+	j := JWT{
+		AuthHeader: "Authorization",
+		VerifyKey:  "test",
+		Roles: []ClaimRoleMap{
+			{Role: "reader", Claim: "user", Value: "*"},
+			{Role: "administrator", Claim: "user", Value: "admin"},
+		},
+	}
+	token, err := generateJWTToken([]byte("test"), jwt.SigningMethodHS256, "admin", time.Now().Add(time.Hour), time.Now(), time.Now(), "test", "test", []string{"test"})
+	assert.Nil(t, err)
+
+	var identity authz.Identity
+	handler := j.JWT(func(ctx *fasthttp.RequestCtx) {
+		identity = authz.IdentityFromContext(ctx)
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	handler(ctx)
+	assert.Equal(t, "admin", identity.User)
+	assert.Equal(t, []string{"reader", "administrator"}, identity.Roles)
+	assert.Equal(t, "admin", identity.Claims["user"])
+}
+
+func TestJWTSetsPublicIdentity(t *testing.T) {
+	j := JWT{
+		AuthHeader:  "Authorization",
+		VerifyKey:   "test",
+		IgnorePaths: []string{"/v1alpha2/greetings"},
+	}
+	var identity authz.Identity
+	handler := j.JWT(func(ctx *fasthttp.RequestCtx) {
+		identity = authz.IdentityFromContext(ctx)
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/v1alpha2/greetings")
+	handler(ctx)
+	assert.True(t, identity.Public)
+}
+
+func TestJWTSiteTokenOnlyOnSitePaths(t *testing.T) {
+	j := JWT{
+		AuthHeader: "Authorization",
+		VerifyKey:  "test",
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"site": "child1", "sub": "site:child1"}).SignedString([]byte("test"))
+	assert.Nil(t, err)
+	called := false
+	handler := j.JWT(func(ctx *fasthttp.RequestCtx) {
+		called = true
+	})
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/v1alpha2/solutions")
+	ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	handler(ctx)
+	assert.False(t, called)
+	assert.Equal(t, fasthttp.StatusForbidden, ctx.Response.StatusCode())
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/v1alpha2/federation/sync/child1")
+	ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	handler(ctx)
+	assert.True(t, called)
+
+	// other paths can be configured
+	j.SitePaths = []string{"/v1alpha2/sites/"}
+	called = false
+	handler = j.JWT(func(ctx *fasthttp.RequestCtx) {
+		called = true
+	})
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/v1alpha2/federation/sync/child1")
+	ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	handler(ctx)
+	assert.False(t, called)
+}
@@ -0,0 +1,357 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	defaultClockSkew       = time.Minute
+	defaultRefreshInterval = time.Hour
+)
+
+// minKeyRefetchInterval limits how often the keys of an issuer are fetched again, after a failed fetch or when a token
+// is signed with an unknown key, so that tokens with made-up key IDs can't flood the issuer
+var minKeyRefetchInterval = 30 * time.Second
+
+// issuer tokens are only accepted with asymmetric signatures, so that a public key can't be used as a shared secret
+var issuerSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// TrustedIssuer is an OpenID Connect issuer whose tokens are accepted
+type TrustedIssuer struct {
+	// Issuer is the issuer identifier, which tokens carry as their iss claim
+	Issuer string `json:"issuer"`
+	// Audiences are the accepted aud claims, of which tokens must have one. At least one is required, as tokens that
+	// the issuer signs for other applications must not be accepted.
+	Audiences []string `json:"audiences"`
+	// JWKSURI is where the keys of the issuer are fetched from. It's discovered from the OpenID configuration of the
+	// issuer when it's empty.
+	JWKSURI string `json:"jwksUri,omitempty"`
+	// RefreshInterval is how long the keys are cached, such as 1h (default)
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+type openIDConfiguration struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// keySet caches the signing keys of an issuer by key ID
+type keySet struct {
+	issuer          TrustedIssuer
+	refreshInterval time.Duration
+	client          *http.Client
+	lock            sync.Mutex
+	jwksURI         string
+	keys            map[string]interface{}
+	fetched         time.Time
+	// attempted is when the keys were last fetched, whether or not that succeeded, and fetchErr is how it failed
+	attempted time.Time
+	fetchErr  error
+	// inflight is closed when the fetch that is in progress is done
+	inflight chan struct{}
+}
+
+func newKeySet(issuer TrustedIssuer) (*keySet, error) {
+	if issuer.Issuer == "" {
+		return nil, v1alpha2.NewCOAError(nil, "trusted issuer doesn't have an issuer", v1alpha2.BadConfig)
+	}
+	if len(issuer.Audiences) == 0 {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("trusted issuer '%s' doesn't have audiences", issuer.Issuer), v1alpha2.BadConfig)
+	}
+	ret := &keySet{
+		issuer:          issuer,
+		refreshInterval: defaultRefreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		jwksURI:         issuer.JWKSURI,
+	}
+	if issuer.RefreshInterval != "" {
+		d, err := time.ParseDuration(issuer.RefreshInterval)
+		if err != nil || d <= 0 {
+			return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid refresh interval '%s' of issuer '%s'", issuer.RefreshInterval, issuer.Issuer), v1alpha2.BadConfig)
+		}
+		ret.refreshInterval = d
+	}
+	return ret, nil
+}
+
+// getKey returns the key with a key ID. The keys are fetched again when they are older than the refresh interval, or
+// when the key isn't known, as the issuer may have rotated its keys. The cached keys are still used when fetching
+// them again fails.
+func (k *keySet) getKey(kid string) (interface{}, error) {
+	k.lock.Lock()
+	stale := k.keys == nil || time.Since(k.fetched) >= k.refreshInterval
+	k.lock.Unlock()
+	if stale {
+		if err := k.refresh(); err != nil && !k.hasKeys() {
+			return nil, err
+		}
+	}
+	if key, ok := k.findKey(kid); ok {
+		return key, nil
+	}
+	if err := k.refresh(); err == nil {
+		if key, ok := k.findKey(kid); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key '%s' of issuer '%s' is not found", kid, k.issuer.Issuer)
+}
+
+// refresh fetches the keys again, unless they were attempted less than minKeyRefetchInterval ago, in which case the
+// outcome of that attempt is returned. Callers that refresh while a fetch is in progress wait for it instead of
+// fetching again. The lock isn't held while fetching.
+func (k *keySet) refresh() error {
+	k.lock.Lock()
+	if inflight := k.inflight; inflight != nil {
+		k.lock.Unlock()
+		<-inflight
+		k.lock.Lock()
+		defer k.lock.Unlock()
+		return k.fetchErr
+	}
+	if !k.attempted.IsZero() && time.Since(k.attempted) < minKeyRefetchInterval {
+		defer k.lock.Unlock()
+		return k.fetchErr
+	}
+	inflight := make(chan struct{})
+	k.inflight = inflight
+	k.attempted = time.Now()
+	jwksURI := k.jwksURI
+	k.lock.Unlock()
+
+	keys, jwksURI, err := k.fetch(jwksURI)
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err != nil {
+		log.Errorf("failed to fetch the keys of issuer '%s': %s", k.issuer.Issuer, err.Error())
+	} else {
+		k.keys = keys
+		k.fetched = time.Now()
+		k.jwksURI = jwksURI
+	}
+	k.fetchErr = err
+	k.inflight = nil
+	close(inflight)
+	return err
+}
+
+func (k *keySet) hasKeys() bool {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	return k.keys != nil
+}
+
+// findKey looks a key up by ID. A token without a key ID can only be verified when the issuer has a single key.
+func (k *keySet) findKey(kid string) (interface{}, bool) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// fetch gets the keys from the JWKS URI, which is discovered from the OpenID configuration of the issuer when it's
+// empty, and returns them with the JWKS URI
+func (k *keySet) fetch(jwksURI string) (map[string]interface{}, string, error) {
+	if jwksURI == "" {
+		var config openIDConfiguration
+		if err := k.getJSON(strings.TrimSuffix(k.issuer.Issuer, "/")+"/.well-known/openid-configuration", &config); err != nil {
+			return nil, "", err
+		}
+		if config.Issuer != k.issuer.Issuer {
+			return nil, "", fmt.Errorf("openid configuration of issuer '%s' is for issuer '%s'", k.issuer.Issuer, config.Issuer)
+		}
+		if config.JWKSURI == "" {
+			return nil, "", fmt.Errorf("openid configuration of issuer '%s' doesn't have a jwks_uri", k.issuer.Issuer)
+		}
+		jwksURI = config.JWKSURI
+	}
+	var set jsonWebKeySet
+	if err := k.getJSON(jwksURI, &set); err != nil {
+		return nil, "", err
+	}
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJSONWebKey(jwk)
+		if err != nil {
+			log.Errorf("failed to parse key '%s' of issuer '%s': %s", jwk.Kid, k.issuer.Issuer, err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, jwksURI, nil
+}
+
+func (k *keySet) getJSON(url string, v interface{}) error {
+	resp, err := k.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to get '%s': %s", url, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get '%s': status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode '%s': %s", url, err.Error())
+	}
+	return nil
+}
+
+func parseJSONWebKey(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("curve '%s' is not supported", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("key type '%s' is not supported", jwk.Kty)
+	}
+}
+
+// initIssuers sets up the key sets of the trusted issuers and the clock skew. It's called once when the handler is
+// created, before it serves requests, as the handler doesn't guard the key sets against concurrent setup.
+func (j *JWT) initIssuers() error {
+	j.clockSkew = defaultClockSkew
+	if j.ClockSkew != "" {
+		d, err := time.ParseDuration(j.ClockSkew)
+		if err != nil || d < 0 {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid clock skew '%s'", j.ClockSkew), v1alpha2.BadConfig)
+		}
+		j.clockSkew = d
+	}
+	j.keySets = make(map[string]*keySet)
+	for _, issuer := range j.Issuers {
+		set, err := newKeySet(issuer)
+		if err != nil {
+			return err
+		}
+		j.keySets[issuer.Issuer] = set
+	}
+	return nil
+}
+
+// getIssuerKeySet returns the key set of the trusted issuer of a token, if any
+func (j *JWT) getIssuerKeySet(tokenStr string) (*keySet, error) {
+	if j.keySets == nil {
+		return nil, errors.New("trusted issuers are not initialized")
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return nil, err
+	}
+	issuer, _ := claims["iss"].(string)
+	return j.keySets[issuer], nil
+}
+
+// parseIssuerToken verifies a token of a trusted issuer with the issuer's keys, and checks its iss, aud, exp, nbf and
+// iat claims. Times are allowed to be off by the clock skew.
+func (j *JWT) parseIssuerToken(tokenStr string, set *keySet) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(issuerSigningMethods), jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return set.getKey(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	now := time.Now()
+	if !claims.VerifyIssuer(set.issuer.Issuer, true) {
+		return nil, errors.New("token has an invalid issuer")
+	}
+	if !claims.VerifyExpiresAt(now.Add(-j.clockSkew).Unix(), true) {
+		return nil, errors.New("token is expired")
+	}
+	if !claims.VerifyNotBefore(now.Add(j.clockSkew).Unix(), false) {
+		return nil, errors.New("token is not valid yet")
+	}
+	if !claims.VerifyIssuedAt(now.Add(j.clockSkew).Unix(), false) {
+		return nil, errors.New("token is used before issued")
+	}
+	for _, audience := range set.issuer.Audiences {
+		if claims.VerifyAudience(audience, true) {
+			return claims, nil
+		}
+	}
+	return nil, errors.New("token has an invalid audience")
+}
@@ -0,0 +1,296 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1alpha2 "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/bindings/http/testissuer"
+	jwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIssuer(t *testing.T) *testissuer.Issuer {
+	issuer, err := testissuer.New()
+	assert.Nil(t, err)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func TestIssuerTokenWithDiscovery(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+		Roles:   []ClaimRoleMap{{Role: "administrator", Claim: "user", Value: "admin"}},
+	}
+	assert.Nil(t, j.initIssuers())
+
+	token, err := issuer.Issue(jwt.MapClaims{"user": "admin", "aud": "symphony"})
+	assert.Nil(t, err)
+	claims, roles, err := j.validateToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", claims["user"])
+	assert.Equal(t, []string{"administrator"}, roles)
+
+	// the keys are cached
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, issuer.JWKSRequests())
+}
+
+func TestIssuerTokenWrongAudience(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), JWKSURI: issuer.JWKSURI(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"aud": []string{"other"}})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "token has an invalid audience")
+
+	token, err = issuer.Issue(jwt.MapClaims{})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "token has an invalid audience")
+}
+
+func TestIssuerTokenWithoutInit(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "trusted issuers are not initialized")
+}
+
+func TestIssuerTokenClockSkew(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers:   []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+		ClockSkew: "2m",
+	}
+	assert.Nil(t, j.initIssuers())
+
+	token, err := issuer.Issue(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix(), "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+
+	token, err = issuer.Issue(jwt.MapClaims{"exp": time.Now().Add(-5 * time.Minute).Unix(), "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "token is expired")
+
+	token, err = issuer.Issue(jwt.MapClaims{"nbf": time.Now().Add(time.Minute).Unix(), "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+
+	token, err = issuer.Issue(jwt.MapClaims{"nbf": time.Now().Add(5 * time.Minute).Unix(), "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "token is not valid yet")
+}
+
+func TestIssuerTokenWithoutExpiry(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"exp": nil, "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+}
+
+func TestIssuerKeyRotation(t *testing.T) {
+	interval := minKeyRefetchInterval
+	minKeyRefetchInterval = 0
+	t.Cleanup(func() { minKeyRefetchInterval = interval })
+
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	oldToken, err := issuer.Issue(jwt.MapClaims{"user": "admin", "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(oldToken)
+	assert.Nil(t, err)
+
+	// a token signed with a new key makes the keys to be fetched again
+	assert.Nil(t, issuer.Rotate(false))
+	newToken, err := issuer.Issue(jwt.MapClaims{"user": "admin", "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(newToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, issuer.JWKSRequests())
+
+	// the retired key is gone
+	_, _, err = j.validateToken(oldToken)
+	assert.NotNil(t, err)
+}
+
+func TestIssuerKeyRefetchIsLimited(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+
+	assert.Nil(t, issuer.Rotate(true))
+	token, err = issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, issuer.JWKSRequests())
+}
+
+func TestIssuerKeysAreFetchedOnce(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := j.validateToken(token)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, issuer.JWKSRequests())
+}
+
+func TestIssuerKeysAreKeptWhenRefreshFails(t *testing.T) {
+	interval := minKeyRefetchInterval
+	minKeyRefetchInterval = 0
+	t.Cleanup(func() { minKeyRefetchInterval = interval })
+
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), JWKSURI: issuer.JWKSURI(), RefreshInterval: "1ms", Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+
+	issuer.Close()
+	time.Sleep(10 * time.Millisecond)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+}
+
+func TestIssuerKeyFetchBacksOffAfterFailure(t *testing.T) {
+	var requests int32
+	keys := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer keys.Close()
+	issuer := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer.URL(), JWKSURI: keys.URL, Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestMultipleIssuers(t *testing.T) {
+	issuer1 := newTestIssuer(t)
+	issuer2 := newTestIssuer(t)
+	untrusted := newTestIssuer(t)
+	j := JWT{
+		Issuers: []TrustedIssuer{{Issuer: issuer1.URL(), Audiences: []string{"symphony"}}, {Issuer: issuer2.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	for _, issuer := range []*testissuer.Issuer{issuer1, issuer2} {
+		token, err := issuer.Issue(jwt.MapClaims{"aud": "symphony"})
+		assert.Nil(t, err)
+		_, _, err = j.validateToken(token)
+		assert.Nil(t, err)
+	}
+	token, err := untrusted.Issue(jwt.MapClaims{"aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.EqualError(t, err, "token is not issued by a trusted issuer")
+
+	// a token that claims a trusted issuer, but is signed with another issuer's key, is rejected
+	token, err = untrusted.Issue(jwt.MapClaims{"iss": issuer1.URL(), "aud": "symphony"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+}
+
+func TestIssuerTokenWithSharedSecretIsRejected(t *testing.T) {
+	issuer := newTestIssuer(t)
+	j := JWT{
+		VerifyKey: "test",
+		Issuers:   []TrustedIssuer{{Issuer: issuer.URL(), Audiences: []string{"symphony"}}},
+	}
+	assert.Nil(t, j.initIssuers())
+	token, err := generateJWTToken([]byte("test"), jwt.SigningMethodHS256, "admin", time.Now().Add(time.Hour), time.Now(), time.Now(), issuer.URL(), "test", []string{"test"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.NotNil(t, err)
+
+	// tokens of other issuers are still verified with the verify key
+	token, err = generateJWTToken([]byte("test"), jwt.SigningMethodHS256, "admin", time.Now().Add(time.Hour), time.Now(), time.Now(), "symphony", "test", []string{"test"})
+	assert.Nil(t, err)
+	_, _, err = j.validateToken(token)
+	assert.Nil(t, err)
+}
+
+func TestInvalidIssuerConfig(t *testing.T) {
+	j := JWT{ClockSkew: "soon"}
+	err := j.initIssuers()
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+
+	j = JWT{Issuers: []TrustedIssuer{{Issuer: "https://issuer", RefreshInterval: "-1h", Audiences: []string{"symphony"}}}}
+	err = j.initIssuers()
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+
+	j = JWT{Issuers: []TrustedIssuer{{Issuer: "https://issuer"}}}
+	err = j.initIssuers()
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+// Package testissuer provides a local OpenID Connect issuer for unit tests. It publishes its OpenID configuration and
+// its keys over HTTP, signs tokens with RS256 and can rotate its keys.
+package testissuer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v4"
+)
+
+type signingKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+// Issuer is a local OpenID Connect issuer
+type Issuer struct {
+	server       *httptest.Server
+	lock         sync.Mutex
+	keys         []signingKey
+	nextKey      int
+	jwksRequests int
+}
+
+// New starts an issuer with a single key
+func New() (*Issuer, error) {
+	i := &Issuer{}
+	if err := i.Rotate(false); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", i.handleConfiguration)
+	mux.HandleFunc("/keys", i.handleKeys)
+	i.server = httptest.NewServer(mux)
+	return i, nil
+}
+
+// URL is the issuer identifier, which tokens carry as their iss claim
+func (i *Issuer) URL() string {
+	return i.server.URL
+}
+
+// JWKSURI is where the issuer publishes its keys
+func (i *Issuer) JWKSURI() string {
+	return i.server.URL + "/keys"
+}
+
+// JWKSRequests is how many times the keys have been fetched
+func (i *Issuer) JWKSRequests() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.jwksRequests
+}
+
+// Close stops the issuer
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// Rotate makes a new key the signing key. The previous keys are still published when keepPrevious is set, and retired
+// otherwise.
+func (i *Issuer) Rotate(keepPrevious bool) error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.nextKey++
+	signing := signingKey{kid: fmt.Sprintf("key-%d", i.nextKey), key: key}
+	if keepPrevious {
+		i.keys = append([]signingKey{signing}, i.keys...)
+	} else {
+		i.keys = []signingKey{signing}
+	}
+	return nil
+}
+
+// Issue signs a token with the current key. The iss claim is set to the issuer, and exp, nbf and iat are set for an
+// hour from now unless the claims have them.
+func (i *Issuer) Issue(claims jwt.MapClaims) (string, error) {
+	ret := jwt.MapClaims{
+		"iss": i.URL(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range claims {
+		ret[k] = v
+	}
+	i.lock.Lock()
+	key := i.keys[0]
+	i.lock.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, ret)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.key)
+}
+
+func (i *Issuer) handleConfiguration(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"issuer":                                i.URL(),
+		"jwks_uri":                              i.JWKSURI(),
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *Issuer) handleKeys(w http.ResponseWriter, r *http.Request) {
+	i.lock.Lock()
+	i.jwksRequests++
+	keys := make([]map[string]string, 0, len(i.keys))
+	for _, k := range i.keys {
+		keys = append(keys, map[string]string{
+			"kty": "RSA",
+			"kid": k.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(k.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.key.E)).Bytes()),
+		})
+	}
+	i.lock.Unlock()
+	writeJSON(w, map[string]interface{}{"keys": keys})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
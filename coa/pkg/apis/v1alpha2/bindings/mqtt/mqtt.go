@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/authz"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 )
@@ -24,6 +25,10 @@ type MQTTBindingConfig struct {
 	ClientID      string `json:"clientID"`
 	RequestTopic  string `json:"requestTopic"`
 	ResponseTopic string `json:"responseTopic"`
+	// Authorization enforces role-based policies on the endpoints. Requests aren't authorized when it's not set.
+	Authorization *authz.Config `json:"authorization,omitempty"`
+	// Roles are the roles of the requests over the binding, as the broker authenticates the clients that publish them
+	Roles []string `json:"roles,omitempty"`
 }
 
 type MQTTBinding struct {
@@ -33,8 +38,19 @@ type MQTTBinding struct {
 var routeTable map[string]v1alpha2.Endpoint
 
 func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endpoint) error {
+	var authorizer *authz.Authorizer
+	if config.Authorization != nil {
+		var err error
+		authorizer, err = authz.NewAuthorizer(*config.Authorization)
+		if err != nil {
+			return err
+		}
+	}
 	routeTable = make(map[string]v1alpha2.Endpoint)
 	for _, endpoint := range endpoints {
+		if authorizer != nil {
+			endpoint.Handler = authorizer.Wrap(endpoint, endpoint.Handler)
+		}
 		route := endpoint.Route
 		lastSlash := strings.LastIndex(endpoint.Route, "/")
 		if lastSlash > 0 {
@@ -55,7 +71,7 @@ func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endp
 	if token := m.MQTTClient.Subscribe(config.RequestTopic, 0, func(client gmqtt.Client, msg gmqtt.Message) {
 		var request v1alpha2.COARequest
 		var response v1alpha2.COAResponse
-		request.Context = context.TODO()
+		request.Context = authz.WithIdentity(context.TODO(), authz.Identity{User: "mqtt", Roles: config.Roles})
 		err := json.Unmarshal(msg.Payload(), &request)
 		if err != nil {
 			response = v1alpha2.COAResponse{
@@ -32,8 +32,10 @@ func FromHTTPResponseCode(code int, body []byte) COAError {
 	switch code {
 	case 400:
 		state = BadRequest
-	case 403:
+	case 401:
 		state = Unauthorized
+	case 403:
+		state = Forbidden
 	case 404:
 		state = NotFound
 	case 405:
@@ -69,3 +71,18 @@ func IsDelayed(err error) bool {
 	}
 	return coaE.State == Delayed
 }
+
+// IsRetriable tells whether an operation that failed with err may succeed if tried again.
+// Errors that are not COA errors are considered retriable.
+func IsRetriable(err error) bool {
+	coaE, ok := err.(COAError)
+	if !ok {
+		return true
+	}
+	switch coaE.State {
+	case TerminalError, BadRequest, Unauthorized, Forbidden, MethodNotAllowed, BadConfig, MissingConfig, InvalidArgument, SerializationError, ValidateFailed, NotImplemented:
+		return false
+	default:
+		return true
+	}
+}
@@ -35,10 +35,14 @@ func TestFromHTTPResponseCode(t *testing.T) {
 			"state":   BadRequest,
 			"message": "Bad Request",
 		},
-		403: {
+		401: {
 			"state":   Unauthorized,
 			"message": "Unauthorized",
 		},
+		403: {
+			"state":   Forbidden,
+			"message": "Forbidden",
+		},
 		404: {
 			"state":   NotFound,
 			"message": "Not Found",
@@ -79,3 +83,11 @@ func TestIsDelayed(t *testing.T) {
 	assert.False(t, IsDelayed(errors.New("Mock Error")))
 	assert.True(t, IsDelayed(NewCOAError(errors.New("Mock Error"), "Mock Error Message", Delayed)))
 }
+
+func TestIsRetriable(t *testing.T) {
+	assert.True(t, IsRetriable(errors.New("Mock Error")))
+	assert.True(t, IsRetriable(NewCOAError(errors.New("Mock Error"), "Mock Error Message", InternalError)))
+	assert.True(t, IsRetriable(NewCOAError(errors.New("Mock Error"), "Mock Error Message", TransientError)))
+	assert.False(t, IsRetriable(NewCOAError(errors.New("Mock Error"), "Mock Error Message", TerminalError)))
+	assert.False(t, IsRetriable(NewCOAError(errors.New("Mock Error"), "Mock Error Message", BadConfig)))
+}
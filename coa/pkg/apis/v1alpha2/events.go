@@ -28,6 +28,8 @@ const (
 	JobUpdate JobAction = "UPDATE"
 	JobDelete JobAction = "DELETE"
 	JobRun    JobAction = "RUN"
+	// JobCancel interrupts the stages a site is running for an activation
+	JobCancel JobAction = "CANCEL"
 )
 
 type JobData struct {
@@ -63,12 +65,23 @@ type HeartBeatData struct {
 	Time   time.Time       `json:"time"`
 }
 type ScheduleSpec struct {
-	Date string `json:"date"`
-	Time string `json:"time"`
-	Zone string `json:"zone"`
+	Date string `json:"date,omitempty"`
+	Time string `json:"time,omitempty"`
+	Zone string `json:"zone,omitempty"`
+	// Cron is a five-field cron expression, such as "0 2 * * SUN", evaluated in Zone
+	Cron string `json:"cron,omitempty"`
+	// Interval is the time between fire times, such as "6h"
+	Interval        string          `json:"interval,omitempty"`
+	MissedRunPolicy MissedRunPolicy `json:"missedRunPolicy,omitempty"`
+	// LastFireTime is the latest fire time of a recurring schedule that has been handled, in RFC 3339 format
+	LastFireTime string `json:"lastFireTime,omitempty"`
 }
 
 func (s ScheduleSpec) ShouldFireNow() (bool, error) {
+	if s.IsRecurring() {
+		fireTimes, _, err := s.DueFireTimes(time.Now())
+		return len(fireTimes) > 0, err
+	}
 	dt, err := s.GetTime()
 	if err != nil {
 		return false, err
@@ -88,20 +101,7 @@ func (s ScheduleSpec) GetTime() (time.Time, error) {
 func parseTimeWithZone(timeStr string, dateStr string, zoneStr string) (time.Time, error) {
 	dtStr := dateStr + " " + timeStr
 
-	switch zoneStr {
-	case "LOCAL":
-		zoneStr = ""
-	case "PST", "PDT":
-		zoneStr = "America/Los_Angeles"
-	case "EST", "EDT":
-		zoneStr = "America/New_York"
-	case "CST", "CDT":
-		zoneStr = "America/Chicago"
-	case "MST", "MDT":
-		zoneStr = "America/Denver"
-	}
-
-	loc, err := time.LoadLocation(zoneStr)
+	loc, err := LoadScheduleLocation(zoneStr)
 	if err != nil {
 		return time.Time{}, err
 	}
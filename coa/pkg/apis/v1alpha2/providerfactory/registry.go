@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package providerfactory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+)
+
+// ProviderConstructor returns a new, uninitialized provider instance
+type ProviderConstructor func() providers.IProvider
+
+// ConfigDecoder builds a provider config from a flat property map, such as the config of a target binding
+type ConfigDecoder func(properties map[string]string) (providers.IProviderConfig, error)
+
+type ProviderRegistration struct {
+	Type         string
+	New          ProviderConstructor
+	DecodeConfig ConfigDecoder
+}
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]ProviderRegistration)
+)
+
+// Register makes a provider type available to the provider factories. It's meant to be called from init functions,
+// and panics if the type is empty, the constructor is nil or the type is registered twice.
+// When decoder is nil, property maps are passed to the provider's Init as they are.
+func Register(providerType string, constructor ProviderConstructor, decoder ConfigDecoder) {
+	if providerType == "" {
+		panic("providerfactory: provider type is empty")
+	}
+	if constructor == nil {
+		panic(fmt.Sprintf("providerfactory: constructor for '%s' is nil", providerType))
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[providerType]; ok {
+		panic(fmt.Sprintf("providerfactory: provider type '%s' is registered twice", providerType))
+	}
+	registry[providerType] = ProviderRegistration{
+		Type:         providerType,
+		New:          constructor,
+		DecodeConfig: decoder,
+	}
+}
+
+func Lookup(providerType string) (ProviderRegistration, bool) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	reg, ok := registry[providerType]
+	return reg, ok
+}
+
+// RegisteredTypes returns the registered provider types in sorted order
+func RegisteredTypes() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	ret := make([]string, 0, len(registry))
+	for k := range registry {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// CreateRegisteredProvider creates and initializes a registered provider with the given config
+func CreateRegisteredProvider(providerType string, config providers.IProviderConfig) (providers.IProvider, error) {
+	reg, ok := Lookup(providerType)
+	if !ok {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("provider type '%s' is not registered", providerType), v1alpha2.NotFound)
+	}
+	provider := reg.New()
+	if err := provider.Init(config); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// CreateRegisteredProviderFromMap decodes the property map with the registered decoder, creates and initializes
+// the provider and hands it the manager context when the provider accepts one
+func CreateRegisteredProviderFromMap(providerType string, properties map[string]string, context *contexts.ManagerContext) (providers.IProvider, error) {
+	reg, ok := Lookup(providerType)
+	if !ok {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("provider type '%s' is not registered", providerType), v1alpha2.NotFound)
+	}
+	var config providers.IProviderConfig = properties
+	if reg.DecodeConfig != nil {
+		var err error
+		config, err = reg.DecodeConfig(properties)
+		if err != nil {
+			return nil, err
+		}
+	}
+	provider := reg.New()
+	if err := provider.Init(config); err != nil {
+		return nil, err
+	}
+	if c, ok := provider.(contexts.IWithManagerContext); ok && context != nil {
+		c.SetContext(context)
+	}
+	return provider, nil
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package providerfactory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+type testProviderConfig struct {
+	Name string
+}
+
+type testProvider struct {
+	Config  testProviderConfig
+	Context *contexts.ManagerContext
+}
+
+func (p *testProvider) Init(config providers.IProviderConfig) error {
+	c, ok := config.(testProviderConfig)
+	if !ok {
+		return errors.New("unexpected config")
+	}
+	p.Config = c
+	return nil
+}
+
+func (p *testProvider) SetContext(ctx *contexts.ManagerContext) {
+	p.Context = ctx
+}
+
+func newTestProvider() providers.IProvider {
+	return &testProvider{}
+}
+
+func decodeTestProviderConfig(properties map[string]string) (providers.IProviderConfig, error) {
+	name, ok := properties["name"]
+	if !ok {
+		return nil, v1alpha2.NewCOAError(nil, "name is missing", v1alpha2.MissingConfig)
+	}
+	return testProviderConfig{Name: name}, nil
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	Register("providers.test.lookup", newTestProvider, decodeTestProviderConfig)
+	reg, ok := Lookup("providers.test.lookup")
+	assert.True(t, ok)
+	assert.Equal(t, "providers.test.lookup", reg.Type)
+	assert.NotNil(t, reg.New)
+	assert.NotNil(t, reg.DecodeConfig)
+
+	_, ok = Lookup("providers.test.missing")
+	assert.False(t, ok)
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	Register("providers.test.twice", newTestProvider, nil)
+	assert.Panics(t, func() {
+		Register("providers.test.twice", newTestProvider, nil)
+	})
+}
+
+func TestRegisterInvalidPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		Register("", newTestProvider, nil)
+	})
+	assert.Panics(t, func() {
+		Register("providers.test.nil", nil, nil)
+	})
+}
+
+func TestRegisteredTypesSorted(t *testing.T) {
+	Register("providers.test.sorted.b", newTestProvider, nil)
+	Register("providers.test.sorted.a", newTestProvider, nil)
+	types := RegisteredTypes()
+	a, b := -1, -1
+	for i, k := range types {
+		if k == "providers.test.sorted.a" {
+			a = i
+		}
+		if k == "providers.test.sorted.b" {
+			b = i
+		}
+	}
+	assert.True(t, a >= 0 && b >= 0)
+	assert.True(t, a < b)
+}
+
+func TestCreateRegisteredProvider(t *testing.T) {
+	Register("providers.test.create", newTestProvider, decodeTestProviderConfig)
+	provider, err := CreateRegisteredProvider("providers.test.create", testProviderConfig{Name: "test"})
+	assert.Nil(t, err)
+	assert.Equal(t, "test", provider.(*testProvider).Config.Name)
+
+	_, err = CreateRegisteredProvider("providers.test.create", "bad config")
+	assert.NotNil(t, err)
+
+	_, err = CreateRegisteredProvider("providers.test.unknown", nil)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+}
+
+func TestCreateRegisteredProviderFromMap(t *testing.T) {
+	Register("providers.test.map", newTestProvider, decodeTestProviderConfig)
+	context := &contexts.ManagerContext{}
+	provider, err := CreateRegisteredProviderFromMap("providers.test.map", map[string]string{"name": "test"}, context)
+	assert.Nil(t, err)
+	assert.Equal(t, "test", provider.(*testProvider).Config.Name)
+	assert.Equal(t, context, provider.(*testProvider).Context)
+
+	_, err = CreateRegisteredProviderFromMap("providers.test.map", map[string]string{}, context)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.MissingConfig, coaErr.State)
+}
@@ -0,0 +1,456 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package bboltqueue
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+var qLog = logger.NewLogger("coa.runtime")
+
+const (
+	defaultOpenTimeoutSeconds       = 5
+	defaultVisibilityTimeoutSeconds = 300
+	defaultMaxAttempts              = 5
+)
+
+// bbolt takes an exclusive lock on the database file, so every provider pointing at the same path shares one handle
+var (
+	dbLock sync.Mutex
+	dbs    = make(map[string]*bolt.DB)
+)
+
+type BboltQueueProviderConfig struct {
+	Name               string `json:"name"`
+	Path               string `json:"path"`
+	OpenTimeoutSeconds int    `json:"openTimeoutSeconds,omitempty"`
+	// VisibilityTimeoutSeconds is how long a received element is hidden when Receive doesn't set a timeout
+	VisibilityTimeoutSeconds int `json:"visibilityTimeoutSeconds,omitempty"`
+	// MaxAttempts is how many times an element is delivered before it's dead-lettered
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+func BboltQueueProviderConfigFromMap(properties map[string]string) (BboltQueueProviderConfig, error) {
+	ret := BboltQueueProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = utils.ParseProperty(v)
+	}
+	if v, ok := properties["path"]; ok {
+		ret.Path = utils.ParseProperty(v)
+	} else {
+		return ret, v1alpha2.NewCOAError(nil, "'path' is missing in bbolt queue provider config", v1alpha2.BadConfig)
+	}
+	for key, field := range map[string]*int{
+		"openTimeoutSeconds":       &ret.OpenTimeoutSeconds,
+		"visibilityTimeoutSeconds": &ret.VisibilityTimeoutSeconds,
+		"maxAttempts":              &ret.MaxAttempts,
+	} {
+		if v, ok := properties[key]; ok {
+			num, err := strconv.Atoi(v)
+			if err != nil {
+				return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("'%s' is not an integer in bbolt queue provider config", key), v1alpha2.BadConfig)
+			}
+			*field = num
+		}
+	}
+	return ret, nil
+}
+
+// BboltQueueProvider keeps queues in an embedded bbolt database, so that queued elements survive restarts. Each queue
+// is a bucket of messages in the order they were enqueued. Elements are stored as JSON, so they are received as the
+// generic form that JSON decodes to, such as map[string]interface{} for a struct.
+type BboltQueueProvider struct {
+	Config  BboltQueueProviderConfig
+	Context *contexts.ManagerContext
+	db      *bolt.DB
+}
+
+// message is the stored form of a queued element
+type message struct {
+	Element  json.RawMessage `json:"element"`
+	Attempts int             `json:"attempts,omitempty"`
+	// VisibleAt is when a received element is delivered again, unless it's acknowledged before
+	VisibleAt time.Time `json:"visibleAt,omitempty"`
+}
+
+func (s *BboltQueueProvider) ID() string {
+	return s.Config.Name
+}
+
+func (s *BboltQueueProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *BboltQueueProvider) InitWithMap(properties map[string]string) error {
+	config, err := BboltQueueProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func toBboltQueueProviderConfig(config providers.IProviderConfig) (BboltQueueProviderConfig, error) {
+	ret := BboltQueueProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (s *BboltQueueProvider) Init(config providers.IProviderConfig) error {
+	queueConfig, err := toBboltQueueProviderConfig(config)
+	if err != nil {
+		qLog.Errorf("  P (Bbolt Queue): failed to parse provider config %+v", err)
+		return errors.New("expected BboltQueueProviderConfig")
+	}
+	if queueConfig.Path == "" {
+		return v1alpha2.NewCOAError(nil, "'path' is missing in bbolt queue provider config", v1alpha2.BadConfig)
+	}
+	if queueConfig.OpenTimeoutSeconds <= 0 {
+		queueConfig.OpenTimeoutSeconds = defaultOpenTimeoutSeconds
+	}
+	if queueConfig.VisibilityTimeoutSeconds <= 0 {
+		queueConfig.VisibilityTimeoutSeconds = defaultVisibilityTimeoutSeconds
+	}
+	if queueConfig.MaxAttempts <= 0 {
+		queueConfig.MaxAttempts = defaultMaxAttempts
+	}
+	s.Config = queueConfig
+
+	dbLock.Lock()
+	defer dbLock.Unlock()
+	if db, ok := dbs[s.Config.Path]; ok {
+		s.db = db
+		return nil
+	}
+	db, err := bolt.Open(s.Config.Path, 0600, &bolt.Options{Timeout: time.Duration(s.Config.OpenTimeoutSeconds) * time.Second})
+	if err != nil {
+		qLog.Errorf("  P (Bbolt Queue): failed to open database %s: %+v", s.Config.Path, err)
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to open database %s", s.Config.Path), v1alpha2.InternalError)
+	}
+	dbs[s.Config.Path] = db
+	s.db = db
+	return nil
+}
+
+func (s *BboltQueueProvider) Enqueue(queue string, element interface{}) error {
+	data, err := json.Marshal(element)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to serialize element of queue '%s'", queue), v1alpha2.SerializationError)
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(queue))
+		if err != nil {
+			return err
+		}
+		return putMessage(bucket, message{Element: data})
+	})
+	if err != nil {
+		qLog.Errorf("  P (Bbolt Queue): failed to enqueue to %s: %+v", queue, err)
+		return err
+	}
+	return nil
+}
+
+func (s *BboltQueueProvider) Dequeue(queue string) (interface{}, error) {
+	var ret interface{}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(queue))
+		if bucket == nil {
+			return emptyQueueError(queue)
+		}
+		key, m, err := s.firstVisible(tx, bucket, queue, time.Now())
+		if err != nil {
+			return err
+		}
+		ret, err = decodeElement(m)
+		if err != nil {
+			return err
+		}
+		return bucket.Delete(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *BboltQueueProvider) Peek(queue string) (interface{}, error) {
+	var ret interface{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(queue))
+		if bucket == nil {
+			return emptyQueueError(queue)
+		}
+		c := bucket.Cursor()
+		now := time.Now()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			m, err := readMessage(v)
+			if err != nil {
+				return err
+			}
+			if s.deliverable(m, now) {
+				ret, err = decodeElement(m)
+				return err
+			}
+		}
+		return emptyQueueError(queue)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Size is the number of elements of a queue that can be received now. Received elements that haven't been
+// acknowledged aren't counted until they're visible again.
+func (s *BboltQueueProvider) Size(queue string) int {
+	ret := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(queue))
+		if bucket == nil {
+			return nil
+		}
+		now := time.Now()
+		return bucket.ForEach(func(k, v []byte) error {
+			m, err := readMessage(v)
+			if err != nil {
+				return err
+			}
+			if s.deliverable(m, now) {
+				ret++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		qLog.Errorf("  P (Bbolt Queue): failed to get size of %s: %+v", queue, err)
+		return 0
+	}
+	return ret
+}
+
+func (s *BboltQueueProvider) Receive(queueName string, visibilityTimeout time.Duration) (queue.QueueMessage, error) {
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = time.Duration(s.Config.VisibilityTimeoutSeconds) * time.Second
+	}
+	ret := queue.QueueMessage{}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(queueName))
+		if bucket == nil {
+			return emptyQueueError(queueName)
+		}
+		now := time.Now()
+		key, m, err := s.firstVisible(tx, bucket, queueName, now)
+		if err != nil {
+			return err
+		}
+		m.Attempts++
+		m.VisibleAt = now.Add(visibilityTimeout)
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err = bucket.Put(key, data); err != nil {
+			return err
+		}
+		element, err := decodeElement(m)
+		if err != nil {
+			return err
+		}
+		ret = queue.QueueMessage{
+			ID:       formatID(key),
+			Element:  element,
+			Attempts: m.Attempts,
+		}
+		return nil
+	})
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+func (s *BboltQueueProvider) Ack(queue string, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, key, _, err := findMessage(tx, queue, id)
+		if err != nil {
+			return err
+		}
+		return bucket.Delete(key)
+	})
+}
+
+func (s *BboltQueueProvider) Nack(queue string, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, key, m, err := findMessage(tx, queue, id)
+		if err != nil {
+			return err
+		}
+		m.VisibleAt = time.Time{}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+func (a *BboltQueueProvider) Clone(config providers.IProviderConfig) (providers.IProvider, error) {
+	ret := &BboltQueueProvider{}
+	if config == nil {
+		config = a.Config
+	}
+	err := ret.Init(config)
+	if err != nil {
+		return nil, err
+	}
+	if a.Context != nil {
+		ret.Context = a.Context
+	}
+	return ret, nil
+}
+
+// firstVisible finds the first element of a queue that can be received. Elements that have been delivered
+// MaxAttempts times are moved to the dead letter queue on the way.
+func (s *BboltQueueProvider) firstVisible(tx *bolt.Tx, bucket *bolt.Bucket, queueName string, now time.Time) ([]byte, message, error) {
+	deadLetters := make([][]byte, 0)
+	c := bucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		m, err := readMessage(v)
+		if err != nil {
+			return nil, m, err
+		}
+		if m.VisibleAt.After(now) {
+			continue
+		}
+		if !s.deliverable(m, now) {
+			deadLetters = append(deadLetters, append([]byte{}, k...))
+			if err = s.deadLetter(tx, queueName, m); err != nil {
+				return nil, m, err
+			}
+			continue
+		}
+		if err = deleteKeys(bucket, deadLetters); err != nil {
+			return nil, m, err
+		}
+		return append([]byte{}, k...), m, nil
+	}
+	if err := deleteKeys(bucket, deadLetters); err != nil {
+		return nil, message{}, err
+	}
+	return nil, message{}, emptyQueueError(queueName)
+}
+
+// deliverable tells whether an element can be received now, and isn't due to be dead-lettered
+func (s *BboltQueueProvider) deliverable(m message, now time.Time) bool {
+	return !m.VisibleAt.After(now) && m.Attempts < s.Config.MaxAttempts
+}
+
+func (s *BboltQueueProvider) deadLetter(tx *bolt.Tx, queueName string, m message) error {
+	qLog.Infof("  P (Bbolt Queue): moving an element of %s to its dead letter queue after %d attempts", queueName, m.Attempts)
+	bucket, err := tx.CreateBucketIfNotExists([]byte(queueName + queue.DeadLetterSuffix))
+	if err != nil {
+		return err
+	}
+	return putMessage(bucket, message{Element: m.Element})
+}
+
+// deleteKeys deletes keys after a cursor is done with them, since deleting under a cursor skips the next key
+func deleteKeys(bucket *bolt.Bucket, keys [][]byte) error {
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findMessage(tx *bolt.Tx, queue string, id string) (*bolt.Bucket, []byte, message, error) {
+	key, err := parseID(id)
+	if err != nil {
+		return nil, nil, message{}, err
+	}
+	bucket := tx.Bucket([]byte(queue))
+	if bucket == nil {
+		return nil, nil, message{}, messageNotFoundError(queue, id)
+	}
+	data := bucket.Get(key)
+	if data == nil {
+		return nil, nil, message{}, messageNotFoundError(queue, id)
+	}
+	m, err := readMessage(data)
+	return bucket, key, m, err
+}
+
+func putMessage(bucket *bolt.Bucket, m message) error {
+	sequence, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return bucket.Put(key, data)
+}
+
+func readMessage(data []byte) (message, error) {
+	var ret message
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return ret, v1alpha2.NewCOAError(err, "found invalid queue element", v1alpha2.InternalError)
+	}
+	return ret, nil
+}
+
+func decodeElement(m message) (interface{}, error) {
+	var ret interface{}
+	if err := json.Unmarshal(m.Element, &ret); err != nil {
+		return nil, v1alpha2.NewCOAError(err, "found invalid queue element", v1alpha2.InternalError)
+	}
+	return ret, nil
+}
+
+func formatID(key []byte) string {
+	return strconv.FormatUint(binary.BigEndian.Uint64(key), 10)
+}
+
+func parseID(id string) ([]byte, error) {
+	sequence, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("'%s' is not a valid queue element ID", id), v1alpha2.BadRequest)
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	return key, nil
+}
+
+func emptyQueueError(queue string) error {
+	return v1alpha2.NewCOAError(nil, fmt.Sprintf("queue '%s' is empty", queue), v1alpha2.NotFound)
+}
+
+func messageNotFoundError(queue string, id string) error {
+	return v1alpha2.NewCOAError(nil, fmt.Sprintf("element '%s' is not found in queue '%s'", id, queue), v1alpha2.NotFound)
+}
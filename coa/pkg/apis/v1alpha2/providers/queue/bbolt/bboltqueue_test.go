@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package bboltqueue
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProvider(t *testing.T, path string) *BboltQueueProvider {
+	provider := &BboltQueueProvider{}
+	err := provider.Init(BboltQueueProviderConfig{
+		Name:        "bbolt",
+		Path:        path,
+		MaxAttempts: 2,
+	})
+	assert.Nil(t, err)
+	return provider
+}
+
+func TestInitWithMap(t *testing.T) {
+	provider := &BboltQueueProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"name":                     "test",
+		"path":                     filepath.Join(t.TempDir(), "queue.db"),
+		"visibilityTimeoutSeconds": "60",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "test", provider.ID())
+	assert.Equal(t, 60, provider.Config.VisibilityTimeoutSeconds)
+	assert.Equal(t, defaultMaxAttempts, provider.Config.MaxAttempts)
+}
+
+func TestInitWithMapInvalid(t *testing.T) {
+	provider := &BboltQueueProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"name": "test",
+	})
+	assert.NotNil(t, err)
+
+	err = provider.InitWithMap(map[string]string{
+		"path":        filepath.Join(t.TempDir(), "queue.db"),
+		"maxAttempts": "many",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	provider.Enqueue("queue1", "a")
+	provider.Enqueue("queue1", "b")
+	provider.Enqueue("queue1", v1alpha2.JobData{Id: "catalog1", Action: v1alpha2.JobUpdate})
+	assert.Equal(t, 3, provider.Size("queue1"))
+
+	element, err := provider.Peek("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = provider.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = provider.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+	element, err = provider.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog1", element.(map[string]interface{})["id"])
+	assert.Equal(t, 0, provider.Size("queue1"))
+}
+
+func TestDequeueEmpty(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	element, err := provider.Dequeue("queue1")
+	assert.True(t, v1alpha2.IsNotFound(err))
+	assert.Nil(t, element)
+	element, err = provider.Peek("queue1")
+	assert.True(t, v1alpha2.IsNotFound(err))
+	assert.Nil(t, element)
+	assert.Equal(t, 0, provider.Size("queue1"))
+}
+
+func TestSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	provider := newProvider(t, path)
+	provider.Enqueue("queue1", "a")
+	provider.Enqueue("queue1", "b")
+
+	// a restart reopens the database file
+	dbLock.Lock()
+	provider.db.Close()
+	delete(dbs, path)
+	dbLock.Unlock()
+	provider = newProvider(t, path)
+
+	assert.Equal(t, 2, provider.Size("queue1"))
+	element, err := provider.Dequeue("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+}
+
+func TestReceiveAck(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	provider.Enqueue("queue1", "a")
+	provider.Enqueue("queue1", "b")
+
+	message, err := provider.Receive("queue1", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", message.Element)
+	assert.Equal(t, 1, message.Attempts)
+
+	// the received element is hidden until it's acknowledged
+	assert.Equal(t, 1, provider.Size("queue1"))
+	element, err := provider.Peek("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+
+	assert.Nil(t, provider.Ack("queue1", message.ID))
+	assert.True(t, v1alpha2.IsNotFound(provider.Ack("queue1", message.ID)))
+	message, err = provider.Receive("queue1", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", message.Element)
+}
+
+func TestReceiveVisibilityTimeout(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	provider.Enqueue("queue1", "a")
+
+	message, err := provider.Receive("queue1", 10*time.Millisecond)
+	assert.Nil(t, err)
+	_, err = provider.Receive("queue1", time.Minute)
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	// the element is delivered again when it isn't acknowledged in time
+	time.Sleep(20 * time.Millisecond)
+	redelivered, err := provider.Receive("queue1", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, message.ID, redelivered.ID)
+	assert.Equal(t, 2, redelivered.Attempts)
+}
+
+func TestNackDeadLetters(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	provider.Enqueue("queue1", "a")
+	provider.Enqueue("queue1", "b")
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		message, err := provider.Receive("queue1", time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, "a", message.Element)
+		assert.Equal(t, attempt, message.Attempts)
+		assert.Nil(t, provider.Nack("queue1", message.ID))
+	}
+
+	// the element is dead-lettered after MaxAttempts deliveries
+	message, err := provider.Receive("queue1", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", message.Element)
+	assert.Equal(t, 1, provider.Size("queue1"+queue.DeadLetterSuffix))
+	element, err := provider.Dequeue("queue1" + queue.DeadLetterSuffix)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+}
+
+func TestAckInvalidID(t *testing.T) {
+	provider := newProvider(t, filepath.Join(t.TempDir(), "queue.db"))
+	err := provider.Ack("queue1", "not-an-id")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+}
+
+func TestImplementsReliableQueue(t *testing.T) {
+	var provider interface{} = &BboltQueueProvider{}
+	_, ok := provider.(queue.IReliableQueueProvider)
+	assert.True(t, ok)
+}
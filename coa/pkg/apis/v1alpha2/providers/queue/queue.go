@@ -6,9 +6,39 @@
 
 package queue
 
+import "time"
+
+// DeadLetterSuffix is appended to the name of a queue to get the queue that its dead-lettered elements are moved to
+const DeadLetterSuffix = "-dead-letter"
+
 type IQueueProvider interface {
 	Enqueue(queue string, element interface{}) error
 	Dequeue(queue string) (interface{}, error)
 	Peek(queue string) (interface{}, error)
 	Size(queue string) int
 }
+
+// QueueMessage is an element received from a queue, with the ID that acknowledges it
+type QueueMessage struct {
+	ID      string
+	Element interface{}
+	// Attempts is how many times the element has been received, including this time
+	Attempts int
+}
+
+// IReliableQueueProvider is a queue provider with at-least-once delivery. A received element stays in the queue,
+// hidden from other receivers for a visibility timeout, until it's acknowledged. An element that isn't acknowledged
+// in time, or is negatively acknowledged, is delivered again. An element that has been delivered too many times is
+// moved to the dead letter queue of its queue, named with DeadLetterSuffix.
+//
+// Dequeue of a reliable queue removes an element without waiting for an acknowledgement.
+type IReliableQueueProvider interface {
+	IQueueProvider
+	// Receive gets the first element of a queue that isn't hidden, and hides it for the visibility timeout. A zero
+	// timeout uses the default timeout of the provider.
+	Receive(queue string, visibilityTimeout time.Duration) (QueueMessage, error)
+	// Ack removes a received element from its queue
+	Ack(queue string, id string) error
+	// Nack makes a received element visible again, so that it's delivered again
+	Nack(queue string, id string) error
+}
@@ -0,0 +1,515 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package bboltstate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	contexts "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	providers "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	states "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	bolt "go.etcd.io/bbolt"
+)
+
+var sLog = logger.NewLogger("coa.runtime")
+
+const (
+	defaultNamespace = "default"
+	defaultResource  = "entries"
+)
+
+// bbolt takes an exclusive lock on the database file, so every provider pointing at the same path shares one handle
+var (
+	dbLock sync.Mutex
+	dbs    = make(map[string]*bolt.DB)
+)
+
+type BboltStateProviderConfig struct {
+	Name               string `json:"name"`
+	Path               string `json:"path"`
+	OpenTimeoutSeconds int    `json:"openTimeoutSeconds,omitempty"`
+}
+
+func BboltStateProviderConfigFromMap(properties map[string]string) (BboltStateProviderConfig, error) {
+	ret := BboltStateProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = utils.ParseProperty(v)
+	}
+	if v, ok := properties["path"]; ok {
+		ret.Path = utils.ParseProperty(v)
+	} else {
+		return ret, v1alpha2.NewCOAError(nil, "'path' is missing in bbolt state provider config", v1alpha2.BadConfig)
+	}
+	if v, ok := properties["openTimeoutSeconds"]; ok {
+		num, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(nil, "'openTimeoutSeconds' is not an integer in bbolt state provider config", v1alpha2.BadConfig)
+		}
+		ret.OpenTimeoutSeconds = num
+	}
+	return ret, nil
+}
+
+// BboltStateProvider persists state in an embedded bbolt database for deployments without Kubernetes. Entries are
+// kept in one bucket per resource type with a nested bucket per namespace, and are versioned with an ETag that
+// Upsert and Delete check when the request carries one.
+type BboltStateProvider struct {
+	Config  BboltStateProviderConfig
+	Context *contexts.ManagerContext
+	db      *bolt.DB
+}
+
+// record is the stored form of a state entry
+type record struct {
+	ID   string          `json:"id"`
+	ETag int64           `json:"etag"`
+	Body json.RawMessage `json:"body"`
+}
+
+func (s *BboltStateProvider) ID() string {
+	return s.Config.Name
+}
+
+func (s *BboltStateProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *BboltStateProvider) InitWithMap(properties map[string]string) error {
+	config, err := BboltStateProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func (s *BboltStateProvider) Init(config providers.IProviderConfig) error {
+	stateConfig, err := toBboltStateProviderConfig(config)
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to parse provider config %+v", err)
+		return errors.New("expected BboltStateProviderConfig")
+	}
+	if stateConfig.Path == "" {
+		return v1alpha2.NewCOAError(nil, "'path' is missing in bbolt state provider config", v1alpha2.BadConfig)
+	}
+	if stateConfig.OpenTimeoutSeconds <= 0 {
+		stateConfig.OpenTimeoutSeconds = 5
+	}
+	s.Config = stateConfig
+
+	dbLock.Lock()
+	defer dbLock.Unlock()
+	if db, ok := dbs[s.Config.Path]; ok {
+		s.db = db
+		return nil
+	}
+	db, err := bolt.Open(s.Config.Path, 0600, &bolt.Options{Timeout: time.Duration(s.Config.OpenTimeoutSeconds) * time.Second})
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to open database %s: %+v", s.Config.Path, err)
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to open database %s", s.Config.Path), v1alpha2.InternalError)
+	}
+	dbs[s.Config.Path] = db
+	s.db = db
+	return nil
+}
+
+func toBboltStateProviderConfig(config providers.IProviderConfig) (BboltStateProviderConfig, error) {
+	ret := BboltStateProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (s *BboltStateProvider) Upsert(ctx context.Context, entry states.UpsertRequest) (string, error) {
+	_, span := observability.StartSpan("Bbolt State Provider", ctx, &map[string]string{
+		"method": "Upsert",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Debugf("  P (Bbolt State): upsert state %s, traceId: %s", entry.Value.ID, span.SpanContext().TraceID().String())
+
+	if entry.Value.ID == "" {
+		err = v1alpha2.NewCOAError(nil, "found invalid request ID", v1alpha2.BadRequest)
+		return "", err
+	}
+	body, err := json.Marshal(entry.Value.Body)
+	if err != nil {
+		err = v1alpha2.NewCOAError(err, fmt.Sprintf("failed to serialize entry '%s'", entry.Value.ID), v1alpha2.SerializationError)
+		return "", err
+	}
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := namespaceBucket(tx, entry.Metadata, true)
+		if err != nil {
+			return err
+		}
+		existing, found, err := readRecord(bucket, entry.Value.ID)
+		if err != nil {
+			return err
+		}
+		if err = checkETag(entry.Value.ID, entry.ETag, existing, found); err != nil {
+			return err
+		}
+		if entry.Options.UpdateStateOnly && !found {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' is not found", entry.Value.ID), v1alpha2.NotFound)
+		}
+		newRecord := record{
+			ID:   entry.Value.ID,
+			ETag: existing.ETag + 1,
+			Body: body,
+		}
+		if found {
+			newRecord.Body, err = mergeBody(existing.Body, body, entry.Options.UpdateStateOnly)
+			if err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(newRecord)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(entry.Value.ID), data)
+	})
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to upsert %s state: %+v, traceId: %s", entry.Value.ID, err, span.SpanContext().TraceID().String())
+		return "", err
+	}
+	return entry.Value.ID, nil
+}
+
+func (s *BboltStateProvider) List(ctx context.Context, request states.ListRequest) ([]states.StateEntry, string, error) {
+	_, span := observability.StartSpan("Bbolt State Provider", ctx, &map[string]string{
+		"method": "List",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Debugf("  P (Bbolt State): list states, traceId: %s", span.SpanContext().TraceID().String())
+
+	filter, err := states.NewEntryFilter(request.FilterType, request.Filter)
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to parse filter: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, "", err
+	}
+	startNamespace, startID, err := decodeContinuationToken(request.ContinuationToken)
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to list states: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, "", err
+	}
+
+	entities := make([]states.StateEntry, 0)
+	token := ""
+	lastNamespace := ""
+	err = s.db.View(func(tx *bolt.Tx) error {
+		resourceBucket := tx.Bucket(resourceKey(request.Metadata))
+		if resourceBucket == nil {
+			return nil
+		}
+		namespaces := []string{}
+		if namespace := readMetadata(request.Metadata, "namespace"); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		} else {
+			// an empty namespace lists across all namespaces, like the Kubernetes state provider
+			resourceBucket.ForEach(func(k, v []byte) error {
+				if v == nil {
+					namespaces = append(namespaces, string(k))
+				}
+				return nil
+			})
+		}
+		for _, namespace := range namespaces {
+			if namespace < startNamespace {
+				continue
+			}
+			bucket := resourceBucket.Bucket([]byte(namespace))
+			if bucket == nil {
+				continue
+			}
+			c := bucket.Cursor()
+			k, v := c.First()
+			if namespace == startNamespace && startID != "" {
+				k, v = c.Seek([]byte(startID))
+				if k != nil && string(k) == startID {
+					k, v = c.Next()
+				}
+			}
+			for ; k != nil; k, v = c.Next() {
+				if v == nil {
+					continue
+				}
+				var r record
+				if err := json.Unmarshal(v, &r); err != nil {
+					return v1alpha2.NewCOAError(err, "found invalid state entry", v1alpha2.InternalError)
+				}
+				entry, err := r.toStateEntry()
+				if err != nil {
+					return err
+				}
+				if !filter(entry) {
+					continue
+				}
+				if request.PageSize > 0 && len(entities) == request.PageSize {
+					// there's at least one more match, so hand out a token that resumes after the last entry returned
+					token = encodeContinuationToken(lastNamespace, entities[len(entities)-1].ID)
+					return nil
+				}
+				entities = append(entities, entry)
+				lastNamespace = namespace
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to list states: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, "", err
+	}
+	return entities, token, nil
+}
+
+func (s *BboltStateProvider) Delete(ctx context.Context, request states.DeleteRequest) error {
+	_, span := observability.StartSpan("Bbolt State Provider", ctx, &map[string]string{
+		"method": "Delete",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Debugf("  P (Bbolt State): delete state %s, traceId: %s", request.ID, span.SpanContext().TraceID().String())
+
+	if request.ID == "" {
+		err = v1alpha2.NewCOAError(nil, "found invalid request ID", v1alpha2.BadRequest)
+		return err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := namespaceBucket(tx, request.Metadata, false)
+		if err != nil {
+			return err
+		}
+		var existing record
+		found := false
+		if bucket != nil {
+			existing, found, err = readRecord(bucket, request.ID)
+			if err != nil {
+				return err
+			}
+		}
+		if !found {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' is not found", request.ID), v1alpha2.NotFound)
+		}
+		if err = checkETag(request.ID, request.ETag, existing, found); err != nil {
+			return err
+		}
+		return bucket.Delete([]byte(request.ID))
+	})
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to delete %s: %+v, traceId: %s", request.ID, err, span.SpanContext().TraceID().String())
+		return err
+	}
+	return nil
+}
+
+func (s *BboltStateProvider) Get(ctx context.Context, request states.GetRequest) (states.StateEntry, error) {
+	_, span := observability.StartSpan("Bbolt State Provider", ctx, &map[string]string{
+		"method": "Get",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Debugf("  P (Bbolt State): get state %s, traceId: %s", request.ID, span.SpanContext().TraceID().String())
+
+	if request.ID == "" {
+		err = v1alpha2.NewCOAError(nil, "found invalid request ID", v1alpha2.BadRequest)
+		return states.StateEntry{}, err
+	}
+	var ret states.StateEntry
+	err = s.db.View(func(tx *bolt.Tx) error {
+		bucket, err := namespaceBucket(tx, request.Metadata, false)
+		if err != nil {
+			return err
+		}
+		if bucket != nil {
+			existing, found, err := readRecord(bucket, request.ID)
+			if err != nil {
+				return err
+			}
+			if found {
+				ret, err = existing.toStateEntry()
+				return err
+			}
+		}
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' is not found", request.ID), v1alpha2.NotFound)
+	})
+	if err != nil {
+		sLog.Errorf("  P (Bbolt State): failed to get %s state: %+v, traceId: %s", request.ID, err, span.SpanContext().TraceID().String())
+		return states.StateEntry{}, err
+	}
+	return ret, nil
+}
+
+func (a *BboltStateProvider) Clone(config providers.IProviderConfig) (providers.IProvider, error) {
+	ret := &BboltStateProvider{}
+	if config == nil {
+		config = a.Config
+	}
+	err := ret.Init(config)
+	if err != nil {
+		return nil, err
+	}
+	if a.Context != nil {
+		ret.Context = a.Context
+	}
+	return ret, nil
+}
+
+func (r record) toStateEntry() (states.StateEntry, error) {
+	var body interface{}
+	if err := json.Unmarshal(r.Body, &body); err != nil {
+		return states.StateEntry{}, v1alpha2.NewCOAError(err, fmt.Sprintf("entry '%s' is not a valid state entry", r.ID), v1alpha2.InternalError)
+	}
+	return states.StateEntry{
+		ID:   r.ID,
+		ETag: strconv.FormatInt(r.ETag, 10),
+		Body: body,
+	}, nil
+}
+
+func readRecord(bucket *bolt.Bucket, id string) (record, bool, error) {
+	ret := record{}
+	data := bucket.Get([]byte(id))
+	if data == nil {
+		return ret, false, nil
+	}
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return ret, false, v1alpha2.NewCOAError(err, fmt.Sprintf("entry '%s' is not a valid state entry", id), v1alpha2.InternalError)
+	}
+	return ret, true, nil
+}
+
+// checkETag enforces optimistic concurrency: a request ETag has to match the stored one, and an empty request ETag
+// means the entry must not exist yet
+func checkETag(id string, etag *string, existing record, found bool) error {
+	if etag == nil {
+		return nil
+	}
+	if !found {
+		if *etag == "" {
+			return nil
+		}
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' has been deleted", id), v1alpha2.Conflict)
+	}
+	if *etag != strconv.FormatInt(existing.ETag, 10) {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' has been modified, expected etag '%s' but found '%d'", id, *etag, existing.ETag), v1alpha2.Conflict)
+	}
+	return nil
+}
+
+// mergeBody overlays the top-level fields of the new body on the existing one, so a spec update keeps the status and
+// vice versa. With stateOnly, only the new status is merged into the existing status.
+func mergeBody(existing json.RawMessage, body json.RawMessage, stateOnly bool) (json.RawMessage, error) {
+	var existingMap map[string]interface{}
+	var newMap map[string]interface{}
+	if json.Unmarshal(existing, &existingMap) != nil || json.Unmarshal(body, &newMap) != nil || existingMap == nil || newMap == nil {
+		if stateOnly {
+			return nil, v1alpha2.NewCOAError(nil, "state only updates require an object body", v1alpha2.BadRequest)
+		}
+		return body, nil
+	}
+	if stateOnly {
+		status, _ := existingMap["status"].(map[string]interface{})
+		if status == nil {
+			status = make(map[string]interface{})
+		}
+		if newStatus, ok := newMap["status"].(map[string]interface{}); ok {
+			for k, v := range newStatus {
+				status[k] = v
+			}
+		}
+		existingMap["status"] = status
+	} else {
+		for k, v := range newMap {
+			existingMap[k] = v
+		}
+	}
+	return json.Marshal(existingMap)
+}
+
+func readMetadata(metadata map[string]interface{}, key string) string {
+	if v, ok := metadata[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// resourceKey names the top-level bucket for a resource type, so that objects of different types may share a name
+func resourceKey(metadata map[string]interface{}) []byte {
+	resource := readMetadata(metadata, "resource")
+	if resource == "" {
+		// bbolt doesn't allow empty bucket names; requests without a resource type share one bucket
+		resource = defaultResource
+	}
+	if group := readMetadata(metadata, "group"); group != "" {
+		resource = resource + "." + group
+	}
+	return []byte(resource)
+}
+
+func namespaceBucket(tx *bolt.Tx, metadata map[string]interface{}, create bool) (*bolt.Bucket, error) {
+	namespace := readMetadata(metadata, "namespace")
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if !create {
+		resourceBucket := tx.Bucket(resourceKey(metadata))
+		if resourceBucket == nil {
+			return nil, nil
+		}
+		return resourceBucket.Bucket([]byte(namespace)), nil
+	}
+	resourceBucket, err := tx.CreateBucketIfNotExists(resourceKey(metadata))
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to create resource bucket", v1alpha2.InternalError)
+	}
+	bucket, err := resourceBucket.CreateBucketIfNotExists([]byte(namespace))
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to create namespace bucket", v1alpha2.InternalError)
+	}
+	return bucket, nil
+}
+
+func encodeContinuationToken(namespace string, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(namespace + "/" + id))
+}
+
+func decodeContinuationToken(token string) (string, string, error) {
+	if token == "" {
+		return "", "", nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", v1alpha2.NewCOAError(err, "invalid continuation token", v1alpha2.BadRequest)
+	}
+	parts := strings.SplitN(string(data), "/", 2)
+	if len(parts) != 2 {
+		return "", "", v1alpha2.NewCOAError(nil, "invalid continuation token", v1alpha2.BadRequest)
+	}
+	return parts[0], parts[1], nil
+}
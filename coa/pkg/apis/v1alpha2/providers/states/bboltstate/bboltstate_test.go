@@ -0,0 +1,367 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package bboltstate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	states "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/stretchr/testify/assert"
+)
+
+func newProvider(t *testing.T) *BboltStateProvider {
+	provider := &BboltStateProvider{}
+	err := provider.Init(BboltStateProviderConfig{
+		Name: "bbolt",
+		Path: filepath.Join(t.TempDir(), "state.db"),
+	})
+	assert.Nil(t, err)
+	return provider
+}
+
+func solution(name string, namespace string, labels map[string]string) states.UpsertRequest {
+	return states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: name,
+			Body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": namespace,
+					"labels":    labels,
+				},
+				"spec": map[string]interface{}{
+					"displayName": name,
+				},
+			},
+		},
+		Metadata: map[string]interface{}{
+			"namespace": namespace,
+			"group":     "solution.symphony",
+			"version":   "v1",
+			"resource":  "solutions",
+		},
+	}
+}
+
+func solutionMetadata(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"namespace": namespace,
+		"group":     "solution.symphony",
+		"version":   "v1",
+		"resource":  "solutions",
+	}
+}
+
+func TestBboltStateProviderConfigFromMap(t *testing.T) {
+	config, err := BboltStateProviderConfigFromMap(map[string]string{
+		"name":               "bbolt",
+		"path":               "/var/lib/symphony/state.db",
+		"openTimeoutSeconds": "3",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "/var/lib/symphony/state.db", config.Path)
+	assert.Equal(t, 3, config.OpenTimeoutSeconds)
+}
+
+func TestBboltStateProviderConfigFromMapMissingPath(t *testing.T) {
+	_, err := BboltStateProviderConfigFromMap(map[string]string{
+		"name": "bbolt",
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadConfig, coaErr.State)
+}
+
+func TestUpsertGet(t *testing.T) {
+	provider := newProvider(t)
+	id, err := provider.Upsert(context.Background(), solution("s1", "default", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "s1", id)
+
+	entry, err := provider.Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "s1", entry.ID)
+	assert.Equal(t, "1", entry.ETag)
+	assert.Equal(t, "s1", entry.Body.(map[string]interface{})["spec"].(map[string]interface{})["displayName"])
+}
+
+func TestGetNotFound(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+}
+
+func TestNamespacesAreIsolated(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), solution("s1", "ns1", nil))
+	assert.Nil(t, err)
+
+	_, err = provider.Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("ns2"),
+	})
+	assert.NotNil(t, err)
+
+	// the same name under another resource type is a different entry
+	_, err = provider.Get(context.Background(), states.GetRequest{
+		ID: "s1",
+		Metadata: map[string]interface{}{
+			"namespace": "ns1",
+			"group":     "solution.symphony",
+			"resource":  "instances",
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestUpsertETag(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), solution("s1", "default", nil))
+	assert.Nil(t, err)
+
+	stale := "0"
+	request := solution("s1", "default", nil)
+	request.ETag = &stale
+	_, err = provider.Upsert(context.Background(), request)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.Conflict, coaErr.State)
+
+	current := "1"
+	request.ETag = &current
+	_, err = provider.Upsert(context.Background(), request)
+	assert.Nil(t, err)
+
+	entry, err := provider.Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", entry.ETag)
+}
+
+func TestUpsertETagCreateOnly(t *testing.T) {
+	provider := newProvider(t)
+	empty := ""
+	request := solution("s1", "default", nil)
+	request.ETag = &empty
+	_, err := provider.Upsert(context.Background(), request)
+	assert.Nil(t, err)
+
+	_, err = provider.Upsert(context.Background(), request)
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.Conflict, coaErr.State)
+}
+
+func TestUpsertKeepsStatus(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), solution("s1", "default", nil))
+	assert.Nil(t, err)
+
+	_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: "s1",
+			Body: map[string]interface{}{
+				"status": map[string]interface{}{
+					"phase": "Running",
+				},
+			},
+		},
+		Metadata: solutionMetadata("default"),
+		Options: states.UpsertOption{
+			UpdateStateOnly: true,
+		},
+	})
+	assert.Nil(t, err)
+
+	_, err = provider.Upsert(context.Background(), solution("s1", "default", map[string]string{"env": "prod"}))
+	assert.Nil(t, err)
+
+	entry, err := provider.Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	body := entry.Body.(map[string]interface{})
+	assert.Equal(t, "Running", body["status"].(map[string]interface{})["phase"])
+	assert.Equal(t, "prod", body["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["env"])
+}
+
+func TestUpdateStateOnlyNotFound(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "s1",
+			Body: map[string]interface{}{"status": map[string]interface{}{}},
+		},
+		Metadata: solutionMetadata("default"),
+		Options: states.UpsertOption{
+			UpdateStateOnly: true,
+		},
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+}
+
+func TestDelete(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), solution("s1", "default", nil))
+	assert.Nil(t, err)
+
+	stale := "5"
+	err = provider.Delete(context.Background(), states.DeleteRequest{
+		ID:       "s1",
+		ETag:     &stale,
+		Metadata: solutionMetadata("default"),
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.Conflict, coaErr.State)
+
+	err = provider.Delete(context.Background(), states.DeleteRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+
+	err = provider.Delete(context.Background(), states.DeleteRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	coaErr, ok = err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.NotFound, coaErr.State)
+}
+
+func TestListAllNamespaces(t *testing.T) {
+	provider := newProvider(t)
+	for _, ns := range []string{"ns1", "ns2"} {
+		_, err := provider.Upsert(context.Background(), solution("s1", ns, nil))
+		assert.Nil(t, err)
+	}
+
+	entries, token, err := provider.List(context.Background(), states.ListRequest{
+		Metadata: solutionMetadata(""),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "", token)
+	assert.Equal(t, 2, len(entries))
+
+	entries, _, err = provider.List(context.Background(), states.ListRequest{
+		Metadata: solutionMetadata("ns2"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestListWithFilter(t *testing.T) {
+	provider := newProvider(t)
+	_, err := provider.Upsert(context.Background(), solution("s1", "default", map[string]string{"env": "prod"}))
+	assert.Nil(t, err)
+	_, err = provider.Upsert(context.Background(), solution("s2", "default", map[string]string{"env": "dev"}))
+	assert.Nil(t, err)
+
+	entries, _, err := provider.List(context.Background(), states.ListRequest{
+		FilterType: states.FilterTypeLabel,
+		Filter:     "env=prod",
+		Metadata:   solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "s1", entries[0].ID)
+
+	entries, _, err = provider.List(context.Background(), states.ListRequest{
+		FilterType: states.FilterTypeSpec,
+		Filter:     "$.displayName=s2",
+		Metadata:   solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "s2", entries[0].ID)
+
+	_, _, err = provider.List(context.Background(), states.ListRequest{
+		FilterType: "owner",
+		Filter:     "me",
+		Metadata:   solutionMetadata("default"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestListPaged(t *testing.T) {
+	provider := newProvider(t)
+	for _, ns := range []string{"ns1", "ns2"} {
+		for i := 0; i < 3; i++ {
+			_, err := provider.Upsert(context.Background(), solution(fmt.Sprintf("s%d", i), ns, nil))
+			assert.Nil(t, err)
+		}
+	}
+
+	ids := []string{}
+	token := ""
+	pages := 0
+	for {
+		entries, next, err := provider.List(context.Background(), states.ListRequest{
+			Metadata:          solutionMetadata(""),
+			PageSize:          4,
+			ContinuationToken: token,
+		})
+		assert.Nil(t, err)
+		pages++
+		for _, e := range entries {
+			body := e.Body.(map[string]interface{})
+			ids = append(ids, body["metadata"].(map[string]interface{})["namespace"].(string)+"/"+e.ID)
+		}
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	assert.Equal(t, 2, pages)
+	assert.Equal(t, []string{"ns1/s0", "ns1/s1", "ns1/s2", "ns2/s0", "ns2/s1", "ns2/s2"}, ids)
+}
+
+func TestListInvalidToken(t *testing.T) {
+	provider := newProvider(t)
+	_, _, err := provider.List(context.Background(), states.ListRequest{
+		Metadata:          solutionMetadata(""),
+		ContinuationToken: "not a token!",
+	})
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+}
+
+func TestSharedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	first := &BboltStateProvider{}
+	assert.Nil(t, first.InitWithMap(map[string]string{"path": path}))
+	_, err := first.Upsert(context.Background(), solution("s1", "default", nil))
+	assert.Nil(t, err)
+
+	clone, err := first.Clone(nil)
+	assert.Nil(t, err)
+	entry, err := clone.(*BboltStateProvider).Get(context.Background(), states.GetRequest{
+		ID:       "s1",
+		Metadata: solutionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "s1", entry.ID)
+}
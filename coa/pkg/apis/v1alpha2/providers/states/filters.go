@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package states
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/yalp/jsonpath"
+)
+
+// Filter types understood by ListRequest.FilterType
+const (
+	// FilterTypeLabel matches metadata.labels against a selector such as "env=prod,tier!=web,canary,!legacy"
+	FilterTypeLabel = "label"
+	// FilterTypeField matches dotted body paths, such as "metadata.name=s1,metadata.namespace!=default"
+	FilterTypeField = "field"
+	// FilterTypeSpec matches a JSONPath evaluated against spec, such as "$.displayName=s1" or "$.components" to test presence
+	FilterTypeSpec = "spec"
+	// FilterTypeStatus matches a JSONPath evaluated against status, in the same form as FilterTypeSpec
+	FilterTypeStatus = "status"
+)
+
+// EntryFilter reports whether a state entry passes a list filter
+type EntryFilter func(entry StateEntry) bool
+
+type requirement struct {
+	key    string
+	value  string
+	negate bool
+	exists bool // match on presence of the key only
+}
+
+// NewEntryFilter parses filter according to filterType. An empty filter matches every entry.
+func NewEntryFilter(filterType string, filter string) (EntryFilter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return func(StateEntry) bool { return true }, nil
+	}
+	switch filterType {
+	case FilterTypeLabel:
+		reqs, err := parseRequirements(filter, true)
+		if err != nil {
+			return nil, err
+		}
+		return func(entry StateEntry) bool {
+			labels, _ := readPath(toMap(entry.Body), []string{"metadata", "labels"}).(map[string]interface{})
+			for _, r := range reqs {
+				v, ok := labels[r.key]
+				if !r.matches(fmt.Sprint(v), ok) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case FilterTypeField:
+		reqs, err := parseRequirements(filter, false)
+		if err != nil {
+			return nil, err
+		}
+		return func(entry StateEntry) bool {
+			body := toMap(entry.Body)
+			for _, r := range reqs {
+				v := readPath(body, strings.Split(r.key, "."))
+				if !r.matches(scalarString(v), v != nil) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case FilterTypeSpec, FilterTypeStatus:
+		r, err := parseRequirement(strings.TrimSpace(filter), false)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(r.key, "$") {
+			return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("%s filter '%s' must be a JSONPath starting with '$'", filterType, filter), v1alpha2.BadRequest)
+		}
+		path, err := jsonpath.Prepare(r.key)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid JSONPath in %s filter '%s'", filterType, filter), v1alpha2.BadRequest)
+		}
+		return func(entry StateEntry) bool {
+			target := toMap(entry.Body)[filterType]
+			if target == nil {
+				return r.negate
+			}
+			res, err := path(target)
+			if err != nil || res == nil {
+				return r.negate
+			}
+			if r.exists {
+				return true
+			}
+			if list, ok := res.([]interface{}); ok {
+				for _, item := range list {
+					if scalarString(item) == r.value {
+						return !r.negate
+					}
+				}
+				return r.negate
+			}
+			return r.matches(scalarString(res), true)
+		}, nil
+	}
+	return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("filter type '%s' is not supported", filterType), v1alpha2.BadRequest)
+}
+
+func parseRequirements(filter string, allowNotExists bool) ([]requirement, error) {
+	ret := make([]requirement, 0)
+	for _, part := range strings.Split(filter, ",") {
+		r, err := parseRequirement(strings.TrimSpace(part), allowNotExists)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func parseRequirement(expr string, allowNotExists bool) (requirement, error) {
+	ret := requirement{}
+	if i := strings.Index(expr, "!="); i >= 0 {
+		ret.key, ret.value, ret.negate = expr[:i], expr[i+2:], true
+	} else if i := strings.Index(expr, "=="); i >= 0 {
+		ret.key, ret.value = expr[:i], expr[i+2:]
+	} else if i := strings.Index(expr, "="); i >= 0 {
+		ret.key, ret.value = expr[:i], expr[i+1:]
+	} else if allowNotExists && strings.HasPrefix(expr, "!") {
+		ret.key, ret.exists, ret.negate = expr[1:], true, true
+	} else {
+		ret.key, ret.exists = expr, true
+	}
+	ret.key = strings.TrimSpace(ret.key)
+	ret.value = strings.TrimSpace(ret.value)
+	if ret.key == "" {
+		return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid filter expression '%s'", expr), v1alpha2.BadRequest)
+	}
+	return ret, nil
+}
+
+func (r requirement) matches(value string, found bool) bool {
+	if r.exists {
+		return found != r.negate
+	}
+	if r.negate {
+		return !found || value != r.value
+	}
+	return found && value == r.value
+}
+
+// toMap returns body in its JSON form so typed values, such as model.ObjectMeta, read the same as decoded ones
+func toMap(body interface{}) map[string]interface{} {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	var ret map[string]interface{}
+	json.Unmarshal(data, &ret)
+	return ret
+}
+
+func readPath(body map[string]interface{}, path []string) interface{} {
+	var current interface{} = body
+	for _, p := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[p]
+	}
+	return current
+}
+
+func scalarString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package states
+
+import (
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+var filterEntry = StateEntry{
+	ID: "s1",
+	Body: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "s1",
+			"namespace": "default",
+			"labels": map[string]string{
+				"env":  "prod",
+				"tier": "web",
+			},
+		},
+		"spec": map[string]interface{}{
+			"displayName": "solution 1",
+			"replicas":    3,
+			"components": []interface{}{
+				map[string]interface{}{"name": "c1"},
+			},
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+		},
+	},
+}
+
+func matchFilter(t *testing.T, filterType string, filter string) bool {
+	f, err := NewEntryFilter(filterType, filter)
+	assert.Nil(t, err)
+	return f(filterEntry)
+}
+
+func TestEmptyFilterMatchesAll(t *testing.T) {
+	assert.True(t, matchFilter(t, "", ""))
+	assert.True(t, matchFilter(t, FilterTypeLabel, " "))
+}
+
+func TestLabelFilter(t *testing.T) {
+	assert.True(t, matchFilter(t, FilterTypeLabel, "env=prod"))
+	assert.True(t, matchFilter(t, FilterTypeLabel, "env==prod,tier=web"))
+	assert.False(t, matchFilter(t, FilterTypeLabel, "env=prod,tier=db"))
+	assert.True(t, matchFilter(t, FilterTypeLabel, "tier!=db"))
+	assert.True(t, matchFilter(t, FilterTypeLabel, "owner!=me"))
+	assert.True(t, matchFilter(t, FilterTypeLabel, "env"))
+	assert.False(t, matchFilter(t, FilterTypeLabel, "canary"))
+	assert.True(t, matchFilter(t, FilterTypeLabel, "!canary"))
+	assert.False(t, matchFilter(t, FilterTypeLabel, "!env"))
+}
+
+func TestFieldFilter(t *testing.T) {
+	assert.True(t, matchFilter(t, FilterTypeField, "metadata.name=s1"))
+	assert.True(t, matchFilter(t, FilterTypeField, "metadata.name=s1,metadata.namespace!=kube-system"))
+	assert.False(t, matchFilter(t, FilterTypeField, "metadata.namespace=kube-system"))
+	assert.True(t, matchFilter(t, FilterTypeField, "spec.replicas=3"))
+	assert.True(t, matchFilter(t, FilterTypeField, "spec.missing!=x"))
+}
+
+func TestSpecFilter(t *testing.T) {
+	assert.True(t, matchFilter(t, FilterTypeSpec, "$.displayName=solution 1"))
+	assert.False(t, matchFilter(t, FilterTypeSpec, "$.displayName=solution 2"))
+	assert.True(t, matchFilter(t, FilterTypeSpec, "$.displayName!=solution 2"))
+	assert.True(t, matchFilter(t, FilterTypeSpec, "$.components[*].name=c1"))
+	assert.True(t, matchFilter(t, FilterTypeSpec, "$.components"))
+	assert.False(t, matchFilter(t, FilterTypeSpec, "$.scope"))
+}
+
+func TestStatusFilter(t *testing.T) {
+	assert.True(t, matchFilter(t, FilterTypeStatus, "$.phase=Running"))
+	assert.False(t, matchFilter(t, FilterTypeStatus, "$.phase=Failed"))
+}
+
+func TestInvalidFilters(t *testing.T) {
+	_, err := NewEntryFilter("owner", "me")
+	coaErr, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+
+	_, err = NewEntryFilter(FilterTypeLabel, "env=prod,=web")
+	assert.NotNil(t, err)
+
+	_, err = NewEntryFilter(FilterTypeSpec, "displayName=solution 1")
+	assert.NotNil(t, err)
+}
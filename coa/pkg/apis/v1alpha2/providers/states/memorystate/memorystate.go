@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -40,11 +41,16 @@ func MemoryStateProviderConfigFromMap(properties map[string]string) (MemoryState
 }
 
 type MemoryStateProvider struct {
-	Config  MemoryStateProviderConfig
-	Data    map[string]interface{}
-	Context *contexts.ManagerContext
+	Config          MemoryStateProviderConfig
+	Data            map[string]interface{}
+	Context         *contexts.ManagerContext
+	watchers        map[chan states.WatchEvent]bool
+	resourceVersion int64
 }
 
+// watchBufferSize is how many events a watcher may fall behind before it's dropped
+const watchBufferSize = 100
+
 func (s *MemoryStateProvider) ID() string {
 	return s.Config.Name
 }
@@ -70,6 +76,7 @@ func (s *MemoryStateProvider) Init(config providers.IProviderConfig) error {
 	}
 	s.Config = stateConfig
 	s.Data = make(map[string]interface{}, 0)
+	s.watchers = make(map[chan states.WatchEvent]bool)
 	return nil
 }
 
@@ -123,7 +130,12 @@ func (s *MemoryStateProvider) Upsert(ctx context.Context, entry states.UpsertReq
 		entry.Value.Body = mapRef
 	}
 
+	eventType := states.WatchEventUpdated
+	if _, ok := s.Data[entry.Value.ID]; !ok {
+		eventType = states.WatchEventCreated
+	}
 	s.Data[entry.Value.ID] = entry.Value
+	s.notify(eventType, entry.Value)
 
 	return entry.Value.ID, nil
 }
@@ -139,19 +151,36 @@ func (s *MemoryStateProvider) List(ctx context.Context, request states.ListReque
 
 	sLog.Debugf("  P (Memory State): list states, traceId: %s", span.SpanContext().TraceID().String())
 
+	filter, err := states.NewEntryFilter(request.FilterType, request.Filter)
+	if err != nil {
+		sLog.Errorf("  P (Memory State): failed to parse filter: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, "", err
+	}
+
+	// entries are listed in ID order, so the ID of the last entry of a page is where the next page starts
+	ids := make([]string, 0, len(s.Data))
+	for k := range s.Data {
+		if request.ContinuationToken == "" || k > request.ContinuationToken {
+			ids = append(ids, k)
+		}
+	}
+	sort.Strings(ids)
+
 	var entities []states.StateEntry
-	for _, v := range s.Data {
-		vE, ok := v.(states.StateEntry)
-		if ok {
-			if request.Filter != "" {
-				//TODO: support filters in the future
-			}
-			entities = append(entities, vE)
-		} else {
+	for _, id := range ids {
+		vE, ok := s.Data[id].(states.StateEntry)
+		if !ok {
 			err = v1alpha2.NewCOAError(nil, "found invalid state entry", v1alpha2.InternalError)
 			sLog.Errorf("  P (Memory State): failed to list states: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
 			return entities, "", err
 		}
+		if !filter(vE) {
+			continue
+		}
+		if request.PageSize > 0 && len(entities) == request.PageSize {
+			return entities, entities[len(entities)-1].ID, nil
+		}
+		entities = append(entities, vE)
 	}
 
 	return entities, "", nil
@@ -168,12 +197,16 @@ func (s *MemoryStateProvider) Delete(ctx context.Context, request states.DeleteR
 
 	sLog.Debugf("  P (Memory State): delete state %s, traceId: %s", request.ID, span.SpanContext().TraceID().String())
 
-	if _, ok := s.Data[request.ID]; !ok {
+	existing, ok := s.Data[request.ID]
+	if !ok {
 		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' is not found", request.ID), v1alpha2.NotFound)
 		sLog.Errorf("  P (Memory State): failed to delete %s: %+v, traceId: %s", request.ID, err, span.SpanContext().TraceID().String())
 		return err
 	}
 	delete(s.Data, request.ID)
+	if existingEntry, ok := existing.(states.StateEntry); ok {
+		s.notify(states.WatchEventDeleted, existingEntry)
+	}
 
 	return nil
 }
@@ -205,6 +238,65 @@ func (s *MemoryStateProvider) Get(ctx context.Context, request states.GetRequest
 	return states.StateEntry{}, err
 }
 
+// Watch streams changes to all entries. Entries aren't namespaced, so request metadata is ignored. A watcher that
+// falls more than watchBufferSize events behind is dropped, which closes its channel.
+func (s *MemoryStateProvider) Watch(ctx context.Context, request states.WatchRequest) (<-chan states.WatchEvent, error) {
+	mLock.Lock()
+	defer mLock.Unlock()
+	_, span := observability.StartSpan("Memory State Provider", ctx, &map[string]string{
+		"method": "Watch",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Debugf("  P (Memory State): watch states, traceId: %s", span.SpanContext().TraceID().String())
+
+	if s.watchers == nil {
+		s.watchers = make(map[chan states.WatchEvent]bool)
+	}
+	ch := make(chan states.WatchEvent, len(s.Data)+watchBufferSize)
+	version := strconv.FormatInt(s.resourceVersion, 10)
+	for _, v := range s.Data {
+		if vE, ok := v.(states.StateEntry); ok {
+			ch <- states.WatchEvent{
+				Type:            states.WatchEventCreated,
+				Entry:           vE,
+				ResourceVersion: version,
+			}
+		}
+	}
+	s.watchers[ch] = true
+	go func() {
+		<-ctx.Done()
+		mLock.Lock()
+		defer mLock.Unlock()
+		if s.watchers[ch] {
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
+
+// notify sends an event to all watchers. Callers hold mLock.
+func (s *MemoryStateProvider) notify(eventType states.WatchEventType, entry states.StateEntry) {
+	s.resourceVersion++
+	event := states.WatchEvent{
+		Type:            eventType,
+		Entry:           entry,
+		ResourceVersion: strconv.FormatInt(s.resourceVersion, 10),
+	}
+	for ch := range s.watchers {
+		select {
+		case ch <- event:
+		default:
+			sLog.Errorf("  P (Memory State): dropping a watcher that fell behind")
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
 func toMemoryStateProviderConfig(config providers.IProviderConfig) (MemoryStateProviderConfig, error) {
 	ret := MemoryStateProviderConfig{}
 	data, err := json.Marshal(config)
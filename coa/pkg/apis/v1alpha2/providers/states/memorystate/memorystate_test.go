@@ -94,6 +94,84 @@ func TestList(t *testing.T) {
 	assert.Equal(t, "123", entries[0].ID)
 }
 
+func TestListPaging(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+	for _, id := range []string{"e", "b", "d", "a", "c"} {
+		_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID:   id,
+				Body: map[string]interface{}{},
+			},
+		})
+		assert.Nil(t, err)
+	}
+	ids := []string{}
+	token := ""
+	pages := 0
+	for {
+		entries, next, err := provider.List(context.Background(), states.ListRequest{
+			PageSize:          2,
+			ContinuationToken: token,
+		})
+		assert.Nil(t, err)
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		pages++
+		if next == "" {
+			break
+		}
+		token = next
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids)
+	assert.Equal(t, 3, pages)
+}
+
+func TestListLabelFilter(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+	for id, env := range map[string]string{"s1": "prod", "s2": "dev", "s3": "prod"} {
+		_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID: id,
+				Body: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]string{"env": env},
+					},
+				},
+			},
+		})
+		assert.Nil(t, err)
+	}
+	entries, token, err := provider.List(context.Background(), states.ListRequest{
+		FilterType: states.FilterTypeLabel,
+		Filter:     "env=prod",
+		PageSize:   1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "s1", entries[0].ID)
+	entries, token, err = provider.List(context.Background(), states.ListRequest{
+		FilterType:        states.FilterTypeLabel,
+		Filter:            "env=prod",
+		PageSize:          1,
+		ContinuationToken: token,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "s3", entries[0].ID)
+	assert.Equal(t, "", token)
+
+	_, _, err = provider.List(context.Background(), states.ListRequest{
+		FilterType: "owner",
+		Filter:     "me",
+	})
+	assert.NotNil(t, err)
+}
+
 func TestDelete(t *testing.T) {
 	provider := MemoryStateProvider{}
 	err := provider.Init(MemoryStateProvider{})
@@ -286,3 +364,71 @@ func TestClone(t *testing.T) {
 	assert.NotNil(t, p)
 	assert.Nil(t, err)
 }
+
+func TestWatch(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+	_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "123",
+			Body: TestPayload{Name: "existing"},
+		},
+	})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := provider.Watch(ctx, states.WatchRequest{})
+	assert.Nil(t, err)
+
+	event := <-events
+	assert.Equal(t, states.WatchEventCreated, event.Type)
+	assert.Equal(t, "123", event.Entry.ID)
+
+	_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "123",
+			Body: TestPayload{Name: "changed"},
+		},
+	})
+	assert.Nil(t, err)
+	event = <-events
+	assert.Equal(t, states.WatchEventUpdated, event.Type)
+	assert.Equal(t, "2", event.ResourceVersion)
+
+	err = provider.Delete(context.Background(), states.DeleteRequest{
+		ID: "123",
+	})
+	assert.Nil(t, err)
+	event = <-events
+	assert.Equal(t, states.WatchEventDeleted, event.Type)
+	assert.Equal(t, "123", event.Entry.ID)
+	assert.Equal(t, "3", event.ResourceVersion)
+
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestWatchSlowConsumer(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProviderConfig{})
+	assert.Nil(t, err)
+
+	events, err := provider.Watch(context.Background(), states.WatchRequest{})
+	assert.Nil(t, err)
+	for i := 0; i <= watchBufferSize; i++ {
+		_, err = provider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID:   "123",
+				Body: TestPayload{Value: i},
+			},
+		})
+		assert.Nil(t, err)
+	}
+	count := 0
+	for range events {
+		count++
+	}
+	assert.Equal(t, watchBufferSize, count)
+}
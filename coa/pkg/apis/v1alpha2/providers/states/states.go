@@ -29,6 +29,31 @@ type IStateProvider interface {
 	List(context.Context, ListRequest) ([]StateEntry, string, error)
 	SetContext(context *contexts.ManagerContext)
 }
+
+// IWatchableStateProvider is implemented by state providers that can push changes instead of being re-listed. Watch
+// first emits a created event for every existing entry, then an event for each change. The channel is closed when ctx
+// is cancelled or the provider stops delivering events, after which callers should watch again.
+type IWatchableStateProvider interface {
+	IStateProvider
+	Watch(context.Context, WatchRequest) (<-chan WatchEvent, error)
+}
+
+type WatchEventType string
+
+const (
+	WatchEventCreated WatchEventType = "created"
+	WatchEventUpdated WatchEventType = "updated"
+	WatchEventDeleted WatchEventType = "deleted"
+)
+
+type WatchEvent struct {
+	Type            WatchEventType `json:"type"`
+	Entry           StateEntry     `json:"entry"`
+	ResourceVersion string         `json:"resourceVersion"`
+}
+type WatchRequest struct {
+	Metadata map[string]interface{} `json:"metadata"`
+}
 type GetOption struct {
 	Consistency string `json:"consistency"` //eventual or strong
 }
@@ -59,10 +84,12 @@ type UpsertRequest struct {
 	Options  UpsertOption           `json:"options,omitempty"`
 }
 type ListRequest struct {
-	FilterType       string                 `json:"filterType"`
-	Filter           string                 `json:"filter"`
-	FilterParameters map[string]string      `json:"filterParameters"`
-	Metadata         map[string]interface{} `json:"metadata"`
+	FilterType        string                 `json:"filterType"`
+	Filter            string                 `json:"filter"`
+	FilterParameters  map[string]string      `json:"filterParameters"`
+	Metadata          map[string]interface{} `json:"metadata"`
+	PageSize          int                    `json:"pageSize,omitempty"`
+	ContinuationToken string                 `json:"continuationToken,omitempty"`
 }
 
 func JsonPathMatch(jsonData interface{}, path string, target string) bool {
@@ -80,4 +80,6 @@ type Endpoint struct {
 	Route      string
 	Handler    COAHandler
 	Parameters []string
+	// Compress compresses the responses of the endpoint with gzip for clients that accept it
+	Compress bool
 }
@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1alpha2
+
+import (
+	"fmt"
+	"time"
+	// embeds the IANA time zone database, so schedule zones resolve in images without one
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MissedRunPolicy decides what a recurring schedule does with fire times that passed while it wasn't polled,
+// for example because Symphony was down
+type MissedRunPolicy string
+
+const (
+	// MissedRunSkip fires only when the latest fire time passed within ScheduleFireTolerance. This is the default.
+	MissedRunSkip MissedRunPolicy = "skip"
+	// MissedRunCatchUpOnce fires once for all the fire times that passed
+	MissedRunCatchUpOnce MissedRunPolicy = "catchUpOnce"
+	// MissedRunCatchUpAll fires once for every fire time that passed, up to MaxCatchUpRuns
+	MissedRunCatchUpAll MissedRunPolicy = "catchUpAll"
+)
+
+const (
+	// ScheduleFireTolerance is how late a poll can see a fire time before the fire time counts as missed
+	ScheduleFireTolerance = time.Minute
+	// MaxCatchUpRuns caps how many missed fire times MissedRunCatchUpAll fires; older ones are dropped
+	MaxCatchUpRuns = 100
+	// MaxUpcomingFireTimes caps how many upcoming fire times can be listed for a schedule
+	MaxUpcomingFireTimes = 100
+)
+
+// legacyZones maps the zone abbreviations schedules accepted before IANA zone names were supported
+var legacyZones = map[string]string{
+	"LOCAL": "",
+	"PST":   "America/Los_Angeles",
+	"PDT":   "America/Los_Angeles",
+	"EST":   "America/New_York",
+	"EDT":   "America/New_York",
+	"CST":   "America/Chicago",
+	"CDT":   "America/Chicago",
+	"MST":   "America/Denver",
+	"MDT":   "America/Denver",
+}
+
+// LoadScheduleLocation loads a schedule zone, which is an IANA zone name such as "Europe/Berlin". An empty zone is UTC.
+func LoadScheduleLocation(zone string) (*time.Location, error) {
+	if v, ok := legacyZones[zone]; ok {
+		zone = v
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, NewCOAError(err, fmt.Sprintf("invalid schedule zone '%s'", zone), BadRequest)
+	}
+	return loc, nil
+}
+
+// IsRecurring reports whether the schedule fires repeatedly, on a cron expression or an interval
+func (s ScheduleSpec) IsRecurring() bool {
+	return s.Cron != "" || s.Interval != ""
+}
+
+// Validate checks the schedule can be evaluated
+func (s ScheduleSpec) Validate() error {
+	if s.Cron != "" && s.Interval != "" {
+		return NewCOAError(nil, "a schedule can't have both a cron expression and an interval", BadRequest)
+	}
+	if _, err := LoadScheduleLocation(s.Zone); err != nil {
+		return err
+	}
+	if !s.IsRecurring() || s.Date != "" || s.Time != "" {
+		if _, err := s.GetTime(); err != nil {
+			return NewCOAError(err, fmt.Sprintf("invalid schedule date '%s' or time '%s'", s.Date, s.Time), BadRequest)
+		}
+	}
+	if s.Cron != "" {
+		if _, err := cron.ParseStandard(s.Cron); err != nil {
+			return NewCOAError(err, fmt.Sprintf("invalid schedule cron expression '%s'", s.Cron), BadRequest)
+		}
+	}
+	if s.Interval != "" {
+		if _, err := s.interval(); err != nil {
+			return err
+		}
+	}
+	switch s.MissedRunPolicy {
+	case "", MissedRunSkip, MissedRunCatchUpOnce, MissedRunCatchUpAll:
+	default:
+		return NewCOAError(nil, fmt.Sprintf("invalid schedule missed run policy '%s'", s.MissedRunPolicy), BadRequest)
+	}
+	if s.LastFireTime != "" {
+		if _, err := time.Parse(time.RFC3339, s.LastFireTime); err != nil {
+			return NewCOAError(err, fmt.Sprintf("invalid schedule last fire time '%s'", s.LastFireTime), BadRequest)
+		}
+	}
+	return nil
+}
+
+// NextFireTime returns the first fire time after the given time. It returns false when the schedule doesn't fire
+// after that time. A recurring schedule with a date and time doesn't fire before them; an interval schedule
+// without them counts intervals from LastFireTime.
+func (s ScheduleSpec) NextFireTime(after time.Time) (time.Time, bool, error) {
+	next, err := s.nextFireTimeFunc()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	dt, ok := next(after)
+	return dt, ok, nil
+}
+
+// nextFireTimeFunc parses the schedule once and returns a function that computes the first fire time after a given
+// time, the way NextFireTime does
+func (s ScheduleSpec) nextFireTimeFunc() (func(after time.Time) (time.Time, bool), error) {
+	if !s.IsRecurring() {
+		dt, err := s.GetTime()
+		if err != nil {
+			return nil, err
+		}
+		return func(after time.Time) (time.Time, bool) {
+			return dt, dt.After(after)
+		}, nil
+	}
+	start, hasStart, err := s.startTime()
+	if err != nil {
+		return nil, err
+	}
+	if s.Cron != "" {
+		schedule, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			return nil, NewCOAError(err, fmt.Sprintf("invalid schedule cron expression '%s'", s.Cron), BadRequest)
+		}
+		loc, err := LoadScheduleLocation(s.Zone)
+		if err != nil {
+			return nil, err
+		}
+		return func(after time.Time) (time.Time, bool) {
+			if hasStart && after.Before(start) {
+				after = start.Add(-time.Nanosecond)
+			}
+			next := schedule.Next(after.In(loc))
+			return next, !next.IsZero()
+		}, nil
+	}
+	interval, err := s.interval()
+	if err != nil {
+		return nil, err
+	}
+	anchor := start
+	if !hasStart {
+		anchor, err = s.lastFireTime()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(after time.Time) (time.Time, bool) {
+		if anchor.IsZero() {
+			return after.Add(interval), true
+		}
+		// fire times are anchor + k * interval; find the first one after the given time
+		elapsed := after.Sub(anchor)
+		k := elapsed / interval
+		if elapsed < 0 && elapsed%interval != 0 {
+			k--
+		}
+		next := anchor.Add((k + 1) * interval)
+		if hasStart && next.Before(start) {
+			next = start
+		}
+		return next, true
+	}, nil
+}
+
+// UpcomingFireTimes lists up to count fire times after the given time
+func (s ScheduleSpec) UpcomingFireTimes(after time.Time, count int) ([]time.Time, error) {
+	nextFireTime, err := s.nextFireTimeFunc()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]time.Time, 0, count)
+	for len(ret) < count {
+		next, ok := nextFireTime(after)
+		if !ok {
+			break
+		}
+		ret = append(ret, next)
+		after = next
+	}
+	return ret, nil
+}
+
+// DueFireTimes returns the fire times of a recurring schedule that a poll at now should fire, according to
+// MissedRunPolicy. It also returns the latest fire time that has passed, which the caller records in LastFireTime
+// so that the next poll doesn't fire it again; it's zero when no fire time passed since LastFireTime.
+// Without a LastFireTime or a date and time, no fire time has passed yet.
+func (s ScheduleSpec) DueFireTimes(now time.Time) ([]time.Time, time.Time, error) {
+	since, err := s.lastFireTime()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if since.IsZero() {
+		start, hasStart, err := s.startTime()
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if !hasStart {
+			return nil, time.Time{}, nil
+		}
+		since = start.Add(-time.Nanosecond)
+	}
+	nextFireTime, err := s.nextFireTimeFunc()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	due := make([]time.Time, 0)
+	for {
+		next, ok := nextFireTime(since)
+		if !ok || next.After(now) {
+			break
+		}
+		due = append(due, next)
+		if len(due) > MaxCatchUpRuns {
+			due = due[1:]
+		}
+		since = next
+	}
+	if len(due) == 0 {
+		return nil, time.Time{}, nil
+	}
+	last := due[len(due)-1]
+	switch s.MissedRunPolicy {
+	case MissedRunCatchUpAll:
+		return due, last, nil
+	case MissedRunCatchUpOnce:
+		return []time.Time{last}, last, nil
+	}
+	if now.Sub(last) <= ScheduleFireTolerance {
+		return []time.Time{last}, last, nil
+	}
+	return nil, last, nil
+}
+
+func (s ScheduleSpec) startTime() (time.Time, bool, error) {
+	if s.Date == "" && s.Time == "" {
+		return time.Time{}, false, nil
+	}
+	dt, err := s.GetTime()
+	if err != nil {
+		return time.Time{}, false, NewCOAError(err, fmt.Sprintf("invalid schedule date '%s' or time '%s'", s.Date, s.Time), BadRequest)
+	}
+	return dt, true, nil
+}
+
+func (s ScheduleSpec) lastFireTime() (time.Time, error) {
+	if s.LastFireTime == "" {
+		return time.Time{}, nil
+	}
+	dt, err := time.Parse(time.RFC3339, s.LastFireTime)
+	if err != nil {
+		return time.Time{}, NewCOAError(err, fmt.Sprintf("invalid schedule last fire time '%s'", s.LastFireTime), BadRequest)
+	}
+	return dt, nil
+}
+
+func (s ScheduleSpec) interval() (time.Duration, error) {
+	interval, err := time.ParseDuration(s.Interval)
+	if err != nil || interval <= 0 {
+		return 0, NewCOAError(err, fmt.Sprintf("invalid schedule interval '%s'", s.Interval), BadRequest)
+	}
+	return interval, nil
+}
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParse(t *testing.T, value string) time.Time {
+	dt, err := time.Parse(time.RFC3339, value)
+	assert.Nil(t, err)
+	return dt
+}
+
+func TestScheduleIANAZone(t *testing.T) {
+	schedule := ScheduleSpec{
+		Date: "2020-01-01",
+		Time: "12:00:00PM",
+		Zone: "Europe/Berlin",
+	}
+	dt, err := schedule.GetTime()
+	assert.Nil(t, err)
+	assert.Equal(t, "2020-01-01 12:00:00 +0100 CET", dt.String())
+}
+
+func TestScheduleIsRecurring(t *testing.T) {
+	assert.False(t, ScheduleSpec{Date: "2020-01-01", Time: "12:00:00PM"}.IsRecurring())
+	assert.True(t, ScheduleSpec{Cron: "0 2 * * SUN"}.IsRecurring())
+	assert.True(t, ScheduleSpec{Interval: "6h"}.IsRecurring())
+}
+
+func TestScheduleValidate(t *testing.T) {
+	valid := []ScheduleSpec{
+		{Date: "2020-01-01", Time: "12:00:00PM", Zone: "PST"},
+		{Cron: "0 2 * * SUN", Zone: "America/New_York"},
+		{Interval: "6h", MissedRunPolicy: MissedRunCatchUpAll},
+		{Interval: "30m", Date: "2020-01-01", Time: "12:00:00PM", MissedRunPolicy: MissedRunCatchUpOnce},
+	}
+	for _, s := range valid {
+		assert.Nil(t, s.Validate(), "%+v", s)
+	}
+	invalid := []ScheduleSpec{
+		{},
+		{Date: "2020-01-01"},
+		{Date: "2020-01-01", Time: "12:00:00PM", Zone: "XXX"},
+		{Cron: "0 2 * * SUN", Interval: "6h"},
+		{Cron: "every sunday"},
+		{Interval: "soon"},
+		{Interval: "-1h"},
+		{Interval: "6h", MissedRunPolicy: "sometimes"},
+		{Interval: "6h", Date: "2020-01-01"},
+		{Interval: "6h", LastFireTime: "yesterday"},
+	}
+	for _, s := range invalid {
+		err := s.Validate()
+		assert.NotNil(t, err, "%+v", s)
+		coaErr, ok := err.(COAError)
+		assert.True(t, ok)
+		assert.Equal(t, BadRequest, coaErr.State)
+	}
+}
+
+func TestScheduleCronUpcomingFireTimes(t *testing.T) {
+	schedule := ScheduleSpec{
+		Cron: "0 3 * * SUN",
+		Zone: "America/Los_Angeles",
+	}
+	times, err := schedule.UpcomingFireTimes(mustParse(t, "2023-03-01T00:00:00Z"), 3)
+	assert.Nil(t, err)
+	// the fire times follow the zone across the daylight saving change on March 12th
+	assert.Equal(t, []string{"2023-03-05T11:00:00Z", "2023-03-12T10:00:00Z", "2023-03-19T10:00:00Z"}, formatTimes(times))
+}
+
+func TestScheduleCronStartsAtDate(t *testing.T) {
+	schedule := ScheduleSpec{
+		Date: "2023-06-01",
+		Time: "12:00:00AM",
+		Cron: "0 * * * *",
+	}
+	next, ok, err := schedule.NextFireTime(mustParse(t, "2023-01-01T00:00:00Z"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2023-06-01T00:00:00Z", next.UTC().Format(time.RFC3339))
+}
+
+func TestScheduleIntervalUpcomingFireTimes(t *testing.T) {
+	schedule := ScheduleSpec{
+		Date:     "2023-01-01",
+		Time:     "12:00:00AM",
+		Interval: "6h",
+	}
+	times, err := schedule.UpcomingFireTimes(mustParse(t, "2023-01-02T07:00:00Z"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-02T12:00:00Z", "2023-01-02T18:00:00Z"}, formatTimes(times))
+
+	times, err = schedule.UpcomingFireTimes(mustParse(t, "2022-12-01T00:00:00Z"), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-01T00:00:00Z", "2023-01-01T06:00:00Z"}, formatTimes(times))
+}
+
+func TestScheduleIntervalFromLastFireTime(t *testing.T) {
+	schedule := ScheduleSpec{
+		Interval:     "1h",
+		LastFireTime: "2023-01-01T00:30:00Z",
+	}
+	next, ok, err := schedule.NextFireTime(mustParse(t, "2023-01-01T02:00:00Z"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2023-01-01T02:30:00Z", next.UTC().Format(time.RFC3339))
+}
+
+func TestScheduleOneShotNextFireTime(t *testing.T) {
+	schedule := ScheduleSpec{
+		Date: "2023-01-01",
+		Time: "12:00:00PM",
+	}
+	_, ok, err := schedule.NextFireTime(mustParse(t, "2022-01-01T00:00:00Z"))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, ok, err = schedule.NextFireTime(mustParse(t, "2024-01-01T00:00:00Z"))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestScheduleDueFireTimesNotYet(t *testing.T) {
+	schedule := ScheduleSpec{
+		Interval:     "1h",
+		LastFireTime: "2023-01-01T00:00:00Z",
+	}
+	due, last, err := schedule.DueFireTimes(mustParse(t, "2023-01-01T00:59:00Z"))
+	assert.Nil(t, err)
+	assert.Empty(t, due)
+	assert.True(t, last.IsZero())
+}
+
+func TestScheduleDueFireTimesOnTime(t *testing.T) {
+	schedule := ScheduleSpec{
+		Interval:     "1h",
+		LastFireTime: "2023-01-01T00:00:00Z",
+	}
+	due, last, err := schedule.DueFireTimes(mustParse(t, "2023-01-01T01:00:15Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-01T01:00:00Z"}, formatTimes(due))
+	assert.Equal(t, "2023-01-01T01:00:00Z", last.UTC().Format(time.RFC3339))
+}
+
+func TestScheduleDueFireTimesMissedRunPolicies(t *testing.T) {
+	now := mustParse(t, "2023-01-01T03:30:00Z")
+	schedule := ScheduleSpec{
+		Interval:     "1h",
+		LastFireTime: "2023-01-01T00:00:00Z",
+	}
+
+	due, last, err := schedule.DueFireTimes(now)
+	assert.Nil(t, err)
+	assert.Empty(t, due)
+	assert.Equal(t, "2023-01-01T03:00:00Z", last.UTC().Format(time.RFC3339))
+
+	schedule.MissedRunPolicy = MissedRunCatchUpOnce
+	due, last, err = schedule.DueFireTimes(now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-01T03:00:00Z"}, formatTimes(due))
+	assert.Equal(t, "2023-01-01T03:00:00Z", last.UTC().Format(time.RFC3339))
+
+	schedule.MissedRunPolicy = MissedRunCatchUpAll
+	due, _, err = schedule.DueFireTimes(now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-01T01:00:00Z", "2023-01-01T02:00:00Z", "2023-01-01T03:00:00Z"}, formatTimes(due))
+}
+
+func TestScheduleDueFireTimesCatchUpAllIsCapped(t *testing.T) {
+	schedule := ScheduleSpec{
+		Interval:        "1m",
+		LastFireTime:    "2023-01-01T00:00:00Z",
+		MissedRunPolicy: MissedRunCatchUpAll,
+	}
+	due, last, err := schedule.DueFireTimes(mustParse(t, "2023-01-02T00:00:00Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, MaxCatchUpRuns, len(due))
+	assert.Equal(t, last, due[len(due)-1])
+	assert.Equal(t, "2023-01-02T00:00:00Z", last.UTC().Format(time.RFC3339))
+}
+
+func TestScheduleDueFireTimesStartsAtDate(t *testing.T) {
+	schedule := ScheduleSpec{
+		Date:     "2023-01-01",
+		Time:     "12:00:00AM",
+		Interval: "1h",
+	}
+	due, _, err := schedule.DueFireTimes(mustParse(t, "2023-01-01T00:00:30Z"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2023-01-01T00:00:00Z"}, formatTimes(due))
+}
+
+func TestScheduleShouldFireNowRecurring(t *testing.T) {
+	schedule := ScheduleSpec{
+		Interval:     "1h",
+		LastFireTime: time.Now().UTC().Add(-time.Hour - time.Second).Format(time.RFC3339),
+	}
+	fire, err := schedule.ShouldFireNow()
+	assert.Nil(t, err)
+	assert.True(t, fire)
+
+	schedule.LastFireTime = time.Now().UTC().Format(time.RFC3339)
+	fire, err = schedule.ShouldFireNow()
+	assert.Nil(t, err)
+	assert.False(t, fire)
+}
+
+func formatTimes(times []time.Time) []string {
+	ret := make([]string, 0, len(times))
+	for _, t := range times {
+		ret = append(ret, t.UTC().Format(time.RFC3339))
+	}
+	return ret
+}
@@ -16,4 +16,7 @@ type SiteConnection struct {
 	BaseUrl  string `json:"baseUrl"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// TokenPath is a file with the token that the site is enrolled with at the parent site. When it's set, the site
+	// authenticates to the parent with the token instead of the user name and password.
+	TokenPath string `json:"tokenPath,omitempty"`
 }
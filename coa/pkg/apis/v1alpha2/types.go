@@ -20,8 +20,10 @@ const (
 	Accepted State = 202
 	// BadRequest = HTTP 400
 	BadRequest State = 400
-	// Unauthorized = HTTP 403
-	Unauthorized State = 403
+	// Unauthorized = HTTP 401
+	Unauthorized State = 401
+	// Forbidden = HTTP 403
+	Forbidden State = 403
 	// NotFound = HTTP 404
 	NotFound State = 404
 	// MethodNotAllowed = HTTP 405
@@ -41,6 +43,9 @@ const (
 	SerializationError State = 5000
 	// Async requets
 	DeleteRequested State = 6000
+	// Retry hints
+	TransientError State = 7000
+	TerminalError  State = 7001
 	// Operation results
 	UpdateFailed   State = 8001
 	DeleteFailed   State = 8002
@@ -48,6 +53,7 @@ const (
 	Updated        State = 8004
 	Deleted        State = 8005
 	// Workflow status
+	Cancelled      State = 9993
 	Running        State = 9994
 	Paused         State = 9995
 	Done           State = 9996
@@ -66,6 +72,8 @@ func (s State) String() string {
 		return "Bad Request"
 	case Unauthorized:
 		return "Unauthorized"
+	case Forbidden:
+		return "Forbidden"
 	case NotFound:
 		return "Not Found"
 	case MethodNotAllowed:
@@ -88,6 +96,10 @@ func (s State) String() string {
 		return "Serialization Error"
 	case DeleteRequested:
 		return "Delete Requested"
+	case TransientError:
+		return "Transient Error"
+	case TerminalError:
+		return "Terminal Error"
 	case UpdateFailed:
 		return "Update Failed"
 	case DeleteFailed:
@@ -98,6 +110,8 @@ func (s State) String() string {
 		return "Updated"
 	case Deleted:
 		return "Deleted"
+	case Cancelled:
+		return "Cancelled"
 	case Running:
 		return "Running"
 	case Paused:
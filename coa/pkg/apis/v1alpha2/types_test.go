@@ -18,6 +18,7 @@ func TestStateString(t *testing.T) {
 		Accepted:           "Accepted",
 		BadRequest:         "Bad Request",
 		Unauthorized:       "Unauthorized",
+		Forbidden:          "Forbidden",
 		NotFound:           "Not Found",
 		MethodNotAllowed:   "Method Not Allowed",
 		Conflict:           "Conflict",
@@ -29,11 +30,14 @@ func TestStateString(t *testing.T) {
 		FileAccessError:    "File Access Error",
 		SerializationError: "Serialization Error",
 		DeleteRequested:    "Delete Requested",
+		TransientError:     "Transient Error",
+		TerminalError:      "Terminal Error",
 		UpdateFailed:       "Update Failed",
 		DeleteFailed:       "Delete Failed",
 		ValidateFailed:     "Validate Failed",
 		Updated:            "Updated",
 		Deleted:            "Deleted",
+		Cancelled:          "Cancelled",
 		Running:            "Running",
 		Paused:             "Paused",
 		Done:               "Done",
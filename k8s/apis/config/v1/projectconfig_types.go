@@ -25,7 +25,30 @@ type ProjectConfig struct {
 
 	SyncIntervalSeconds uint `json:"syncIntervalSeconds,omitempty"`
 
+	// InstanceDeletionTimeoutSeconds is how long a deleted instance waits for its components to be removed before it's
+	// orphaned. Defaults to 300.
+	InstanceDeletionTimeoutSeconds uint `json:"instanceDeletionTimeoutSeconds,omitempty"`
+
 	ValidationPolicies map[string][]ValidationPolicy `json:"validationPolicies,omitempty"`
+
+	// SymphonyAPI configures how the controllers call the Symphony API
+	SymphonyAPI SymphonyAPIConfig `json:"symphonyAPI,omitempty"`
+}
+
+type SymphonyAPIConfig struct {
+	// URL of the Symphony API, such as http://symphony-service:8080/v1alpha2/
+	URL string `json:"url,omitempty"`
+	// AuthMethod is password (default), serviceAccountToken or mTLS
+	AuthMethod string `json:"authMethod,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	// SecretName is a secret in the namespace of the controller manager that can carry the username, password, ca.crt,
+	// tls.crt and tls.key. Its values take precedence over the ones above.
+	SecretName string `json:"secretName,omitempty"`
+	// TokenPath is the token file of serviceAccountToken. Defaults to the token of the service account.
+	TokenPath string `json:"tokenPath,omitempty"`
+	// CAFile verifies the API over TLS, in place of the system roots
+	CAFile string `json:"caFile,omitempty"`
 }
 
 type ValidationPolicy struct {
@@ -63,9 +63,44 @@ type SolutionSpec struct {
 
 // +kubebuilder:object:generate=true
 type ScheduleSpec struct {
-	Date string `json:"date"`
-	Time string `json:"time"`
-	Zone string `json:"zone"`
+	Date string `json:"date,omitempty"`
+	Time string `json:"time,omitempty"`
+	Zone string `json:"zone,omitempty"`
+	// Cron is a five-field cron expression, such as "0 2 * * SUN", evaluated in Zone
+	Cron string `json:"cron,omitempty"`
+	// Interval is the time between fire times, such as "6h"
+	Interval string `json:"interval,omitempty"`
+	// +kubebuilder:validation:Enum=skip;catchUpOnce;catchUpAll
+	MissedRunPolicy string `json:"missedRunPolicy,omitempty"`
+	LastFireTime    string `json:"lastFireTime,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type RolloutSpec struct {
+	// MaxConcurrency is how many sites of a wave are processed at the same time; 0 processes the whole wave at once
+	// +kubebuilder:validation:Minimum=0
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// WaveSize is how many sites are in a wave; 0 puts all the sites in one wave
+	// +kubebuilder:validation:Minimum=0
+	WaveSize int `json:"waveSize,omitempty"`
+	// FailureThreshold is how many sites can fail before the remaining waves are halted; 0 never halts
+	// +kubebuilder:validation:Minimum=0
+	FailureThreshold int `json:"failureThreshold,omitempty"`
+	// WavePause is the time to wait between waves, such as "5m"
+	WavePause string `json:"wavePause,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type StageRetrySpec struct {
+	// MaxAttempts is the number of attempts, including the first one
+	// +kubebuilder:validation:Minimum=0
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialDelay is the time to wait before the first retry, such as "10s"
+	InitialDelay string `json:"initialDelay,omitempty"`
+	// MaxDelay caps the time to wait between attempts, such as "5m"
+	MaxDelay string `json:"maxDelay,omitempty"`
+	// AttemptTimeout bounds each attempt, such as "1m", so that a hung attempt is retried
+	AttemptTimeout string `json:"attemptTimeout,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -82,6 +117,12 @@ type StageSpec struct {
 	Inputs          runtime.RawExtension `json:"inputs,omitempty"`
 	TriggeringStage string               `json:"triggeringStage,omitempty"`
 	Schedule        *ScheduleSpec        `json:"schedule,omitempty"`
+	Rollout         *RolloutSpec         `json:"rollout,omitempty"`
+	// Timeout is the deadline of the stage on all its sites, retries included, such as "30m"
+	Timeout string          `json:"timeout,omitempty"`
+	Retry   *StageRetrySpec `json:"retry,omitempty"`
+	// OnFailure is the stage that runs instead of the stage selector when the stage fails
+	OnFailure string `json:"onFailure,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -101,6 +142,7 @@ type CampaignSpec struct {
 	FirstStage  string               `json:"firstStage,omitempty"`
 	Stages      map[string]StageSpec `json:"stages,omitempty"`
 	SelfDriving bool                 `json:"selfDriving,omitempty"`
+	Schedule    *ScheduleSpec        `json:"schedule,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -41,6 +41,11 @@ func (in *CampaignSpec) DeepCopyInto(out *CampaignSpec) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(ScheduleSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CampaignSpec.
@@ -117,6 +122,21 @@ func (in *ComponentSpec) DeepCopy() *ComponentSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutSpec) DeepCopyInto(out *RolloutSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutSpec.
+func (in *RolloutSpec) DeepCopy() *RolloutSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScheduleSpec) DeepCopyInto(out *ScheduleSpec) {
 	*out = *in
@@ -177,6 +197,21 @@ func (in *SolutionSpec) DeepCopy() *SolutionSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageRetrySpec) DeepCopyInto(out *StageRetrySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageRetrySpec.
+func (in *StageRetrySpec) DeepCopy() *StageRetrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StageRetrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StageSpec) DeepCopyInto(out *StageSpec) {
 	*out = *in
@@ -187,6 +222,16 @@ func (in *StageSpec) DeepCopyInto(out *StageSpec) {
 		*out = new(ScheduleSpec)
 		**out = **in
 	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutSpec)
+		**out = **in
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(StageRetrySpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageSpec.
@@ -27,6 +27,21 @@ type ActivationStatus struct {
 	IsActive             bool                 `json:"isActive,omitempty"`
 	ActivationGeneration string               `json:"activationGeneration,omitempty"`
 	UpdateTime           string               `json:"updateTime,omitempty"`
+	Waves                []WaveStatus         `json:"waves,omitempty"`
+	// StopReason is why a stage stopped before it was done, such as timedOut or retriesExhausted
+	StopReason string `json:"stopReason,omitempty"`
+	// Control is the last operator request on the activation, such as pause, resume or cancel
+	Control string `json:"control,omitempty"`
+}
+
+// WaveStatus is the result of a wave of a stage rollout
+type WaveStatus struct {
+	Wave        int            `json:"wave"`
+	Sites       []string       `json:"sites,omitempty"`
+	Status      v1alpha2.State `json:"status,omitempty"`
+	Succeeded   int            `json:"succeeded,omitempty"`
+	Failed      int            `json:"failed,omitempty"`
+	FailedSites []string       `json:"failedSites,omitempty"`
 }
 
 // +kubebuilder:object:root=true
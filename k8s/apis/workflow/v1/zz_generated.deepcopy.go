@@ -79,6 +79,13 @@ func (in *ActivationStatus) DeepCopyInto(out *ActivationStatus) {
 	*out = *in
 	in.Inputs.DeepCopyInto(&out.Inputs)
 	in.Outputs.DeepCopyInto(&out.Outputs)
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]WaveStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActivationStatus.
@@ -163,3 +170,28 @@ func (in *CampaignStatus) DeepCopy() *CampaignStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaveStatus) DeepCopyInto(out *WaveStatus) {
+	*out = *in
+	if in.Sites != nil {
+		in, out := &in.Sites, &out.Sites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedSites != nil {
+		in, out := &in.FailedSites, &out.FailedSites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaveStatus.
+func (in *WaveStatus) DeepCopy() *WaveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WaveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
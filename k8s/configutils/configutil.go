@@ -8,11 +8,15 @@ package configutils
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	configv1 "gopls-workspace/apis/config/v1"
+	"gopls-workspace/utils"
 
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -21,6 +25,7 @@ import (
 
 var (
 	namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	tokenFile     = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 	configName    = os.Getenv("CONFIG_NAME")
 )
 
@@ -35,35 +40,132 @@ func GetValidationPoilicies() (map[string][]configv1.ValidationPolicy, error) {
 	// // create the clientset
 	// clientset, err := kubernetes.NewForConfig(config)
 
-	config, err := rest.InClusterConfig()
+	myConfig, err := getProjectConfig()
 	if err != nil {
 		return nil, err
 	}
+	return myConfig.ValidationPolicies, nil
+}
+
+// getProjectConfig reads the configuration of the controller manager from the config map named by CONFIG_NAME
+func getProjectConfig() (configv1.ProjectConfig, error) {
+	var myConfig configv1.ProjectConfig
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return myConfig, err
+	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
 	namespace, err := getNamespace()
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
 	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configName, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		return myConfig, err
 	}
 
-	var myConfig configv1.ProjectConfig
 	data := configMap.Data["controller_manager_config.yaml"]
 	err = yaml.Unmarshal([]byte(data), &myConfig)
+	return myConfig, err
+}
+
+// GetSymphonyAPI finds where and how the controllers call the Symphony API, and registers how the calls
+// authenticate. The settings come from the given configuration, such as the one of the --config file, or else from the
+// config map named by CONFIG_NAME. Values of the secret that the settings name override the settings. The secret is read
+// from the controller's namespace, where the secret-reader role grants access to it.
+func GetSymphonyAPI(ctx context.Context, apiConfig configv1.SymphonyAPIConfig) (utils.SymphonyAPI, error) {
+	if apiConfig == (configv1.SymphonyAPIConfig{}) && configName != "" {
+		if myConfig, err := getProjectConfig(); err == nil {
+			apiConfig = myConfig.SymphonyAPI
+		}
+	}
+	ret := utils.DefaultSymphonyAPI()
+	if apiConfig.URL != "" {
+		ret.BaseUrl = apiConfig.URL
+		if !strings.HasSuffix(ret.BaseUrl, "/") {
+			ret.BaseUrl += "/"
+		}
+	}
+	if apiConfig.Username != "" {
+		ret.User = apiConfig.Username
+	}
+	ret.Password = apiConfig.Password
+
+	var ca, cert, key []byte
+	if apiConfig.CAFile != "" {
+		data, err := ioutil.ReadFile(apiConfig.CAFile)
+		if err != nil {
+			return ret, err
+		}
+		ca = data
+	}
+	if apiConfig.SecretName != "" {
+		secret, err := getSecret(ctx, apiConfig.SecretName)
+		if err != nil {
+			return ret, fmt.Errorf("failed to get Symphony API secret '%s': %w", apiConfig.SecretName, err)
+		}
+		if v, ok := secret["username"]; ok {
+			ret.User = string(v)
+		}
+		if v, ok := secret["password"]; ok {
+			ret.Password = string(v)
+		}
+		if v, ok := secret["ca.crt"]; ok {
+			ca = v
+		}
+		cert = secret["tls.crt"]
+		key = secret["tls.key"]
+	}
+
+	auth := api_utils.APIAuth{
+		Method:    apiConfig.AuthMethod,
+		TokenPath: apiConfig.TokenPath,
+	}
+	if auth.Method == api_utils.AuthMethodServiceAccountToken && auth.TokenPath == "" {
+		auth.TokenPath = tokenFile
+	}
+	if len(ca) > 0 || len(cert) > 0 || len(key) > 0 {
+		tlsConfig, err := api_utils.NewAPITLSConfig(ca, cert, key)
+		if err != nil {
+			return ret, err
+		}
+		auth.TLSConfig = tlsConfig
+	}
+	if err := api_utils.SetAPIAuth(ret.BaseUrl, auth); err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
+
+func getSecret(ctx context.Context, name string) (map[string][]byte, error) {
+	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return myConfig.ValidationPolicies, nil
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := getNamespace()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
 }
+
 func getNamespace() (string, error) {
 	// read the namespace from the file
 	data, err := ioutil.ReadFile(namespaceFile)
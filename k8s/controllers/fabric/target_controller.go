@@ -36,6 +36,8 @@ import (
 type TargetReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// API is where the Symphony API is called
+	API utils.SymphonyAPI
 }
 
 //+kubebuilder:rbac:groups=fabric.symphony,resources=targets,verbs=get;list;watch;create;update;patch;delete
@@ -77,7 +79,7 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			}
 		}
 
-		summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
+		summary, err := api_utils.GetSummary(ctx, r.API.BaseUrl, r.API.User, r.API.Password, fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
 		if err != nil && !v1alpha2.IsNotFound(err) {
 			uErr := r.updateTargetStatusToReconciling(target, err)
 			if uErr != nil {
@@ -101,7 +103,7 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
 		} else {
 			// Queue a job every 60s or when the generation is changed
-			err = api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", target.ObjectMeta.Name, target.ObjectMeta.Namespace, false, true)
+			err = api_utils.QueueJob(ctx, r.API.BaseUrl, r.API.User, r.API.Password, target.ObjectMeta.Name, target.ObjectMeta.Namespace, false, true)
 			if err != nil {
 				uErr := r.updateTargetStatusToReconciling(target, err)
 				if uErr != nil {
@@ -130,7 +132,7 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	} else { // remove
 		if controllerutil.ContainsFinalizer(target, myFinalizerName) {
-			err := api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", target.ObjectMeta.Name, target.ObjectMeta.Namespace, true, true)
+			err := api_utils.QueueJob(ctx, r.API.BaseUrl, r.API.User, r.API.Password, target.ObjectMeta.Name, target.ObjectMeta.Namespace, true, true)
 
 			if err != nil {
 				uErr := r.updateTargetStatusToReconciling(target, err)
@@ -149,7 +151,7 @@ func (r *TargetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 					// Timeout exceeded, assume deletion failed and proceed with finalization
 					break loop
 				case <-ticker:
-					summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
+					summary, err := api_utils.GetSummary(ctx, r.API.BaseUrl, r.API.User, r.API.Password, fmt.Sprintf("target-runtime-%s", target.ObjectMeta.Name), target.ObjectMeta.Namespace)
 					if err == nil && summary.Summary.IsRemoval == true && summary.Summary.AllAssignedDeployed {
 						break loop
 					}
@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	federationv1 "gopls-workspace/apis/federation/v1"
+	"gopls-workspace/utils"
 
 	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 )
@@ -24,6 +25,8 @@ import (
 type CatalogReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// API is where the Symphony API is called
+	API utils.SymphonyAPI
 }
 
 //+kubebuilder:rbac:groups=federation.symphony,resources=catalogs,verbs=get;list;watch;create;update;patch;delete
@@ -49,7 +52,7 @@ func (r *CatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	if catalog.ObjectMeta.DeletionTimestamp.IsZero() { // update
 		jData, _ := json.Marshal(catalog.Spec)
-		err := api_utils.CatalogHook(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", jData)
+		err := api_utils.CatalogHook(ctx, r.API.BaseUrl, r.API.User, r.API.Password, jData)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -41,8 +41,25 @@ import (
 type InstanceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// API is where the Symphony API is called
+	API utils.SymphonyAPI
+	// DeletionTimeout is how long a deleted instance waits for its components to be removed before it's orphaned.
+	// Defaults to 5 minutes.
+	DeletionTimeout time.Duration
 }
 
+const (
+	// ForceDeleteAnnotation removes a deleted instance right away, without waiting for its components to be removed
+	ForceDeleteAnnotation = "solution.symphony/force-delete"
+
+	defaultDeletionTimeout = 5 * time.Minute
+	deletionPollInterval   = 10 * time.Second //TODO: adjust based on provider SLA?
+
+	// status properties of a deleted instance
+	deletionStartedKey = "deletion-started"
+	deletionSummaryKey = "deletion-summary"
+)
+
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=solution.symphony,resources=instances/finalizers,verbs=update
@@ -81,7 +98,7 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			}
 		}
 
-		summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+		summary, err := api_utils.GetSummary(ctx, r.API.BaseUrl, r.API.User, r.API.Password, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
 		if err != nil && !v1alpha2.IsNotFound(err) {
 			uErr := r.updateInstanceStatusToReconciling(instance, err)
 			if uErr != nil {
@@ -102,7 +119,7 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 			return ctrl.Result{RequeueAfter: 60 * time.Second}, nil
 		} else {
 			// Queue a job every 60s or when the generation is changed
-			err = api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, false, false)
+			err = api_utils.QueueJob(ctx, r.API.BaseUrl, r.API.User, r.API.Password, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, false, false)
 			if err != nil {
 				uErr := r.updateInstanceStatusToReconciling(instance, err)
 				if uErr != nil {
@@ -128,45 +145,112 @@ func (r *InstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 	} else { // delete
 		if controllerutil.ContainsFinalizer(instance, myFinalizerName) {
-			err := api_utils.QueueJob(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, true, false)
-
-			if err != nil {
-				uErr := r.updateInstanceStatusToReconciling(instance, err)
-				if uErr != nil {
-					return ctrl.Result{}, uErr
-				}
-				return ctrl.Result{}, err
-			}
-			timeout := time.After(5 * time.Minute)
-			ticker := time.Tick(10 * time.Second) //TODO: configurable? adjust based on provider SLA?
-		loop:
-			for {
-				select {
-				case <-timeout:
-					// Timeout exceeded, assume deletion failed and proceed with finalization
-					break loop
-				case <-ticker:
-					summary, err := api_utils.GetSummary(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
-					if err == nil && summary.Summary.IsRemoval == true && summary.Summary.SuccessCount == summary.Summary.TargetCount {
-						break loop
-					}
-					if err != nil && v1alpha2.IsNotFound(err) {
-						break loop
-					}
-				}
-			}
-			// NOTE: we assume the message backend provides at-least-once delivery so that the removal event will be eventually handled.
-			// Until the corresponding provider can successfully carry out the removal job, the job event will remain available for the
-			// provider to pick up.
-			controllerutil.RemoveFinalizer(instance, myFinalizerName)
-			if err := r.Client.Update(ctx, instance); err != nil {
-				return ctrl.Result{}, err
-			}
+			return r.reconcileDelete(ctx, instance, myFinalizerName)
 		}
 	}
 	return ctrl.Result{}, nil
 }
 
+// reconcileDelete queues the removal job of a deleted instance and then checks on it every time it's requeued, so
+// that no worker is held while the components are removed. A failed removal is queued again until the deletion timeout,
+// after which the instance is orphaned.
+func (r *InstanceReconciler) reconcileDelete(ctx context.Context, instance *symphonyv1.Instance, finalizerName string) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	if instance.ObjectMeta.Annotations[ForceDeleteAnnotation] == "true" {
+		log.Info("Force deleting Instance without waiting for its components to be removed")
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance, finalizerName)
+	}
+
+	started, err := time.Parse(time.RFC3339, instance.Status.Properties[deletionStartedKey])
+	if err != nil {
+		// The removal job hasn't been queued yet
+		instance.Status.Properties[deletionStartedKey] = time.Now().UTC().Format(time.RFC3339)
+		return r.queueRemovalJob(ctx, instance)
+	}
+
+	timeout := r.DeletionTimeout
+	if timeout <= 0 {
+		timeout = defaultDeletionTimeout
+	}
+	if time.Since(started) > timeout {
+		// NOTE: we assume the message backend provides at-least-once delivery so that the removal event will be eventually handled.
+		// Until the corresponding provider can successfully carry out the removal job, the job event will remain available for the
+		// provider to pick up.
+		log.Info("Timed out waiting for Instance components to be removed, orphaning them", "timeout", timeout.String())
+		err = r.updateInstanceDeletionStatus(instance, provisioningstates.Orphaned, fmt.Sprintf("Components were not confirmed to be removed within %s", timeout))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance, finalizerName)
+	}
+
+	if instance.Status.Properties["status"] == provisioningstates.DeleteFailed {
+		return r.queueRemovalJob(ctx, instance)
+	}
+
+	summary, err := api_utils.GetSummary(ctx, r.API.BaseUrl, r.API.User, r.API.Password, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return ctrl.Result{}, r.removeFinalizer(ctx, instance, finalizerName)
+		}
+		log.Error(err, "unable to get Instance summary")
+		return ctrl.Result{RequeueAfter: deletionPollInterval}, nil
+	}
+	if !summary.Summary.IsRemoval || summary.Time.Format(time.RFC3339Nano) == instance.Status.Properties[deletionSummaryKey] {
+		// The removal job hasn't been handled yet
+		return ctrl.Result{RequeueAfter: deletionPollInterval}, nil
+	}
+	if summary.Summary.SuccessCount == summary.Summary.TargetCount {
+		return ctrl.Result{}, r.removeFinalizer(ctx, instance, finalizerName)
+	}
+
+	// Remember the failed summary so that it isn't taken for the result of the retry
+	instance.Status.Properties[deletionSummaryKey] = summary.Time.Format(time.RFC3339Nano)
+	err = r.updateInstanceDeletionStatus(instance, provisioningstates.DeleteFailed, summary.Summary.SummaryMessage)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: deletionPollInterval}, nil
+}
+
+func (r *InstanceReconciler) queueRemovalJob(ctx context.Context, instance *symphonyv1.Instance) (ctrl.Result, error) {
+	err := api_utils.QueueJob(ctx, r.API.BaseUrl, r.API.User, r.API.Password, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace, true, false)
+	if err != nil {
+		uErr := r.updateInstanceDeletionStatus(instance, provisioningstates.DeleteFailed, fmt.Sprintf("Failed to queue removal job: %s", err.Error()))
+		if uErr != nil {
+			return ctrl.Result{}, uErr
+		}
+		return ctrl.Result{RequeueAfter: deletionPollInterval}, nil
+	}
+	if err = r.updateInstanceDeletionStatus(instance, provisioningstates.Deleting, ""); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: deletionPollInterval}, nil
+}
+
+func (r *InstanceReconciler) removeFinalizer(ctx context.Context, instance *symphonyv1.Instance, finalizerName string) error {
+	controllerutil.RemoveFinalizer(instance, finalizerName)
+	return r.Client.Update(ctx, instance)
+}
+
+// updateInstanceDeletionStatus updates a deleted Instance object to Deleting, DeleteFailed or Orphaned state
+func (r *InstanceReconciler) updateInstanceDeletionStatus(instance *symphonyv1.Instance, status string, details string) error {
+	instance.Status.Properties["status"] = status
+	instance.Status.Properties["status-details"] = details
+	r.ensureOperationState(instance, status)
+	instance.Status.ProvisioningStatus.Error = apimodel.ErrorType{}
+	if status == provisioningstates.DeleteFailed {
+		instance.Status.ProvisioningStatus.Error = apimodel.ErrorType{
+			Code:    "Symphony: [500]",
+			Message: fmt.Sprintf("Deletion failed. %s", details),
+			Target:  "Symphony",
+		}
+	}
+	instance.Status.LastModified = metav1.Now()
+	return r.Client.Status().Update(context.Background(), instance)
+}
+
 func (r *InstanceReconciler) ensureOperationState(instance *symphonyv1.Instance, provisioningState string) {
 	instance.Status.ProvisioningStatus.Status = provisioningState
 	instance.Status.ProvisioningStatus.OperationID = instance.ObjectMeta.Annotations[constants.AzureOperationKey]
@@ -203,6 +287,18 @@ func (r *InstanceReconciler) updateInstanceStatus(instance *symphonyv1.Instance,
 	instance.Status.Properties["deployed"] = successCount
 	instance.Status.Properties["targets"] = targetCount
 	instance.Status.Properties["status-details"] = summary.SummaryMessage
+	if summary.Rollback != nil {
+		rollbackStatus := "Failed"
+		if summary.Rollback.Succeeded {
+			rollbackStatus = "Succeeded"
+		}
+		if summary.Rollback.Message != "" {
+			rollbackStatus = fmt.Sprintf("%s - %s", rollbackStatus, summary.Rollback.Message)
+		}
+		instance.Status.Properties["rollback"] = rollbackStatus
+	} else {
+		delete(instance.Status.Properties, "rollback")
+	}
 
 	// If a component is ever deployed, it will always show in Status.Properties
 	// If a component is not deleted, it will first be reset to Untouched and
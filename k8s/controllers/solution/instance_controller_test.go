@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	symphonyv1 "gopls-workspace/apis/solution/v1"
+	"gopls-workspace/utils"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	provisioningstates "github.com/eclipse-symphony/symphony/k8s/utils/models"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testFinalizerName = "instance.solution.symphony/finalizer"
+
+// fakeSymphonyAPI serves the queue routes of the Symphony API with a summary that tests can change
+type fakeSymphonyAPI struct {
+	server    *httptest.Server
+	lock      sync.Mutex
+	summary   *model.SummaryResult
+	removals  int
+	summaries int
+}
+
+func newFakeSymphonyAPI(t *testing.T) *fakeSymphonyAPI {
+	f := &fakeSymphonyAPI{}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		switch {
+		case r.URL.Path == "/v1alpha2/users/auth":
+			w.Write([]byte(`{"accessToken":"token","tokenType":"Bearer"}`))
+		case r.URL.Path == "/v1alpha2/solution/queue" && r.Method == http.MethodPost:
+			if r.URL.Query().Get("delete") == "true" {
+				f.removals++
+			}
+		case r.URL.Path == "/v1alpha2/solution/queue" && r.Method == http.MethodGet:
+			f.summaries++
+			if f.summary == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			data, _ := json.Marshal(f.summary)
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSymphonyAPI) setSummary(summary *model.SummaryResult) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.summary = summary
+}
+
+func (f *fakeSymphonyAPI) counts() (int, int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.removals, f.summaries
+}
+
+func newDeletedInstance(annotations map[string]string, properties map[string]string) *symphonyv1.Instance {
+	now := metav1.Now()
+	return &symphonyv1.Instance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "instance1",
+			Namespace:         "default",
+			Annotations:       annotations,
+			Finalizers:        []string{testFinalizerName},
+			DeletionTimestamp: &now,
+		},
+		Status: symphonyv1.InstanceStatus{
+			Properties: properties,
+		},
+	}
+}
+
+func newTestInstanceReconciler(t *testing.T, api *fakeSymphonyAPI, instance *symphonyv1.Instance) *InstanceReconciler {
+	scheme := runtime.NewScheme()
+	require.NoError(t, symphonyv1.AddToScheme(scheme))
+	return &InstanceReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build(),
+		Scheme: scheme,
+		API: utils.SymphonyAPI{
+			BaseUrl: api.server.URL + "/v1alpha2/",
+			User:    "admin",
+		},
+	}
+}
+
+func reconcileInstance(t *testing.T, r *InstanceReconciler) (ctrl.Result, *symphonyv1.Instance) {
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "instance1", Namespace: "default"}})
+	require.NoError(t, err)
+	instance := &symphonyv1.Instance{}
+	err = r.Client.Get(context.Background(), types.NamespacedName{Name: "instance1", Namespace: "default"}, instance)
+	if apierrors.IsNotFound(err) {
+		return result, nil
+	}
+	require.NoError(t, err)
+	return result, instance
+}
+
+func requireFinalized(t *testing.T, instance *symphonyv1.Instance) {
+	if instance != nil {
+		require.NotContains(t, instance.ObjectMeta.Finalizers, testFinalizerName)
+	}
+}
+
+func TestInstanceDeletionRequeues(t *testing.T) {
+	api := newFakeSymphonyAPI(t)
+	api.setSummary(&model.SummaryResult{Summary: model.SummarySpec{TargetCount: 1, SuccessCount: 1}, Time: time.Now()})
+	r := newTestInstanceReconciler(t, api, newDeletedInstance(nil, nil))
+
+	// the removal job is queued without waiting for it
+	result, instance := reconcileInstance(t, r)
+	require.Equal(t, deletionPollInterval, result.RequeueAfter)
+	require.Equal(t, provisioningstates.Deleting, instance.Status.Properties["status"])
+	require.Contains(t, instance.ObjectMeta.Finalizers, testFinalizerName)
+	removals, _ := api.counts()
+	require.Equal(t, 1, removals)
+
+	// the deployment summary isn't the result of the removal job
+	result, instance = reconcileInstance(t, r)
+	require.Equal(t, deletionPollInterval, result.RequeueAfter)
+	require.Equal(t, provisioningstates.Deleting, instance.Status.Properties["status"])
+
+	api.setSummary(&model.SummaryResult{Summary: model.SummarySpec{TargetCount: 1, SuccessCount: 1, IsRemoval: true}, Time: time.Now()})
+	result, instance = reconcileInstance(t, r)
+	require.Zero(t, result.RequeueAfter)
+	requireFinalized(t, instance)
+	removals, _ = api.counts()
+	require.Equal(t, 1, removals)
+}
+
+func TestInstanceDeletionWithoutSummary(t *testing.T) {
+	api := newFakeSymphonyAPI(t)
+	r := newTestInstanceReconciler(t, api, newDeletedInstance(nil, nil))
+
+	reconcileInstance(t, r)
+	_, instance := reconcileInstance(t, r)
+	requireFinalized(t, instance)
+}
+
+func TestInstanceDeletionRetriesFailedRemoval(t *testing.T) {
+	api := newFakeSymphonyAPI(t)
+	failed := &model.SummaryResult{Summary: model.SummarySpec{TargetCount: 2, SuccessCount: 1, IsRemoval: true, SummaryMessage: "failed to remove"}, Time: time.Now()}
+	api.setSummary(failed)
+	r := newTestInstanceReconciler(t, api, newDeletedInstance(nil, nil))
+
+	reconcileInstance(t, r)
+	result, instance := reconcileInstance(t, r)
+	require.Equal(t, deletionPollInterval, result.RequeueAfter)
+	require.Equal(t, provisioningstates.DeleteFailed, instance.Status.Properties["status"])
+	require.Equal(t, "failed to remove", instance.Status.Properties["status-details"])
+	require.Contains(t, instance.ObjectMeta.Finalizers, testFinalizerName)
+
+	// the removal job is queued again
+	_, instance = reconcileInstance(t, r)
+	require.Equal(t, provisioningstates.Deleting, instance.Status.Properties["status"])
+	removals, _ := api.counts()
+	require.Equal(t, 2, removals)
+
+	// the failed summary isn't taken for the result of the retry
+	_, instance = reconcileInstance(t, r)
+	require.Equal(t, provisioningstates.Deleting, instance.Status.Properties["status"])
+
+	api.setSummary(&model.SummaryResult{Summary: model.SummarySpec{TargetCount: 2, SuccessCount: 2, IsRemoval: true}, Time: failed.Time.Add(time.Second)})
+	_, instance = reconcileInstance(t, r)
+	requireFinalized(t, instance)
+}
+
+func TestInstanceDeletionTimeout(t *testing.T) {
+	api := newFakeSymphonyAPI(t)
+	api.setSummary(&model.SummaryResult{Summary: model.SummarySpec{TargetCount: 1, IsRemoval: true}, Time: time.Now()})
+	r := newTestInstanceReconciler(t, api, newDeletedInstance(nil, map[string]string{
+		"status":           provisioningstates.DeleteFailed,
+		deletionStartedKey: time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339),
+	}))
+	r.DeletionTimeout = time.Minute
+
+	result, instance := reconcileInstance(t, r)
+	require.Zero(t, result.RequeueAfter)
+	requireFinalized(t, instance)
+	if instance != nil {
+		require.Equal(t, provisioningstates.Orphaned, instance.Status.Properties["status"])
+	}
+	removals, _ := api.counts()
+	require.Equal(t, 0, removals)
+}
+
+func TestInstanceForceDeletion(t *testing.T) {
+	api := newFakeSymphonyAPI(t)
+	r := newTestInstanceReconciler(t, api, newDeletedInstance(map[string]string{ForceDeleteAnnotation: "true"}, map[string]string{
+		"status": provisioningstates.Deleting,
+	}))
+
+	result, instance := reconcileInstance(t, r)
+	require.Zero(t, result.RequeueAfter)
+	requireFinalized(t, instance)
+	removals, summaries := api.counts()
+	require.Equal(t, 0, removals)
+	require.Equal(t, 0, summaries)
+}
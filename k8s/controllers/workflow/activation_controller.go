@@ -18,6 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	workflowv1 "gopls-workspace/apis/workflow/v1"
+	"gopls-workspace/utils"
 
 	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
@@ -27,6 +28,8 @@ import (
 type ActivationReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// API is where the Symphony API is called
+	API utils.SymphonyAPI
 }
 
 //+kubebuilder:rbac:groups=workflow.symphony,resources=activations,verbs=get;list;watch;create;update;patch;delete
@@ -59,7 +62,7 @@ func (r *ActivationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if activation.ObjectMeta.DeletionTimestamp.IsZero() {
 		log.Info(fmt.Sprintf("Activation status: %v", activation.Status.Status))
 		if !activation.Status.IsActive && activation.Status.Status != v1alpha2.Paused && activation.Status.Status != v1alpha2.Done && activation.Status.ActivationGeneration == "" {
-			err := api_utils.PublishActivationEvent(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", v1alpha2.ActivationData{
+			err := api_utils.PublishActivationEvent(ctx, r.API.BaseUrl, r.API.User, r.API.Password, v1alpha2.ActivationData{
 				Campaign:             activation.Spec.Campaign,
 				Activation:           activation.Name,
 				ActivationGeneration: strconv.FormatInt(activation.Generation, 10),
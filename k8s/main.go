@@ -10,9 +10,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,6 +32,7 @@ import (
 	federationv1 "gopls-workspace/apis/federation/v1"
 	solutionv1 "gopls-workspace/apis/solution/v1"
 	workflowv1 "gopls-workspace/apis/workflow/v1"
+	"gopls-workspace/configutils"
 	"gopls-workspace/constants"
 
 	aicontrollers "gopls-workspace/controllers/ai"
@@ -104,6 +107,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	symphonyAPI, err := configutils.GetSymphonyAPI(context.Background(), ctrlConfig.SymphonyAPI)
+	if err != nil {
+		setupLog.Error(err, "unable to configure the Symphony API client")
+		os.Exit(1)
+	}
+	setupLog.Info("calling Symphony API", "url", symphonyAPI.BaseUrl)
+
 	if err = (&solutioncontrollers.SolutionReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -121,13 +131,16 @@ func main() {
 	if err = (&workflowcontrollers.ActivationReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		API:    symphonyAPI,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Activation")
 		os.Exit(1)
 	}
 	if err = (&solutioncontrollers.InstanceReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		API:             symphonyAPI,
+		DeletionTimeout: time.Duration(ctrlConfig.InstanceDeletionTimeoutSeconds) * time.Second,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Instance")
 		os.Exit(1)
@@ -135,6 +148,7 @@ func main() {
 	if err = (&fabriccontrollers.TargetReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		API:    symphonyAPI,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Target")
 		os.Exit(1)
@@ -177,6 +191,7 @@ func main() {
 	if err = (&federationcontrollers.CatalogReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		API:    symphonyAPI,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Catalog")
 		os.Exit(1)
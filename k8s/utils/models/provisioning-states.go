@@ -16,3 +16,11 @@ const (
 	Cancelled   = "Cancelled"
 	Reconciling = "Reconciling"
 )
+
+// The states of an instance that is being deleted. Deleting is non-terminal, DeleteFailed is retried until the
+// deletion timeout, and Orphaned means that the instance was removed before its components were confirmed to be removed.
+const (
+	Deleting     = "Deleting"
+	DeleteFailed = "DeleteFailed"
+	Orphaned     = "Orphaned"
+)
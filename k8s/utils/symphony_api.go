@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+)
+
+// SymphonyAPI is where the controllers call the Symphony API, and the user they call it as. How the calls
+// authenticate is registered with api_utils.SetAPIAuth for the base URL.
+type SymphonyAPI struct {
+	BaseUrl  string
+	User     string
+	Password string
+}
+
+// DefaultSymphonyAPI is the Symphony API service in the namespace of the controllers, called as admin
+func DefaultSymphonyAPI() SymphonyAPI {
+	return SymphonyAPI{
+		BaseUrl: api_utils.SymphonyAPIAddressBase,
+		User:    "admin",
+	}
+}